@@ -4,9 +4,13 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"time"
 
 	"go-mobile-backend-template/internal/db"
+	"go-mobile-backend-template/internal/db/repository"
 	"go-mobile-backend-template/internal/generator"
+	"go-mobile-backend-template/internal/middleware"
+	authService "go-mobile-backend-template/internal/services/auth"
 	"go-mobile-backend-template/pkg/config"
 
 	"go.uber.org/zap"
@@ -43,8 +47,23 @@ func main() {
 		logger.Fatal("Failed to connect to database", zap.Error(err))
 	}
 
-	// Create generator
+	// Create generator. Routes generated for a single table are protected
+	// like those of the server, with rate limits counted in process.
 	gen := generator.NewAPIGeneratorMain(dbConn, logger, cfg)
+	gen.SetGuard(middleware.NewRouteGuard(
+		authService.NewJWTService(cfg.JWT.Secret, cfg.JWT.AccessTokenExpireInt, cfg.JWT.RefreshTokenExpireInt, authService.JWTOptions{
+			Issuer:   cfg.JWT.Issuer,
+			Audience: cfg.JWT.Audience,
+		}),
+		authService.NewPermissionResolver(
+			repository.NewRoleRepository(dbConn),
+			nil,
+			time.Duration(cfg.Auth.PermissionCacheTTL)*time.Second,
+		),
+		middleware.NewRateLimiter(nil, logger),
+		middleware.NewAuditLogger(dbConn, logger),
+		logger,
+	))
 
 	// Override output directory if specified
 	if *outputDir != "" {
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	_ "go-mobile-backend-template/docs"
+	v1 "go-mobile-backend-template/internal/api/v1"
+	realtimeAPI "go-mobile-backend-template/internal/api/v1/realtime"
+	"go-mobile-backend-template/internal/db"
+	"go-mobile-backend-template/internal/db/repository"
+	"go-mobile-backend-template/internal/generator"
+	"go-mobile-backend-template/internal/middleware"
+	"go-mobile-backend-template/internal/realtime"
+	authService "go-mobile-backend-template/internal/services/auth"
+	"go-mobile-backend-template/pkg/cache"
+	"go-mobile-backend-template/pkg/config"
+	"go-mobile-backend-template/pkg/logger"
+)
+
+// shutdownTimeout bounds how long each shutdown step may take
+const shutdownTimeout = 15 * time.Second
+
+// generatorConfigPath is the location of the API generator configuration
+const generatorConfigPath = "config/generator.yaml"
+
+// @title Go Mobile Backend Template API
+// @version 1.0
+// @description A production-ready Gin backend template for mobile apps
+// @termsOfService http://swagger.io/terms/
+
+// @contact.name API Support
+// @contact.url http://www.swagger.io/support
+// @contact.email support@swagger.io
+
+// @license.name MIT
+// @license.url https://opensource.org/licenses/MIT
+
+// @host localhost:8080
+// @BasePath /api/v1
+// @schemes http
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Type "Bearer" followed by a space and JWT token.
+func main() {
+	// Load configuration
+	cfg := config.Load()
+
+	// Setup logger
+	log := logger.New(cfg.Environment)
+	defer log.Sync()
+
+	// Connect to database
+	dbConn, err := db.Connect(cfg)
+	if err != nil {
+		log.Fatal("Failed to connect to database", zap.Error(err))
+	}
+
+	// Load JWT signing keys when tokens are signed with asymmetric keys
+	keyManager := startKeyManager(cfg, dbConn, log)
+
+	// Connect to Redis. Without it, rate limits are kept per instance.
+	redisClient := connectRedis(cfg, log)
+
+	// Start real-time hub
+	hub := startHub(cfg, redisClient, log)
+
+	// Start database change streamer. Only the replica holding its advisory
+	// lock reads changes, so each one is broadcast once.
+	streamCtx, stopStreamer := context.WithCancel(context.Background())
+	streamer := realtime.NewDBStreamer(dbConn, hub, dbStreamerConfig(cfg, dbConn, log), log)
+	if err := streamer.Start(streamCtx); err != nil {
+		log.Error("Failed to start database change streamer", zap.Error(err))
+	}
+
+	// Setup router
+	if cfg.Environment == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	router := newRouter(cfg, log, dbConn, keyManager)
+
+	api := router.Group("/api/v1")
+	stopRoutes := v1.RegisterRoutes(api, dbConn, log, cfg, hub, redisClient, keyManager)
+
+	// Start auto registry for generated APIs
+	autoRegistry := startAutoRegistry(api, dbConn, cfg, log)
+
+	// Let realtime clients call the API over their connection
+	realtimeAPI.RegisterRESTMethod(hub, router, "/api/v1")
+
+	srv := &http.Server{
+		Addr:         net.JoinHostPort(cfg.Server.Host, cfg.Server.Port),
+		Handler:      router,
+		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
+	}
+
+	// End realtime event streams and polls when shutdown begins, which
+	// would otherwise keep their requests in flight
+	srv.RegisterOnShutdown(hub.CloseSessions)
+
+	go func() {
+		log.Info("Starting HTTP server", zap.String("addr", srv.Addr))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("HTTP server failed", zap.Error(err))
+		}
+	}()
+
+	// Wait for interrupt signal
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-quit
+
+	log.Info("Shutting down server", zap.String("signal", sig.String()))
+
+	// 1. Drain in-flight HTTP requests
+	httpCtx, cancelHTTP := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelHTTP()
+	if err := srv.Shutdown(httpCtx); err != nil {
+		log.Error("HTTP server shutdown failed", zap.Error(err))
+	}
+
+	// 2. Stop database change streamer so nothing new reaches the hub
+	stopStreamer()
+	streamer.Stop()
+
+	// 3. Close WebSocket clients
+	hubCtx, cancelHub := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelHub()
+	if err := hub.Shutdown(hubCtx); err != nil {
+		log.Error("Realtime hub shutdown failed", zap.Error(err))
+	}
+
+	// 4. Stop schema watcher, key rotation and route background workers
+	if autoRegistry != nil {
+		autoRegistry.Stop()
+	}
+	if keyManager != nil {
+		keyManager.Stop()
+	}
+	stopRoutes()
+
+	// 5. Close Redis and database connections
+	if redisClient != nil {
+		if err := redisClient.Close(); err != nil {
+			log.Error("Failed to close Redis connection", zap.Error(err))
+		}
+	}
+	if err := db.Close(dbConn); err != nil {
+		log.Error("Failed to close database connection", zap.Error(err))
+	}
+
+	log.Info("Server stopped")
+}
+
+// newRouter creates the Gin engine with global middleware and system routes.
+// keyManager is nil when tokens are signed with the shared secret.
+func newRouter(cfg *config.Config, log *zap.Logger, dbConn *gorm.DB, keyManager *authService.KeyManager) *gin.Engine {
+	router := gin.New()
+
+	router.Use(middleware.Recovery(log))
+	router.Use(middleware.Logger(log))
+	router.Use(middleware.SecurityHeaders())
+	if cfg.Environment == "production" {
+		router.Use(middleware.SecureCORS())
+	} else {
+		router.Use(middleware.DevelopmentCORS())
+	}
+
+	// Health check
+	router.GET("/healthz", func(c *gin.Context) {
+		sqlDB, err := dbConn.DB()
+		if err == nil {
+			err = sqlDB.PingContext(c.Request.Context())
+		}
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":   "unhealthy",
+				"database": "disconnected",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":   "ok",
+			"database": "connected",
+		})
+	})
+
+	// Public keys for verifying access tokens in other services
+	router.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		jwks := authService.JWKSet{Keys: []authService.JWK{}}
+		if keyManager != nil {
+			jwks = keyManager.JWKS()
+		}
+
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, jwks)
+	})
+
+	// Swagger UI
+	router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	return router
+}
+
+// startKeyManager loads the JWT signing keys and starts their rotation when
+// an asymmetric algorithm is configured. It returns nil for HS256.
+func startKeyManager(cfg *config.Config, dbConn *gorm.DB, log *zap.Logger) *authService.KeyManager {
+	if cfg.JWT.Algorithm == "" || cfg.JWT.Algorithm == authService.AlgorithmHS256 {
+		return nil
+	}
+
+	var (
+		source authService.KeySource
+		err    error
+	)
+	switch cfg.JWT.KeySource {
+	case "db":
+		source, err = authService.NewDBKeySource(repository.NewJWTSigningKeyRepository(dbConn), cfg.JWT.Secret)
+	case "dir":
+		source, err = authService.NewDirKeySource(cfg.JWT.KeyDir)
+	default:
+		log.Fatal("Unknown JWT key source", zap.String("key_source", cfg.JWT.KeySource))
+	}
+	if err != nil {
+		log.Fatal("Failed to open JWT key source", zap.Error(err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	keyManager, err := authService.NewKeyManager(ctx, source, authService.KeyManagerConfig{
+		Algorithm:   cfg.JWT.Algorithm,
+		Rotation:    time.Duration(cfg.JWT.KeyRotation) * time.Hour,
+		Retention:   time.Duration(cfg.JWT.RefreshTokenExpireInt) * time.Minute,
+		Refresh:     time.Duration(cfg.JWT.KeyRefresh) * time.Minute,
+		AcceptHS256: cfg.JWT.AcceptHS256,
+	}, log)
+	if err != nil {
+		log.Fatal("Failed to load JWT signing keys", zap.Error(err))
+	}
+
+	go keyManager.Run()
+
+	log.Info("JWT signing keys loaded",
+		zap.String("algorithm", cfg.JWT.Algorithm),
+		zap.String("kid", keyManager.SigningKey().ID),
+	)
+	return keyManager
+}
+
+// connectRedis connects to Redis when an address is configured. Redis is
+// optional, so failures are logged and nil is returned.
+func connectRedis(cfg *config.Config, log *zap.Logger) *cache.RedisClient {
+	if cfg.Redis.Address == "" {
+		log.Info("Redis not configured")
+		return nil
+	}
+
+	redisClient, err := cache.NewRedisClient(cfg.Redis.Address, cfg.Redis.Password, cfg.Redis.DB)
+	if err != nil {
+		log.Warn("Failed to connect to Redis, continuing without it", zap.Error(err))
+		return nil
+	}
+
+	return redisClient
+}
+
+// startHub creates the realtime hub on the configured backplane and starts
+// it. The Redis backplane requires a Redis connection and also keeps the
+// channel history in Redis.
+func startHub(cfg *config.Config, redisClient *cache.RedisClient, log *zap.Logger) *realtime.Hub {
+	hubConfig := realtime.HubConfig{
+		PresenceTTL:        time.Duration(cfg.Realtime.PresenceTTL) * time.Second,
+		MaxSubscriptions:   cfg.Realtime.MaxSubscriptions,
+		PresenceChannels:   cfg.Realtime.PresenceChannels,
+		QueueSize:          cfg.Realtime.QueueSize,
+		SlowConsumerPolicy: realtime.SlowConsumerPolicy(cfg.Realtime.SlowConsumer),
+		AckTimeout:         time.Duration(cfg.Realtime.AckTimeout) * time.Second,
+		AckRetries:         cfg.Realtime.AckRetries,
+		RPCTimeout:         time.Duration(cfg.Realtime.RPCTimeout) * time.Second,
+	}
+	historySize := cfg.Realtime.HistorySize
+	historyTTL := time.Duration(cfg.Realtime.HistoryTTL) * time.Hour
+
+	switch cfg.Realtime.Backplane {
+	case "", "memory":
+		hubConfig.History = realtime.NewLocalHistory(historySize, historyTTL)
+	case "redis":
+		if redisClient == nil {
+			log.Fatal("The redis realtime backplane requires Redis")
+		}
+		hubConfig.Backplane = realtime.NewRedisBackplane(redisClient, log)
+		hubConfig.History = realtime.NewRedisHistory(redisClient, historySize, historyTTL)
+	default:
+		log.Fatal("Unknown realtime backplane", zap.String("backplane", cfg.Realtime.Backplane))
+	}
+
+	hub, err := realtime.NewHubWithConfig(log, hubConfig)
+	if err != nil {
+		log.Fatal("Failed to start realtime hub", zap.Error(err))
+	}
+	go hub.Run()
+
+	log.Info("Realtime hub started", zap.String("backplane", cfg.Realtime.Backplane))
+	return hub
+}
+
+// dbStreamerConfig builds the database change streamer configuration
+func dbStreamerConfig(cfg *config.Config, dbConn *gorm.DB, log *zap.Logger) realtime.DBStreamerConfig {
+	stream := cfg.Realtime.DBStream
+
+	columns := make(map[string]realtime.ColumnPolicy, len(stream.Columns))
+	for table, policy := range stream.Columns {
+		columns[table] = realtime.ColumnPolicy{Allow: policy.Allow, Deny: policy.Deny}
+	}
+
+	streamerConfig := realtime.DBStreamerConfig{
+		DSN:     cfg.Database.DSN(),
+		Columns: columns,
+	}
+
+	switch stream.Backend {
+	case "", "notify":
+	case "replication":
+		streamerConfig.Source = realtime.NewReplicationSource(dbConn, realtime.ReplicationConfig{
+			Slot:         stream.Replication.Slot,
+			Plugin:       stream.Replication.Plugin,
+			Publication:  stream.Replication.Publication,
+			PollInterval: time.Duration(stream.Replication.PollInterval) * time.Millisecond,
+			BatchSize:    stream.Replication.BatchSize,
+		}, log)
+	default:
+		log.Fatal("Unknown database stream backend", zap.String("backend", stream.Backend))
+	}
+
+	return streamerConfig
+}
+
+// startAutoRegistry starts the generator auto registry when it is enabled in
+// the generator configuration. It returns nil when the registry is not running.
+// Pagination cursors are signed with the JWT secret unless the generator
+// configuration sets its own.
+func startAutoRegistry(api *gin.RouterGroup, dbConn *gorm.DB, cfg *config.Config, log *zap.Logger) *generator.AutoRegistry {
+	genConfig, err := generator.LoadGeneratorConfig(generatorConfigPath)
+	if err != nil {
+		log.Warn("Using default generator config", zap.Error(err))
+		genConfig = generator.DefaultGeneratorConfig()
+	}
+	if genConfig.CursorSecret == "" {
+		genConfig.CursorSecret = cfg.JWT.Secret
+	}
+
+	if !genConfig.AutoRegistration.Enabled {
+		log.Info("Auto registry disabled")
+		return nil
+	}
+
+	autoRegistry := generator.NewAutoRegistry(dbConn, log, genConfig)
+	if err := autoRegistry.Initialize(api); err != nil {
+		log.Error("Failed to initialize auto registry", zap.Error(err))
+		autoRegistry.Stop()
+		return nil
+	}
+
+	return autoRegistry
+}
@@ -3334,7 +3334,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get online presence for all users or a specific user",
+                "description": "Get presence for the users the caller sees, or a specific user, merged across their devices and instances. Users see themselves, their contacts and the members of the presence channels they are in; admins see everyone.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3360,6 +3360,13 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
@@ -3371,7 +3378,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get statistics about connected clients and channels",
+                "description": "Get statistics about connected clients and channels. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3389,6 +3396,13 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
@@ -6052,7 +6066,6 @@ const docTemplate = `{
         "api_keys.ApikeysCreateRequest": {
             "type": "object",
             "required": [
-                "key_hash",
                 "name",
                 "prefix"
             ],
@@ -6063,10 +6076,6 @@ const docTemplate = `{
                 "is_active": {
                     "type": "boolean"
                 },
-                "key_hash": {
-                    "type": "string",
-                    "maxLength": 255
-                },
                 "last_used_at": {
                     "type": "string"
                 },
@@ -6104,9 +6113,6 @@ const docTemplate = `{
                 "is_active": {
                     "type": "boolean"
                 },
-                "key_hash": {
-                    "type": "string"
-                },
                 "last_used_at": {
                     "type": "string"
                 },
@@ -6139,10 +6145,6 @@ const docTemplate = `{
                 "is_active": {
                     "type": "boolean"
                 },
-                "key_hash": {
-                    "type": "string",
-                    "maxLength": 255
-                },
                 "last_used_at": {
                     "type": "string"
                 },
@@ -6355,7 +6357,6 @@ const docTemplate = `{
             "required": [
                 "email",
                 "expires_at",
-                "token",
                 "user_id"
             ],
             "properties": {
@@ -6366,10 +6367,6 @@ const docTemplate = `{
                 "expires_at": {
                     "type": "string"
                 },
-                "token": {
-                    "type": "string",
-                    "maxLength": 255
-                },
                 "used": {
                     "type": "boolean"
                 },
@@ -6396,9 +6393,6 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
-                "token": {
-                    "type": "string"
-                },
                 "used": {
                     "type": "boolean"
                 },
@@ -6420,10 +6414,6 @@ const docTemplate = `{
                 "expires_at": {
                     "type": "string"
                 },
-                "token": {
-                    "type": "string",
-                    "maxLength": 255
-                },
                 "used": {
                     "type": "boolean"
                 },
@@ -6483,9 +6473,6 @@ const docTemplate = `{
                 "user_id"
             ],
             "properties": {
-                "deleted_at": {
-                    "type": "string"
-                },
                 "file_name": {
                     "type": "string"
                 },
@@ -6550,9 +6537,6 @@ const docTemplate = `{
         "files.FilesUpdateRequest": {
             "type": "object",
             "properties": {
-                "deleted_at": {
-                    "type": "string"
-                },
                 "file_name": {
                     "type": "string"
                 },
@@ -6570,9 +6554,6 @@ const docTemplate = `{
                 },
                 "r2_url": {
                     "type": "string"
-                },
-                "user_id": {
-                    "type": "integer"
                 }
             }
         },
@@ -6621,9 +6602,6 @@ const docTemplate = `{
                 "user_id"
             ],
             "properties": {
-                "access_token": {
-                    "type": "string"
-                },
                 "profile_data": {
                     "type": "string"
                 },
@@ -6635,9 +6613,6 @@ const docTemplate = `{
                     "type": "string",
                     "maxLength": 255
                 },
-                "refresh_token": {
-                    "type": "string"
-                },
                 "token_expires_at": {
                     "type": "string"
                 },
@@ -6649,9 +6624,6 @@ const docTemplate = `{
         "oauth_providers.OauthprovidersResponse": {
             "type": "object",
             "properties": {
-                "access_token": {
-                    "type": "string"
-                },
                 "created_at": {
                     "type": "string"
                 },
@@ -6667,9 +6639,6 @@ const docTemplate = `{
                 "provider_user_id": {
                     "type": "string"
                 },
-                "refresh_token": {
-                    "type": "string"
-                },
                 "token_expires_at": {
                     "type": "string"
                 },
@@ -6684,9 +6653,6 @@ const docTemplate = `{
         "oauth_providers.OauthprovidersUpdateRequest": {
             "type": "object",
             "properties": {
-                "access_token": {
-                    "type": "string"
-                },
                 "profile_data": {
                     "type": "string"
                 },
@@ -6698,9 +6664,6 @@ const docTemplate = `{
                     "type": "string",
                     "maxLength": 255
                 },
-                "refresh_token": {
-                    "type": "string"
-                },
                 "token_expires_at": {
                     "type": "string"
                 },
@@ -6787,17 +6750,12 @@ const docTemplate = `{
             "type": "object",
             "required": [
                 "expires_at",
-                "token",
                 "user_id"
             ],
             "properties": {
                 "expires_at": {
                     "type": "string"
                 },
-                "token": {
-                    "type": "string",
-                    "maxLength": 255
-                },
                 "used": {
                     "type": "boolean"
                 },
@@ -6821,9 +6779,6 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
-                "token": {
-                    "type": "string"
-                },
                 "used": {
                     "type": "boolean"
                 },
@@ -6841,10 +6796,6 @@ const docTemplate = `{
                 "expires_at": {
                     "type": "string"
                 },
-                "token": {
-                    "type": "string",
-                    "maxLength": 255
-                },
                 "used": {
                     "type": "boolean"
                 },
@@ -7023,22 +6974,15 @@ const docTemplate = `{
             "type": "object",
             "required": [
                 "expires_at",
-                "token",
                 "user_id"
             ],
             "properties": {
-                "deleted_at": {
-                    "type": "string"
-                },
                 "expires_at": {
                     "type": "string"
                 },
                 "is_revoked": {
                     "type": "boolean"
                 },
-                "token": {
-                    "type": "string"
-                },
                 "user_id": {
                     "type": "integer"
                 }
@@ -7062,9 +7006,6 @@ const docTemplate = `{
                 "is_revoked": {
                     "type": "boolean"
                 },
-                "token": {
-                    "type": "string"
-                },
                 "updated_at": {
                     "type": "string"
                 },
@@ -7076,18 +7017,12 @@ const docTemplate = `{
         "refresh_tokens.RefreshtokensUpdateRequest": {
             "type": "object",
             "properties": {
-                "deleted_at": {
-                    "type": "string"
-                },
                 "expires_at": {
                     "type": "string"
                 },
                 "is_revoked": {
                     "type": "boolean"
                 },
-                "token": {
-                    "type": "string"
-                },
                 "user_id": {
                     "type": "integer"
                 }
@@ -7295,7 +7230,6 @@ const docTemplate = `{
             "type": "object",
             "required": [
                 "expires_at",
-                "token",
                 "user_id"
             ],
             "properties": {
@@ -7315,14 +7249,6 @@ const docTemplate = `{
                 "last_used_at": {
                     "type": "string"
                 },
-                "refresh_token": {
-                    "type": "string",
-                    "maxLength": 500
-                },
-                "token": {
-                    "type": "string",
-                    "maxLength": 500
-                },
                 "user_agent": {
                     "type": "string"
                 },
@@ -7355,12 +7281,6 @@ const docTemplate = `{
                 "last_used_at": {
                     "type": "string"
                 },
-                "refresh_token": {
-                    "type": "string"
-                },
-                "token": {
-                    "type": "string"
-                },
                 "user_agent": {
                     "type": "string"
                 },
@@ -7388,14 +7308,6 @@ const docTemplate = `{
                 "last_used_at": {
                     "type": "string"
                 },
-                "refresh_token": {
-                    "type": "string",
-                    "maxLength": 500
-                },
-                "token": {
-                    "type": "string",
-                    "maxLength": 500
-                },
                 "user_agent": {
                     "type": "string"
                 },
@@ -7562,7 +7474,6 @@ const docTemplate = `{
         "user_2fa.User2faCreateRequest": {
             "type": "object",
             "required": [
-                "secret",
                 "user_id"
             ],
             "properties": {
@@ -7578,10 +7489,6 @@ const docTemplate = `{
                 "last_used_at": {
                     "type": "string"
                 },
-                "secret": {
-                    "type": "string",
-                    "maxLength": 255
-                },
                 "user_id": {
                     "type": "integer"
                 }
@@ -7608,9 +7515,6 @@ const docTemplate = `{
                 "last_used_at": {
                     "type": "string"
                 },
-                "secret": {
-                    "type": "string"
-                },
                 "updated_at": {
                     "type": "string"
                 },
@@ -7634,10 +7538,6 @@ const docTemplate = `{
                 "last_used_at": {
                     "type": "string"
                 },
-                "secret": {
-                    "type": "string",
-                    "maxLength": 255
-                },
                 "user_id": {
                     "type": "integer"
                 }
@@ -7776,40 +7676,24 @@ const docTemplate = `{
             "type": "object",
             "required": [
                 "email",
-                "name",
-                "password"
+                "name"
             ],
             "properties": {
                 "bio": {
                     "type": "string"
                 },
-                "deleted_at": {
-                    "type": "string"
-                },
                 "email": {
                     "type": "string"
                 },
                 "email_verified": {
                     "type": "boolean"
                 },
-                "email_verified_at": {
-                    "type": "string"
-                },
-                "failed_login_attempts": {
-                    "type": "integer"
-                },
                 "is_active": {
                     "type": "boolean"
                 },
                 "is_admin": {
                     "type": "boolean"
                 },
-                "last_login_at": {
-                    "type": "string"
-                },
-                "locked_until": {
-                    "type": "string"
-                },
                 "metadata": {
                     "type": "string"
                 },
@@ -7819,9 +7703,6 @@ const docTemplate = `{
                 "nickname": {
                     "type": "string",
                     "maxLength": 50
-                },
-                "password": {
-                    "type": "string"
                 }
             }
         },
@@ -7873,9 +7754,6 @@ const docTemplate = `{
                 "nickname": {
                     "type": "string"
                 },
-                "password": {
-                    "type": "string"
-                },
                 "updated_at": {
                     "type": "string"
                 }
@@ -7887,33 +7765,18 @@ const docTemplate = `{
                 "bio": {
                     "type": "string"
                 },
-                "deleted_at": {
-                    "type": "string"
-                },
                 "email": {
                     "type": "string"
                 },
                 "email_verified": {
                     "type": "boolean"
                 },
-                "email_verified_at": {
-                    "type": "string"
-                },
-                "failed_login_attempts": {
-                    "type": "integer"
-                },
                 "is_active": {
                     "type": "boolean"
                 },
                 "is_admin": {
                     "type": "boolean"
                 },
-                "last_login_at": {
-                    "type": "string"
-                },
-                "locked_until": {
-                    "type": "string"
-                },
                 "metadata": {
                     "type": "string"
                 },
@@ -7923,9 +7786,6 @@ const docTemplate = `{
                 "nickname": {
                     "type": "string",
                     "maxLength": 50
-                },
-                "password": {
-                    "type": "string"
                 }
             }
         },
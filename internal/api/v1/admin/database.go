@@ -3,12 +3,13 @@ package admin
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"go-mobile-backend-template/internal/generator"
 	"go-mobile-backend-template/internal/middleware"
 	"go-mobile-backend-template/internal/utils"
 
@@ -18,14 +19,18 @@ import (
 )
 
 type DatabaseHandler struct {
-	db     *gorm.DB
-	logger *zap.Logger
+	db      *gorm.DB
+	logger  *zap.Logger
+	cursors *generator.CursorCodec
 }
 
-func NewDatabaseHandler(db *gorm.DB, logger *zap.Logger) *DatabaseHandler {
+// NewDatabaseHandler creates a database handler whose table data cursors
+// are signed with cursorSecret
+func NewDatabaseHandler(db *gorm.DB, logger *zap.Logger, cursorSecret string) *DatabaseHandler {
 	return &DatabaseHandler{
-		db:     db,
-		logger: logger,
+		db:      db,
+		logger:  logger,
+		cursors: generator.NewCursorCodec(cursorSecret),
 	}
 }
 
@@ -150,25 +155,36 @@ func (h *DatabaseHandler) GetTableSchema(c *gin.Context) {
 
 // GetTableData godoc
 // @Summary Get table data (Admin)
-// @Description Get paginated data from a specific table
+// @Description Get paginated data from a specific table. Without a page, tables with a primary key are paginated with the cursors returned in next_cursor and prev_cursor. count is exact, estimate (from pg_class.reltuples) or none.
 // @Tags admin
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param tableName path string true "Table name"
-// @Param page query int false "Page number" default(1)
+// @Param page query int false "Page number"
 // @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "Cursor of the page to fetch"
+// @Param count query string false "Count mode" default(exact)
 // @Success 200 {object} utils.Response
 // @Router /admin/database/tables/{tableName}/data [get]
 func (h *DatabaseHandler) GetTableData(c *gin.Context) {
 	tableName := c.Param("tableName")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
 	if limit > 100 {
 		limit = 100
 	}
-	offset := (page - 1) * limit
+	if limit < 1 {
+		limit = 20
+	}
+
+	countMode := c.DefaultQuery("count", generator.CountExact)
+	switch countMode {
+	case generator.CountExact, generator.CountEstimate, generator.CountNone:
+	default:
+		c.JSON(http.StatusBadRequest, utils.ErrorResponseData("count must be exact, estimate or none"))
+		return
+	}
 
 	// Validate table name to prevent SQL injection
 	var exists bool
@@ -178,72 +194,106 @@ func (h *DatabaseHandler) GetTableData(c *gin.Context) {
 		return
 	}
 
-	// Get total count - using string formatting for table name (safe after validation)
-	var total int64
-	err = h.db.Raw(fmt.Sprintf("SELECT COUNT(*) FROM %s", tableName)).Scan(&total).Error
+	table, err := generator.NewSchemaAnalyzer(h.db, h.logger).GetTableByName(tableName)
 	if err != nil {
-		h.logger.Error("Failed to get table count", zap.Error(err), zap.String("table", tableName))
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to get table count"))
+		h.logger.Error("Failed to get columns", zap.Error(err), zap.String("table", tableName))
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to get columns"))
 		return
 	}
 
-	// Get data - using string formatting for table name (safe after validation)
-	rows, err := h.db.Raw(fmt.Sprintf("SELECT * FROM %s LIMIT %d OFFSET %d", tableName, limit, offset)).Rows()
+	columns := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		columns[i] = column.Name
+	}
+
+	query := h.db.Table(tableName).Session(&gorm.Session{})
+
+	total, estimated, err := generator.CountRows(query, tableName, countMode, false)
 	if err != nil {
-		h.logger.Error("Failed to get table data", zap.Error(err), zap.String("table", tableName))
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to get table data"))
+		h.logger.Error("Failed to get table count", zap.Error(err), zap.String("table", tableName))
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to get table count"))
 		return
 	}
-	defer rows.Close()
 
-	// Get column names
-	columns, err := rows.Columns()
-	if err != nil {
-		h.logger.Error("Failed to get columns", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to get columns"))
+	response := gin.H{
+		"table":   tableName,
+		"columns": columns,
+		"limit":   limit,
+	}
+	if total != nil {
+		response["total"] = *total
+		response["total_estimated"] = estimated
+	}
+
+	// Rows are ordered by primary key; tables without one keep the
+	// order PostgreSQL returns them in
+	keyset, keysetErr := generator.NewKeyset(table, nil)
+	_, paged := c.GetQuery("page")
+	cursor := c.Query("cursor")
+	if cursor != "" && keysetErr != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponseData("Table has no primary key to paginate with cursors"))
 		return
 	}
 
-	// Prepare to scan rows
 	var data []map[string]interface{}
+	if keysetErr == nil && (cursor != "" || !paged) {
+		page, err := keyset.Page(query, h.cursors, cursor, limit)
+		if errors.Is(err, generator.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponseData("Invalid cursor"))
+			return
+		}
+		if err != nil {
+			h.logger.Error("Failed to get table data", zap.Error(err), zap.String("table", tableName))
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to get table data"))
+			return
+		}
 
-	for rows.Next() {
-		// Create a slice of interface{}'s to represent each column
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-		for i := range columns {
-			valuePtrs[i] = &values[i]
+		data = page.Rows
+		response["has_next"] = page.HasNext
+		response["has_prev"] = page.HasPrev
+		response["next_cursor"] = nil
+		response["prev_cursor"] = nil
+		if page.Next != "" {
+			response["next_cursor"] = page.Next
+		}
+		if page.Prev != "" {
+			response["prev_cursor"] = page.Prev
+		}
+	} else {
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		if page < 1 {
+			page = 1
+		}
+		if keysetErr == nil {
+			query = query.Order(keyset.Order(false))
 		}
 
-		if err := rows.Scan(valuePtrs...); err != nil {
-			h.logger.Error("Failed to scan row", zap.Error(err))
-			continue
+		if err := query.Offset((page - 1) * limit).Limit(limit + 1).Find(&data).Error; err != nil {
+			h.logger.Error("Failed to get table data", zap.Error(err), zap.String("table", tableName))
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to get table data"))
+			return
 		}
 
-		// Create map for this row
-		row := make(map[string]interface{})
-		for i, col := range columns {
-			val := values[i]
+		hasNext := len(data) > limit
+		if hasNext {
+			data = data[:limit]
+		}
+		response["page"] = page
+		response["has_next"] = hasNext
+		response["has_prev"] = page > 1
+	}
 
-			// Convert []byte to string for display
+	// Convert []byte to string for display
+	for _, row := range data {
+		for col, val := range row {
 			if b, ok := val.([]byte); ok {
 				row[col] = string(b)
-			} else {
-				row[col] = val
 			}
 		}
-
-		data = append(data, row)
 	}
+	response["data"] = data
 
-	c.JSON(http.StatusOK, utils.SuccessResponseData("Data fetched successfully", gin.H{
-		"table":   tableName,
-		"columns": columns,
-		"data":    data,
-		"total":   total,
-		"page":    page,
-		"limit":   limit,
-	}))
+	c.JSON(http.StatusOK, utils.SuccessResponseData("Data fetched successfully", response))
 }
 
 // ExecuteQuery godoc
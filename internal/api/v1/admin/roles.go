@@ -14,12 +14,14 @@ import (
 
 type RoleHandler struct {
 	db     *gorm.DB
+	roles  repository.RoleRepository
 	logger *zap.Logger
 }
 
-func NewRoleHandler(db *gorm.DB, logger *zap.Logger) *RoleHandler {
+func NewRoleHandler(db *gorm.DB, logger *zap.Logger, roles repository.RoleRepository) *RoleHandler {
 	return &RoleHandler{
 		db:     db,
+		roles:  roles,
 		logger: logger,
 	}
 }
@@ -38,7 +40,7 @@ func (h *RoleHandler) ListRoles(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	offset := (page - 1) * limit
 
-	roleRepo := repository.NewRoleRepository(h.db)
+	roleRepo := h.roles
 
 	roles, total, err := roleRepo.List(c.Request.Context(), limit, offset)
 	if err != nil {
@@ -83,7 +85,7 @@ func (h *RoleHandler) CreateRole(c *gin.Context) {
 		Description: req.Description,
 	}
 
-	roleRepo := repository.NewRoleRepository(h.db)
+	roleRepo := h.roles
 
 	if err := roleRepo.Create(c.Request.Context(), role); err != nil {
 		h.logger.Error("Failed to create role", zap.Error(err))
@@ -111,7 +113,7 @@ func (h *RoleHandler) GetRole(c *gin.Context) {
 		return
 	}
 
-	roleRepo := repository.NewRoleRepository(h.db)
+	roleRepo := h.roles
 
 	role, err := roleRepo.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
@@ -151,7 +153,7 @@ func (h *RoleHandler) AssignPermissions(c *gin.Context) {
 		return
 	}
 
-	roleRepo := repository.NewRoleRepository(h.db)
+	roleRepo := h.roles
 
 	if err := roleRepo.AssignPermissions(c.Request.Context(), uint(id), req.PermissionIDs); err != nil {
 		h.logger.Error("Failed to assign permissions", zap.Error(err))
@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"go-mobile-backend-template/internal/db/repository"
 	"go-mobile-backend-template/internal/middleware"
 	authService "go-mobile-backend-template/internal/services/auth"
 	"go-mobile-backend-template/pkg/config"
@@ -10,24 +11,20 @@ import (
 	"gorm.io/gorm"
 )
 
-// RegisterRoutes registers admin routes
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cfg *config.Config) {
-	// Initialize JWT service for auth middleware
-	jwtService := authService.NewJWTService(
-		cfg.JWT.Secret,
-		cfg.JWT.AccessTokenExpireInt,
-		cfg.JWT.RefreshTokenExpireInt,
-	)
-
+// RegisterRoutes registers admin routes. Role changes invalidate the
+// permissions cached by permissions, and unlocking users clears their state
+// in lockout.
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cfg *config.Config, jwtService *authService.JWTService, permissions *authService.PermissionResolver, lockout *authService.LockoutService) {
 	// Apply auth middleware
 	router.Use(middleware.AuthMiddleware(jwtService))
 
 	// Apply admin middleware - all admin routes require admin role
 	router.Use(middleware.RequireAdmin())
 
-	userHandler := NewUserHandler(db, logger)
-	roleHandler := NewRoleHandler(db, logger)
-	dbHandler := NewDatabaseHandler(db, logger)
+	roleRepo := permissions.RoleRepository(repository.NewRoleRepository(db))
+	userHandler := NewUserHandler(db, logger, roleRepo, lockout)
+	roleHandler := NewRoleHandler(db, logger, roleRepo)
+	dbHandler := NewDatabaseHandler(db, logger, cfg.JWT.Secret)
 	tableHandler := NewTableManagerHandler(db, logger)
 	tableDataHandler := NewTableDataHandler(db)
 
@@ -41,6 +38,7 @@ func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cf
 		users.GET("/:id", userHandler.GetUser)
 		users.PUT("/:id", userHandler.UpdateUser)
 		users.DELETE("/:id", userHandler.DeleteUser)
+		users.POST("/:id/unlock", userHandler.UnlockUser)
 		users.POST("/:id/roles", userHandler.AssignRole)
 		users.DELETE("/:id/roles/:roleId", userHandler.RemoveRole)
 	}
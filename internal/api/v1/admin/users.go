@@ -5,6 +5,8 @@ import (
 	"strconv"
 
 	"go-mobile-backend-template/internal/db/repository"
+	"go-mobile-backend-template/internal/middleware"
+	authService "go-mobile-backend-template/internal/services/auth"
 	"go-mobile-backend-template/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -13,14 +15,20 @@ import (
 )
 
 type UserHandler struct {
-	db     *gorm.DB
-	logger *zap.Logger
+	db      *gorm.DB
+	roles   repository.RoleRepository
+	lockout *authService.LockoutService
+	logger  *zap.Logger
+	audit   *middleware.AuditLogger
 }
 
-func NewUserHandler(db *gorm.DB, logger *zap.Logger) *UserHandler {
+func NewUserHandler(db *gorm.DB, logger *zap.Logger, roles repository.RoleRepository, lockout *authService.LockoutService) *UserHandler {
 	return &UserHandler{
-		db:     db,
-		logger: logger,
+		db:      db,
+		roles:   roles,
+		lockout: lockout,
+		logger:  logger,
+		audit:   middleware.NewAuditLogger(db, logger),
 	}
 }
 
@@ -52,7 +60,7 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 		limit = 100
 	}
 
-	roleRepo := repository.NewRoleRepository(h.db)
+	roleRepo := h.roles
 
 	// Build query
 	query := h.db.Model(&repository.User{})
@@ -151,7 +159,7 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 	}
 
 	userRepo := repository.NewUserRepository(h.db)
-	roleRepo := repository.NewRoleRepository(h.db)
+	roleRepo := h.roles
 
 	user, err := userRepo.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
@@ -263,7 +271,7 @@ func (h *UserHandler) AssignRole(c *gin.Context) {
 		return
 	}
 
-	roleRepo := repository.NewRoleRepository(h.db)
+	roleRepo := h.roles
 
 	// Get current admin user ID
 	adminUserID := c.GetUint("user_id")
@@ -302,7 +310,7 @@ func (h *UserHandler) RemoveRole(c *gin.Context) {
 		return
 	}
 
-	roleRepo := repository.NewRoleRepository(h.db)
+	roleRepo := h.roles
 
 	if err := roleRepo.RemoveRoleFromUser(c.Request.Context(), uint(userID), uint(roleID)); err != nil {
 		h.logger.Error("Failed to remove role", zap.Error(err))
@@ -348,3 +356,45 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, utils.SuccessResponseData("User deleted successfully", nil))
 }
+
+// UnlockUser godoc
+// @Summary Unlock user (Admin)
+// @Description Clear failed login attempts, lift an account lockout and unblock the IPs the failed logins came from
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /admin/users/{id}/unlock [post]
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponseData("Invalid user ID"))
+		return
+	}
+
+	userRepo := repository.NewUserRepository(h.db)
+
+	user, err := userRepo.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponseData("User not found"))
+		return
+	}
+
+	if err := h.lockout.Unlock(c.Request.Context(), user.ID); err != nil {
+		h.logger.Error("Failed to unlock user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to unlock user"))
+		return
+	}
+
+	h.audit.LogSecurityEvent("ACCOUNT_UNLOCKED", "Account unlocked by admin", c, map[string]interface{}{
+		"target_user_id":  user.ID,
+		"failed_attempts": user.FailedLoginAttempts,
+		"locked_until":    user.LockedUntil,
+	})
+
+	c.JSON(http.StatusOK, utils.SuccessResponseData("User unlocked successfully", nil))
+}
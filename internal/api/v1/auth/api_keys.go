@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go-mobile-backend-template/internal/db/repository"
+	"go-mobile-backend-template/internal/services/auth"
+	"go-mobile-backend-template/internal/utils"
+)
+
+// CreateAPIKey issues an API key for the current user
+// @Summary Create API key
+// @Description Create an API key for the current user. The key is only returned in this response. Scopes are resource:action pairs; "*" matches any resource or action.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateAPIKeyRequest true "API key settings"
+// @Success 201 {object} utils.Response{data=CreateAPIKeyResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Router /auth/api-keys [post]
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Expiry must be in the future")
+		return
+	}
+
+	rateLimit := req.RateLimit
+	if rateLimit == 0 {
+		rateLimit = h.cfg.Auth.APIKeyRateLimit
+	}
+
+	ctx := context.Background()
+	key, plaintext, err := h.apiKeys.Create(ctx, userID.(uint), req.Name, req.Scopes, rateLimit, req.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidScope) {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.Error("Failed to create API key", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create API key")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "API key created successfully", CreateAPIKeyResponse{
+		APIKeyData: newAPIKeyData(key),
+		Key:        plaintext,
+	})
+}
+
+// ListAPIKeys lists the API keys of the current user
+// @Summary List API keys
+// @Description List the API keys of the current user, including revoked and expired keys
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]APIKeyData}
+// @Failure 401 {object} utils.Response
+// @Router /auth/api-keys [get]
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx := context.Background()
+	keys, err := h.apiKeys.List(ctx, userID.(uint))
+	if err != nil {
+		h.logger.Error("Failed to list API keys", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to list API keys")
+		return
+	}
+
+	response := make([]APIKeyData, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, newAPIKeyData(key))
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API keys retrieved successfully", response)
+}
+
+// RevokeAPIKey revokes an API key of the current user
+// @Summary Revoke API key
+// @Description Revoke one of the current user's API keys. It stops working immediately.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /auth/api-keys/{id} [delete]
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	keyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	ctx := context.Background()
+	if err := h.apiKeys.Revoke(ctx, userID.(uint), uint(keyID)); err != nil {
+		if errors.Is(err, auth.ErrAPIKeyNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		h.logger.Error("Failed to revoke API key", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to revoke API key")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API key revoked successfully", nil)
+}
+
+// newAPIKeyData converts an API key to its response form
+func newAPIKeyData(key *repository.APIKey) APIKeyData {
+	scopes := []string(key.Scopes)
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	return APIKeyData{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     scopes,
+		RateLimit:  key.RateLimit,
+		IsActive:   key.IsActive,
+		LastUsedAt: key.LastUsedAt,
+		ExpiresAt:  key.ExpiresAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
@@ -2,14 +2,21 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"go-mobile-backend-template/internal/db/repository"
+	"go-mobile-backend-template/internal/middleware"
 	"go-mobile-backend-template/internal/services/auth"
+	"go-mobile-backend-template/internal/services/oauth"
 	"go-mobile-backend-template/internal/utils"
 	"go-mobile-backend-template/pkg/config"
 )
@@ -18,25 +25,69 @@ import (
 type Handler struct {
 	userRepo         repository.UserRepository
 	refreshTokenRepo repository.RefreshTokenRepository
+	sessionService   *auth.SessionService
+	twoFactorService *auth.TwoFactorService
+	oauthService     *oauth.Service
+	passwordReset    *auth.PasswordResetService
+	emailVerifier    *auth.EmailVerificationService
+	apiKeys          *auth.APIKeyService
+	lockout          *auth.LockoutService
+	denylist         *auth.TokenDenylist
+	permissions      *auth.PermissionResolver
+	audit            *middleware.AuditLogger
 	jwtService       *auth.JWTService
 	logger           *zap.Logger
 	cfg              *config.Config
 }
 
-// NewHandler creates a new auth handler
-func NewHandler(db *gorm.DB, logger *zap.Logger, cfg *config.Config) *Handler {
-	jwtService := auth.NewJWTService(
-		cfg.JWT.Secret,
-		cfg.JWT.AccessTokenExpireInt,
-		cfg.JWT.RefreshTokenExpireInt,
-	)
+// NewHandler creates a new auth handler. Logout revokes access tokens
+// through denylist, which must be one of jwtService's validators, failed
+// logins are counted by lockout, and permissions are embedded in access
+// tokens from permissions when enabled.
+func NewHandler(
+	db *gorm.DB,
+	logger *zap.Logger,
+	cfg *config.Config,
+	jwtService *auth.JWTService,
+	denylist *auth.TokenDenylist,
+	lockout *auth.LockoutService,
+	permissions *auth.PermissionResolver,
+) *Handler {
+	userRepo := repository.NewUserRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	sessionService := auth.NewSessionService(repository.NewSessionRepository(db))
+	notifier := newNotifier(logger, cfg)
 
 	return &Handler{
-		userRepo:         repository.NewUserRepository(db),
-		refreshTokenRepo: repository.NewRefreshTokenRepository(db),
-		jwtService:       jwtService,
-		logger:           logger,
-		cfg:              cfg,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		sessionService:   sessionService,
+		twoFactorService: auth.NewTwoFactorService(repository.NewTwoFactorRepository(db), cfg.Auth.TOTPIssuer),
+		oauthService:     newOAuthService(db, logger, cfg, userRepo, denylist),
+		passwordReset: auth.NewPasswordResetService(
+			repository.NewPasswordResetTokenRepository(db),
+			userRepo,
+			refreshTokenRepo,
+			sessionService,
+			notifier,
+			time.Duration(cfg.Auth.PasswordResetExpire)*time.Minute,
+			cfg.Auth.PasswordResetURL,
+		),
+		emailVerifier: auth.NewEmailVerificationService(
+			repository.NewEmailVerificationTokenRepository(db),
+			userRepo,
+			notifier,
+			time.Duration(cfg.Auth.EmailVerificationExpire)*time.Minute,
+			cfg.Auth.EmailVerificationURL,
+		),
+		apiKeys:     auth.NewAPIKeyService(repository.NewAPIKeyRepository(db), userRepo, nil),
+		lockout:     lockout,
+		denylist:    denylist,
+		permissions: permissions,
+		audit:       middleware.NewAuditLogger(db, logger),
+		jwtService:  jwtService,
+		logger:      logger,
+		cfg:         cfg,
 	}
 }
 
@@ -89,58 +140,34 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
-	// Generate tokens
-	accessToken, err := h.jwtService.GenerateAccessToken(user.ID, user.Email, user.IsAdmin)
-	if err != nil {
-		h.logger.Error("Failed to generate access token", zap.Error(err))
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
-		return
+	// Best effort - the user can request another verification email
+	if err := h.emailVerifier.Send(ctx, user); err != nil {
+		h.logger.Error("Failed to send verification email", zap.Error(err))
 	}
 
-	refreshToken, err := h.jwtService.GenerateRefreshToken(user.ID, user.Email, user.IsAdmin)
+	// Open a session and generate tokens
+	response, err := h.issueTokens(ctx, c, user, req.DeviceInfo)
 	if err != nil {
-		h.logger.Error("Failed to generate refresh token", zap.Error(err))
+		h.logger.Error("Failed to issue tokens", zap.Error(err))
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
-	// Save refresh token
-	refreshTokenRecord := &repository.RefreshToken{
-		UserID:    user.ID,
-		Token:     refreshToken,
-		ExpiresAt: h.jwtService.GetRefreshTokenExpiration(),
-		IsRevoked: false,
-	}
-	if err := h.refreshTokenRepo.Create(ctx, refreshTokenRecord); err != nil {
-		h.logger.Error("Failed to save refresh token", zap.Error(err))
-	}
-
-	response := AuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		User: UserData{
-			ID:       user.ID,
-			Email:    user.Email,
-			Name:     user.Name,
-			IsActive: user.IsActive,
-			IsAdmin:  user.IsAdmin,
-		},
-		ExpiresIn: h.cfg.JWT.AccessTokenExpireInt * 60,
-	}
-
 	utils.SuccessResponse(c, http.StatusCreated, "User registered successfully", response)
 }
 
 // Login handles user login
 // @Summary User login
-// @Description Authenticate user and return tokens
+// @Description Authenticate user and return tokens. When two-factor authentication is enabled a challenge token is returned instead, to be completed at /auth/2fa/verify. Repeated failures lock the account and block the client IP for a growing period.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body LoginRequest true "Login request"
 // @Success 200 {object} AuthResponse
+// @Success 202 {object} TwoFactorChallengeResponse
 // @Failure 400 {object} utils.Response
 // @Failure 401 {object} utils.Response
+// @Failure 429 {object} utils.Response
 // @Router /auth/login [post]
 func (h *Handler) Login(c *gin.Context) {
 	var req LoginRequest
@@ -150,64 +177,37 @@ func (h *Handler) Login(c *gin.Context) {
 	}
 
 	ctx := context.Background()
-	user, err := h.userRepo.GetByEmail(ctx, req.Email)
-	if err != nil {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid credentials")
+	if h.ipBlocked(ctx, c) {
 		return
 	}
 
-	// Check password
-	if err := auth.CheckPassword(user.Password, req.Password); err != nil {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid credentials")
+	// Unknown, locked, inactive and wrong-password logins all get the same
+	// response after a password check, so they cannot be told apart
+	user, err := h.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		auth.SimulatePasswordCheck(req.Password)
+		h.loginFailed(ctx, c, nil, loginFailureUnknownUser)
 		return
 	}
 
-	// Check if user is active
-	if !user.IsActive {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "User account is inactive")
-		return
-	}
+	passwordErr := auth.CheckPassword(user.Password, req.Password)
 
-	// Generate tokens
-	accessToken, err := h.jwtService.GenerateAccessToken(user.ID, user.Email, user.IsAdmin)
-	if err != nil {
-		h.logger.Error("Failed to generate access token", zap.Error(err))
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
+	if h.lockout.IsLocked(user) {
+		h.loginFailed(ctx, c, user, loginFailureAccountLocked)
 		return
 	}
 
-	refreshToken, err := h.jwtService.GenerateRefreshToken(user.ID, user.Email, user.IsAdmin)
-	if err != nil {
-		h.logger.Error("Failed to generate refresh token", zap.Error(err))
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
+	if passwordErr != nil {
+		h.loginFailed(ctx, c, user, loginFailureInvalidPassword)
 		return
 	}
 
-	// Save refresh token
-	refreshTokenRecord := &repository.RefreshToken{
-		UserID:    user.ID,
-		Token:     refreshToken,
-		ExpiresAt: h.jwtService.GetRefreshTokenExpiration(),
-		IsRevoked: false,
-	}
-	if err := h.refreshTokenRepo.Create(ctx, refreshTokenRecord); err != nil {
-		h.logger.Error("Failed to save refresh token", zap.Error(err))
-	}
-
-	response := AuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		User: UserData{
-			ID:       user.ID,
-			Email:    user.Email,
-			Name:     user.Name,
-			IsActive: user.IsActive,
-			IsAdmin:  user.IsAdmin,
-		},
-		ExpiresIn: h.cfg.JWT.AccessTokenExpireInt * 60,
+	if !user.IsActive {
+		h.loginFailed(ctx, c, user, loginFailureAccountInactive)
+		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Login successful", response)
+	h.completeLogin(ctx, c, user, req.DeviceInfo)
 }
 
 // RefreshToken handles token refresh
@@ -229,14 +229,37 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 	}
 
 	// Validate refresh token
-	claims, err := h.jwtService.ValidateToken(req.RefreshToken)
+	claims, err := h.jwtService.ValidateRefreshToken(req.RefreshToken)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired refresh token")
 		return
 	}
 
-	// Check if refresh token exists and is not revoked
+	// Every refresh token belongs to a session. Resolve it before looking at
+	// the stored token, so an exchanged token is detected as reused rather
+	// than rejected as revoked
+	if claims.SessionID == 0 {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired refresh token")
+		return
+	}
 	ctx := context.Background()
+	session, err := h.sessionService.VerifyRefreshToken(ctx, claims.SessionID, req.RefreshToken)
+	if errors.Is(err, auth.ErrRefreshTokenReused) {
+		h.logger.Warn("Refresh token reuse detected, session revoked",
+			zap.Uint("user_id", claims.UserID),
+			zap.Uint("session_id", claims.SessionID),
+		)
+		h.audit.LogSecurityEvent(securityEventTokenReused, "Refresh token reused, session revoked", c, map[string]interface{}{
+			"user_id":    claims.UserID,
+			"session_id": claims.SessionID,
+		})
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired refresh token")
+		return
+	}
+
+	// Check if refresh token exists and is not revoked
 	refreshTokenRecord, err := h.refreshTokenRepo.GetByToken(ctx, req.RefreshToken)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired refresh token")
@@ -256,49 +279,20 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// Generate new access token
-	accessToken, err := h.jwtService.GenerateAccessToken(user.ID, user.Email, user.IsAdmin)
-	if err != nil {
-		h.logger.Error("Failed to generate access token", zap.Error(err))
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
-		return
-	}
-
-	// Optionally generate new refresh token
-	newRefreshToken, err := h.jwtService.GenerateRefreshToken(user.ID, user.Email, user.IsAdmin)
-	if err != nil {
-		h.logger.Error("Failed to generate refresh token", zap.Error(err))
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
-		return
-	}
-
 	// Revoke old refresh token
 	if err := h.refreshTokenRepo.Revoke(ctx, req.RefreshToken); err != nil {
 		h.logger.Error("Failed to revoke refresh token", zap.Error(err))
 	}
 
-	// Save new refresh token
-	newRefreshTokenRecord := &repository.RefreshToken{
-		UserID:    user.ID,
-		Token:     newRefreshToken,
-		ExpiresAt: h.jwtService.GetRefreshTokenExpiration(),
-		IsRevoked: false,
-	}
-	if err := h.refreshTokenRepo.Create(ctx, newRefreshTokenRecord); err != nil {
-		h.logger.Error("Failed to save refresh token", zap.Error(err))
+	response, err := h.generateTokens(ctx, user, session, req.RefreshToken)
+	if errors.Is(err, auth.ErrRefreshTokenExchanged) {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired refresh token")
+		return
 	}
-
-	response := AuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: newRefreshToken,
-		User: UserData{
-			ID:       user.ID,
-			Email:    user.Email,
-			Name:     user.Name,
-			IsActive: user.IsActive,
-			IsAdmin:  user.IsAdmin,
-		},
-		ExpiresIn: h.cfg.JWT.AccessTokenExpireInt * 60,
+	if err != nil {
+		h.logger.Error("Failed to issue tokens", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
+		return
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, "Token refreshed successfully", response)
@@ -306,7 +300,7 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 
 // Logout handles user logout
 // @Summary User logout
-// @Description Revoke user's refresh tokens
+// @Description End the current session and revoke its tokens
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -322,6 +316,29 @@ func (h *Handler) Logout(c *gin.Context) {
 	}
 
 	ctx := context.Background()
+
+	// Reject the access token of this request right away, without waiting
+	// for it to expire
+	if tokenID := c.GetString("token_id"); tokenID != "" {
+		if err := h.denylist.Revoke(ctx, tokenID, c.GetTime("token_expires_at")); err != nil {
+			h.logger.Error("Failed to revoke access token", zap.Error(err))
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to logout")
+			return
+		}
+	}
+
+	// Only end the current session when the token is bound to one
+	if sessionID := c.GetUint("session_id"); sessionID != 0 {
+		if err := h.sessionService.Revoke(ctx, userID.(uint), sessionID); err != nil {
+			h.logger.Error("Failed to revoke session", zap.Error(err))
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to logout")
+			return
+		}
+
+		utils.SuccessResponse(c, http.StatusOK, "Logout successful", nil)
+		return
+	}
+
 	if err := h.refreshTokenRepo.RevokeAllForUser(ctx, userID.(uint)); err != nil {
 		h.logger.Error("Failed to revoke refresh tokens", zap.Error(err))
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to logout")
@@ -354,8 +371,146 @@ func (h *Handler) ValidateToken(c *gin.Context) {
 		"user_id":    userID,
 		"user_email": userEmail,
 		"is_admin":   isAdmin,
+		"session_id": c.GetUint("session_id"),
 		"valid":      true,
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, "Token is valid", response)
 }
+
+// completeLogin finishes a login for an authenticated user. Users with
+// two-factor authentication get a challenge token; everyone else gets a
+// token pair for a new session.
+func (h *Handler) completeLogin(ctx context.Context, c *gin.Context, user *repository.User, deviceInfo map[string]interface{}) {
+	// Require a second factor before issuing tokens
+	twoFactorEnabled, err := h.twoFactorService.IsEnabled(ctx, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to check two-factor status", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to login")
+		return
+	}
+	if twoFactorEnabled {
+		expiresIn := h.cfg.Auth.TwoFactorChallengeExpire * 60
+		challenge, err := h.jwtService.GenerateTwoFactorChallenge(user.ID, time.Duration(expiresIn)*time.Second)
+		if err != nil {
+			h.logger.Error("Failed to generate two-factor challenge", zap.Error(err))
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
+			return
+		}
+
+		utils.SuccessResponse(c, http.StatusAccepted, "Two-factor authentication required", TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+			ExpiresIn:         expiresIn,
+		})
+		return
+	}
+
+	h.recordLoginSuccess(ctx, user)
+
+	// Open a session and generate tokens
+	response, err := h.issueTokens(ctx, c, user, deviceInfo)
+	if err != nil {
+		h.logger.Error("Failed to issue tokens", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Login successful", response)
+}
+
+// ipBlocked answers 429 when the client IP is blocked after repeated
+// failed logins, and reports whether it was
+func (h *Handler) ipBlocked(ctx context.Context, c *gin.Context) bool {
+	blockedFor, err := h.lockout.IPBlockedFor(ctx, c.ClientIP())
+	if err != nil {
+		h.logger.Error("Failed to check login IP block", zap.Error(err))
+	}
+	if blockedFor <= 0 {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(blockedFor.Seconds()))))
+	utils.ErrorResponse(c, http.StatusTooManyRequests, "Too many failed login attempts, try again later")
+	return true
+}
+
+// issueTokens opens a new session for the user and returns a token pair
+// bound to it
+func (h *Handler) issueTokens(ctx context.Context, c *gin.Context, user *repository.User, deviceInfo map[string]interface{}) (*AuthResponse, error) {
+	session, err := h.sessionService.Create(ctx, user.ID, sessionMetadata(c, deviceInfo), h.jwtService.GetRefreshTokenExpiration())
+	if err != nil {
+		return nil, err
+	}
+
+	return h.generateTokens(ctx, user, session, "")
+}
+
+// generateTokens generates an access and refresh token pair for an existing
+// session and records the refresh token. A refresh passes the token it
+// exchanges as previousToken, which the new one replaces only if it is
+// still the session's current token.
+func (h *Handler) generateTokens(ctx context.Context, user *repository.User, session *repository.Session, previousToken string) (*AuthResponse, error) {
+	var permissions *auth.EffectivePermissions
+	if h.cfg.JWT.EmbedPermissions {
+		resolved, err := h.permissions.Resolve(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve permissions: %w", err)
+		}
+		permissions = resolved
+	}
+
+	accessToken, err := h.jwtService.GenerateAccessTokenWithPermissions(user.ID, user.Email, user.IsAdmin, session.ID, permissions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	refreshToken, err := h.jwtService.GenerateRefreshToken(user.ID, user.Email, user.IsAdmin, session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	expiresAt := h.jwtService.GetRefreshTokenExpiration()
+
+	if previousToken != "" {
+		if err := h.sessionService.RotateRefreshToken(ctx, session, previousToken, refreshToken, expiresAt); err != nil {
+			return nil, fmt.Errorf("failed to rotate refresh token of session: %w", err)
+		}
+	} else if err := h.sessionService.BindRefreshToken(ctx, session, refreshToken, expiresAt); err != nil {
+		return nil, fmt.Errorf("failed to bind refresh token to session: %w", err)
+	}
+
+	// Save refresh token
+	refreshTokenRecord := &repository.RefreshToken{
+		UserID:    user.ID,
+		Token:     refreshToken,
+		ExpiresAt: expiresAt,
+		IsRevoked: false,
+	}
+	if err := h.refreshTokenRepo.Create(ctx, refreshTokenRecord); err != nil {
+		h.logger.Error("Failed to save refresh token", zap.Error(err))
+	}
+
+	return &AuthResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		User: UserData{
+			ID:            user.ID,
+			Email:         user.Email,
+			Name:          user.Name,
+			IsActive:      user.IsActive,
+			IsAdmin:       user.IsAdmin,
+			EmailVerified: user.EmailVerified,
+		},
+		ExpiresIn: h.cfg.JWT.AccessTokenExpireInt * 60,
+	}, nil
+}
+
+// sessionMetadata collects device details for a new session from the request
+func sessionMetadata(c *gin.Context, deviceInfo map[string]interface{}) auth.SessionMetadata {
+	return auth.SessionMetadata{
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		DeviceInfo: deviceInfo,
+	}
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go-mobile-backend-template/internal/db/repository"
+	"go-mobile-backend-template/internal/utils"
+)
+
+// Reasons a login attempt failed, as recorded in security events
+const (
+	loginFailureUnknownUser     = "unknown_user"
+	loginFailureAccountLocked   = "account_locked"
+	loginFailureAccountInactive = "account_inactive"
+	loginFailureInvalidPassword = "invalid_password"
+	loginFailureInvalidCode     = "invalid_two_factor_code"
+)
+
+// Security event types written to the audit log
+const (
+	securityEventLoginFailed   = "LOGIN_FAILED"
+	securityEventAccountLocked = "ACCOUNT_LOCKED"
+	securityEventIPBlocked     = "LOGIN_IP_BLOCKED"
+	securityEventTokenReused   = "REFRESH_TOKEN_REUSED"
+)
+
+// loginFailed records a failed password login and writes the response
+// shared by every kind of failure
+func (h *Handler) loginFailed(ctx context.Context, c *gin.Context, user *repository.User, reason string) {
+	h.recordLoginFailure(ctx, c, user, reason)
+	utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid credentials")
+}
+
+// recordLoginFailure counts a failed login against the client IP and, for
+// wrong passwords and two-factor codes, against the account. Lockouts it
+// triggers are written as security events.
+func (h *Handler) recordLoginFailure(ctx context.Context, c *gin.Context, user *repository.User, reason string) {
+	countedUser := user
+	if reason != loginFailureInvalidPassword && reason != loginFailureInvalidCode {
+		countedUser = nil
+	}
+
+	data := map[string]interface{}{"reason": reason}
+	if user != nil {
+		data["user_id"] = user.ID
+	}
+
+	result, err := h.lockout.RecordFailure(ctx, countedUser, c.ClientIP())
+	if err != nil {
+		h.logger.Error("Failed to record failed login", zap.Error(err))
+	}
+
+	h.audit.LogSecurityEvent(securityEventLoginFailed, "Failed login attempt", c, data)
+
+	if result == nil {
+		return
+	}
+
+	if result.AccountLockedUntil != nil {
+		h.audit.LogSecurityEvent(securityEventAccountLocked, "Account locked after failed logins", c, map[string]interface{}{
+			"user_id":      user.ID,
+			"attempts":     result.Attempts,
+			"locked_until": result.AccountLockedUntil,
+		})
+	}
+
+	if result.IPBlockedFor > 0 {
+		h.audit.LogSecurityEvent(securityEventIPBlocked, "Client IP blocked after failed logins", c, map[string]interface{}{
+			"blocked_for": result.IPBlockedFor.String(),
+		})
+	}
+}
+
+// recordLoginSuccess records a completed login, clearing failed attempts
+func (h *Handler) recordLoginSuccess(ctx context.Context, user *repository.User) {
+	if err := h.lockout.RecordSuccess(ctx, user.ID); err != nil {
+		h.logger.Error("Failed to record login", zap.Error(err))
+	}
+}
@@ -0,0 +1,306 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"go-mobile-backend-template/internal/db/repository"
+	"go-mobile-backend-template/internal/services/auth"
+	"go-mobile-backend-template/internal/services/oauth"
+	"go-mobile-backend-template/internal/utils"
+	"go-mobile-backend-template/pkg/config"
+)
+
+// oauthBindingCookie holds the secret binding a browser to the OAuth flow it
+// started. Callbacks without it are rejected, so a victim cannot be made to
+// complete a flow started by someone else.
+const oauthBindingCookie = "oauth_binding"
+
+// newOAuthService builds the social login service from configuration. A
+// misconfigured provider disables social login rather than the whole API.
+func newOAuthService(db *gorm.DB, logger *zap.Logger, cfg *config.Config, userRepo repository.UserRepository, denylist *auth.TokenDenylist) *oauth.Service {
+	registry, err := oauth.NewRegistry(cfg.Auth.OAuth)
+	if err != nil {
+		logger.Error("Failed to configure OAuth providers, social login disabled", zap.Error(err))
+		registry, _ = oauth.NewRegistry(config.OAuth{})
+	}
+
+	stateCodec, err := oauth.NewStateCodec(cfg.JWT.Secret, time.Duration(cfg.Auth.OAuth.StateExpire)*time.Minute, denylist)
+	if err != nil {
+		logger.Error("Failed to create OAuth state codec", zap.Error(err))
+	}
+
+	return oauth.NewService(registry, stateCodec, userRepo, repository.NewOAuthProviderRepository(db))
+}
+
+// ListOAuthProviders lists the configured social login providers
+// @Summary List OAuth providers
+// @Description List the social login providers configured on this server
+// @Tags auth
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]string}
+// @Router /auth/oauth/providers [get]
+func (h *Handler) ListOAuthProviders(c *gin.Context) {
+	utils.SuccessResponse(c, http.StatusOK, "OAuth providers retrieved successfully", h.oauthService.Providers())
+}
+
+// StartOAuth starts a social login flow
+// @Summary Start OAuth login
+// @Description Redirect to the provider's authorization page using the authorization code flow with PKCE. The flow is bound to the browser with an HttpOnly cookie. Pass redirect=false to receive the URL and binding as JSON instead; apps send the binding back with the callback.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param redirect query bool false "Redirect to the provider (default true)"
+// @Success 200 {object} utils.Response{data=OAuthStartResponse}
+// @Success 302
+// @Failure 404 {object} utils.Response
+// @Router /auth/oauth/{provider} [get]
+func (h *Handler) StartOAuth(c *gin.Context) {
+	ctx := context.Background()
+	authURL, binding, err := h.oauthService.Start(ctx, c.Param("provider"), 0)
+	if err != nil {
+		h.oauthError(c, err)
+		return
+	}
+
+	h.setOAuthBinding(c, path.Join(c.Request.URL.Path, "callback"), binding)
+
+	if c.Query("redirect") == "false" {
+		utils.SuccessResponse(c, http.StatusOK, "OAuth flow started", OAuthStartResponse{AuthorizationURL: authURL, Binding: binding})
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OAuthCallback completes a social login flow from a browser redirect
+// @Summary OAuth callback
+// @Description Complete the authorization code flow in the browser that started it. Sign-in flows return the same response as /auth/login; link flows return the linked provider. A state can be used once.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State returned by the provider"
+// @Success 200 {object} AuthResponse
+// @Success 202 {object} TwoFactorChallengeResponse
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 429 {object} utils.Response
+// @Router /auth/oauth/{provider}/callback [get]
+func (h *Handler) OAuthCallback(c *gin.Context) {
+	if providerError := c.Query("error"); providerError != "" {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "OAuth authorization denied: "+providerError)
+		return
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Missing code or state")
+		return
+	}
+
+	binding, _ := c.Cookie(oauthBindingCookie)
+	h.completeOAuth(c, code, state, binding, nil)
+}
+
+// OAuthCallbackPost completes a social login flow for apps that receive the
+// provider redirect themselves
+// @Summary OAuth callback (app)
+// @Description Complete the authorization code flow with the code and state received by the app's redirect handler and the binding returned when the flow was started. A state can be used once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param request body OAuthCallbackRequest true "Code and state"
+// @Success 200 {object} AuthResponse
+// @Success 202 {object} TwoFactorChallengeResponse
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 429 {object} utils.Response
+// @Router /auth/oauth/{provider}/callback [post]
+func (h *Handler) OAuthCallbackPost(c *gin.Context) {
+	var req OAuthCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	binding := req.Binding
+	if binding == "" {
+		binding, _ = c.Cookie(oauthBindingCookie)
+	}
+	h.completeOAuth(c, req.Code, req.State, binding, req.DeviceInfo)
+}
+
+// StartOAuthLink starts linking a provider to the current user
+// @Summary Link OAuth provider
+// @Description Start an authorization code flow that links the provider identity to the current user. The callback must present the returned binding, or the cookie set by this response.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param provider path string true "Provider name"
+// @Success 200 {object} utils.Response{data=OAuthStartResponse}
+// @Failure 401 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /auth/oauth/{provider}/link [post]
+func (h *Handler) StartOAuthLink(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx := context.Background()
+	authURL, binding, err := h.oauthService.Start(ctx, c.Param("provider"), userID.(uint))
+	if err != nil {
+		h.oauthError(c, err)
+		return
+	}
+
+	h.setOAuthBinding(c, path.Join(path.Dir(c.Request.URL.Path), "callback"), binding)
+	utils.SuccessResponse(c, http.StatusOK, "OAuth link flow started", OAuthStartResponse{AuthorizationURL: authURL, Binding: binding})
+}
+
+// UnlinkOAuth removes a linked provider from the current user
+// @Summary Unlink OAuth provider
+// @Description Remove a linked provider. The last sign-in method of an account cannot be removed.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param provider path string true "Provider name"
+// @Success 200 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Router /auth/oauth/{provider}/link [delete]
+func (h *Handler) UnlinkOAuth(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx := context.Background()
+	if err := h.oauthService.Unlink(ctx, userID.(uint), c.Param("provider")); err != nil {
+		h.oauthError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "OAuth provider unlinked successfully", nil)
+}
+
+// ListOAuthLinks lists the providers linked to the current user
+// @Summary List linked OAuth providers
+// @Description List the social login providers linked to the current user
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]OAuthLinkData}
+// @Failure 401 {object} utils.Response
+// @Router /auth/oauth/links [get]
+func (h *Handler) ListOAuthLinks(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx := context.Background()
+	links, err := h.oauthService.Links(ctx, userID.(uint))
+	if err != nil {
+		h.logger.Error("Failed to list OAuth links", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to list linked providers")
+		return
+	}
+
+	response := make([]OAuthLinkData, 0, len(links))
+	for _, link := range links {
+		response = append(response, newOAuthLinkData(link))
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Linked providers retrieved successfully", response)
+}
+
+// setOAuthBinding stores the flow binding in an HttpOnly cookie sent only to
+// the provider callback at callbackPath
+func (h *Handler) setOAuthBinding(c *gin.Context, callbackPath, binding string) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthBindingCookie, binding, h.cfg.Auth.OAuth.StateExpire*60, callbackPath, "", secure, true)
+}
+
+// completeOAuth finishes a provider flow and writes the response
+func (h *Handler) completeOAuth(c *gin.Context, code, state, binding string, deviceInfo map[string]interface{}) {
+	ctx := context.Background()
+	if h.ipBlocked(ctx, c) {
+		return
+	}
+
+	result, err := h.oauthService.Complete(ctx, c.Param("provider"), code, state, binding)
+	if err != nil {
+		h.oauthError(c, err)
+		return
+	}
+
+	// The state is used up either way
+	c.SetCookie(oauthBindingCookie, "", -1, c.Request.URL.Path, "", false, true)
+
+	if result.Linked {
+		utils.SuccessResponse(c, http.StatusOK, "OAuth provider linked successfully", newOAuthLinkData(result.Link))
+		return
+	}
+
+	if !result.User.IsActive {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User account is inactive")
+		return
+	}
+
+	// Social logins do not get around a lockout
+	if h.lockout.IsLocked(result.User) {
+		h.loginFailed(ctx, c, result.User, loginFailureAccountLocked)
+		return
+	}
+
+	if result.Created {
+		h.logger.Info("Created user from OAuth login",
+			zap.Uint("user_id", result.User.ID),
+			zap.String("provider", result.Link.Provider),
+		)
+	}
+
+	h.completeLogin(ctx, c, result.User, deviceInfo)
+}
+
+// oauthError maps OAuth service errors to responses
+func (h *Handler) oauthError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, oauth.ErrUnknownProvider):
+		utils.ErrorResponse(c, http.StatusNotFound, "OAuth provider not found")
+	case errors.Is(err, oauth.ErrInvalidState):
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, oauth.ErrEmailRequired):
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, oauth.ErrAlreadyLinked), errors.Is(err, oauth.ErrAccountExists), errors.Is(err, oauth.ErrLastLoginMethod):
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	default:
+		h.logger.Error("OAuth flow failed", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusUnauthorized, "OAuth authentication failed")
+	}
+}
+
+// newOAuthLinkData converts a provider link to its response form
+func newOAuthLinkData(link *repository.OAuthProvider) OAuthLinkData {
+	return OAuthLinkData{
+		Provider:       link.Provider,
+		ProviderUserID: link.ProviderUserID,
+		CreatedAt:      link.CreatedAt,
+	}
+}
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go-mobile-backend-template/internal/services/auth"
+	"go-mobile-backend-template/internal/services/mail"
+	"go-mobile-backend-template/internal/utils"
+	"go-mobile-backend-template/pkg/config"
+)
+
+// newNotifier builds the email notifier from configuration. A misconfigured
+// mailer stops the server from starting rather than silently dropping
+// password reset and verification emails.
+func newNotifier(logger *zap.Logger, cfg *config.Config) *auth.Notifier {
+	mailer, err := mail.New(cfg.Mail)
+	if err != nil {
+		logger.Fatal("Failed to configure mailer", zap.Error(err))
+	}
+
+	renderer, err := mail.NewRenderer()
+	if err != nil {
+		logger.Fatal("Failed to parse email templates", zap.Error(err))
+	}
+
+	return auth.NewNotifier(mailer, renderer, logger)
+}
+
+// ForgotPassword emails a password reset link
+// @Summary Request password reset
+// @Description Email a single-use password reset link. The response is the same whether or not the email is registered.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ForgotPasswordRequest true "Account email"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Router /auth/forgot-password [post]
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ctx := context.Background()
+	if err := h.passwordReset.Request(ctx, req.Email); err != nil {
+		h.logger.Error("Failed to create password reset token", zap.Error(err))
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "If the email is registered, a password reset link has been sent", nil)
+}
+
+// ResetPassword sets a new password using a reset token
+// @Summary Reset password
+// @Description Set a new password with a reset token. All sessions and refresh tokens of the user are revoked.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Router /auth/reset-password [post]
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ctx := context.Background()
+	if err := h.passwordReset.Reset(ctx, req.Token, req.NewPassword); err != nil {
+		if errors.Is(err, auth.ErrInvalidResetToken) {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.Error("Failed to reset password", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Password reset successfully", nil)
+}
+
+// VerifyEmail confirms an email address using a verification token
+// @Summary Verify email
+// @Description Confirm the user's email address with a verification token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body VerifyEmailRequest true "Verification token"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Router /auth/verify-email [post]
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ctx := context.Background()
+	if _, err := h.emailVerifier.Verify(ctx, req.Token); err != nil {
+		if errors.Is(err, auth.ErrInvalidVerificationToken) {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.Error("Failed to verify email", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to verify email")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Email verified successfully", nil)
+}
+
+// ResendVerificationEmail sends a new verification email to the current user
+// @Summary Resend verification email
+// @Description Send a new email verification link. Earlier links stop working.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Router /auth/verify-email/resend [post]
+func (h *Handler) ResendVerificationEmail(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx := context.Background()
+	user, err := h.userRepo.GetByID(ctx, userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if err := h.emailVerifier.Send(ctx, user); err != nil {
+		if errors.Is(err, auth.ErrEmailAlreadyVerified) {
+			utils.ErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		h.logger.Error("Failed to send verification email", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to send verification email")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Verification email sent", nil)
+}
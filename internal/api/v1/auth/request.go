@@ -1,16 +1,46 @@
 package auth
 
+import "time"
+
 // RegisterRequest represents user registration request
 type RegisterRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8"`
-	Name     string `json:"name" binding:"required,min=2"`
+	Email      string                 `json:"email" binding:"required,email"`
+	Password   string                 `json:"password" binding:"required,min=8"`
+	Name       string                 `json:"name" binding:"required,min=2"`
+	DeviceInfo map[string]interface{} `json:"device_info,omitempty"`
 }
 
 // LoginRequest represents user login request
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
+	Email      string                 `json:"email" binding:"required,email"`
+	Password   string                 `json:"password" binding:"required"`
+	DeviceInfo map[string]interface{} `json:"device_info,omitempty"`
+}
+
+// TwoFactorLoginRequest completes a login that requires a second factor
+type TwoFactorLoginRequest struct {
+	ChallengeToken string                 `json:"challenge_token" binding:"required"`
+	Code           string                 `json:"code" binding:"required"`
+	DeviceInfo     map[string]interface{} `json:"device_info,omitempty"`
+}
+
+// TwoFactorCodeRequest represents a request carrying a TOTP or backup code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorDisableRequest represents a request to turn off two-factor authentication
+type TwoFactorDisableRequest struct {
 	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// OAuthCallbackRequest completes a social login from an app redirect handler
+type OAuthCallbackRequest struct {
+	Code       string                 `json:"code" binding:"required"`
+	State      string                 `json:"state" binding:"required"`
+	Binding    string                 `json:"binding,omitempty"`
+	DeviceInfo map[string]interface{} `json:"device_info,omitempty"`
 }
 
 // RefreshTokenRequest represents refresh token request
@@ -18,6 +48,22 @@ type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
+// ForgotPasswordRequest represents a password reset request
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest sets a new password with a reset token
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8"`
+}
+
+// VerifyEmailRequest confirms an email address with a verification token
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
 // ChangePasswordRequest represents change password request
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
@@ -32,11 +78,84 @@ type AuthResponse struct {
 	ExpiresIn    int      `json:"expires_in"`
 }
 
+// TwoFactorChallengeResponse is returned by login when a second factor is required
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"`
+}
+
+// TwoFactorEnrollResponse carries the secret to add to an authenticator app
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TwoFactorBackupCodesResponse carries one-time backup codes, shown only once
+type TwoFactorBackupCodesResponse struct {
+	BackupCodes []string `json:"backup_codes"`
+}
+
+// OAuthStartResponse carries the provider authorization URL and the binding
+// the callback must present
+type OAuthStartResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	Binding          string `json:"binding"`
+}
+
+// OAuthLinkData represents a provider linked to the current user
+type OAuthLinkData struct {
+	Provider       string    `json:"provider"`
+	ProviderUserID string    `json:"provider_user_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
 // UserData represents user data in auth response
 type UserData struct {
-	ID       uint   `json:"id"`
-	Email    string `json:"email"`
-	Name     string `json:"name"`
-	IsActive bool   `json:"is_active"`
-	IsAdmin  bool   `json:"is_admin"`
+	ID            uint   `json:"id"`
+	Email         string `json:"email"`
+	Name          string `json:"name"`
+	IsActive      bool   `json:"is_active"`
+	IsAdmin       bool   `json:"is_admin"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// SessionData represents a device session in session listings
+type SessionData struct {
+	ID         uint                   `json:"id"`
+	DeviceInfo map[string]interface{} `json:"device_info,omitempty"`
+	IPAddress  string                 `json:"ip_address,omitempty"`
+	UserAgent  string                 `json:"user_agent,omitempty"`
+	Current    bool                   `json:"current"`
+	CreatedAt  time.Time              `json:"created_at"`
+	LastUsedAt time.Time              `json:"last_used_at"`
+	ExpiresAt  time.Time              `json:"expires_at"`
+}
+
+// CreateAPIKeyRequest represents an API key creation request
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	RateLimit int        `json:"rate_limit" binding:"omitempty,min=1"` // requests per rate window
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// APIKeyData represents an API key in listings. The key itself is never
+// returned after creation; the prefix identifies it.
+type APIKeyData struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	RateLimit  int        `json:"rate_limit"`
+	IsActive   bool       `json:"is_active"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateAPIKeyResponse represents a newly created API key
+type CreateAPIKeyResponse struct {
+	APIKeyData
+	Key string `json:"key"`
 }
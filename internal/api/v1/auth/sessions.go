@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go-mobile-backend-template/internal/services/auth"
+	"go-mobile-backend-template/internal/utils"
+)
+
+// ListSessions lists the active sessions of the current user
+// @Summary List sessions
+// @Description List the active device sessions of the current user
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]SessionData}
+// @Failure 401 {object} utils.Response
+// @Router /auth/sessions [get]
+func (h *Handler) ListSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx := context.Background()
+	sessions, err := h.sessionService.List(ctx, userID.(uint))
+	if err != nil {
+		h.logger.Error("Failed to list sessions", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to list sessions")
+		return
+	}
+
+	currentID := c.GetUint("session_id")
+	response := make([]SessionData, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, SessionData{
+			ID:         session.ID,
+			DeviceInfo: session.DeviceInfo,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			Current:    session.ID == currentID,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+		})
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sessions retrieved successfully", response)
+}
+
+// RevokeSession revokes one session of the current user
+// @Summary Revoke session
+// @Description Revoke one of the current user's sessions. Tokens bound to it stop working immediately.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Session ID"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /auth/sessions/{id} [delete]
+func (h *Handler) RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	ctx := context.Background()
+	if err := h.sessionService.Revoke(ctx, userID.(uint), uint(sessionID)); err != nil {
+		if errors.Is(err, auth.ErrSessionNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Session not found")
+			return
+		}
+		h.logger.Error("Failed to revoke session", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Session revoked successfully", nil)
+}
+
+// RevokeOtherSessions revokes every session of the current user except the current one
+// @Summary Revoke other sessions
+// @Description Sign out of all other devices, keeping the current session
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Router /auth/sessions [delete]
+func (h *Handler) RevokeOtherSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx := context.Background()
+	if err := h.sessionService.RevokeOthers(ctx, userID.(uint), c.GetUint("session_id")); err != nil {
+		h.logger.Error("Failed to revoke sessions", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Other sessions revoked successfully", nil)
+}
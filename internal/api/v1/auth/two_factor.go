@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go-mobile-backend-template/internal/services/auth"
+	"go-mobile-backend-template/internal/utils"
+)
+
+// EnrollTwoFactor starts two-factor enrollment for the current user
+// @Summary Enroll in two-factor authentication
+// @Description Generate a TOTP secret and otpauth:// URI. Two-factor authentication is enforced once confirmed.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=TwoFactorEnrollResponse}
+// @Failure 401 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Router /auth/2fa/enroll [post]
+func (h *Handler) EnrollTwoFactor(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx := context.Background()
+	user, err := h.userRepo.GetByID(ctx, userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	enrollment, err := h.twoFactorService.Enroll(ctx, user.ID, user.Email)
+	if err != nil {
+		if errors.Is(err, auth.ErrTwoFactorAlreadyEnabled) {
+			utils.ErrorResponse(c, http.StatusConflict, "Two-factor authentication is already enabled")
+			return
+		}
+		h.logger.Error("Failed to enroll two-factor authentication", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to enroll two-factor authentication")
+		return
+	}
+
+	response := TwoFactorEnrollResponse{
+		Secret:     enrollment.Secret,
+		OTPAuthURI: enrollment.URI,
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Two-factor enrollment started", response)
+}
+
+// ConfirmTwoFactor enables two-factor authentication for the current user
+// @Summary Confirm two-factor authentication
+// @Description Verify a code from the authenticator app and enable two-factor authentication. Returns one-time backup codes that are not shown again.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} utils.Response{data=TwoFactorBackupCodesResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Router /auth/2fa/confirm [post]
+func (h *Handler) ConfirmTwoFactor(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ctx := context.Background()
+	backupCodes, err := h.twoFactorService.Confirm(ctx, userID.(uint), req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrTwoFactorNotEnrolled):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Two-factor enrollment has not been started")
+		case errors.Is(err, auth.ErrTwoFactorAlreadyEnabled):
+			utils.ErrorResponse(c, http.StatusConflict, "Two-factor authentication is already enabled")
+		case errors.Is(err, auth.ErrInvalidTwoFactorCode):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid two-factor code")
+		default:
+			h.logger.Error("Failed to confirm two-factor authentication", zap.Error(err))
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to confirm two-factor authentication")
+		}
+		return
+	}
+
+	response := TwoFactorBackupCodesResponse{BackupCodes: backupCodes}
+	utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication enabled", response)
+}
+
+// DisableTwoFactor turns off two-factor authentication for the current user
+// @Summary Disable two-factor authentication
+// @Description Disable two-factor authentication after verifying the password and a TOTP or backup code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TwoFactorDisableRequest true "Password and code"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Router /auth/2fa/disable [post]
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ctx := context.Background()
+	user, err := h.userRepo.GetByID(ctx, userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if err := auth.CheckPassword(user.Password, req.Password); err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid credentials")
+		return
+	}
+
+	if err := h.twoFactorService.Disable(ctx, user.ID, req.Code); err != nil {
+		switch {
+		case errors.Is(err, auth.ErrTwoFactorNotEnabled):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Two-factor authentication is not enabled")
+		case errors.Is(err, auth.ErrInvalidTwoFactorCode):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid two-factor code")
+		default:
+			h.logger.Error("Failed to disable two-factor authentication", zap.Error(err))
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to disable two-factor authentication")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication disabled", nil)
+}
+
+// VerifyTwoFactor completes a login that requires a second factor
+// @Summary Complete two-factor login
+// @Description Exchange the challenge token from /auth/login and a TOTP or backup code for a token pair. Each challenge token can be used once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body TwoFactorLoginRequest true "Challenge token and code"
+// @Success 200 {object} AuthResponse
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 429 {object} utils.Response
+// @Router /auth/2fa/verify [post]
+func (h *Handler) VerifyTwoFactor(c *gin.Context) {
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Codes are guessed at like passwords, so blocked IPs are turned away
+	ctx := context.Background()
+	if h.ipBlocked(ctx, c) {
+		return
+	}
+
+	claims, err := h.jwtService.ValidateTwoFactorChallenge(req.ChallengeToken)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, auth.ErrTwoFactorChallengeFailed.Error())
+		return
+	}
+
+	// A challenge is good for one attempt; after a wrong code the user logs
+	// in again for a new one
+	consumed, err := h.denylist.Consume(ctx, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		h.logger.Error("Failed to consume two-factor challenge", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to verify two-factor code")
+		return
+	}
+	if !consumed {
+		utils.ErrorResponse(c, http.StatusUnauthorized, auth.ErrTwoFactorChallengeFailed.Error())
+		return
+	}
+
+	user, err := h.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil || !user.IsActive {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid credentials")
+		return
+	}
+
+	// Wrong codes count towards the account lockout like wrong passwords
+	if h.lockout.IsLocked(user) {
+		h.recordLoginFailure(ctx, c, user, loginFailureAccountLocked)
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid two-factor code")
+		return
+	}
+
+	if err := h.twoFactorService.Verify(ctx, user.ID, req.Code); err != nil {
+		if !errors.Is(err, auth.ErrInvalidTwoFactorCode) && !errors.Is(err, auth.ErrTwoFactorNotEnabled) {
+			h.logger.Error("Failed to verify two-factor code", zap.Error(err))
+		}
+		h.recordLoginFailure(ctx, c, user, loginFailureInvalidCode)
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid two-factor code")
+		return
+	}
+
+	h.recordLoginSuccess(ctx, user)
+
+	// Open a session and generate tokens
+	response, err := h.issueTokens(ctx, c, user, req.DeviceInfo)
+	if err != nil {
+		h.logger.Error("Failed to issue tokens", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Login successful", response)
+}
@@ -1,34 +1,29 @@
 package dancing_table
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"go-mobile-backend-template/internal/middleware"
-	authService "go-mobile-backend-template/internal/services/auth"
-	"go-mobile-backend-template/pkg/config"
 )
 
 // RegisterRoutes registers dancing_table routes
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cfg *config.Config) {
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, guard *middleware.RouteGuard) {
 	handler := NewHandler(db, logger)
-	
-	// Initialize JWT service for auth middleware
-	jwtService := authService.NewJWTService(
-		cfg.JWT.Secret,
-		cfg.JWT.AccessTokenExpireInt,
-		cfg.JWT.RefreshTokenExpireInt,
-	)
 
 	// dancing_table routes (all protected)
 	dancingTableRoutes := router.Group("/dancing_table")
-	dancingTableRoutes.Use(middleware.AuthMiddleware(jwtService))
+	dancingTableRoutes.Use(guard.Authenticate())
+	dancingTableRoutes.Use(guard.Limit("dancing_table", 110, time.Minute))
+	dancingTableRoutes.Use(guard.Audit())
 	{
-		dancingTableRoutes.POST("", handler.CreateDancingtable)
-		dancingTableRoutes.GET("", handler.GetAllDancingtables)
-		dancingTableRoutes.GET("/:id", handler.GetDancingtable)
-		dancingTableRoutes.PUT("/:id", handler.UpdateDancingtable)
-		dancingTableRoutes.DELETE("/:id", handler.DeleteDancingtable)
+		dancingTableRoutes.POST("", guard.Require("dancing_table", "write"), handler.CreateDancingtable)
+		dancingTableRoutes.GET("", guard.Require("dancing_table", "read"), handler.GetAllDancingtables)
+		dancingTableRoutes.GET("/:id", guard.Require("dancing_table", "read"), handler.GetDancingtable)
+		dancingTableRoutes.PUT("/:id", guard.Require("dancing_table", "write"), handler.UpdateDancingtable)
+		dancingTableRoutes.DELETE("/:id", guard.Require("dancing_table", "delete"), handler.DeleteDancingtable)
 	}
 }
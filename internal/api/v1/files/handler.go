@@ -62,8 +62,6 @@ func (h *Handler) CreateFiles(c *gin.Context) {
 
 		Ispublic: req.Ispublic,
 
-		Deletedat: req.Deletedat,
-
 	}
 
 	ctx := context.Background()
@@ -286,10 +284,6 @@ func (h *Handler) UpdateFiles(c *gin.Context) {
 
 
 	
-	files.Userid = req.Userid
-	
-
-	
 	files.Filename = req.Filename
 	
 
@@ -313,10 +307,6 @@ func (h *Handler) UpdateFiles(c *gin.Context) {
 	files.Ispublic = req.Ispublic
 	
 
-	
-	files.Deletedat = req.Deletedat
-	
-
 
 	if err := h.filesRepo.Update(ctx, files); err != nil {
 		h.logger.Error("Failed to update files", zap.Error(err))
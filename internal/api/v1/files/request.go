@@ -47,15 +47,11 @@ type FilesCreateRequest struct {
 
 	Ispublic bool `json:"is_public" binding:""`
 
-	Deletedat time.Time `json:"deleted_at" binding:""`
-
 }
 
 // FilesUpdateRequest represents update files request
 type FilesUpdateRequest struct {
 
-	Userid uint `json:"user_id" binding:"omitempty"`
-
 	Filename string `json:"file_name" binding:"omitempty"`
 
 	Filesize uint `json:"file_size" binding:"omitempty"`
@@ -68,8 +64,6 @@ type FilesUpdateRequest struct {
 
 	Ispublic bool `json:"is_public" binding:"omitempty"`
 
-	Deletedat time.Time `json:"deleted_at" binding:"omitempty"`
-
 }
 
 // PaginationResponse represents pagination response
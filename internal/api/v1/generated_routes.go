@@ -5,75 +5,60 @@ import (
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
-	"go-mobile-backend-template/pkg/config"
+	"go-mobile-backend-template/internal/middleware"
 
 	"go-mobile-backend-template/internal/api/v1/dancing_table"
 
-	"go-mobile-backend-template/internal/api/v1/password_reset_tokens"
-
-	"go-mobile-backend-template/internal/api/v1/refresh_tokens"
+	"go-mobile-backend-template/internal/api/v1/files"
 
-	"go-mobile-backend-template/internal/api/v1/role_permissions"
+	"go-mobile-backend-template/internal/api/v1/oauth_providers"
 
-	"go-mobile-backend-template/internal/api/v1/user_2fa"
+	"go-mobile-backend-template/internal/api/v1/permissions"
 
-	"go-mobile-backend-template/internal/api/v1/email_verification_tokens"
+	"go-mobile-backend-template/internal/api/v1/refresh_tokens"
 
-	"go-mobile-backend-template/internal/api/v1/files"
+	"go-mobile-backend-template/internal/api/v1/role_permissions"
 
-	"go-mobile-backend-template/internal/api/v1/permissions"
+	"go-mobile-backend-template/internal/api/v1/roles"
 
 	"go-mobile-backend-template/internal/api/v1/sessions"
 
 	"go-mobile-backend-template/internal/api/v1/test_table"
 
-	"go-mobile-backend-template/internal/api/v1/users"
-
-	"go-mobile-backend-template/internal/api/v1/api_keys"
-
-	"go-mobile-backend-template/internal/api/v1/oauth_providers"
-
-	"go-mobile-backend-template/internal/api/v1/roles"
-
 	"go-mobile-backend-template/internal/api/v1/user_roles"
 
+	"go-mobile-backend-template/internal/api/v1/users"
+
 	"go-mobile-backend-template/internal/api/v1/wow_table"
 
 )
 
-// RegisterGeneratedRoutes registers all auto-generated API routes
-func RegisterGeneratedRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cfg *config.Config) {
-
-	dancing_table.RegisterRoutes(router, db, logger, cfg)
-
-	password_reset_tokens.RegisterRoutes(router, db, logger, cfg)
-
-	refresh_tokens.RegisterRoutes(router, db, logger, cfg)
-
-	role_permissions.RegisterRoutes(router, db, logger, cfg)
+// RegisterGeneratedRoutes registers all auto-generated API routes, protected
+// by guard as their tables are configured
+func RegisterGeneratedRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, guard *middleware.RouteGuard) {
 
-	user_2fa.RegisterRoutes(router, db, logger, cfg)
+	dancing_table.RegisterRoutes(router, db, logger, guard)
 
-	email_verification_tokens.RegisterRoutes(router, db, logger, cfg)
+	files.RegisterRoutes(router, db, logger, guard)
 
-	files.RegisterRoutes(router, db, logger, cfg)
+	oauth_providers.RegisterRoutes(router, db, logger, guard)
 
-	permissions.RegisterRoutes(router, db, logger, cfg)
+	permissions.RegisterRoutes(router, db, logger, guard)
 
-	sessions.RegisterRoutes(router, db, logger, cfg)
+	refresh_tokens.RegisterRoutes(router, db, logger, guard)
 
-	test_table.RegisterRoutes(router, db, logger, cfg)
+	role_permissions.RegisterRoutes(router, db, logger, guard)
 
-	users.RegisterRoutes(router, db, logger, cfg)
+	roles.RegisterRoutes(router, db, logger, guard)
 
-	api_keys.RegisterRoutes(router, db, logger, cfg)
+	sessions.RegisterRoutes(router, db, logger, guard)
 
-	oauth_providers.RegisterRoutes(router, db, logger, cfg)
+	test_table.RegisterRoutes(router, db, logger, guard)
 
-	roles.RegisterRoutes(router, db, logger, cfg)
+	user_roles.RegisterRoutes(router, db, logger, guard)
 
-	user_roles.RegisterRoutes(router, db, logger, cfg)
+	users.RegisterRoutes(router, db, logger, guard)
 
-	wow_table.RegisterRoutes(router, db, logger, cfg)
+	wow_table.RegisterRoutes(router, db, logger, guard)
 
 }
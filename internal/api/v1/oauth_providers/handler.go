@@ -54,10 +54,6 @@ func (h *Handler) CreateOauthproviders(c *gin.Context) {
 
 		Provideruserid: req.Provideruserid,
 
-		Accesstoken: req.Accesstoken,
-
-		Refreshtoken: req.Refreshtoken,
-
 		Tokenexpiresat: req.Tokenexpiresat,
 
 		Profiledata: req.Profiledata,
@@ -81,10 +77,6 @@ func (h *Handler) CreateOauthproviders(c *gin.Context) {
 
 		Provideruserid: oauthProviders.Provideruserid,
 
-		Accesstoken: oauthProviders.Accesstoken,
-
-		Refreshtoken: oauthProviders.Refreshtoken,
-
 		Tokenexpiresat: oauthProviders.Tokenexpiresat,
 
 		Profiledata: oauthProviders.Profiledata,
@@ -141,10 +133,6 @@ func (h *Handler) GetOauthproviders(c *gin.Context) {
 
 		Provideruserid: oauthProviders.Provideruserid,
 
-		Accesstoken: oauthProviders.Accesstoken,
-
-		Refreshtoken: oauthProviders.Refreshtoken,
-
 		Tokenexpiresat: oauthProviders.Tokenexpiresat,
 
 		Profiledata: oauthProviders.Profiledata,
@@ -204,10 +192,6 @@ func (h *Handler) GetAllOauthproviderss(c *gin.Context) {
 
 			Provideruserid: oauthProviders.Provideruserid,
 
-			Accesstoken: oauthProviders.Accesstoken,
-
-			Refreshtoken: oauthProviders.Refreshtoken,
-
 			Tokenexpiresat: oauthProviders.Tokenexpiresat,
 
 			Profiledata: oauthProviders.Profiledata,
@@ -290,14 +274,6 @@ func (h *Handler) UpdateOauthproviders(c *gin.Context) {
 	
 
 	
-	oauthProviders.Accesstoken = req.Accesstoken
-	
-
-	
-	oauthProviders.Refreshtoken = req.Refreshtoken
-	
-
-	
 	oauthProviders.Tokenexpiresat = req.Tokenexpiresat
 	
 
@@ -322,10 +298,6 @@ func (h *Handler) UpdateOauthproviders(c *gin.Context) {
 
 		Provideruserid: oauthProviders.Provideruserid,
 
-		Accesstoken: oauthProviders.Accesstoken,
-
-		Refreshtoken: oauthProviders.Refreshtoken,
-
 		Tokenexpiresat: oauthProviders.Tokenexpiresat,
 
 		Profiledata: oauthProviders.Profiledata,
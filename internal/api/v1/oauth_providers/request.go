@@ -14,10 +14,6 @@ type OauthprovidersResponse struct {
 
 	Provideruserid string `json:"provider_user_id"`
 
-	Accesstoken string `json:"access_token"`
-
-	Refreshtoken string `json:"refresh_token"`
-
 	Tokenexpiresat time.Time `json:"token_expires_at"`
 
 	Profiledata string `json:"profile_data"`
@@ -37,10 +33,6 @@ type OauthprovidersCreateRequest struct {
 
 	Provideruserid string `json:"provider_user_id" binding:"required,max=255"`
 
-	Accesstoken string `json:"access_token" binding:""`
-
-	Refreshtoken string `json:"refresh_token" binding:""`
-
 	Tokenexpiresat time.Time `json:"token_expires_at" binding:""`
 
 	Profiledata string `json:"profile_data" binding:""`
@@ -56,10 +48,6 @@ type OauthprovidersUpdateRequest struct {
 
 	Provideruserid string `json:"provider_user_id" binding:"omitempty,max=255"`
 
-	Accesstoken string `json:"access_token" binding:"omitempty"`
-
-	Refreshtoken string `json:"refresh_token" binding:"omitempty"`
-
 	Tokenexpiresat time.Time `json:"token_expires_at" binding:"omitempty"`
 
 	Profiledata string `json:"profile_data" binding:"omitempty"`
@@ -1,34 +1,29 @@
 package oauth_providers
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"go-mobile-backend-template/internal/middleware"
-	authService "go-mobile-backend-template/internal/services/auth"
-	"go-mobile-backend-template/pkg/config"
 )
 
 // RegisterRoutes registers oauth_providers routes
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cfg *config.Config) {
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, guard *middleware.RouteGuard) {
 	handler := NewHandler(db, logger)
-	
-	// Initialize JWT service for auth middleware
-	jwtService := authService.NewJWTService(
-		cfg.JWT.Secret,
-		cfg.JWT.AccessTokenExpireInt,
-		cfg.JWT.RefreshTokenExpireInt,
-	)
 
 	// oauth_providers routes (all protected)
 	oauthProvidersRoutes := router.Group("/oauth_providers")
-	oauthProvidersRoutes.Use(middleware.AuthMiddleware(jwtService))
+	oauthProvidersRoutes.Use(guard.Authenticate())
+	oauthProvidersRoutes.Use(guard.Limit("oauth_providers", 110, time.Minute))
+	oauthProvidersRoutes.Use(guard.Audit())
 	{
-		oauthProvidersRoutes.POST("", handler.CreateOauthproviders)
-		oauthProvidersRoutes.GET("", handler.GetAllOauthproviderss)
-		oauthProvidersRoutes.GET("/:id", handler.GetOauthproviders)
-		oauthProvidersRoutes.PUT("/:id", handler.UpdateOauthproviders)
-		oauthProvidersRoutes.DELETE("/:id", handler.DeleteOauthproviders)
+		oauthProvidersRoutes.POST("", guard.Require("oauth_providers", "write"), handler.CreateOauthproviders)
+		oauthProvidersRoutes.GET("", guard.Require("oauth_providers", "read"), handler.GetAllOauthproviderss)
+		oauthProvidersRoutes.GET("/:id", guard.Require("oauth_providers", "read"), handler.GetOauthproviders)
+		oauthProvidersRoutes.PUT("/:id", guard.Require("oauth_providers", "write"), handler.UpdateOauthproviders)
+		oauthProvidersRoutes.DELETE("/:id", guard.Require("oauth_providers", "delete"), handler.DeleteOauthproviders)
 	}
 }
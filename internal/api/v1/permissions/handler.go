@@ -10,6 +10,7 @@ import (
 	"gorm.io/gorm"
 
 	"go-mobile-backend-template/internal/db/repository/generated"
+	"go-mobile-backend-template/internal/services/auth"
 	"go-mobile-backend-template/internal/utils"
 )
 
@@ -17,13 +18,40 @@ import (
 type Handler struct {
 	permissionsRepo generated.PermissionsRepository
 	logger             *zap.Logger
+	permissions        *auth.PermissionResolver
 }
 
-// NewHandler creates a new permissions handler
-func NewHandler(db *gorm.DB, logger *zap.Logger) *Handler {
+// NewHandler creates a new permissions handler. Writes change the
+// permissions of users, so they invalidate those cached by permissions,
+// which may be nil.
+func NewHandler(db *gorm.DB, logger *zap.Logger, permissions *auth.PermissionResolver) *Handler {
 	return &Handler{
 		permissionsRepo: generated.NewPermissionsRepository(db),
 		logger:             logger,
+		permissions:        permissions,
+	}
+}
+
+// usersGrantedBy returns the users whose permissions depend on the
+// permissions rows of key
+func (h *Handler) usersGrantedBy(ctx context.Context, key uint) []uint {
+	if h.permissions == nil {
+		return nil
+	}
+	userIDs, err := h.permissions.UsersGrantedBy(ctx, "permissions", key)
+	if err != nil {
+		h.logger.Error("Failed to get users granted by permissions", zap.Error(err))
+	}
+	return userIDs
+}
+
+// invalidatePermissions drops the cached permissions of users
+func (h *Handler) invalidatePermissions(ctx context.Context, userIDs []uint) {
+	if h.permissions == nil {
+		return
+	}
+	if err := h.permissions.Invalidate(ctx, userIDs...); err != nil {
+		h.logger.Error("Failed to invalidate permissions", zap.Error(err))
 	}
 }
 
@@ -64,6 +92,7 @@ func (h *Handler) CreatePermissions(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create permissions")
 		return
 	}
+	h.invalidatePermissions(ctx, h.usersGrantedBy(ctx, permissions.Id))
 
 	response := PermissionsResponse{
 
@@ -252,6 +281,9 @@ func (h *Handler) UpdatePermissions(c *gin.Context) {
 		return
 	}
 
+	// Users granted permissions by the row before the update may lose them
+	affected := h.usersGrantedBy(ctx, permissions.Id)
+
 
 	
 	permissions.Name = req.Name
@@ -275,6 +307,7 @@ func (h *Handler) UpdatePermissions(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update permissions")
 		return
 	}
+	h.invalidatePermissions(ctx, append(affected, h.usersGrantedBy(ctx, permissions.Id)...))
 
 	response := PermissionsResponse{
 
@@ -319,6 +352,8 @@ func (h *Handler) DeletePermissions(c *gin.Context) {
 	}
 
 	ctx := context.Background()
+	// Collect the users first; the assignments go with the rows
+	affected := h.usersGrantedBy(ctx, uint(id))
 	if err := h.permissionsRepo.Delete(ctx, uint(id)); err != nil {
 		if err == gorm.ErrRecordNotFound {
 			utils.ErrorResponse(c, http.StatusNotFound, "permissions not found")
@@ -328,6 +363,7 @@ func (h *Handler) DeletePermissions(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete permissions")
 		return
 	}
+	h.invalidatePermissions(ctx, affected)
 
 	utils.SuccessResponse(c, http.StatusOK, "permissions deleted successfully", nil)
 }
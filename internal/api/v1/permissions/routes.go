@@ -1,34 +1,29 @@
 package permissions
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"go-mobile-backend-template/internal/middleware"
-	authService "go-mobile-backend-template/internal/services/auth"
-	"go-mobile-backend-template/pkg/config"
 )
 
 // RegisterRoutes registers permissions routes
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cfg *config.Config) {
-	handler := NewHandler(db, logger)
-	
-	// Initialize JWT service for auth middleware
-	jwtService := authService.NewJWTService(
-		cfg.JWT.Secret,
-		cfg.JWT.AccessTokenExpireInt,
-		cfg.JWT.RefreshTokenExpireInt,
-	)
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, guard *middleware.RouteGuard) {
+	handler := NewHandler(db, logger, guard.Permissions())
 
 	// permissions routes (all protected)
 	permissionsRoutes := router.Group("/permissions")
-	permissionsRoutes.Use(middleware.AuthMiddleware(jwtService))
+	permissionsRoutes.Use(guard.Authenticate())
+	permissionsRoutes.Use(guard.Limit("permissions", 33, time.Minute))
+	permissionsRoutes.Use(guard.Audit())
 	{
-		permissionsRoutes.POST("", handler.CreatePermissions)
-		permissionsRoutes.GET("", handler.GetAllPermissionss)
-		permissionsRoutes.GET("/:id", handler.GetPermissions)
-		permissionsRoutes.PUT("/:id", handler.UpdatePermissions)
-		permissionsRoutes.DELETE("/:id", handler.DeletePermissions)
+		permissionsRoutes.POST("", guard.Require("permissions", "permissions:write"), handler.CreatePermissions)
+		permissionsRoutes.GET("", guard.Require("permissions", "permissions:read"), handler.GetAllPermissionss)
+		permissionsRoutes.GET("/:id", guard.Require("permissions", "permissions:read"), handler.GetPermissions)
+		permissionsRoutes.PUT("/:id", guard.Require("permissions", "permissions:write"), handler.UpdatePermissions)
+		permissionsRoutes.DELETE("/:id", guard.Require("permissions", "permissions:delete"), handler.DeletePermissions)
 	}
 }
@@ -1,9 +1,14 @@
 package realtime
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"go-mobile-backend-template/internal/middleware"
 	"go-mobile-backend-template/internal/realtime"
 	authService "go-mobile-backend-template/internal/services/auth"
 
@@ -12,6 +17,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// defaultHistoryLimit is the page size of GetHistory
+const defaultHistoryLimit = 50
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
@@ -42,78 +50,187 @@ func NewHandler(hub *realtime.Hub, logger *zap.Logger, jwtService *authService.J
 // @Accept json
 // @Produce json
 // @Param token query string true "JWT token for authentication"
-// @Param channel query string false "Channel to subscribe to"
+// @Param channel query []string false "Channels to subscribe to, repeatable" collectionFormat(multi)
+// @Param last_event_id query string false "Replay channel messages after this message ID"
+// @Param since query string false "Replay channel messages since this RFC 3339 time"
 // @Success 101 {string} string "Switching Protocols"
 // @Router /realtime/ws [get]
 func (h *Handler) HandleWebSocket(c *gin.Context) {
-	// Get token from query parameter (WebSocket can't send headers)
-	token := c.Query("token")
+	conn, ok := h.prepareConnection(c)
+	if !ok {
+		return
+	}
+
+	// Upgrade HTTP connection to WebSocket
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		h.logger.Error("Failed to upgrade to WebSocket", zap.Error(err))
+		return
+	}
+
+	// Create client
+	client := h.newClient(c, ws, conn)
+
+	// Register client with hub
+	h.hub.RegisterClient(client, conn.channels...)
+
+	// Start client goroutines
+	go client.WritePump()
+	go client.ReadPump()
+
+	if conn.resume {
+		h.hub.Replay(client, conn.channels, conn.replay)
+	}
+}
+
+// connection is an authenticated request to attach a client to the hub
+type connection struct {
+	token      string
+	claims     *authService.Claims
+	subscriber realtime.Subscriber
+	channels   []string
+	replay     realtime.HistoryQuery
+	resume     bool
+}
+
+// authenticate validates the access token of a realtime connection. It is
+// read from the token query parameter, since browsers cannot set headers
+// on WebSocket and EventSource requests, or else from the Authorization
+// header. On failure the response is written and false returned.
+func (h *Handler) authenticate(c *gin.Context) (*authService.Claims, bool) {
+	token := requestToken(c)
 	if token == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   "Token required",
 		})
-		return
+		return nil, false
 	}
 
 	// Validate token and get user info
-	userID, username, err := h.validateToken(token)
+	claims, err := h.jwtService.ValidateToken(token)
 	if err != nil {
-		h.logger.Error("Invalid token for WebSocket connection", zap.Error(err))
+		h.logger.Error("Invalid token for realtime connection", zap.Error(err))
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   "Invalid token",
 		})
-		return
+		return nil, false
 	}
 
-	// Get optional channel/room parameter
-	channel := c.Query("channel")
+	return claims, true
+}
 
-	// Upgrade HTTP connection to WebSocket
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
-	if err != nil {
-		h.logger.Error("Failed to upgrade to WebSocket", zap.Error(err))
-		return
+// requestToken returns the access token of a realtime request
+func requestToken(c *gin.Context) string {
+	token := c.Query("token")
+	if token == "" {
+		if scheme, bearer, ok := strings.Cut(c.GetHeader("Authorization"), " "); ok && scheme == "Bearer" {
+			token = bearer
+		}
 	}
+	return token
+}
 
-	// Create client
-	client := realtime.NewClient(
-		conn,
-		h.hub,
-		userID,
-		username,
-		channel,
-		c.Request.UserAgent(),
-		h.logger,
-	)
+// newClient creates the client of an authenticated connection. The client
+// keeps the token and address of the request for the calls it makes with
+// the rest RPC method, until an auth message replaces the token.
+func (h *Handler) newClient(c *gin.Context, ws *websocket.Conn, conn *connection) *realtime.Client {
+	client := realtime.NewClient(ws, h.hub, conn.subscriber, conn.claims.Email, c.Request.UserAgent(), h.logger)
+	var expiresAt time.Time
+	if conn.claims.ExpiresAt != nil {
+		expiresAt = conn.claims.ExpiresAt.Time
+	}
+	client.SetAccessToken(conn.token, expiresAt)
+	client.RemoteAddr = c.ClientIP()
+	return client
+}
 
-	// Register client with hub
-	h.hub.RegisterClient(client)
+// prepareConnection authenticates a connection request and checks the
+// channels it subscribes to and where it resumes from. On failure the
+// response is written and false returned.
+func (h *Handler) prepareConnection(c *gin.Context) (*connection, bool) {
+	claims, ok := h.authenticate(c)
+	if !ok {
+		return nil, false
+	}
 
-	// Start client goroutines
-	go client.WritePump()
-	go client.ReadPump()
+	conn := &connection{
+		token:  requestToken(c),
+		claims: claims,
+		subscriber: realtime.Subscriber{
+			UserID:  claims.UserID,
+			IsAdmin: claims.IsAdmin,
+		},
+		channels: c.QueryArray("channel"),
+	}
+	if claims.Roles != nil || claims.Scopes != nil {
+		conn.subscriber.Permissions = &authService.EffectivePermissions{Roles: claims.Roles, Permissions: claims.Scopes}
+	}
+
+	// Replay stored messages after the last one the client saw
+	lastEventID := c.Query("last_event_id")
+	if lastEventID == "" {
+		lastEventID = c.GetHeader("Last-Event-ID")
+	}
+	var err error
+	conn.replay, conn.resume, err = realtime.ReplayQuery(lastEventID, c.Query("since"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid last_event_id or since",
+		})
+		return nil, false
+	}
+
+	for _, channel := range conn.channels {
+		if channel == "" {
+			continue
+		}
+		if _, err := h.hub.AuthorizeChannel(c.Request.Context(), conn.subscriber, channel); err != nil {
+			h.logger.Warn("Realtime channel not authorized",
+				zap.Uint("user_id", claims.UserID),
+				zap.String("channel", channel),
+				zap.Error(err),
+			)
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Not authorized for channel",
+			})
+			return nil, false
+		}
+	}
+
+	return conn, true
 }
 
 // GetPresence godoc
 // @Summary Get presence information
-// @Description Get online presence for all users or a specific user
+// @Description Get presence for the users the caller sees, or a specific user, merged across their devices and instances. Users see themselves, their contacts and the members of the presence channels they are in; admins see everyone.
 // @Tags realtime
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param user_id query int false "User ID to get presence for"
 // @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
 // @Router /realtime/presence [get]
 func (h *Handler) GetPresence(c *gin.Context) {
+	sub := subscriberFromContext(c)
 	userIDStr := c.Query("user_id")
 
 	if userIDStr != "" {
-		// Get specific user presence
+		// Get specific user presence. Users the caller does not see are
+		// reported as not found.
 		var userID uint
 		if _, err := fmt.Sscanf(userIDStr, "%d", &userID); err == nil {
-			if info := h.hub.GetPresence(userID); info != nil {
+			var info *realtime.PresenceInfo
+			if sub.IsAdmin {
+				info = h.hub.GetPresence(userID)
+			} else {
+				info = h.hub.VisiblePresence(sub.UserID, userID)
+			}
+			if info != nil {
 				c.JSON(http.StatusOK, gin.H{
 					"success": true,
 					"data":    info,
@@ -128,19 +245,32 @@ func (h *Handler) GetPresence(c *gin.Context) {
 		return
 	}
 
-	// Get all presence
-	presence := h.hub.GetAllPresence()
+	// Get the presence of every user the caller sees
+	var presence map[uint]*realtime.PresenceInfo
+	if sub.IsAdmin {
+		presence = h.hub.GetAllPresence()
+	} else {
+		presence = h.hub.ListVisiblePresence(sub.UserID)
+	}
+
+	online := 0
+	for _, info := range presence {
+		if info.Status != realtime.StatusOffline {
+			online++
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    presence,
 		"count":   len(presence),
-		"online":  h.hub.GetOnlineCount(),
+		"online":  online,
 	})
 }
 
 // BroadcastMessage godoc
 // @Summary Broadcast a message
-// @Description Send a message to a channel or all users
+// @Description Send a message to a channel or all users. Channel messages may set a priority (low, normal or high) for the queues of slow clients, and a coalesce_key so a newer message with the same key replaces a queued one. With require_ack, clients must answer {"type": "ack", "id": <message id>} or are sent the message again.
 // @Tags realtime
 // @Accept json
 // @Produce json
@@ -158,8 +288,21 @@ func (h *Handler) BroadcastMessage(c *gin.Context) {
 		return
 	}
 
+	priority, err := realtime.ParsePriority(req.Priority)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid priority",
+		})
+		return
+	}
+
 	if req.Channel != "" {
-		h.hub.BroadcastToChannel(req.Channel, req.Event, req.Payload)
+		h.hub.BroadcastToChannelWithOptions(req.Channel, req.Event, req.Payload, realtime.BroadcastOptions{
+			Priority:    priority,
+			CoalesceKey: req.CoalesceKey,
+			RequireAck:  req.RequireAck,
+		})
 	} else {
 		h.hub.BroadcastToAll(req.Event, req.Payload)
 	}
@@ -172,12 +315,13 @@ func (h *Handler) BroadcastMessage(c *gin.Context) {
 
 // GetStats godoc
 // @Summary Get real-time statistics
-// @Description Get statistics about connected clients and channels
+// @Description Get statistics about connected clients and channels. Admin only.
 // @Tags realtime
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Router /realtime/stats [get]
 func (h *Handler) GetStats(c *gin.Context) {
 	stats := h.hub.GetStats()
@@ -188,18 +332,132 @@ func (h *Handler) GetStats(c *gin.Context) {
 	})
 }
 
+// GetHistory godoc
+// @Summary Get channel history
+// @Description Page through the stored messages of a channel, oldest first. Without after or since the newest messages are returned; pass the first ID as before to get older ones.
+// @Tags realtime
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param channel query string true "Channel name"
+// @Param before query string false "Return messages before this message ID"
+// @Param after query string false "Return messages after this message ID"
+// @Param since query string false "Return messages since this RFC 3339 time"
+// @Param limit query int false "Maximum number of messages" default(50)
+// @Success 200 {object} map[string]interface{}
+// @Router /realtime/history [get]
+func (h *Handler) GetHistory(c *gin.Context) {
+	channel := c.Query("channel")
+	if channel == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Channel required",
+		})
+		return
+	}
+
+	query, _, err := realtime.ReplayQuery(c.Query("after"), c.Query("since"))
+	if err == nil && c.Query("before") != "" {
+		query.Before = c.Query("before")
+		err = realtime.ValidateMessageID(query.Before)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid before, after or since",
+		})
+		return
+	}
+
+	query.Limit = defaultHistoryLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid limit",
+			})
+			return
+		}
+		query.Limit = limit
+	}
+
+	filter, err := h.hub.AuthorizeChannel(c.Request.Context(), subscriberFromContext(c), channel)
+	if err != nil {
+		if errors.Is(err, realtime.ErrChannelForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Not authorized for channel",
+			})
+			return
+		}
+		h.logger.Error("Failed to authorize channel", zap.String("channel", channel), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to read history",
+		})
+		return
+	}
+
+	messages, err := h.hub.History(c.Request.Context(), channel, query)
+	if err != nil {
+		h.logger.Error("Failed to read channel history", zap.String("channel", channel), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to read history",
+		})
+		return
+	}
+
+	// A full page may have older messages before it
+	response := gin.H{
+		"success": true,
+		"count":   0,
+	}
+	if len(messages) == query.Limit {
+		response["next_before"] = messages[0].ID
+	}
+
+	if filter != nil {
+		visible := messages[:0]
+		for _, message := range messages {
+			if filter(message) {
+				visible = append(visible, message)
+			}
+		}
+		messages = visible
+	}
+	response["data"] = messages
+	response["count"] = len(messages)
+
+	c.JSON(http.StatusOK, response)
+}
+
 type BroadcastRequest struct {
 	Channel string                 `json:"channel,omitempty"`
 	Event   string                 `json:"event" binding:"required"`
 	Payload map[string]interface{} `json:"payload"`
+	// Priority is low, normal or high
+	Priority    string `json:"priority,omitempty"`
+	CoalesceKey string `json:"coalesce_key,omitempty"`
+	// RequireAck has clients acknowledge the message; it is sent again to
+	// those who do not
+	RequireAck bool `json:"require_ack,omitempty"`
 }
 
-// validateToken validates a JWT token and returns user info
-func (h *Handler) validateToken(token string) (uint, string, error) {
-	claims, err := h.jwtService.ValidateToken(token)
-	if err != nil {
-		return 0, "", err
+// subscriberFromContext returns the user authenticated by AuthMiddleware,
+// or the API key authenticated by APIKeyMiddleware
+func subscriberFromContext(c *gin.Context) realtime.Subscriber {
+	subscriber := realtime.Subscriber{
+		UserID:  c.GetUint("user_id"),
+		IsAdmin: c.GetBool("is_admin"),
 	}
-
-	return claims.UserID, claims.Email, nil
+	if permissions, ok := middleware.TokenPermissions(c); ok {
+		subscriber.Permissions = permissions
+	}
+	if principal, ok := middleware.APIKeyPrincipal(c); ok {
+		subscriber.APIKey = true
+		subscriber.Scopes = principal.Key.Scopes
+	}
+	return subscriber
 }
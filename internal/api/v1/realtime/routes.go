@@ -11,30 +11,52 @@ import (
 )
 
 // RegisterRoutes registers real-time routes
-func RegisterRoutes(router *gin.RouterGroup, hub *realtime.Hub, logger *zap.Logger, cfg *config.Config) {
-	// Initialize JWT service for auth middleware
-	jwtService := authService.NewJWTService(
-		cfg.JWT.Secret,
-		cfg.JWT.AccessTokenExpireInt,
-		cfg.JWT.RefreshTokenExpireInt,
-	)
+func RegisterRoutes(router *gin.RouterGroup, hub *realtime.Hub, logger *zap.Logger, cfg *config.Config, jwtService *authService.JWTService, permissions *authService.PermissionResolver) {
+	// Configured channel rules take precedence over the built-in ones
+	rules := make([]realtime.ChannelRule, 0, len(cfg.Realtime.Channels))
+	for _, channel := range cfg.Realtime.Channels {
+		rules = append(rules, realtime.ChannelRule{
+			Pattern:     channel.Pattern,
+			Resource:    channel.Resource,
+			Action:      channel.Action,
+			OwnerColumn: channel.OwnerColumn,
+			ReadOnly:    channel.ReadOnly,
+		})
+	}
+	rules = append(rules, realtime.DefaultChannelRules()...)
+	hub.SetAuthorizer(realtime.NewChannelAuthorizer(rules, permissions))
+
+	// Clients refresh the token their RPC calls are made with by sending
+	// an auth message
+	hub.SetTokenValidator(jwtService.ValidateToken)
 
 	handler := NewHandler(hub, logger, jwtService)
 
 	// WebSocket endpoint (token-based auth via query parameter)
 	router.GET("/ws", handler.HandleWebSocket)
 
-	// REST endpoints for real-time features
+	// Server-Sent Events and long-poll transports and their sessions. These
+	// accept the token as a query parameter or a bearer token.
+	router.GET("/sse", handler.HandleSSE)
+	router.GET("/poll", handler.HandlePoll)
+	router.POST("/sessions/:id/messages", handler.SendSessionMessage)
+	router.DELETE("/sessions/:id", handler.CloseSession)
+
+	// REST endpoints for real-time features. API keys need the realtime:read
+	// scope, and channel rules check their scopes too.
 	authorized := router.Group("")
 	authorized.Use(middleware.AuthMiddleware(jwtService))
 	{
 		// Presence
-		authorized.GET("/presence", handler.GetPresence)
+		authorized.GET("/presence", middleware.RequireScope("realtime", "read", logger), handler.GetPresence)
 
 		// Broadcasting (admin only recommended)
 		authorized.POST("/broadcast", middleware.RequireAdmin(), handler.BroadcastMessage)
 
-		// Stats
-		authorized.GET("/stats", handler.GetStats)
+		// Channel history
+		authorized.GET("/history", middleware.RequireScope("realtime", "read", logger), handler.GetHistory)
+
+		// Stats cover every client and channel on the server
+		authorized.GET("/stats", middleware.RequireAdmin(), handler.GetStats)
 	}
 }
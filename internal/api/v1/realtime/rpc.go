@@ -0,0 +1,158 @@
+package realtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"go-mobile-backend-template/internal/realtime"
+)
+
+// restMethods are the HTTP methods clients may use with the rest RPC method
+var restMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// RegisterRESTMethod lets clients call the API's HTTP endpoints, such as
+// the generated table endpoints, over their realtime connection. A call
+// to the "rest" method with
+//
+//	{"method": "GET", "path": "/users", "query": {"page": "2"}, "body": {...}}
+//
+// is served by handler at prefix + path with the client's access token,
+// so it passes the same middleware as an HTTP request. The result holds
+// the response status and its JSON body. Once the token expires, calls are
+// refused until the client sends a new one in an auth message.
+func RegisterRESTMethod(hub *realtime.Hub, handler http.Handler, prefix string) {
+	hub.HandleRPC("rest", func(ctx context.Context, call *realtime.RPCCall) (interface{}, error) {
+		token, expiresAt := call.Client.AccessToken()
+		if token != "" && !expiresAt.IsZero() && !time.Now().Before(expiresAt) {
+			return nil, realtime.NewRPCError(realtime.RPCUnauthorized, "Access token expired; send an auth message with a new token")
+		}
+
+		req, err := restRequest(ctx, call, token, prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		recorder := newResponseRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		result := map[string]interface{}{
+			"status": recorder.status,
+		}
+		if recorder.body.Len() > 0 {
+			var body interface{}
+			if err := json.Unmarshal(recorder.body.Bytes(), &body); err != nil {
+				body = recorder.body.String()
+			}
+			result["body"] = body
+		}
+		return result, nil
+	})
+}
+
+// restRequest builds the HTTP request of a rest call made with token
+func restRequest(ctx context.Context, call *realtime.RPCCall, token, prefix string) (*http.Request, error) {
+	method, _ := call.Params["method"].(string)
+	if method == "" {
+		method = http.MethodGet
+	}
+	method = strings.ToUpper(method)
+	if !restMethods[method] {
+		return nil, realtime.NewRPCError(realtime.RPCInvalidRequest, "Unsupported method "+method)
+	}
+
+	// Realtime endpoints hold connections open and cannot be called
+	// through another connection
+	requestPath, _ := call.Params["path"].(string)
+	if !strings.HasPrefix(requestPath, "/") {
+		return nil, realtime.NewRPCError(realtime.RPCInvalidRequest, "path must start with /")
+	}
+	requestPath = path.Clean(requestPath)
+	if requestPath == "/realtime" || strings.HasPrefix(requestPath, "/realtime/") {
+		return nil, realtime.NewRPCError(realtime.RPCInvalidRequest, "Realtime endpoints cannot be called over RPC")
+	}
+
+	target := url.URL{Path: prefix + requestPath}
+	if query, ok := call.Params["query"].(map[string]interface{}); ok {
+		values := url.Values{}
+		for key, value := range query {
+			switch v := value.(type) {
+			case []interface{}:
+				for _, item := range v {
+					values.Add(key, fmt.Sprint(item))
+				}
+			default:
+				values.Set(key, fmt.Sprint(v))
+			}
+		}
+		target.RawQuery = values.Encode()
+	}
+
+	var body bytes.Buffer
+	if payload, ok := call.Params["body"]; ok && payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			return nil, realtime.NewRPCError(realtime.RPCInvalidRequest, "Invalid body")
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), &body)
+	if err != nil {
+		return nil, realtime.NewRPCError(realtime.RPCInvalidRequest, "Invalid request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if call.Client.UserAgent != "" {
+		req.Header.Set("User-Agent", call.Client.UserAgent)
+	}
+	if call.Client.RemoteAddr != "" {
+		req.RemoteAddr = net.JoinHostPort(call.Client.RemoteAddr, "0")
+	}
+	return req, nil
+}
+
+// responseRecorder collects the response to a rest call
+type responseRecorder struct {
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{header: make(http.Header), status: http.StatusOK}
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	return r.body.Write(data)
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.status = status
+}
@@ -0,0 +1,253 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-mobile-backend-template/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// sseHeartbeatInterval is how often an idle event stream sends a comment
+	// so proxies keep it open
+	sseHeartbeatInterval = 25 * time.Second
+
+	// sseRetry is the reconnect delay suggested to EventSource clients, in
+	// milliseconds
+	sseRetry = 3000
+
+	// maxPollWait is the longest a poll request waits for messages. It stays
+	// below the server write timeout.
+	maxPollWait = 25 * time.Second
+)
+
+// HandleSSE godoc
+// @Summary Server-Sent Events connection endpoint
+// @Description Receive real-time messages as an event stream. The first event is "session" with the session_id used to send subscribe and other client messages. Each message is a "message" event whose id can be sent back as Last-Event-ID to resume.
+// @Tags realtime
+// @Produce text/event-stream
+// @Param token query string false "JWT token, if not sent in the Authorization header"
+// @Param channel query []string false "Channels to subscribe to, repeatable" collectionFormat(multi)
+// @Param last_event_id query string false "Replay channel messages after this message ID"
+// @Param since query string false "Replay channel messages since this RFC 3339 time"
+// @Param Last-Event-ID header string false "Replay channel messages after this message ID"
+// @Success 200 {string} string "Event stream"
+// @Router /realtime/sse [get]
+func (h *Handler) HandleSSE(c *gin.Context) {
+	conn, ok := h.prepareConnection(c)
+	if !ok {
+		return
+	}
+
+	client := h.newClient(c, nil, conn)
+	session := h.hub.OpenSession(client, conn.channels...)
+	defer h.hub.CloseSession(session.ID)
+
+	// The stream outlives the server write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Failed to clear event stream write deadline", zap.Error(err))
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	sessionData, _ := json.Marshal(gin.H{"session_id": session.ID})
+	fmt.Fprintf(c.Writer, "retry: %d\nevent: session\ndata: %s\n\n", sseRetry, sessionData)
+	c.Writer.Flush()
+
+	if conn.resume {
+		go h.hub.Replay(client, conn.channels, conn.replay)
+	}
+
+	write := func(message []byte) error {
+		if id := realtime.EventID(message); id != "" {
+			if _, err := fmt.Fprintf(c.Writer, "id: %s\n", id); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(c.Writer, "event: message\ndata: %s\n\n", message); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+	heartbeat := func() error {
+		if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+
+	session.Stream(c.Request.Context(), sseHeartbeatInterval, write, heartbeat)
+}
+
+// HandlePoll godoc
+// @Summary Long-poll for real-time messages
+// @Description Wait for real-time messages. The first request opens a session and returns its session_id; pass it on every following request. A request returns as soon as messages are queued, or empty after wait seconds. A session not polled for a minute is closed; reopen it with last_event_id to resume.
+// @Tags realtime
+// @Produce json
+// @Security BearerAuth
+// @Param session_id query string false "Session to poll; omit to open one"
+// @Param channel query []string false "Channels to subscribe to when opening a session, repeatable" collectionFormat(multi)
+// @Param last_event_id query string false "Replay channel messages after this message ID when opening a session"
+// @Param since query string false "Replay channel messages since this RFC 3339 time when opening a session"
+// @Param wait query int false "Seconds to wait for messages, at most 25" default(25)
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /realtime/poll [get]
+func (h *Handler) HandlePoll(c *gin.Context) {
+	wait := maxPollWait
+	if waitStr := c.Query("wait"); waitStr != "" {
+		seconds, err := strconv.Atoi(waitStr)
+		if err != nil || seconds < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid wait",
+			})
+			return
+		}
+		if time.Duration(seconds)*time.Second < wait {
+			wait = time.Duration(seconds) * time.Second
+		}
+	}
+
+	var session *realtime.Session
+	if sessionID := c.Query("session_id"); sessionID != "" {
+		claims, ok := h.authenticate(c)
+		if !ok {
+			return
+		}
+		if session, ok = h.hub.Session(sessionID, claims.UserID); !ok {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Session not found",
+			})
+			return
+		}
+	} else {
+		conn, ok := h.prepareConnection(c)
+		if !ok {
+			return
+		}
+
+		client := h.newClient(c, nil, conn)
+		session = h.hub.OpenSession(client, conn.channels...)
+		if conn.resume {
+			h.hub.Replay(client, conn.channels, conn.replay)
+		}
+	}
+
+	messages, open := session.Poll(c.Request.Context(), wait)
+	if !open {
+		h.hub.CloseSession(session.ID)
+	}
+
+	data := make([]json.RawMessage, 0, len(messages))
+	lastEventID := ""
+	for _, message := range messages {
+		data = append(data, message)
+		if id := realtime.EventID(message); id != "" {
+			lastEventID = id
+		}
+	}
+
+	response := gin.H{
+		"success":    true,
+		"session_id": session.ID,
+		"data":       data,
+		"count":      len(data),
+	}
+	if lastEventID != "" {
+		response["last_event_id"] = lastEventID
+	}
+	if !open {
+		response["closed"] = true
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SendSessionMessage godoc
+// @Summary Send a message on an SSE or long-poll session
+// @Description Send a subscribe, unsubscribe, broadcast, presence, typing or activity message as a WebSocket client would. Acknowledgements arrive on the session.
+// @Tags realtime
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Param request body realtime.ClientMessage true "Client message"
+// @Success 202 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /realtime/sessions/{id}/messages [post]
+func (h *Handler) SendSessionMessage(c *gin.Context) {
+	claims, ok := h.authenticate(c)
+	if !ok {
+		return
+	}
+
+	session, ok := h.hub.Session(c.Param("id"), claims.UserID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Session not found",
+		})
+		return
+	}
+
+	var msg realtime.ClientMessage
+	if err := c.ShouldBindJSON(&msg); err != nil || msg.Type == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request",
+		})
+		return
+	}
+
+	session.Client.HandleMessage(&msg)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+	})
+}
+
+// CloseSession godoc
+// @Summary Close an SSE or long-poll session
+// @Tags realtime
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /realtime/sessions/{id} [delete]
+func (h *Handler) CloseSession(c *gin.Context) {
+	claims, ok := h.authenticate(c)
+	if !ok {
+		return
+	}
+
+	session, ok := h.hub.Session(c.Param("id"), claims.UserID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Session not found",
+		})
+		return
+	}
+
+	h.hub.CloseSession(session.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Session closed",
+	})
+}
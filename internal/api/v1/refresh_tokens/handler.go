@@ -50,14 +50,10 @@ func (h *Handler) CreateRefreshtokens(c *gin.Context) {
 
 		Userid: req.Userid,
 
-		Token: req.Token,
-
 		Expiresat: req.Expiresat,
 
 		Isrevoked: req.Isrevoked,
 
-		Deletedat: req.Deletedat,
-
 	}
 
 	ctx := context.Background()
@@ -73,8 +69,6 @@ func (h *Handler) CreateRefreshtokens(c *gin.Context) {
 
 		Userid: refreshTokens.Userid,
 
-		Token: refreshTokens.Token,
-
 		Expiresat: refreshTokens.Expiresat,
 
 		Isrevoked: refreshTokens.Isrevoked,
@@ -129,8 +123,6 @@ func (h *Handler) GetRefreshtokens(c *gin.Context) {
 
 		Userid: refreshTokens.Userid,
 
-		Token: refreshTokens.Token,
-
 		Expiresat: refreshTokens.Expiresat,
 
 		Isrevoked: refreshTokens.Isrevoked,
@@ -188,8 +180,6 @@ func (h *Handler) GetAllRefreshtokenss(c *gin.Context) {
 
 			Userid: refreshTokens.Userid,
 
-			Token: refreshTokens.Token,
-
 			Expiresat: refreshTokens.Expiresat,
 
 			Isrevoked: refreshTokens.Isrevoked,
@@ -266,10 +256,6 @@ func (h *Handler) UpdateRefreshtokens(c *gin.Context) {
 	
 
 	
-	refreshTokens.Token = req.Token
-	
-
-	
 	refreshTokens.Expiresat = req.Expiresat
 	
 
@@ -277,10 +263,6 @@ func (h *Handler) UpdateRefreshtokens(c *gin.Context) {
 	refreshTokens.Isrevoked = req.Isrevoked
 	
 
-	
-	refreshTokens.Deletedat = req.Deletedat
-	
-
 
 	if err := h.refreshTokensRepo.Update(ctx, refreshTokens); err != nil {
 		h.logger.Error("Failed to update refresh_tokens", zap.Error(err))
@@ -294,8 +276,6 @@ func (h *Handler) UpdateRefreshtokens(c *gin.Context) {
 
 		Userid: refreshTokens.Userid,
 
-		Token: refreshTokens.Token,
-
 		Expiresat: refreshTokens.Expiresat,
 
 		Isrevoked: refreshTokens.Isrevoked,
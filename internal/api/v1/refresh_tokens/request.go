@@ -10,8 +10,6 @@ type RefreshtokensResponse struct {
 
 	Userid uint `json:"user_id"`
 
-	Token string `json:"token"`
-
 	Expiresat time.Time `json:"expires_at"`
 
 	Isrevoked bool `json:"is_revoked"`
@@ -29,14 +27,10 @@ type RefreshtokensCreateRequest struct {
 
 	Userid uint `json:"user_id" binding:"required"`
 
-	Token string `json:"token" binding:"required"`
-
 	Expiresat time.Time `json:"expires_at" binding:"required"`
 
 	Isrevoked bool `json:"is_revoked" binding:""`
 
-	Deletedat time.Time `json:"deleted_at" binding:""`
-
 }
 
 // RefreshtokensUpdateRequest represents update refresh_tokens request
@@ -44,14 +38,10 @@ type RefreshtokensUpdateRequest struct {
 
 	Userid uint `json:"user_id" binding:"omitempty"`
 
-	Token string `json:"token" binding:"omitempty"`
-
 	Expiresat time.Time `json:"expires_at" binding:"omitempty"`
 
 	Isrevoked bool `json:"is_revoked" binding:"omitempty"`
 
-	Deletedat time.Time `json:"deleted_at" binding:"omitempty"`
-
 }
 
 // PaginationResponse represents pagination response
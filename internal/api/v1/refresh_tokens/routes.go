@@ -1,34 +1,29 @@
 package refresh_tokens
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"go-mobile-backend-template/internal/middleware"
-	authService "go-mobile-backend-template/internal/services/auth"
-	"go-mobile-backend-template/pkg/config"
 )
 
 // RegisterRoutes registers refresh_tokens routes
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cfg *config.Config) {
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, guard *middleware.RouteGuard) {
 	handler := NewHandler(db, logger)
-	
-	// Initialize JWT service for auth middleware
-	jwtService := authService.NewJWTService(
-		cfg.JWT.Secret,
-		cfg.JWT.AccessTokenExpireInt,
-		cfg.JWT.RefreshTokenExpireInt,
-	)
 
 	// refresh_tokens routes (all protected)
 	refreshTokensRoutes := router.Group("/refresh_tokens")
-	refreshTokensRoutes.Use(middleware.AuthMiddleware(jwtService))
+	refreshTokensRoutes.Use(guard.Authenticate())
+	refreshTokensRoutes.Use(guard.Limit("refresh_tokens", 110, time.Minute))
+	refreshTokensRoutes.Use(guard.Audit())
 	{
-		refreshTokensRoutes.POST("", handler.CreateRefreshtokens)
-		refreshTokensRoutes.GET("", handler.GetAllRefreshtokenss)
-		refreshTokensRoutes.GET("/:id", handler.GetRefreshtokens)
-		refreshTokensRoutes.PUT("/:id", handler.UpdateRefreshtokens)
-		refreshTokensRoutes.DELETE("/:id", handler.DeleteRefreshtokens)
+		refreshTokensRoutes.POST("", guard.Require("refresh_tokens", "write"), handler.CreateRefreshtokens)
+		refreshTokensRoutes.GET("", guard.Require("refresh_tokens", "read"), handler.GetAllRefreshtokenss)
+		refreshTokensRoutes.GET("/:id", guard.Require("refresh_tokens", "read"), handler.GetRefreshtokens)
+		refreshTokensRoutes.PUT("/:id", guard.Require("refresh_tokens", "write"), handler.UpdateRefreshtokens)
+		refreshTokensRoutes.DELETE("/:id", guard.Require("refresh_tokens", "delete"), handler.DeleteRefreshtokens)
 	}
 }
@@ -10,6 +10,7 @@ import (
 	"gorm.io/gorm"
 
 	"go-mobile-backend-template/internal/db/repository/generated"
+	"go-mobile-backend-template/internal/services/auth"
 	"go-mobile-backend-template/internal/utils"
 )
 
@@ -17,13 +18,40 @@ import (
 type Handler struct {
 	rolePermissionsRepo generated.RolepermissionsRepository
 	logger             *zap.Logger
+	permissions        *auth.PermissionResolver
 }
 
-// NewHandler creates a new role_permissions handler
-func NewHandler(db *gorm.DB, logger *zap.Logger) *Handler {
+// NewHandler creates a new role_permissions handler. Writes change the
+// permissions of users, so they invalidate those cached by permissions,
+// which may be nil.
+func NewHandler(db *gorm.DB, logger *zap.Logger, permissions *auth.PermissionResolver) *Handler {
 	return &Handler{
 		rolePermissionsRepo: generated.NewRolepermissionsRepository(db),
 		logger:             logger,
+		permissions:        permissions,
+	}
+}
+
+// usersGrantedBy returns the users whose permissions depend on the
+// role_permissions rows of key
+func (h *Handler) usersGrantedBy(ctx context.Context, key uint) []uint {
+	if h.permissions == nil {
+		return nil
+	}
+	userIDs, err := h.permissions.UsersGrantedBy(ctx, "role_permissions", key)
+	if err != nil {
+		h.logger.Error("Failed to get users granted by role_permissions", zap.Error(err))
+	}
+	return userIDs
+}
+
+// invalidatePermissions drops the cached permissions of users
+func (h *Handler) invalidatePermissions(ctx context.Context, userIDs []uint) {
+	if h.permissions == nil {
+		return
+	}
+	if err := h.permissions.Invalidate(ctx, userIDs...); err != nil {
+		h.logger.Error("Failed to invalidate permissions", zap.Error(err))
 	}
 }
 
@@ -60,6 +88,7 @@ func (h *Handler) CreateRolepermissions(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create role_permissions")
 		return
 	}
+	h.invalidatePermissions(ctx, h.usersGrantedBy(ctx, rolePermissions.Roleid))
 
 	response := RolepermissionsResponse{
 
@@ -224,6 +253,9 @@ func (h *Handler) UpdateRolepermissions(c *gin.Context) {
 		return
 	}
 
+	// Users granted permissions by the row before the update may lose them
+	affected := h.usersGrantedBy(ctx, rolePermissions.Roleid)
+
 
 	
 	rolePermissions.Roleid = req.Roleid
@@ -239,6 +271,7 @@ func (h *Handler) UpdateRolepermissions(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update role_permissions")
 		return
 	}
+	h.invalidatePermissions(ctx, append(affected, h.usersGrantedBy(ctx, rolePermissions.Roleid)...))
 
 	response := RolepermissionsResponse{
 
@@ -275,6 +308,8 @@ func (h *Handler) DeleteRolepermissions(c *gin.Context) {
 	}
 
 	ctx := context.Background()
+	// Collect the users first; the assignments go with the rows
+	affected := h.usersGrantedBy(ctx, uint(id))
 	if err := h.rolePermissionsRepo.Delete(ctx, uint(id)); err != nil {
 		if err == gorm.ErrRecordNotFound {
 			utils.ErrorResponse(c, http.StatusNotFound, "role_permissions not found")
@@ -284,6 +319,7 @@ func (h *Handler) DeleteRolepermissions(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete role_permissions")
 		return
 	}
+	h.invalidatePermissions(ctx, affected)
 
 	utils.SuccessResponse(c, http.StatusOK, "role_permissions deleted successfully", nil)
 }
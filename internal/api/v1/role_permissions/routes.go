@@ -1,34 +1,29 @@
 package role_permissions
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"go-mobile-backend-template/internal/middleware"
-	authService "go-mobile-backend-template/internal/services/auth"
-	"go-mobile-backend-template/pkg/config"
 )
 
 // RegisterRoutes registers role_permissions routes
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cfg *config.Config) {
-	handler := NewHandler(db, logger)
-	
-	// Initialize JWT service for auth middleware
-	jwtService := authService.NewJWTService(
-		cfg.JWT.Secret,
-		cfg.JWT.AccessTokenExpireInt,
-		cfg.JWT.RefreshTokenExpireInt,
-	)
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, guard *middleware.RouteGuard) {
+	handler := NewHandler(db, logger, guard.Permissions())
 
 	// role_permissions routes (all protected)
 	rolePermissionsRoutes := router.Group("/role_permissions")
-	rolePermissionsRoutes.Use(middleware.AuthMiddleware(jwtService))
+	rolePermissionsRoutes.Use(guard.Authenticate())
+	rolePermissionsRoutes.Use(guard.Limit("role_permissions", 110, time.Minute))
+	rolePermissionsRoutes.Use(guard.Audit())
 	{
-		rolePermissionsRoutes.POST("", handler.CreateRolepermissions)
-		rolePermissionsRoutes.GET("", handler.GetAllRolepermissionss)
-		rolePermissionsRoutes.GET("/:id", handler.GetRolepermissions)
-		rolePermissionsRoutes.PUT("/:id", handler.UpdateRolepermissions)
-		rolePermissionsRoutes.DELETE("/:id", handler.DeleteRolepermissions)
+		rolePermissionsRoutes.POST("", guard.Require("role_permissions", "write"), handler.CreateRolepermissions)
+		rolePermissionsRoutes.GET("", guard.Require("role_permissions", "read"), handler.GetAllRolepermissionss)
+		rolePermissionsRoutes.GET("/:id", guard.Require("role_permissions", "read"), handler.GetRolepermissions)
+		rolePermissionsRoutes.PUT("/:id", guard.Require("role_permissions", "write"), handler.UpdateRolepermissions)
+		rolePermissionsRoutes.DELETE("/:id", guard.Require("role_permissions", "delete"), handler.DeleteRolepermissions)
 	}
 }
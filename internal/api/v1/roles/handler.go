@@ -10,6 +10,7 @@ import (
 	"gorm.io/gorm"
 
 	"go-mobile-backend-template/internal/db/repository/generated"
+	"go-mobile-backend-template/internal/services/auth"
 	"go-mobile-backend-template/internal/utils"
 )
 
@@ -17,13 +18,40 @@ import (
 type Handler struct {
 	rolesRepo generated.RolesRepository
 	logger             *zap.Logger
+	permissions        *auth.PermissionResolver
 }
 
-// NewHandler creates a new roles handler
-func NewHandler(db *gorm.DB, logger *zap.Logger) *Handler {
+// NewHandler creates a new roles handler. Writes change the
+// permissions of users, so they invalidate those cached by permissions,
+// which may be nil.
+func NewHandler(db *gorm.DB, logger *zap.Logger, permissions *auth.PermissionResolver) *Handler {
 	return &Handler{
 		rolesRepo: generated.NewRolesRepository(db),
 		logger:             logger,
+		permissions:        permissions,
+	}
+}
+
+// usersGrantedBy returns the users whose permissions depend on the
+// roles rows of key
+func (h *Handler) usersGrantedBy(ctx context.Context, key uint) []uint {
+	if h.permissions == nil {
+		return nil
+	}
+	userIDs, err := h.permissions.UsersGrantedBy(ctx, "roles", key)
+	if err != nil {
+		h.logger.Error("Failed to get users granted by roles", zap.Error(err))
+	}
+	return userIDs
+}
+
+// invalidatePermissions drops the cached permissions of users
+func (h *Handler) invalidatePermissions(ctx context.Context, userIDs []uint) {
+	if h.permissions == nil {
+		return
+	}
+	if err := h.permissions.Invalidate(ctx, userIDs...); err != nil {
+		h.logger.Error("Failed to invalidate permissions", zap.Error(err))
 	}
 }
 
@@ -60,6 +88,7 @@ func (h *Handler) CreateRoles(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create roles")
 		return
 	}
+	h.invalidatePermissions(ctx, h.usersGrantedBy(ctx, roles.Id))
 
 	response := RolesResponse{
 
@@ -236,6 +265,9 @@ func (h *Handler) UpdateRoles(c *gin.Context) {
 		return
 	}
 
+	// Users granted permissions by the row before the update may lose them
+	affected := h.usersGrantedBy(ctx, roles.Id)
+
 
 	
 	roles.Name = req.Name
@@ -251,6 +283,7 @@ func (h *Handler) UpdateRoles(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update roles")
 		return
 	}
+	h.invalidatePermissions(ctx, append(affected, h.usersGrantedBy(ctx, roles.Id)...))
 
 	response := RolesResponse{
 
@@ -291,6 +324,8 @@ func (h *Handler) DeleteRoles(c *gin.Context) {
 	}
 
 	ctx := context.Background()
+	// Collect the users first; the assignments go with the rows
+	affected := h.usersGrantedBy(ctx, uint(id))
 	if err := h.rolesRepo.Delete(ctx, uint(id)); err != nil {
 		if err == gorm.ErrRecordNotFound {
 			utils.ErrorResponse(c, http.StatusNotFound, "roles not found")
@@ -300,6 +335,7 @@ func (h *Handler) DeleteRoles(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete roles")
 		return
 	}
+	h.invalidatePermissions(ctx, affected)
 
 	utils.SuccessResponse(c, http.StatusOK, "roles deleted successfully", nil)
 }
@@ -1,34 +1,29 @@
 package roles
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"go-mobile-backend-template/internal/middleware"
-	authService "go-mobile-backend-template/internal/services/auth"
-	"go-mobile-backend-template/pkg/config"
 )
 
 // RegisterRoutes registers roles routes
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cfg *config.Config) {
-	handler := NewHandler(db, logger)
-	
-	// Initialize JWT service for auth middleware
-	jwtService := authService.NewJWTService(
-		cfg.JWT.Secret,
-		cfg.JWT.AccessTokenExpireInt,
-		cfg.JWT.RefreshTokenExpireInt,
-	)
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, guard *middleware.RouteGuard) {
+	handler := NewHandler(db, logger, guard.Permissions())
 
 	// roles routes (all protected)
 	rolesRoutes := router.Group("/roles")
-	rolesRoutes.Use(middleware.AuthMiddleware(jwtService))
+	rolesRoutes.Use(guard.Authenticate())
+	rolesRoutes.Use(guard.Limit("roles", 33, time.Minute))
+	rolesRoutes.Use(guard.Audit())
 	{
-		rolesRoutes.POST("", handler.CreateRoles)
-		rolesRoutes.GET("", handler.GetAllRoless)
-		rolesRoutes.GET("/:id", handler.GetRoles)
-		rolesRoutes.PUT("/:id", handler.UpdateRoles)
-		rolesRoutes.DELETE("/:id", handler.DeleteRoles)
+		rolesRoutes.POST("", guard.Require("roles", "roles:write"), handler.CreateRoles)
+		rolesRoutes.GET("", guard.Require("roles", "roles:read"), handler.GetAllRoless)
+		rolesRoutes.GET("/:id", guard.Require("roles", "roles:read"), handler.GetRoles)
+		rolesRoutes.PUT("/:id", guard.Require("roles", "roles:write"), handler.UpdateRoles)
+		rolesRoutes.DELETE("/:id", guard.Require("roles", "roles:delete"), handler.DeleteRoles)
 	}
 }
@@ -1,6 +1,8 @@
 package v1
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -11,43 +13,102 @@ import (
 	// "go-mobile-backend-template/internal/api/v1/files"  // temporarily commented out
 	"go-mobile-backend-template/internal/api/v1/migration"
 	realtimeAPI "go-mobile-backend-template/internal/api/v1/realtime"
+	"go-mobile-backend-template/internal/db/repository"
 
 	// "go-mobile-backend-template/internal/api/v1/users"  // temporarily commented out
 	"go-mobile-backend-template/internal/middleware"
 	"go-mobile-backend-template/internal/realtime"
 	authService "go-mobile-backend-template/internal/services/auth"
 	migrationService "go-mobile-backend-template/internal/services/migration"
+	"go-mobile-backend-template/pkg/cache"
 	"go-mobile-backend-template/pkg/config"
 )
 
-// RegisterRoutes registers all v1 API routes
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cfg *config.Config, hub *realtime.Hub) {
-	// Initialize handlers
-	authHandler := auth.NewHandler(db, logger, cfg)
-	// usersHandler := users.NewHandler(db, logger)  // temporarily commented out
-	// filesHandler := files.NewHandler(db, logger, cfg)  // temporarily commented out
-
-	// Initialize JWT service for auth middleware
+// RegisterRoutes registers all v1 API routes. redis may be nil, and
+// keyManager is nil when tokens are signed with the shared secret. The
+// returned function stops background workers started for the routes.
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cfg *config.Config, hub *realtime.Hub, redis *cache.RedisClient, keyManager *authService.KeyManager) func() {
+	// Tokens carry this deployment's issuer and audience. Access tokens
+	// revoked by logout and tokens of revoked sessions are rejected before
+	// they expire.
+	denylist := authService.NewTokenDenylist(redis)
 	jwtService := authService.NewJWTService(
 		cfg.JWT.Secret,
 		cfg.JWT.AccessTokenExpireInt,
 		cfg.JWT.RefreshTokenExpireInt,
+		authService.JWTOptions{
+			Issuer:     cfg.JWT.Issuer,
+			Audience:   cfg.JWT.Audience,
+			KeyManager: keyManager,
+			Validators: []authService.TokenValidator{
+				denylist,
+				authService.NewSessionService(repository.NewSessionRepository(db)),
+			},
+		},
+	)
+
+	// Resolve and cache effective permissions for RequirePermission and
+	// RequireRole
+	permissions := authService.NewPermissionResolver(
+		repository.NewRoleRepository(db),
+		redis,
+		time.Duration(cfg.Auth.PermissionCacheTTL)*time.Second,
 	)
 
+	// Failed logins lock accounts and block IPs; admins unlock through the
+	// same service so its IP state is cleared too
+	lockout := authService.NewLockoutService(repository.NewUserRepository(db), redis, cfg.Auth.Lockout)
+
+	// Initialize handlers
+	authHandler := auth.NewHandler(db, logger, cfg, jwtService, denylist, lockout, permissions)
+	// usersHandler := users.NewHandler(db, logger)  // temporarily commented out
+	// filesHandler := files.NewHandler(db, logger, cfg)  // temporarily commented out
+
+	// Accept API keys on every route; AuthMiddleware lets them through and
+	// RequirePermission checks their scopes
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	apiKeyUsage := authService.NewAPIKeyUsageRecorder(apiKeyRepo, logger, time.Duration(cfg.Auth.APIKeyUsageFlush)*time.Second)
+	go apiKeyUsage.Run()
+	apiKeys := authService.NewAPIKeyService(apiKeyRepo, repository.NewUserRepository(db), apiKeyUsage)
+	rateLimiter := middleware.NewRateLimiter(redis, logger)
+	router.Use(middleware.APIKeyMiddleware(apiKeys, rateLimiter, time.Duration(cfg.Auth.APIKeyRateWindow)*time.Minute, logger))
+
 	// Public auth routes
 	authRoutes := router.Group("/auth")
 	{
 		authRoutes.POST("/register", authHandler.Register)
 		authRoutes.POST("/login", authHandler.Login)
 		authRoutes.POST("/refresh", authHandler.RefreshToken)
+		authRoutes.POST("/forgot-password", authHandler.ForgotPassword)
+		authRoutes.POST("/reset-password", authHandler.ResetPassword)
+		authRoutes.POST("/verify-email", authHandler.VerifyEmail)
+		authRoutes.POST("/2fa/verify", authHandler.VerifyTwoFactor)
+		authRoutes.GET("/oauth/providers", authHandler.ListOAuthProviders)
+		authRoutes.GET("/oauth/:provider", authHandler.StartOAuth)
+		authRoutes.GET("/oauth/:provider/callback", authHandler.OAuthCallback)
+		authRoutes.POST("/oauth/:provider/callback", authHandler.OAuthCallbackPost)
 	}
 
-	// Protected auth routes
+	// Protected auth routes. These manage the account itself, so API keys
+	// are not accepted.
 	authProtected := router.Group("/auth")
-	authProtected.Use(middleware.AuthMiddleware(jwtService))
+	authProtected.Use(middleware.AuthMiddleware(jwtService), middleware.RejectAPIKey())
 	{
 		authProtected.POST("/logout", authHandler.Logout)
 		authProtected.GET("/validate", authHandler.ValidateToken)
+		authProtected.POST("/verify-email/resend", authHandler.ResendVerificationEmail)
+		authProtected.GET("/sessions", authHandler.ListSessions)
+		authProtected.DELETE("/sessions", authHandler.RevokeOtherSessions)
+		authProtected.DELETE("/sessions/:id", authHandler.RevokeSession)
+		authProtected.POST("/2fa/enroll", authHandler.EnrollTwoFactor)
+		authProtected.POST("/2fa/confirm", authHandler.ConfirmTwoFactor)
+		authProtected.POST("/2fa/disable", authHandler.DisableTwoFactor)
+		authProtected.GET("/oauth/links", authHandler.ListOAuthLinks)
+		authProtected.POST("/oauth/:provider/link", authHandler.StartOAuthLink)
+		authProtected.DELETE("/oauth/:provider/link", authHandler.UnlinkOAuth)
+		authProtected.GET("/api-keys", authHandler.ListAPIKeys)
+		authProtected.POST("/api-keys", authHandler.CreateAPIKey)
+		authProtected.DELETE("/api-keys/:id", authHandler.RevokeAPIKey)
 	}
 
 	// User routes (all protected) - temporarily commented out due to conflicts with generated APIs
@@ -73,7 +134,7 @@ func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cf
 
 	// Admin routes (admin only)
 	adminRoutes := router.Group("/admin")
-	admin.RegisterRoutes(adminRoutes, db, logger, cfg)
+	admin.RegisterRoutes(adminRoutes, db, logger, cfg, jwtService, permissions, lockout)
 
 	// Migration routes (admin only)
 	migrationConfig := &migrationService.GoogleScriptsConfig{
@@ -86,9 +147,12 @@ func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cf
 
 	// Real-time routes (WebSocket, presence, etc.)
 	realtimeRoutes := router.Group("/realtime")
-	realtimeAPI.RegisterRoutes(realtimeRoutes, hub, logger, cfg)
+	realtimeAPI.RegisterRoutes(realtimeRoutes, hub, logger, cfg, jwtService, permissions)
 
 	// Auto-generated routes (if generated_routes.go exists)
 	// This will be populated by the generator
-	RegisterGeneratedRoutes(router, db, logger, cfg)
+	guard := middleware.NewRouteGuard(jwtService, permissions, rateLimiter, middleware.NewAuditLogger(db, logger), logger)
+	RegisterGeneratedRoutes(router, db, logger, guard)
+
+	return apiKeyUsage.Stop
 }
@@ -50,10 +50,6 @@ func (h *Handler) CreateSessions(c *gin.Context) {
 
 		Userid: req.Userid,
 
-		Token: req.Token,
-
-		Refreshtoken: req.Refreshtoken,
-
 		Deviceinfo: req.Deviceinfo,
 
 		Ipaddress: req.Ipaddress,
@@ -81,10 +77,6 @@ func (h *Handler) CreateSessions(c *gin.Context) {
 
 		Userid: sessions.Userid,
 
-		Token: sessions.Token,
-
-		Refreshtoken: sessions.Refreshtoken,
-
 		Deviceinfo: sessions.Deviceinfo,
 
 		Ipaddress: sessions.Ipaddress,
@@ -143,10 +135,6 @@ func (h *Handler) GetSessions(c *gin.Context) {
 
 		Userid: sessions.Userid,
 
-		Token: sessions.Token,
-
-		Refreshtoken: sessions.Refreshtoken,
-
 		Deviceinfo: sessions.Deviceinfo,
 
 		Ipaddress: sessions.Ipaddress,
@@ -208,10 +196,6 @@ func (h *Handler) GetAllSessionss(c *gin.Context) {
 
 			Userid: sessions.Userid,
 
-			Token: sessions.Token,
-
-			Refreshtoken: sessions.Refreshtoken,
-
 			Deviceinfo: sessions.Deviceinfo,
 
 			Ipaddress: sessions.Ipaddress,
@@ -292,14 +276,6 @@ func (h *Handler) UpdateSessions(c *gin.Context) {
 	
 
 	
-	sessions.Token = req.Token
-	
-
-	
-	sessions.Refreshtoken = req.Refreshtoken
-	
-
-	
 	sessions.Deviceinfo = req.Deviceinfo
 	
 
@@ -336,10 +312,6 @@ func (h *Handler) UpdateSessions(c *gin.Context) {
 
 		Userid: sessions.Userid,
 
-		Token: sessions.Token,
-
-		Refreshtoken: sessions.Refreshtoken,
-
 		Deviceinfo: sessions.Deviceinfo,
 
 		Ipaddress: sessions.Ipaddress,
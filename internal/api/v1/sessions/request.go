@@ -10,10 +10,6 @@ type SessionsResponse struct {
 
 	Userid uint `json:"user_id"`
 
-	Token string `json:"token"`
-
-	Refreshtoken string `json:"refresh_token"`
-
 	Deviceinfo string `json:"device_info"`
 
 	Ipaddress string `json:"ip_address"`
@@ -35,10 +31,6 @@ type SessionsCreateRequest struct {
 
 	Userid uint `json:"user_id" binding:"required"`
 
-	Token string `json:"token" binding:"required,max=500"`
-
-	Refreshtoken string `json:"refresh_token" binding:"max=500"`
-
 	Deviceinfo string `json:"device_info" binding:""`
 
 	Ipaddress string `json:"ip_address" binding:"max=45"`
@@ -58,10 +50,6 @@ type SessionsUpdateRequest struct {
 
 	Userid uint `json:"user_id" binding:"omitempty"`
 
-	Token string `json:"token" binding:"omitempty,max=500"`
-
-	Refreshtoken string `json:"refresh_token" binding:"omitempty,max=500"`
-
 	Deviceinfo string `json:"device_info" binding:"omitempty"`
 
 	Ipaddress string `json:"ip_address" binding:"omitempty,max=45"`
@@ -1,34 +1,29 @@
 package test_table
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"go-mobile-backend-template/internal/middleware"
-	authService "go-mobile-backend-template/internal/services/auth"
-	"go-mobile-backend-template/pkg/config"
 )
 
 // RegisterRoutes registers test_table routes
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cfg *config.Config) {
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, guard *middleware.RouteGuard) {
 	handler := NewHandler(db, logger)
-	
-	// Initialize JWT service for auth middleware
-	jwtService := authService.NewJWTService(
-		cfg.JWT.Secret,
-		cfg.JWT.AccessTokenExpireInt,
-		cfg.JWT.RefreshTokenExpireInt,
-	)
 
 	// test_table routes (all protected)
 	testTableRoutes := router.Group("/test_table")
-	testTableRoutes.Use(middleware.AuthMiddleware(jwtService))
+	testTableRoutes.Use(guard.Authenticate())
+	testTableRoutes.Use(guard.Limit("test_table", 110, time.Minute))
+	testTableRoutes.Use(guard.Audit())
 	{
-		testTableRoutes.POST("", handler.CreateTesttable)
-		testTableRoutes.GET("", handler.GetAllTesttables)
-		testTableRoutes.GET("/:id", handler.GetTesttable)
-		testTableRoutes.PUT("/:id", handler.UpdateTesttable)
-		testTableRoutes.DELETE("/:id", handler.DeleteTesttable)
+		testTableRoutes.POST("", guard.Require("test_table", "write"), handler.CreateTesttable)
+		testTableRoutes.GET("", guard.Require("test_table", "read"), handler.GetAllTesttables)
+		testTableRoutes.GET("/:id", guard.Require("test_table", "read"), handler.GetTesttable)
+		testTableRoutes.PUT("/:id", guard.Require("test_table", "write"), handler.UpdateTesttable)
+		testTableRoutes.DELETE("/:id", guard.Require("test_table", "delete"), handler.DeleteTesttable)
 	}
 }
@@ -10,6 +10,7 @@ import (
 	"gorm.io/gorm"
 
 	"go-mobile-backend-template/internal/db/repository/generated"
+	"go-mobile-backend-template/internal/services/auth"
 	"go-mobile-backend-template/internal/utils"
 )
 
@@ -17,13 +18,40 @@ import (
 type Handler struct {
 	userRolesRepo generated.UserrolesRepository
 	logger             *zap.Logger
+	permissions        *auth.PermissionResolver
 }
 
-// NewHandler creates a new user_roles handler
-func NewHandler(db *gorm.DB, logger *zap.Logger) *Handler {
+// NewHandler creates a new user_roles handler. Writes change the
+// permissions of users, so they invalidate those cached by permissions,
+// which may be nil.
+func NewHandler(db *gorm.DB, logger *zap.Logger, permissions *auth.PermissionResolver) *Handler {
 	return &Handler{
 		userRolesRepo: generated.NewUserrolesRepository(db),
 		logger:             logger,
+		permissions:        permissions,
+	}
+}
+
+// usersGrantedBy returns the users whose permissions depend on the
+// user_roles rows of key
+func (h *Handler) usersGrantedBy(ctx context.Context, key uint) []uint {
+	if h.permissions == nil {
+		return nil
+	}
+	userIDs, err := h.permissions.UsersGrantedBy(ctx, "user_roles", key)
+	if err != nil {
+		h.logger.Error("Failed to get users granted by user_roles", zap.Error(err))
+	}
+	return userIDs
+}
+
+// invalidatePermissions drops the cached permissions of users
+func (h *Handler) invalidatePermissions(ctx context.Context, userIDs []uint) {
+	if h.permissions == nil {
+		return
+	}
+	if err := h.permissions.Invalidate(ctx, userIDs...); err != nil {
+		h.logger.Error("Failed to invalidate permissions", zap.Error(err))
 	}
 }
 
@@ -64,6 +92,7 @@ func (h *Handler) CreateUserroles(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create user_roles")
 		return
 	}
+	h.invalidatePermissions(ctx, h.usersGrantedBy(ctx, userRoles.Userid))
 
 	response := UserrolesResponse{
 
@@ -234,6 +263,9 @@ func (h *Handler) UpdateUserroles(c *gin.Context) {
 		return
 	}
 
+	// Users granted permissions by the row before the update may lose them
+	affected := h.usersGrantedBy(ctx, userRoles.Userid)
+
 
 	
 	userRoles.Userid = req.Userid
@@ -257,6 +289,7 @@ func (h *Handler) UpdateUserroles(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update user_roles")
 		return
 	}
+	h.invalidatePermissions(ctx, append(affected, h.usersGrantedBy(ctx, userRoles.Userid)...))
 
 	response := UserrolesResponse{
 
@@ -295,6 +328,8 @@ func (h *Handler) DeleteUserroles(c *gin.Context) {
 	}
 
 	ctx := context.Background()
+	// Collect the users first; the assignments go with the rows
+	affected := h.usersGrantedBy(ctx, uint(id))
 	if err := h.userRolesRepo.Delete(ctx, uint(id)); err != nil {
 		if err == gorm.ErrRecordNotFound {
 			utils.ErrorResponse(c, http.StatusNotFound, "user_roles not found")
@@ -304,6 +339,7 @@ func (h *Handler) DeleteUserroles(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete user_roles")
 		return
 	}
+	h.invalidatePermissions(ctx, affected)
 
 	utils.SuccessResponse(c, http.StatusOK, "user_roles deleted successfully", nil)
 }
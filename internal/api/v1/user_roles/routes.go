@@ -1,34 +1,29 @@
 package user_roles
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"go-mobile-backend-template/internal/middleware"
-	authService "go-mobile-backend-template/internal/services/auth"
-	"go-mobile-backend-template/pkg/config"
 )
 
 // RegisterRoutes registers user_roles routes
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cfg *config.Config) {
-	handler := NewHandler(db, logger)
-	
-	// Initialize JWT service for auth middleware
-	jwtService := authService.NewJWTService(
-		cfg.JWT.Secret,
-		cfg.JWT.AccessTokenExpireInt,
-		cfg.JWT.RefreshTokenExpireInt,
-	)
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, guard *middleware.RouteGuard) {
+	handler := NewHandler(db, logger, guard.Permissions())
 
 	// user_roles routes (all protected)
 	userRolesRoutes := router.Group("/user_roles")
-	userRolesRoutes.Use(middleware.AuthMiddleware(jwtService))
+	userRolesRoutes.Use(guard.Authenticate())
+	userRolesRoutes.Use(guard.Limit("user_roles", 110, time.Minute))
+	userRolesRoutes.Use(guard.Audit())
 	{
-		userRolesRoutes.POST("", handler.CreateUserroles)
-		userRolesRoutes.GET("", handler.GetAllUserroless)
-		userRolesRoutes.GET("/:id", handler.GetUserroles)
-		userRolesRoutes.PUT("/:id", handler.UpdateUserroles)
-		userRolesRoutes.DELETE("/:id", handler.DeleteUserroles)
+		userRolesRoutes.POST("", guard.Require("user_roles", "write"), handler.CreateUserroles)
+		userRolesRoutes.GET("", guard.Require("user_roles", "read"), handler.GetAllUserroless)
+		userRolesRoutes.GET("/:id", guard.Require("user_roles", "read"), handler.GetUserroles)
+		userRolesRoutes.PUT("/:id", guard.Require("user_roles", "write"), handler.UpdateUserroles)
+		userRolesRoutes.DELETE("/:id", guard.Require("user_roles", "delete"), handler.DeleteUserroles)
 	}
 }
@@ -50,26 +50,8 @@ func (h *Handler) CreateUsers(c *gin.Context) {
 
 		Email: req.Email,
 
-		Password: req.Password,
-
 		Name: req.Name,
 
-		Isactive: req.Isactive,
-
-		Isadmin: req.Isadmin,
-
-		Deletedat: req.Deletedat,
-
-		Emailverified: req.Emailverified,
-
-		Emailverifiedat: req.Emailverifiedat,
-
-		Lastloginat: req.Lastloginat,
-
-		Failedloginattempts: req.Failedloginattempts,
-
-		Lockeduntil: req.Lockeduntil,
-
 		Metadata: req.Metadata,
 
 		Nickname: req.Nickname,
@@ -78,6 +60,30 @@ func (h *Handler) CreateUsers(c *gin.Context) {
 
 	}
 
+	if req.Isactive != nil {
+		if !c.GetBool("is_admin") {
+			utils.ErrorResponse(c, http.StatusForbidden, "Only admins may set is_active")
+			return
+		}
+		users.Isactive = *req.Isactive
+	}
+
+	if req.Isadmin != nil {
+		if !c.GetBool("is_admin") {
+			utils.ErrorResponse(c, http.StatusForbidden, "Only admins may set is_admin")
+			return
+		}
+		users.Isadmin = *req.Isadmin
+	}
+
+	if req.Emailverified != nil {
+		if !c.GetBool("is_admin") {
+			utils.ErrorResponse(c, http.StatusForbidden, "Only admins may set email_verified")
+			return
+		}
+		users.Emailverified = *req.Emailverified
+	}
+
 	ctx := context.Background()
 	if err := h.usersRepo.Create(ctx, users); err != nil {
 		h.logger.Error("Failed to create users", zap.Error(err))
@@ -91,8 +97,6 @@ func (h *Handler) CreateUsers(c *gin.Context) {
 
 		Email: users.Email,
 
-		Password: users.Password,
-
 		Name: users.Name,
 
 		Isactive: users.Isactive,
@@ -165,8 +169,6 @@ func (h *Handler) GetUsers(c *gin.Context) {
 
 		Email: users.Email,
 
-		Password: users.Password,
-
 		Name: users.Name,
 
 		Isactive: users.Isactive,
@@ -242,8 +244,6 @@ func (h *Handler) GetAllUserss(c *gin.Context) {
 
 			Email: users.Email,
 
-			Password: users.Password,
-
 			Name: users.Name,
 
 			Isactive: users.Isactive,
@@ -338,43 +338,37 @@ func (h *Handler) UpdateUsers(c *gin.Context) {
 	
 
 	
-	users.Password = req.Password
-	
-
-	
 	users.Name = req.Name
 	
 
 	
-	users.Isactive = req.Isactive
-	
-
-	
-	users.Isadmin = req.Isadmin
-	
-
-	
-	users.Deletedat = req.Deletedat
-	
-
-	
-	users.Emailverified = req.Emailverified
-	
-
-	
-	users.Emailverifiedat = req.Emailverifiedat
-	
-
-	
-	users.Lastloginat = req.Lastloginat
+	if req.Isactive != nil {
+		if !c.GetBool("is_admin") {
+			utils.ErrorResponse(c, http.StatusForbidden, "Only admins may set is_active")
+			return
+		}
+		users.Isactive = *req.Isactive
+	}
 	
 
 	
-	users.Failedloginattempts = req.Failedloginattempts
+	if req.Isadmin != nil {
+		if !c.GetBool("is_admin") {
+			utils.ErrorResponse(c, http.StatusForbidden, "Only admins may set is_admin")
+			return
+		}
+		users.Isadmin = *req.Isadmin
+	}
 	
 
 	
-	users.Lockeduntil = req.Lockeduntil
+	if req.Emailverified != nil {
+		if !c.GetBool("is_admin") {
+			utils.ErrorResponse(c, http.StatusForbidden, "Only admins may set email_verified")
+			return
+		}
+		users.Emailverified = *req.Emailverified
+	}
 	
 
 	
@@ -402,8 +396,6 @@ func (h *Handler) UpdateUsers(c *gin.Context) {
 
 		Email: users.Email,
 
-		Password: users.Password,
-
 		Name: users.Name,
 
 		Isactive: users.Isactive,
@@ -10,8 +10,6 @@ type UsersResponse struct {
 
 	Email string `json:"email"`
 
-	Password string `json:"password"`
-
 	Name string `json:"name"`
 
 	Isactive bool `json:"is_active"`
@@ -47,25 +45,13 @@ type UsersCreateRequest struct {
 
 	Email string `json:"email" binding:"required"`
 
-	Password string `json:"password" binding:"required"`
-
 	Name string `json:"name" binding:"required"`
 
-	Isactive bool `json:"is_active" binding:""`
-
-	Isadmin bool `json:"is_admin" binding:""`
-
-	Deletedat time.Time `json:"deleted_at" binding:""`
-
-	Emailverified bool `json:"email_verified" binding:""`
-
-	Emailverifiedat time.Time `json:"email_verified_at" binding:""`
-
-	Lastloginat time.Time `json:"last_login_at" binding:""`
+	Isactive *bool `json:"is_active" binding:"omitempty"`
 
-	Failedloginattempts uint `json:"failed_login_attempts" binding:""`
+	Isadmin *bool `json:"is_admin" binding:"omitempty"`
 
-	Lockeduntil time.Time `json:"locked_until" binding:""`
+	Emailverified *bool `json:"email_verified" binding:"omitempty"`
 
 	Metadata string `json:"metadata" binding:""`
 
@@ -80,25 +66,13 @@ type UsersUpdateRequest struct {
 
 	Email string `json:"email" binding:"omitempty"`
 
-	Password string `json:"password" binding:"omitempty"`
-
 	Name string `json:"name" binding:"omitempty"`
 
-	Isactive bool `json:"is_active" binding:"omitempty"`
-
-	Isadmin bool `json:"is_admin" binding:"omitempty"`
-
-	Deletedat time.Time `json:"deleted_at" binding:"omitempty"`
-
-	Emailverified bool `json:"email_verified" binding:"omitempty"`
-
-	Emailverifiedat time.Time `json:"email_verified_at" binding:"omitempty"`
-
-	Lastloginat time.Time `json:"last_login_at" binding:"omitempty"`
+	Isactive *bool `json:"is_active" binding:"omitempty"`
 
-	Failedloginattempts uint `json:"failed_login_attempts" binding:"omitempty"`
+	Isadmin *bool `json:"is_admin" binding:"omitempty"`
 
-	Lockeduntil time.Time `json:"locked_until" binding:"omitempty"`
+	Emailverified *bool `json:"email_verified" binding:"omitempty"`
 
 	Metadata string `json:"metadata" binding:"omitempty"`
 
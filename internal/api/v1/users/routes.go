@@ -1,34 +1,29 @@
 package users
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"go-mobile-backend-template/internal/middleware"
-	authService "go-mobile-backend-template/internal/services/auth"
-	"go-mobile-backend-template/pkg/config"
 )
 
 // RegisterRoutes registers users routes
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cfg *config.Config) {
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, guard *middleware.RouteGuard) {
 	handler := NewHandler(db, logger)
-	
-	// Initialize JWT service for auth middleware
-	jwtService := authService.NewJWTService(
-		cfg.JWT.Secret,
-		cfg.JWT.AccessTokenExpireInt,
-		cfg.JWT.RefreshTokenExpireInt,
-	)
 
 	// users routes (all protected)
 	usersRoutes := router.Group("/users")
-	usersRoutes.Use(middleware.AuthMiddleware(jwtService))
+	usersRoutes.Use(guard.Authenticate())
+	usersRoutes.Use(guard.Limit("users", 55, time.Minute))
+	usersRoutes.Use(guard.Audit())
 	{
-		usersRoutes.POST("", handler.CreateUsers)
-		usersRoutes.GET("", handler.GetAllUserss)
-		usersRoutes.GET("/:id", handler.GetUsers)
-		usersRoutes.PUT("/:id", handler.UpdateUsers)
-		usersRoutes.DELETE("/:id", handler.DeleteUsers)
+		usersRoutes.POST("", guard.Require("users", "users:write"), handler.CreateUsers)
+		usersRoutes.GET("", guard.Require("users", "users:read"), handler.GetAllUserss)
+		usersRoutes.GET("/:id", guard.Require("users", "users:read"), handler.GetUsers)
+		usersRoutes.PUT("/:id", guard.Require("users", "users:write"), handler.UpdateUsers)
+		usersRoutes.DELETE("/:id", guard.Require("users", "users:delete"), handler.DeleteUsers)
 	}
 }
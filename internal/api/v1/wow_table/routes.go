@@ -1,34 +1,29 @@
 package wow_table
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"go-mobile-backend-template/internal/middleware"
-	authService "go-mobile-backend-template/internal/services/auth"
-	"go-mobile-backend-template/pkg/config"
 )
 
 // RegisterRoutes registers wow_table routes
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cfg *config.Config) {
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, guard *middleware.RouteGuard) {
 	handler := NewHandler(db, logger)
-	
-	// Initialize JWT service for auth middleware
-	jwtService := authService.NewJWTService(
-		cfg.JWT.Secret,
-		cfg.JWT.AccessTokenExpireInt,
-		cfg.JWT.RefreshTokenExpireInt,
-	)
 
 	// wow_table routes (all protected)
 	wowTableRoutes := router.Group("/wow_table")
-	wowTableRoutes.Use(middleware.AuthMiddleware(jwtService))
+	wowTableRoutes.Use(guard.Authenticate())
+	wowTableRoutes.Use(guard.Limit("wow_table", 110, time.Minute))
+	wowTableRoutes.Use(guard.Audit())
 	{
-		wowTableRoutes.POST("", handler.CreateWowtable)
-		wowTableRoutes.GET("", handler.GetAllWowtables)
-		wowTableRoutes.GET("/:id", handler.GetWowtable)
-		wowTableRoutes.PUT("/:id", handler.UpdateWowtable)
-		wowTableRoutes.DELETE("/:id", handler.DeleteWowtable)
+		wowTableRoutes.POST("", guard.Require("wow_table", "write"), handler.CreateWowtable)
+		wowTableRoutes.GET("", guard.Require("wow_table", "read"), handler.GetAllWowtables)
+		wowTableRoutes.GET("/:id", guard.Require("wow_table", "read"), handler.GetWowtable)
+		wowTableRoutes.PUT("/:id", guard.Require("wow_table", "write"), handler.UpdateWowtable)
+		wowTableRoutes.DELETE("/:id", guard.Require("wow_table", "delete"), handler.DeleteWowtable)
 	}
 }
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Columns written by middleware.AuditLogger for request and security events
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS request_data TEXT;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS response_data TEXT;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS status INTEGER;
+ALTER TABLE audit_logs ALTER COLUMN resource TYPE VARCHAR(255);
+ALTER TABLE audit_logs ALTER COLUMN resource_id TYPE VARCHAR(255);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS status;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS response_data;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS request_data;
+
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- JWT signing keys used when jwt.key_source is "db". Private keys are sealed
+-- with a key derived from jwt.secret.
+CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+    id SERIAL PRIMARY KEY,
+    kid VARCHAR(64) UNIQUE NOT NULL,
+    algorithm VARCHAR(16) NOT NULL,
+    private_key TEXT NOT NULL,
+    activates_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_jwt_signing_keys_activates_at ON jwt_signing_keys(activates_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS jwt_signing_keys;
+
+-- +goose StatementEnd
@@ -4,8 +4,13 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"time"
+
+	"go-mobile-backend-template/internal/db/repository"
 )
 
+// User is the user model shared with the repository layer
+type User = repository.User
+
 // Role represents a user role in the system
 type Role struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyRepository implements APIKeyRepository interface
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+// Create creates a new API key
+func (r *apiKeyRepository) Create(ctx context.Context, key *APIKey) error {
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an API key by ID
+func (r *apiKeyRepository) GetByID(ctx context.Context, id uint) (*APIKey, error) {
+	var key APIKey
+	if err := r.db.WithContext(ctx).First(&key, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("api key not found")
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return &key, nil
+}
+
+// GetByHash retrieves an API key by the hash of its value
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*APIKey, error) {
+	var key APIKey
+	if err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("api key not found")
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return &key, nil
+}
+
+// GetByUserID retrieves all API keys of a user
+func (r *apiKeyRepository) GetByUserID(ctx context.Context, userID uint) ([]*APIKey, error) {
+	var keys []*APIKey
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to get api keys by user ID: %w", err)
+	}
+	return keys, nil
+}
+
+// Deactivate deactivates an API key
+func (r *apiKeyRepository) Deactivate(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).
+		Model(&APIKey{}).
+		Where("id = ?", id).
+		Update("is_active", false).Error; err != nil {
+		return fmt.Errorf("failed to deactivate api key: %w", err)
+	}
+	return nil
+}
+
+// UpdateLastUsed records last use times for several keys in one transaction
+func (r *apiKeyRepository) UpdateLastUsed(ctx context.Context, usage map[uint]time.Time) error {
+	if len(usage) == 0 {
+		return nil
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for id, usedAt := range usage {
+			if err := tx.Model(&APIKey{}).
+				Where("id = ?", id).
+				UpdateColumn("last_used_at", usedAt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update api key usage: %w", err)
+	}
+	return nil
+}
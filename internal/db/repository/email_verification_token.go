@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// emailVerificationTokenRepository implements EmailVerificationTokenRepository interface
+type emailVerificationTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewEmailVerificationTokenRepository creates a new email verification token repository
+func NewEmailVerificationTokenRepository(db *gorm.DB) EmailVerificationTokenRepository {
+	return &emailVerificationTokenRepository{db: db}
+}
+
+// Create creates a new email verification token
+func (r *emailVerificationTokenRepository) Create(ctx context.Context, token *EmailVerificationToken) error {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return fmt.Errorf("failed to create email verification token: %w", err)
+	}
+	return nil
+}
+
+// GetByToken retrieves a email verification token by its hash
+func (r *emailVerificationTokenRepository) GetByToken(ctx context.Context, tokenHash string) (*EmailVerificationToken, error) {
+	var token EmailVerificationToken
+	if err := r.db.WithContext(ctx).Where("token = ?", tokenHash).First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("email verification token not found")
+		}
+		return nil, fmt.Errorf("failed to get email verification token: %w", err)
+	}
+	return &token, nil
+}
+
+// MarkUsed marks an unused email verification token as used. It reports false when the
+// token was already used, so concurrent requests cannot both consume it.
+func (r *emailVerificationTokenRepository) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&EmailVerificationToken{}).
+		Where("id = ? AND used = ?", id, false).
+		Updates(map[string]interface{}{"used": true, "used_at": time.Now()})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to mark email verification token as used: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// InvalidateAllForUser marks every outstanding email verification token of a user as used
+func (r *emailVerificationTokenRepository) InvalidateAllForUser(ctx context.Context, userID uint) error {
+	if err := r.db.WithContext(ctx).
+		Model(&EmailVerificationToken{}).
+		Where("user_id = ? AND used = ?", userID, false).
+		Updates(map[string]interface{}{"used": true, "used_at": time.Now()}).Error; err != nil {
+		return fmt.Errorf("failed to invalidate email verification tokens for user: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired deletes expired email verification tokens
+func (r *emailVerificationTokenRepository) DeleteExpired(ctx context.Context) error {
+	if err := r.db.WithContext(ctx).
+		Where("expires_at < ?", time.Now()).
+		Delete(&EmailVerificationToken{}).Error; err != nil {
+		return fmt.Errorf("failed to delete expired email verification tokens: %w", err)
+	}
+	return nil
+}
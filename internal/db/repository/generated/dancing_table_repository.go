@@ -32,7 +32,7 @@ func (r *dancingTableRepository) Create(ctx context.Context, dancingTable *Danci
 // GetByID gets a dancingTable by ID
 func (r *dancingTableRepository) GetByID(ctx context.Context, id uint) (*Dancingtable, error) {
 	var dancingTable Dancingtable
-	err := r.db.WithContext(ctx).First(&dancingTable, id).Error
+	err := r.records(ctx).First(&dancingTable, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -45,12 +45,12 @@ func (r *dancingTableRepository) GetAll(ctx context.Context, limit, offset int)
 	var total int64
 
 	// Get total count
-	if err := r.db.WithContext(ctx).Model(&Dancingtable{}).Count(&total).Error; err != nil {
+	if err := r.records(ctx).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get data with pagination
-	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&dancingTables).Error
+	err := r.records(ctx).Limit(limit).Offset(offset).Find(&dancingTables).Error
 	return dancingTables, total, err
 }
 
@@ -63,3 +63,8 @@ func (r *dancingTableRepository) Update(ctx context.Context, dancingTable *Danci
 func (r *dancingTableRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&Dancingtable{}, id).Error
 }
+
+// records returns the query of the dancingTables
+func (r *dancingTableRepository) records(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&Dancingtable{})
+}
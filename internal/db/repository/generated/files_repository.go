@@ -2,6 +2,7 @@ package generated
 
 import (
 	"context"
+	"time"
 	"gorm.io/gorm"
 )
 
@@ -32,7 +33,7 @@ func (r *filesRepository) Create(ctx context.Context, files *Files) error {
 // GetByID gets a files by ID
 func (r *filesRepository) GetByID(ctx context.Context, id uint) (*Files, error) {
 	var files Files
-	err := r.db.WithContext(ctx).First(&files, id).Error
+	err := r.records(ctx).First(&files, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -45,12 +46,12 @@ func (r *filesRepository) GetAll(ctx context.Context, limit, offset int) ([]File
 	var total int64
 
 	// Get total count
-	if err := r.db.WithContext(ctx).Model(&Files{}).Count(&total).Error; err != nil {
+	if err := r.records(ctx).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get data with pagination
-	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&filess).Error
+	err := r.records(ctx).Limit(limit).Offset(offset).Find(&filess).Error
 	return filess, total, err
 }
 
@@ -59,7 +60,19 @@ func (r *filesRepository) Update(ctx context.Context, files *Files) error {
 	return r.db.WithContext(ctx).Save(files).Error
 }
 
-// Delete deletes a files by ID
+// Delete marks a files as deleted by ID
 func (r *filesRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&Files{}, id).Error
+	result := r.records(ctx).Where("id = ?", id).Update("deleted_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// records returns the query of the filess that are not deleted
+func (r *filesRepository) records(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&Files{}).Where("deleted_at IS NULL")
 }
@@ -32,7 +32,7 @@ func (r *oauthProvidersRepository) Create(ctx context.Context, oauthProviders *O
 // GetByID gets a oauthProviders by ID
 func (r *oauthProvidersRepository) GetByID(ctx context.Context, id uint) (*Oauthproviders, error) {
 	var oauthProviders Oauthproviders
-	err := r.db.WithContext(ctx).First(&oauthProviders, id).Error
+	err := r.records(ctx).First(&oauthProviders, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -45,12 +45,12 @@ func (r *oauthProvidersRepository) GetAll(ctx context.Context, limit, offset int
 	var total int64
 
 	// Get total count
-	if err := r.db.WithContext(ctx).Model(&Oauthproviders{}).Count(&total).Error; err != nil {
+	if err := r.records(ctx).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get data with pagination
-	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&oauthProviderss).Error
+	err := r.records(ctx).Limit(limit).Offset(offset).Find(&oauthProviderss).Error
 	return oauthProviderss, total, err
 }
 
@@ -63,3 +63,8 @@ func (r *oauthProvidersRepository) Update(ctx context.Context, oauthProviders *O
 func (r *oauthProvidersRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&Oauthproviders{}, id).Error
 }
+
+// records returns the query of the oauthProviderss
+func (r *oauthProvidersRepository) records(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&Oauthproviders{})
+}
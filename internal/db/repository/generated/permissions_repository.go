@@ -32,7 +32,7 @@ func (r *permissionsRepository) Create(ctx context.Context, permissions *Permiss
 // GetByID gets a permissions by ID
 func (r *permissionsRepository) GetByID(ctx context.Context, id uint) (*Permissions, error) {
 	var permissions Permissions
-	err := r.db.WithContext(ctx).First(&permissions, id).Error
+	err := r.records(ctx).First(&permissions, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -45,12 +45,12 @@ func (r *permissionsRepository) GetAll(ctx context.Context, limit, offset int) (
 	var total int64
 
 	// Get total count
-	if err := r.db.WithContext(ctx).Model(&Permissions{}).Count(&total).Error; err != nil {
+	if err := r.records(ctx).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get data with pagination
-	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&permissionss).Error
+	err := r.records(ctx).Limit(limit).Offset(offset).Find(&permissionss).Error
 	return permissionss, total, err
 }
 
@@ -63,3 +63,8 @@ func (r *permissionsRepository) Update(ctx context.Context, permissions *Permiss
 func (r *permissionsRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&Permissions{}, id).Error
 }
+
+// records returns the query of the permissionss
+func (r *permissionsRepository) records(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&Permissions{})
+}
@@ -2,6 +2,7 @@ package generated
 
 import (
 	"context"
+	"time"
 	"gorm.io/gorm"
 )
 
@@ -32,7 +33,7 @@ func (r *refreshTokensRepository) Create(ctx context.Context, refreshTokens *Ref
 // GetByID gets a refreshTokens by ID
 func (r *refreshTokensRepository) GetByID(ctx context.Context, id uint) (*Refreshtokens, error) {
 	var refreshTokens Refreshtokens
-	err := r.db.WithContext(ctx).First(&refreshTokens, id).Error
+	err := r.records(ctx).First(&refreshTokens, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -45,12 +46,12 @@ func (r *refreshTokensRepository) GetAll(ctx context.Context, limit, offset int)
 	var total int64
 
 	// Get total count
-	if err := r.db.WithContext(ctx).Model(&Refreshtokens{}).Count(&total).Error; err != nil {
+	if err := r.records(ctx).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get data with pagination
-	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&refreshTokenss).Error
+	err := r.records(ctx).Limit(limit).Offset(offset).Find(&refreshTokenss).Error
 	return refreshTokenss, total, err
 }
 
@@ -59,7 +60,19 @@ func (r *refreshTokensRepository) Update(ctx context.Context, refreshTokens *Ref
 	return r.db.WithContext(ctx).Save(refreshTokens).Error
 }
 
-// Delete deletes a refreshTokens by ID
+// Delete marks a refreshTokens as deleted by ID
 func (r *refreshTokensRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&Refreshtokens{}, id).Error
+	result := r.records(ctx).Where("id = ?", id).Update("deleted_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// records returns the query of the refreshTokenss that are not deleted
+func (r *refreshTokensRepository) records(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&Refreshtokens{}).Where("deleted_at IS NULL")
 }
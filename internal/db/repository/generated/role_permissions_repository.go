@@ -32,7 +32,7 @@ func (r *rolePermissionsRepository) Create(ctx context.Context, rolePermissions
 // GetByID gets a rolePermissions by ID
 func (r *rolePermissionsRepository) GetByID(ctx context.Context, id uint) (*Rolepermissions, error) {
 	var rolePermissions Rolepermissions
-	err := r.db.WithContext(ctx).First(&rolePermissions, id).Error
+	err := r.records(ctx).First(&rolePermissions, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -45,12 +45,12 @@ func (r *rolePermissionsRepository) GetAll(ctx context.Context, limit, offset in
 	var total int64
 
 	// Get total count
-	if err := r.db.WithContext(ctx).Model(&Rolepermissions{}).Count(&total).Error; err != nil {
+	if err := r.records(ctx).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get data with pagination
-	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&rolePermissionss).Error
+	err := r.records(ctx).Limit(limit).Offset(offset).Find(&rolePermissionss).Error
 	return rolePermissionss, total, err
 }
 
@@ -63,3 +63,8 @@ func (r *rolePermissionsRepository) Update(ctx context.Context, rolePermissions
 func (r *rolePermissionsRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&Rolepermissions{}, id).Error
 }
+
+// records returns the query of the rolePermissionss
+func (r *rolePermissionsRepository) records(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&Rolepermissions{})
+}
@@ -32,7 +32,7 @@ func (r *rolesRepository) Create(ctx context.Context, roles *Roles) error {
 // GetByID gets a roles by ID
 func (r *rolesRepository) GetByID(ctx context.Context, id uint) (*Roles, error) {
 	var roles Roles
-	err := r.db.WithContext(ctx).First(&roles, id).Error
+	err := r.records(ctx).First(&roles, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -45,12 +45,12 @@ func (r *rolesRepository) GetAll(ctx context.Context, limit, offset int) ([]Role
 	var total int64
 
 	// Get total count
-	if err := r.db.WithContext(ctx).Model(&Roles{}).Count(&total).Error; err != nil {
+	if err := r.records(ctx).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get data with pagination
-	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&roless).Error
+	err := r.records(ctx).Limit(limit).Offset(offset).Find(&roless).Error
 	return roless, total, err
 }
 
@@ -63,3 +63,8 @@ func (r *rolesRepository) Update(ctx context.Context, roles *Roles) error {
 func (r *rolesRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&Roles{}, id).Error
 }
+
+// records returns the query of the roless
+func (r *rolesRepository) records(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&Roles{})
+}
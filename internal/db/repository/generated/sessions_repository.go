@@ -32,7 +32,7 @@ func (r *sessionsRepository) Create(ctx context.Context, sessions *Sessions) err
 // GetByID gets a sessions by ID
 func (r *sessionsRepository) GetByID(ctx context.Context, id uint) (*Sessions, error) {
 	var sessions Sessions
-	err := r.db.WithContext(ctx).First(&sessions, id).Error
+	err := r.records(ctx).First(&sessions, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -45,12 +45,12 @@ func (r *sessionsRepository) GetAll(ctx context.Context, limit, offset int) ([]S
 	var total int64
 
 	// Get total count
-	if err := r.db.WithContext(ctx).Model(&Sessions{}).Count(&total).Error; err != nil {
+	if err := r.records(ctx).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get data with pagination
-	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&sessionss).Error
+	err := r.records(ctx).Limit(limit).Offset(offset).Find(&sessionss).Error
 	return sessionss, total, err
 }
 
@@ -63,3 +63,8 @@ func (r *sessionsRepository) Update(ctx context.Context, sessions *Sessions) err
 func (r *sessionsRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&Sessions{}, id).Error
 }
+
+// records returns the query of the sessionss
+func (r *sessionsRepository) records(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&Sessions{})
+}
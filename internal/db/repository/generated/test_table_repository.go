@@ -32,7 +32,7 @@ func (r *testTableRepository) Create(ctx context.Context, testTable *Testtable)
 // GetByID gets a testTable by ID
 func (r *testTableRepository) GetByID(ctx context.Context, id uint) (*Testtable, error) {
 	var testTable Testtable
-	err := r.db.WithContext(ctx).First(&testTable, id).Error
+	err := r.records(ctx).First(&testTable, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -45,12 +45,12 @@ func (r *testTableRepository) GetAll(ctx context.Context, limit, offset int) ([]
 	var total int64
 
 	// Get total count
-	if err := r.db.WithContext(ctx).Model(&Testtable{}).Count(&total).Error; err != nil {
+	if err := r.records(ctx).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get data with pagination
-	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&testTables).Error
+	err := r.records(ctx).Limit(limit).Offset(offset).Find(&testTables).Error
 	return testTables, total, err
 }
 
@@ -63,3 +63,8 @@ func (r *testTableRepository) Update(ctx context.Context, testTable *Testtable)
 func (r *testTableRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&Testtable{}, id).Error
 }
+
+// records returns the query of the testTables
+func (r *testTableRepository) records(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&Testtable{})
+}
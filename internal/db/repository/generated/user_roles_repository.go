@@ -32,7 +32,7 @@ func (r *userRolesRepository) Create(ctx context.Context, userRoles *Userroles)
 // GetByID gets a userRoles by ID
 func (r *userRolesRepository) GetByID(ctx context.Context, id uint) (*Userroles, error) {
 	var userRoles Userroles
-	err := r.db.WithContext(ctx).First(&userRoles, id).Error
+	err := r.records(ctx).First(&userRoles, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -45,12 +45,12 @@ func (r *userRolesRepository) GetAll(ctx context.Context, limit, offset int) ([]
 	var total int64
 
 	// Get total count
-	if err := r.db.WithContext(ctx).Model(&Userroles{}).Count(&total).Error; err != nil {
+	if err := r.records(ctx).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get data with pagination
-	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&userRoless).Error
+	err := r.records(ctx).Limit(limit).Offset(offset).Find(&userRoless).Error
 	return userRoless, total, err
 }
 
@@ -63,3 +63,8 @@ func (r *userRolesRepository) Update(ctx context.Context, userRoles *Userroles)
 func (r *userRolesRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&Userroles{}, id).Error
 }
+
+// records returns the query of the userRoless
+func (r *userRolesRepository) records(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&Userroles{})
+}
@@ -2,6 +2,7 @@ package generated
 
 import (
 	"context"
+	"time"
 	"gorm.io/gorm"
 )
 
@@ -32,7 +33,7 @@ func (r *usersRepository) Create(ctx context.Context, users *Users) error {
 // GetByID gets a users by ID
 func (r *usersRepository) GetByID(ctx context.Context, id uint) (*Users, error) {
 	var users Users
-	err := r.db.WithContext(ctx).First(&users, id).Error
+	err := r.records(ctx).First(&users, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -45,12 +46,12 @@ func (r *usersRepository) GetAll(ctx context.Context, limit, offset int) ([]User
 	var total int64
 
 	// Get total count
-	if err := r.db.WithContext(ctx).Model(&Users{}).Count(&total).Error; err != nil {
+	if err := r.records(ctx).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get data with pagination
-	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&userss).Error
+	err := r.records(ctx).Limit(limit).Offset(offset).Find(&userss).Error
 	return userss, total, err
 }
 
@@ -59,7 +60,19 @@ func (r *usersRepository) Update(ctx context.Context, users *Users) error {
 	return r.db.WithContext(ctx).Save(users).Error
 }
 
-// Delete deletes a users by ID
+// Delete marks a users as deleted by ID
 func (r *usersRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&Users{}, id).Error
+	result := r.records(ctx).Where("id = ?", id).Update("deleted_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// records returns the query of the userss that are not deleted
+func (r *usersRepository) records(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&Users{}).Where("deleted_at IS NULL")
 }
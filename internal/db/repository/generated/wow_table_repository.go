@@ -32,7 +32,7 @@ func (r *wowTableRepository) Create(ctx context.Context, wowTable *Wowtable) err
 // GetByID gets a wowTable by ID
 func (r *wowTableRepository) GetByID(ctx context.Context, id uint) (*Wowtable, error) {
 	var wowTable Wowtable
-	err := r.db.WithContext(ctx).First(&wowTable, id).Error
+	err := r.records(ctx).First(&wowTable, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -45,12 +45,12 @@ func (r *wowTableRepository) GetAll(ctx context.Context, limit, offset int) ([]W
 	var total int64
 
 	// Get total count
-	if err := r.db.WithContext(ctx).Model(&Wowtable{}).Count(&total).Error; err != nil {
+	if err := r.records(ctx).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get data with pagination
-	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&wowTables).Error
+	err := r.records(ctx).Limit(limit).Offset(offset).Find(&wowTables).Error
 	return wowTables, total, err
 }
 
@@ -63,3 +63,8 @@ func (r *wowTableRepository) Update(ctx context.Context, wowTable *Wowtable) err
 func (r *wowTableRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&Wowtable{}, id).Error
 }
+
+// records returns the query of the wowTables
+func (r *wowTableRepository) records(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&Wowtable{})
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 )
 
 // UserRepository defines the interface for user data operations
@@ -12,6 +13,10 @@ type UserRepository interface {
 	Update(ctx context.Context, user *User) error
 	Delete(ctx context.Context, id uint) error
 	List(ctx context.Context, limit, offset int) ([]*User, error)
+	IncrementFailedLogins(ctx context.Context, id uint) (int, error)
+	SetLockedUntil(ctx context.Context, id uint, until *time.Time) error
+	RecordLogin(ctx context.Context, id uint, at time.Time) error
+	ResetLockout(ctx context.Context, id uint) error
 }
 
 // FileRepository defines the interface for file data operations
@@ -34,3 +39,71 @@ type RefreshTokenRepository interface {
 	RevokeAllForUser(ctx context.Context, userID uint) error
 	DeleteExpired(ctx context.Context) error
 }
+
+// SessionRepository defines the interface for session operations
+type SessionRepository interface {
+	Create(ctx context.Context, session *Session) error
+	GetByID(ctx context.Context, id uint) (*Session, error)
+	GetActiveByUserID(ctx context.Context, userID uint) ([]*Session, error)
+	Update(ctx context.Context, session *Session) error
+	RotateRefreshToken(ctx context.Context, id uint, previousHash, hash string, expiresAt time.Time) (bool, error)
+	Touch(ctx context.Context, id uint, usedAt time.Time) error
+	Deactivate(ctx context.Context, id uint) error
+	DeactivateAllForUser(ctx context.Context, userID uint, exceptID uint) error
+	DeleteExpired(ctx context.Context) error
+}
+
+// TwoFactorRepository defines the interface for two-factor authentication operations
+type TwoFactorRepository interface {
+	Create(ctx context.Context, twoFactor *TwoFactor) error
+	GetByUserID(ctx context.Context, userID uint) (*TwoFactor, error)
+	IsEnabledForUser(ctx context.Context, userID uint) (bool, error)
+	Update(ctx context.Context, twoFactor *TwoFactor) error
+	UseTOTPStep(ctx context.Context, userID uint, stepStart time.Time) (bool, error)
+	UseBackupCode(ctx context.Context, userID uint, hash string) (bool, error)
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
+
+// OAuthProviderRepository defines the interface for linked OAuth identity operations
+type OAuthProviderRepository interface {
+	Create(ctx context.Context, link *OAuthProvider) error
+	GetByProviderUserID(ctx context.Context, provider, providerUserID string) (*OAuthProvider, error)
+	GetByUserID(ctx context.Context, userID uint) ([]*OAuthProvider, error)
+	Update(ctx context.Context, link *OAuthProvider) error
+	Delete(ctx context.Context, userID uint, provider string) error
+}
+
+// PasswordResetTokenRepository defines the interface for password reset token operations
+type PasswordResetTokenRepository interface {
+	Create(ctx context.Context, token *PasswordResetToken) error
+	GetByToken(ctx context.Context, tokenHash string) (*PasswordResetToken, error)
+	MarkUsed(ctx context.Context, id uint) (bool, error)
+	InvalidateAllForUser(ctx context.Context, userID uint) error
+	DeleteExpired(ctx context.Context) error
+}
+
+// EmailVerificationTokenRepository defines the interface for email verification token operations
+type EmailVerificationTokenRepository interface {
+	Create(ctx context.Context, token *EmailVerificationToken) error
+	GetByToken(ctx context.Context, tokenHash string) (*EmailVerificationToken, error)
+	MarkUsed(ctx context.Context, id uint) (bool, error)
+	InvalidateAllForUser(ctx context.Context, userID uint) error
+	DeleteExpired(ctx context.Context) error
+}
+
+// APIKeyRepository defines the interface for API key operations
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *APIKey) error
+	GetByID(ctx context.Context, id uint) (*APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	GetByUserID(ctx context.Context, userID uint) ([]*APIKey, error)
+	Deactivate(ctx context.Context, id uint) error
+	UpdateLastUsed(ctx context.Context, usage map[uint]time.Time) error
+}
+
+// JWTSigningKeyRepository defines the interface for JWT signing key operations
+type JWTSigningKeyRepository interface {
+	Create(ctx context.Context, key *JWTSigningKey) error
+	List(ctx context.Context) ([]*JWTSigningKey, error)
+	DeleteActivatedBefore(ctx context.Context, before time.Time, keep uint) error
+}
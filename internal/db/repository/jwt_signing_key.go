@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// jwtSigningKeyRepository implements JWTSigningKeyRepository interface
+type jwtSigningKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewJWTSigningKeyRepository creates a new JWT signing key repository
+func NewJWTSigningKeyRepository(db *gorm.DB) JWTSigningKeyRepository {
+	return &jwtSigningKeyRepository{db: db}
+}
+
+// Create stores a new signing key
+func (r *jwtSigningKeyRepository) Create(ctx context.Context, key *JWTSigningKey) error {
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		return fmt.Errorf("failed to create jwt signing key: %w", err)
+	}
+	return nil
+}
+
+// List returns all signing keys, oldest first
+func (r *jwtSigningKeyRepository) List(ctx context.Context) ([]*JWTSigningKey, error) {
+	var keys []*JWTSigningKey
+	if err := r.db.WithContext(ctx).
+		Order("activates_at ASC, id ASC").
+		Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list jwt signing keys: %w", err)
+	}
+	return keys, nil
+}
+
+// DeleteActivatedBefore deletes keys activated before the given time, except
+// the key with ID keep
+func (r *jwtSigningKeyRepository) DeleteActivatedBefore(ctx context.Context, before time.Time, keep uint) error {
+	if err := r.db.WithContext(ctx).
+		Where("activates_at < ? AND id <> ?", before, keep).
+		Delete(&JWTSigningKey{}).Error; err != nil {
+		return fmt.Errorf("failed to delete jwt signing keys: %w", err)
+	}
+	return nil
+}
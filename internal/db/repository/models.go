@@ -40,6 +40,43 @@ func (j *JSONB) Scan(value interface{}) error {
 	return nil
 }
 
+// StringArray is a custom type for a PostgreSQL JSONB array of strings
+type StringArray []string
+
+// Value implements the driver.Valuer interface
+func (a StringArray) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	return json.Marshal(a)
+}
+
+// Scan implements the sql.Scanner interface
+func (a *StringArray) Scan(value interface{}) error {
+	if value == nil {
+		*a = nil
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+
+	var result []string
+	if err := json.Unmarshal(bytes, &result); err != nil {
+		return err
+	}
+
+	*a = result
+	return nil
+}
+
 // User represents a user in the system
 type User struct {
 	ID                  uint           `json:"id" gorm:"primaryKey"`
@@ -88,6 +125,101 @@ type RefreshToken struct {
 	User      User           `json:"user" gorm:"foreignKey:UserID"`
 }
 
+// Session represents an authenticated device session bound to issued JWTs
+type Session struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null;index"`
+	Token        string    `json:"-" gorm:"uniqueIndex;not null"`
+	RefreshToken *string   `json:"-" gorm:"uniqueIndex"`
+	DeviceInfo   JSONB     `json:"device_info,omitempty" gorm:"type:jsonb"`
+	IPAddress    string    `json:"ip_address,omitempty"`
+	UserAgent    string    `json:"user_agent,omitempty"`
+	IsActive     bool      `json:"is_active" gorm:"default:true;index"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastUsedAt   time.Time `json:"last_used_at"`
+}
+
+// TwoFactor represents a user's TOTP two-factor authentication settings
+type TwoFactor struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"uniqueIndex;not null"`
+	Secret      string     `json:"-" gorm:"not null"`
+	BackupCodes JSONB      `json:"-" gorm:"type:jsonb"`
+	IsEnabled   bool       `json:"is_enabled" gorm:"default:false"`
+	EnabledAt   *time.Time `json:"enabled_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// OAuthProvider links a user to an identity at an external OAuth/OIDC provider
+type OAuthProvider struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	UserID         uint       `json:"user_id" gorm:"not null;index"`
+	Provider       string     `json:"provider" gorm:"not null;index"`
+	ProviderUserID string     `json:"provider_user_id" gorm:"not null"`
+	AccessToken    string     `json:"-"`
+	RefreshToken   string     `json:"-"`
+	TokenExpiresAt *time.Time `json:"token_expires_at,omitempty"`
+	ProfileData    JSONB      `json:"profile_data,omitempty" gorm:"type:jsonb"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// PasswordResetToken represents a single-use password reset token. Only the
+// token hash is stored.
+type PasswordResetToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	Token     string     `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	Used      bool       `json:"used" gorm:"default:false"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// EmailVerificationToken represents a single-use email verification token.
+// Only the token hash is stored.
+type EmailVerificationToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	Email     string     `json:"email" gorm:"not null"`
+	Token     string     `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	Used      bool       `json:"used" gorm:"default:false"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// APIKey represents an API key. Only the key hash is stored; the prefix
+// identifies the key in listings.
+type APIKey struct {
+	ID         uint        `json:"id" gorm:"primaryKey"`
+	UserID     *uint       `json:"user_id,omitempty" gorm:"index"`
+	Name       string      `json:"name" gorm:"not null"`
+	KeyHash    string      `json:"-" gorm:"uniqueIndex;not null"`
+	Prefix     string      `json:"prefix" gorm:"not null"`
+	Scopes     StringArray `json:"scopes" gorm:"type:jsonb"`
+	RateLimit  int         `json:"rate_limit" gorm:"default:1000"`
+	IsActive   bool        `json:"is_active" gorm:"default:true"`
+	LastUsedAt *time.Time  `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time  `json:"expires_at,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// JWTSigningKey represents a JWT signing key pair. The private key is stored
+// sealed, never in the clear.
+type JWTSigningKey struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	KID         string    `json:"kid" gorm:"column:kid;uniqueIndex;not null"`
+	Algorithm   string    `json:"algorithm" gorm:"not null"`
+	PrivateKey  string    `json:"-" gorm:"not null"`
+	ActivatesAt time.Time `json:"activates_at" gorm:"not null;index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
 // Role represents a user role in the system
 type Role struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
@@ -129,6 +261,41 @@ func (RefreshToken) TableName() string {
 	return "refresh_tokens"
 }
 
+// TableName returns the table name for Session
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// TableName returns the table name for TwoFactor
+func (TwoFactor) TableName() string {
+	return "user_2fa"
+}
+
+// TableName returns the table name for OAuthProvider
+func (OAuthProvider) TableName() string {
+	return "oauth_providers"
+}
+
+// TableName returns the table name for PasswordResetToken
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
+// TableName returns the table name for EmailVerificationToken
+func (EmailVerificationToken) TableName() string {
+	return "email_verification_tokens"
+}
+
+// TableName returns the table name for APIKey
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// TableName returns the table name for JWTSigningKey
+func (JWTSigningKey) TableName() string {
+	return "jwt_signing_keys"
+}
+
 // TableName returns the table name for Role
 func (Role) TableName() string {
 	return "roles"
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// oauthProviderRepository implements OAuthProviderRepository interface
+type oauthProviderRepository struct {
+	db *gorm.DB
+}
+
+// NewOAuthProviderRepository creates a new OAuth provider repository
+func NewOAuthProviderRepository(db *gorm.DB) OAuthProviderRepository {
+	return &oauthProviderRepository{db: db}
+}
+
+// Create links a provider identity to a user
+func (r *oauthProviderRepository) Create(ctx context.Context, link *OAuthProvider) error {
+	if err := r.db.WithContext(ctx).Create(link).Error; err != nil {
+		return fmt.Errorf("failed to create oauth provider link: %w", err)
+	}
+	return nil
+}
+
+// GetByProviderUserID retrieves the link for a provider identity
+func (r *oauthProviderRepository) GetByProviderUserID(ctx context.Context, provider, providerUserID string) (*OAuthProvider, error) {
+	var link OAuthProvider
+	if err := r.db.WithContext(ctx).
+		Where("provider = ? AND provider_user_id = ?", provider, providerUserID).
+		First(&link).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("oauth provider link not found")
+		}
+		return nil, fmt.Errorf("failed to get oauth provider link: %w", err)
+	}
+	return &link, nil
+}
+
+// GetByUserID retrieves all provider links of a user
+func (r *oauthProviderRepository) GetByUserID(ctx context.Context, userID uint) ([]*OAuthProvider, error) {
+	var links []*OAuthProvider
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("failed to get oauth provider links by user ID: %w", err)
+	}
+	return links, nil
+}
+
+// Update updates an existing provider link
+func (r *oauthProviderRepository) Update(ctx context.Context, link *OAuthProvider) error {
+	if err := r.db.WithContext(ctx).Save(link).Error; err != nil {
+		return fmt.Errorf("failed to update oauth provider link: %w", err)
+	}
+	return nil
+}
+
+// Delete unlinks a provider from a user
+func (r *oauthProviderRepository) Delete(ctx context.Context, userID uint, provider string) error {
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND provider = ?", userID, provider).
+		Delete(&OAuthProvider{}).Error; err != nil {
+		return fmt.Errorf("failed to delete oauth provider link: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// passwordResetTokenRepository implements PasswordResetTokenRepository interface
+type passwordResetTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetTokenRepository creates a new password reset token repository
+func NewPasswordResetTokenRepository(db *gorm.DB) PasswordResetTokenRepository {
+	return &passwordResetTokenRepository{db: db}
+}
+
+// Create creates a new password reset token
+func (r *passwordResetTokenRepository) Create(ctx context.Context, token *PasswordResetToken) error {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+	return nil
+}
+
+// GetByToken retrieves a password reset token by its hash
+func (r *passwordResetTokenRepository) GetByToken(ctx context.Context, tokenHash string) (*PasswordResetToken, error) {
+	var token PasswordResetToken
+	if err := r.db.WithContext(ctx).Where("token = ?", tokenHash).First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("password reset token not found")
+		}
+		return nil, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+	return &token, nil
+}
+
+// MarkUsed marks an unused password reset token as used. It reports false when the
+// token was already used, so concurrent requests cannot both consume it.
+func (r *passwordResetTokenRepository) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&PasswordResetToken{}).
+		Where("id = ? AND used = ?", id, false).
+		Updates(map[string]interface{}{"used": true, "used_at": time.Now()})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to mark password reset token as used: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// InvalidateAllForUser marks every outstanding password reset token of a user as used
+func (r *passwordResetTokenRepository) InvalidateAllForUser(ctx context.Context, userID uint) error {
+	if err := r.db.WithContext(ctx).
+		Model(&PasswordResetToken{}).
+		Where("user_id = ? AND used = ?", userID, false).
+		Updates(map[string]interface{}{"used": true, "used_at": time.Now()}).Error; err != nil {
+		return fmt.Errorf("failed to invalidate password reset tokens for user: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired deletes expired password reset tokens
+func (r *passwordResetTokenRepository) DeleteExpired(ctx context.Context) error {
+	if err := r.db.WithContext(ctx).
+		Where("expires_at < ?", time.Now()).
+		Delete(&PasswordResetToken{}).Error; err != nil {
+		return fmt.Errorf("failed to delete expired password reset tokens: %w", err)
+	}
+	return nil
+}
@@ -19,6 +19,8 @@ type RoleRepository interface {
 	GetUserRoles(ctx context.Context, userID uint) ([]Role, error)
 	AssignRoleToUser(ctx context.Context, userID, roleID uint, assignedBy *uint) error
 	RemoveRoleFromUser(ctx context.Context, userID, roleID uint) error
+	GetRoleUserIDs(ctx context.Context, roleID uint) ([]uint, error)
+	GetPermissionUserIDs(ctx context.Context, permissionID uint) ([]uint, error)
 }
 
 type roleRepository struct {
@@ -135,6 +137,30 @@ func (r *roleRepository) RemoveRoleFromUser(ctx context.Context, userID, roleID
 	).Error
 }
 
+func (r *roleRepository) GetRoleUserIDs(ctx context.Context, roleID uint) ([]uint, error) {
+	var userIDs []uint
+	if err := r.db.WithContext(ctx).
+		Table("user_roles").
+		Where("role_id = ?", roleID).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+func (r *roleRepository) GetPermissionUserIDs(ctx context.Context, permissionID uint) ([]uint, error) {
+	var userIDs []uint
+	if err := r.db.WithContext(ctx).
+		Table("user_roles").
+		Joins("JOIN role_permissions ON role_permissions.role_id = user_roles.role_id").
+		Where("role_permissions.permission_id = ?", permissionID).
+		Distinct().
+		Pluck("user_roles.user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
 // PermissionRepository defines permission-related database operations
 type PermissionRepository interface {
 	Create(ctx context.Context, permission *Permission) error
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sessionRepository implements SessionRepository interface
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// Create creates a new session
+func (r *sessionRepository) Create(ctx context.Context, session *Session) error {
+	if err := r.db.WithContext(ctx).Create(session).Error; err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a session by ID
+func (r *sessionRepository) GetByID(ctx context.Context, id uint) (*Session, error) {
+	var session Session
+	if err := r.db.WithContext(ctx).First(&session, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("session not found")
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return &session, nil
+}
+
+// GetActiveByUserID retrieves all active, unexpired sessions for a user
+func (r *sessionRepository) GetActiveByUserID(ctx context.Context, userID uint) ([]*Session, error) {
+	var sessions []*Session
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND is_active = ? AND expires_at > ?", userID, true, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sessions by user ID: %w", err)
+	}
+	return sessions, nil
+}
+
+// Update updates an existing session
+func (r *sessionRepository) Update(ctx context.Context, session *Session) error {
+	if err := r.db.WithContext(ctx).Save(session).Error; err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+// RotateRefreshToken replaces the refresh token hash of an active session
+// if it is still previousHash, and reports whether it was replaced. The
+// check and the write are one statement, so only one of two concurrent
+// refreshes with the same token can rotate it.
+func (r *sessionRepository) RotateRefreshToken(ctx context.Context, id uint, previousHash, hash string, expiresAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&Session{}).
+		Where("id = ? AND is_active = ? AND refresh_token = ?", id, true, previousHash).
+		Updates(map[string]interface{}{
+			"refresh_token": hash,
+			"expires_at":    expiresAt,
+			"last_used_at":  time.Now(),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to rotate refresh token: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Touch records that a session was used
+func (r *sessionRepository) Touch(ctx context.Context, id uint, usedAt time.Time) error {
+	if err := r.db.WithContext(ctx).
+		Model(&Session{}).
+		Where("id = ?", id).
+		Update("last_used_at", usedAt).Error; err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+	return nil
+}
+
+// Deactivate deactivates a specific session
+func (r *sessionRepository) Deactivate(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).
+		Model(&Session{}).
+		Where("id = ?", id).
+		Update("is_active", false).Error; err != nil {
+		return fmt.Errorf("failed to deactivate session: %w", err)
+	}
+	return nil
+}
+
+// DeactivateAllForUser deactivates all sessions for a user except exceptID.
+// Pass 0 to deactivate every session.
+func (r *sessionRepository) DeactivateAllForUser(ctx context.Context, userID uint, exceptID uint) error {
+	query := r.db.WithContext(ctx).
+		Model(&Session{}).
+		Where("user_id = ? AND is_active = ?", userID, true)
+	if exceptID != 0 {
+		query = query.Where("id <> ?", exceptID)
+	}
+
+	if err := query.Update("is_active", false).Error; err != nil {
+		return fmt.Errorf("failed to deactivate sessions for user: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired deletes expired sessions
+func (r *sessionRepository) DeleteExpired(ctx context.Context) error {
+	if err := r.db.WithContext(ctx).
+		Where("expires_at < ?", time.Now()).
+		Delete(&Session{}).Error; err != nil {
+		return fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	return nil
+}
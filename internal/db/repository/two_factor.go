@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// twoFactorRepository implements TwoFactorRepository interface
+type twoFactorRepository struct {
+	db *gorm.DB
+}
+
+// NewTwoFactorRepository creates a new two-factor repository
+func NewTwoFactorRepository(db *gorm.DB) TwoFactorRepository {
+	return &twoFactorRepository{db: db}
+}
+
+// Create creates a new two-factor record
+func (r *twoFactorRepository) Create(ctx context.Context, twoFactor *TwoFactor) error {
+	if err := r.db.WithContext(ctx).Create(twoFactor).Error; err != nil {
+		return fmt.Errorf("failed to create two-factor record: %w", err)
+	}
+	return nil
+}
+
+// GetByUserID retrieves the two-factor record of a user
+func (r *twoFactorRepository) GetByUserID(ctx context.Context, userID uint) (*TwoFactor, error) {
+	var twoFactor TwoFactor
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&twoFactor).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("two-factor record not found")
+		}
+		return nil, fmt.Errorf("failed to get two-factor record: %w", err)
+	}
+	return &twoFactor, nil
+}
+
+// IsEnabledForUser reports whether a user has confirmed two-factor authentication
+func (r *twoFactorRepository) IsEnabledForUser(ctx context.Context, userID uint) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Model(&TwoFactor{}).
+		Where("user_id = ? AND is_enabled = ?", userID, true).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check two-factor status: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Update updates an existing two-factor record
+func (r *twoFactorRepository) Update(ctx context.Context, twoFactor *TwoFactor) error {
+	if err := r.db.WithContext(ctx).Save(twoFactor).Error; err != nil {
+		return fmt.Errorf("failed to update two-factor record: %w", err)
+	}
+	return nil
+}
+
+// UseTOTPStep records the time step starting at stepStart as the last one
+// used by an enabled user, unless the same or a later step was used, and
+// reports whether it was recorded. Concurrent uses of one code cannot both
+// be recorded.
+func (r *twoFactorRepository) UseTOTPStep(ctx context.Context, userID uint, stepStart time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&TwoFactor{}).
+		Where("user_id = ? AND is_enabled = ? AND (last_used_at IS NULL OR last_used_at < ?)", userID, true, stepStart).
+		Update("last_used_at", stepStart)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record two-factor code use: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UseBackupCode removes the backup code with the given hash from an
+// enabled user's codes and reports whether it was there. The check and the
+// removal are one statement, so a code can only be used once.
+func (r *twoFactorRepository) UseBackupCode(ctx context.Context, userID uint, hash string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&TwoFactor{}).
+		Where("user_id = ? AND is_enabled = ? AND jsonb_exists(backup_codes->'hashes', ?)", userID, true, hash).
+		Update("backup_codes", gorm.Expr("jsonb_set(backup_codes, '{hashes}', (backup_codes->'hashes') - ?::text)", hash))
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to use backup code: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeleteByUserID deletes the two-factor record of a user
+func (r *twoFactorRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&TwoFactor{}).Error; err != nil {
+		return fmt.Errorf("failed to delete two-factor record: %w", err)
+	}
+	return nil
+}
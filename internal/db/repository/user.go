@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -57,6 +58,59 @@ func (r *userRepository) Update(ctx context.Context, user *User) error {
 	return nil
 }
 
+// IncrementFailedLogins atomically counts a failed login and returns the
+// new number of consecutive failures
+func (r *userRepository) IncrementFailedLogins(ctx context.Context, id uint) (int, error) {
+	var attempts int
+	err := r.db.WithContext(ctx).
+		Raw("UPDATE users SET failed_login_attempts = COALESCE(failed_login_attempts, 0) + 1 WHERE id = ? RETURNING failed_login_attempts", id).
+		Scan(&attempts).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment failed logins: %w", err)
+	}
+	return attempts, nil
+}
+
+// SetLockedUntil locks a user until the given time, or unlocks it when nil
+func (r *userRepository) SetLockedUntil(ctx context.Context, id uint, until *time.Time) error {
+	if err := r.db.WithContext(ctx).
+		Model(&User{}).
+		Where("id = ?", id).
+		UpdateColumn("locked_until", until).Error; err != nil {
+		return fmt.Errorf("failed to set user lock: %w", err)
+	}
+	return nil
+}
+
+// RecordLogin records a successful login and clears failed login state
+func (r *userRepository) RecordLogin(ctx context.Context, id uint, at time.Time) error {
+	if err := r.db.WithContext(ctx).
+		Model(&User{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"last_login_at":         at,
+			"failed_login_attempts": 0,
+			"locked_until":          nil,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to record login: %w", err)
+	}
+	return nil
+}
+
+// ResetLockout clears failed login state
+func (r *userRepository) ResetLockout(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).
+		Model(&User{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"failed_login_attempts": 0,
+			"locked_until":          nil,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to reset lockout: %w", err)
+	}
+	return nil
+}
+
 // Delete soft deletes a user
 func (r *userRepository) Delete(ctx context.Context, id uint) error {
 	if err := r.db.WithContext(ctx).Delete(&User{}, id).Error; err != nil {
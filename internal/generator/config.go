@@ -2,7 +2,11 @@ package generator
 
 import (
 	"fmt"
+	"os"
+	"strings"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // GeneratorConfig holds configuration for the API generator
@@ -17,6 +21,7 @@ type GeneratorConfig struct {
 	TypeScriptAPIClient bool                    `yaml:"typescript_api_client"`
 	Tables              map[string]*TableConfig `yaml:"tables"`
 	Global              *GlobalConfig           `yaml:"global"`
+	CursorSecret        string                  `yaml:"cursor_secret"` // Signs pagination cursors
 }
 
 // AutoRegistrationConfig holds configuration for auto-registration
@@ -38,6 +43,7 @@ type TableConfig struct {
 	Pagination    *PaginationConfig      `yaml:"pagination"`
 	Filtering     *FilteringConfig       `yaml:"filtering"`
 	Sorting       *SortingConfig         `yaml:"sorting"`
+	Fields        *FieldsConfig          `yaml:"fields"`
 	Custom        map[string]interface{} `yaml:"custom"`
 }
 
@@ -52,7 +58,8 @@ type GlobalConfig struct {
 	Documentation *DocumentationConfig `yaml:"documentation"`
 }
 
-// SecurityConfig holds security configuration
+// SecurityConfig holds security configuration. The security of a table
+// overrides the keys it sets of the global one.
 type SecurityConfig struct {
 	RBAC       *RBACConfig      `yaml:"rbac"`
 	RateLimit  *RateLimitConfig `yaml:"rate_limit"`
@@ -60,14 +67,104 @@ type SecurityConfig struct {
 	SoftDelete bool             `yaml:"soft_delete"`
 	Timestamps bool             `yaml:"timestamps"`
 	CSRF       bool             `yaml:"csrf"`
+
+	// keys are the keys set in the configuration file. Sections built in
+	// code set every key.
+	keys map[string]bool
+}
+
+// UnmarshalYAML decodes a security section and records the keys it sets
+func (sc *SecurityConfig) UnmarshalYAML(value *yaml.Node) error {
+	type plain SecurityConfig
+	if err := value.Decode((*plain)(sc)); err != nil {
+		return err
+	}
+
+	sc.keys = make(map[string]bool, len(value.Content)/2)
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		sc.keys[value.Content[i].Value] = true
+	}
+	return nil
+}
+
+// sets reports whether the section sets key
+func (sc *SecurityConfig) sets(key string) bool {
+	return sc.keys == nil || sc.keys[key]
+}
+
+// mergeSecurity returns global with the keys table sets replaced
+func mergeSecurity(global, table *SecurityConfig) *SecurityConfig {
+	if table == nil {
+		return global
+	}
+	if global == nil {
+		return table
+	}
+
+	merged := *global
+	merged.keys = nil
+	if table.sets("rbac") {
+		merged.RBAC = table.RBAC
+	}
+	if table.sets("rate_limit") {
+		merged.RateLimit = table.RateLimit
+	}
+	if table.sets("audit_log") {
+		merged.AuditLog = table.AuditLog
+	}
+	if table.sets("soft_delete") {
+		merged.SoftDelete = table.SoftDelete
+	}
+	if table.sets("timestamps") {
+		merged.Timestamps = table.Timestamps
+	}
+	if table.sets("csrf") {
+		merged.CSRF = table.CSRF
+	}
+	return &merged
 }
 
 // RBACConfig holds RBAC configuration
 type RBACConfig struct {
+	// Resource is the resource of actions without one. It defaults to the
+	// table name, so tables sharing the global section do not share
+	// permissions.
 	Resource    string              `yaml:"resource"`
 	Permissions map[string][]string `yaml:"permissions"`
 }
 
+// ResourceFor returns the resource of the actions on table
+func (rc *RBACConfig) ResourceFor(table string) string {
+	if rc.Resource != "" {
+		return rc.Resource
+	}
+	return table
+}
+
+// defaultActions are the RBAC actions of endpoint types without configured
+// permissions
+var defaultActions = map[string][]string{
+	"list":   {"read"},
+	"get":    {"read"},
+	"search": {"read"},
+	"stats":  {"read"},
+	"export": {"read"},
+	"create": {"write"},
+	"update": {"write"},
+	"bulk":   {"write"},
+	"delete": {"delete"},
+}
+
+// Actions returns the permissions required by an endpoint type. Endpoint
+// types without configured permissions require read, write or delete on
+// the resource, like the endpoints of the global configuration.
+func (rc *RBACConfig) Actions(endpointType string) []string {
+	if actions, ok := rc.Permissions[endpointType]; ok {
+		return actions
+	}
+	return defaultActions[endpointType]
+}
+
 // RateLimitConfig holds rate limiting configuration
 type RateLimitConfig struct {
 	Requests int           `yaml:"requests"`
@@ -75,6 +172,137 @@ type RateLimitConfig struct {
 	Burst    int           `yaml:"burst"`
 }
 
+// Allowance returns the requests allowed per window. Limits are counted
+// in fixed windows, so the burst adds to the requests of each window.
+func (rc *RateLimitConfig) Allowance() int {
+	return rc.Requests + rc.Burst
+}
+
+// FieldsConfig holds the visibility and write protection of the columns
+// of a table
+type FieldsConfig struct {
+	// Hidden columns are never returned, filtered, sorted or written
+	Hidden []string `yaml:"hidden"`
+	// ReadOnly columns are returned but never written by clients
+	ReadOnly []string `yaml:"read_only"`
+	// WriteOnce columns may be set when a record is created but not
+	// updated
+	WriteOnce []string `yaml:"write_once"`
+	// AdminOnly columns may only be written by admins
+	AdminOnly []string `yaml:"admin_only"`
+}
+
+// secretNames and secretSuffixes are the names of columns that hold
+// secrets, such as users.password or api_keys.key_hash
+var (
+	secretNames    = []string{"password", "secret", "token", "salt"}
+	secretSuffixes = []string{"_password", "_hash", "_secret", "_token", "_digest", "_salt"}
+)
+
+// isSecretColumn reports whether a column is named like a secret
+func isSecretColumn(name string) bool {
+	name = strings.ToLower(name)
+	if contains(secretNames, name) {
+		return true
+	}
+	for _, suffix := range secretSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldPolicy is the resolved visibility and write protection of the
+// columns of a table
+type FieldPolicy struct {
+	hidden    map[string]bool
+	readOnly  map[string]bool
+	writeOnce map[string]bool
+	adminOnly map[string]bool
+}
+
+// FieldPolicy returns the field policy of table. Columns its fields
+// configuration names have only the settings it gives them; the others
+// get defaults: columns named like secrets are hidden, ids, generated
+// primary keys and the created_at, updated_at and deleted_at timestamps
+// are read only, and is_admin may only be written by admins. Primary keys
+// without a default, such as those of join tables, are set by clients.
+func (gc *GeneratorConfig) FieldPolicy(table *TableInfo) *FieldPolicy {
+	policy := &FieldPolicy{
+		hidden:    make(map[string]bool),
+		readOnly:  make(map[string]bool),
+		writeOnce: make(map[string]bool),
+		adminOnly: make(map[string]bool),
+	}
+
+	configured := make(map[string]bool)
+	set := func(settings map[string]bool, columns []string) {
+		for _, column := range columns {
+			settings[column] = true
+			configured[column] = true
+		}
+	}
+	if fields := gc.GetTableConfig(table.Name).Fields; fields != nil {
+		set(policy.hidden, fields.Hidden)
+		set(policy.readOnly, fields.ReadOnly)
+		set(policy.writeOnce, fields.WriteOnce)
+		set(policy.adminOnly, fields.AdminOnly)
+	}
+
+	for _, column := range table.Columns {
+		if configured[column.Name] {
+			continue
+		}
+		switch {
+		case isSecretColumn(column.Name):
+			policy.hidden[column.Name] = true
+		case column.Name == "id" || column.IsPrimaryKey && column.DefaultValue != nil ||
+			column.Name == "created_at" || column.Name == "updated_at" || column.Name == "deleted_at":
+			policy.readOnly[column.Name] = true
+		case column.Name == "is_admin":
+			policy.adminOnly[column.Name] = true
+		}
+	}
+
+	return policy
+}
+
+// Readable reports whether a column may be returned, filtered and sorted
+func (fp *FieldPolicy) Readable(column string) bool {
+	return !fp.hidden[column]
+}
+
+// Creatable reports whether clients may set a column when creating a
+// record. Admin only columns also require an admin.
+func (fp *FieldPolicy) Creatable(column string) bool {
+	return !fp.hidden[column] && !fp.readOnly[column]
+}
+
+// Updatable reports whether clients may change a column of a record.
+// Admin only columns also require an admin.
+func (fp *FieldPolicy) Updatable(column string) bool {
+	return fp.Creatable(column) && !fp.writeOnce[column]
+}
+
+// AdminOnly reports whether only admins may write a column
+func (fp *FieldPolicy) AdminOnly(column string) bool {
+	return fp.adminOnly[column]
+}
+
+// visible returns a copy of table with only its readable columns, so
+// filters, sorting and cursors cannot reveal hidden ones
+func (fp *FieldPolicy) visible(table *TableInfo) *TableInfo {
+	visible := *table
+	visible.Columns = nil
+	for _, column := range table.Columns {
+		if fp.Readable(column.Name) {
+			visible.Columns = append(visible.Columns, column)
+		}
+	}
+	return &visible
+}
+
 // ValidationConfig holds validation configuration
 type ValidationConfig struct {
 	Strict      bool                `yaml:"strict"`
@@ -110,6 +338,7 @@ type PaginationConfig struct {
 	OrderParam   string `yaml:"order_param"`
 	EnableCursor bool   `yaml:"enable_cursor"`
 	CursorParam  string `yaml:"cursor_param"`
+	Count        string `yaml:"count"` // exact, estimate or none
 }
 
 // FilteringConfig holds filtering configuration
@@ -153,7 +382,11 @@ func DefaultGeneratorConfig() *GeneratorConfig {
 		AutoScan:    true,
 		OutputDir:   "./generated",
 		PackageName: "generated",
-		Tables:      make(map[string]*TableConfig),
+		AutoRegistration: &AutoRegistrationConfig{
+			Enabled:       false,
+			WatchInterval: 30 * time.Second,
+		},
+		Tables: make(map[string]*TableConfig),
 		Global: &GlobalConfig{
 			Security: &SecurityConfig{
 				AuditLog:   true,
@@ -182,21 +415,24 @@ func DefaultGeneratorConfig() *GeneratorConfig {
 				SortParam:    "sort",
 				OrderParam:   "order",
 				EnableCursor: false,
+				CursorParam:  "cursor",
+				Count:        CountExact,
 			},
 			Filtering: &FilteringConfig{
 				Operators: map[string]string{
-					"eq":    "=",
-					"ne":    "!=",
-					"gt":    ">",
-					"gte":   ">=",
-					"lt":    "<",
-					"lte":   "<=",
-					"like":  "LIKE",
-					"ilike": "ILIKE",
-					"in":    "IN",
-					"nin":   "NOT IN",
-					"null":  "IS NULL",
-					"nnull": "IS NOT NULL",
+					"eq":      "=",
+					"ne":      "!=",
+					"gt":      ">",
+					"gte":     ">=",
+					"lt":      "<",
+					"lte":     "<=",
+					"like":    "LIKE",
+					"ilike":   "ILIKE",
+					"in":      "IN",
+					"nin":     "NOT IN",
+					"null":    "IS NULL",
+					"nnull":   "IS NOT NULL",
+					"between": "BETWEEN",
 				},
 			},
 			Sorting: &SortingConfig{
@@ -214,6 +450,79 @@ func DefaultGeneratorConfig() *GeneratorConfig {
 	}
 }
 
+// LoadGeneratorConfig loads the generator configuration from a YAML file such
+// as config/generator.yaml. Missing sections fall back to the defaults.
+func LoadGeneratorConfig(path string) (*GeneratorConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read generator config: %w", err)
+	}
+
+	var file struct {
+		Generator *GeneratorConfig `yaml:"generator"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse generator config: %w", err)
+	}
+	if file.Generator == nil {
+		return nil, fmt.Errorf("generator section not found in %s", path)
+	}
+
+	gc := file.Generator
+	gc.applyDefaults(DefaultGeneratorConfig())
+
+	return gc, nil
+}
+
+// applyDefaults fills unset sections from the given defaults
+func (gc *GeneratorConfig) applyDefaults(defaults *GeneratorConfig) {
+	if gc.OutputDir == "" {
+		gc.OutputDir = defaults.OutputDir
+	}
+	if gc.PackageName == "" {
+		gc.PackageName = defaults.PackageName
+	}
+	if gc.AutoRegistration == nil {
+		gc.AutoRegistration = defaults.AutoRegistration
+	}
+	if gc.AutoRegistration.WatchInterval <= 0 {
+		gc.AutoRegistration.WatchInterval = defaults.AutoRegistration.WatchInterval
+	}
+
+	if gc.Global == nil {
+		gc.Global = defaults.Global
+	} else {
+		if gc.Global.Security == nil {
+			gc.Global.Security = defaults.Global.Security
+		}
+		if gc.Global.Validation == nil {
+			gc.Global.Validation = defaults.Global.Validation
+		}
+		if gc.Global.Caching == nil {
+			gc.Global.Caching = defaults.Global.Caching
+		}
+		if gc.Global.Pagination == nil {
+			gc.Global.Pagination = defaults.Global.Pagination
+		}
+		if gc.Global.Filtering == nil {
+			gc.Global.Filtering = defaults.Global.Filtering
+		}
+		if gc.Global.Sorting == nil {
+			gc.Global.Sorting = defaults.Global.Sorting
+		}
+		if gc.Global.Documentation == nil {
+			gc.Global.Documentation = defaults.Global.Documentation
+		}
+	}
+
+	if gc.Tables == nil {
+		gc.Tables = make(map[string]*TableConfig)
+	}
+	for name, tableConfig := range gc.Tables {
+		gc.Tables[name] = gc.MergeTableConfig(name, tableConfig)
+	}
+}
+
 // GetTableConfig returns configuration for a specific table
 func (gc *GeneratorConfig) GetTableConfig(tableName string) *TableConfig {
 	if config, exists := gc.Tables[tableName]; exists {
@@ -235,6 +544,32 @@ func (gc *GeneratorConfig) GetTableConfig(tableName string) *TableConfig {
 	}
 }
 
+// SoftDeletes reports whether deleting rows of table sets their deleted_at
+// column rather than removing them. Tables without the column are deleted
+// outright even in soft delete mode.
+func (gc *GeneratorConfig) SoftDeletes(table *TableInfo) bool {
+	security := gc.GetTableConfig(table.Name).Security
+	if security == nil || !security.SoftDelete {
+		return false
+	}
+	for _, column := range table.Columns {
+		if column.Name == "deleted_at" {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiredPermissions returns the resource and permissions that endpointType
+// of a table requires, and false when the table has no RBAC configured
+func (gc *GeneratorConfig) RequiredPermissions(tableName, endpointType string) (string, []string, bool) {
+	security := gc.GetTableConfig(tableName).Security
+	if security == nil || security.RBAC == nil {
+		return "", nil, false
+	}
+	return security.RBAC.ResourceFor(tableName), security.RBAC.Actions(endpointType), true
+}
+
 // ShouldGenerateTable checks if a table should be generated
 func (gc *GeneratorConfig) ShouldGenerateTable(tableName string) bool {
 	if !gc.Enabled {
@@ -263,21 +598,19 @@ func (gc *GeneratorConfig) MergeTableConfig(tableName string, tableConfig *Table
 		Enabled:       tableConfig.Enabled,
 		Endpoints:     tableConfig.Endpoints,
 		Relationships: tableConfig.Relationships,
+		Fields:        tableConfig.Fields,
 		Custom:        tableConfig.Custom,
 	}
 
-	// Merge security config
-	if tableConfig.Security != nil {
-		merged.Security = tableConfig.Security
-	} else {
-		merged.Security = gc.Global.Security
-	}
+	// Merge security config key by key, so a table setting its own rbac
+	// keeps the global soft deletes and timestamps
+	merged.Security = mergeSecurity(gc.Global.Security, tableConfig.Security)
 
 	// Merge validation config
 	if tableConfig.Validation != nil {
 		merged.Validation = tableConfig.Validation
 	} else {
-		merged.Security = gc.Global.Security
+		merged.Validation = gc.Global.Validation
 	}
 
 	// Merge caching config
@@ -329,5 +662,11 @@ func (gc *GeneratorConfig) ValidateConfig() error {
 		return fmt.Errorf("default limit cannot be greater than max limit")
 	}
 
+	switch gc.Global.Pagination.Count {
+	case "", CountExact, CountEstimate, CountNone:
+	default:
+		return fmt.Errorf("unknown count mode: %s", gc.Global.Pagination.Count)
+	}
+
 	return nil
 }
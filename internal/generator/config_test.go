@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const securityTestConfig = `
+generator:
+  global:
+    security:
+      audit_log: true
+      soft_delete: true
+      timestamps: true
+      rbac:
+        permissions:
+          delete: ["delete"]
+  tables:
+    accounts:
+      enabled: true
+      security:
+        rbac:
+          resource: "users"
+          permissions:
+            delete: ["admin"]
+    drafts:
+      enabled: true
+      security:
+        soft_delete: false
+`
+
+func TestTableSecurityOverridesGlobalKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "generator.yaml")
+	if err := os.WriteFile(path, []byte(securityTestConfig), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	config, err := LoadGeneratorConfig(path)
+	if err != nil {
+		t.Fatalf("LoadGeneratorConfig() error = %v", err)
+	}
+
+	tests := []struct {
+		table          string
+		wantSoftDelete bool
+		wantResource   string
+		wantDelete     string
+	}{
+		{table: "accounts", wantSoftDelete: true, wantResource: "users", wantDelete: "admin"},
+		{table: "drafts", wantResource: "drafts", wantDelete: "delete"},
+		{table: "unconfigured", wantSoftDelete: true, wantResource: "unconfigured", wantDelete: "delete"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.table, func(t *testing.T) {
+			table := testTable(tt.table, "id bigint pk", "deleted_at timestamp null")
+
+			security := config.GetTableConfig(tt.table).Security
+			if !security.AuditLog || !security.Timestamps {
+				t.Errorf("security = %+v, want the global audit_log and timestamps", security)
+			}
+			if got := config.SoftDeletes(table); got != tt.wantSoftDelete {
+				t.Errorf("SoftDeletes() = %v, want %v", got, tt.wantSoftDelete)
+			}
+			resource, permissions, ok := config.RequiredPermissions(tt.table, "delete")
+			if !ok || resource != tt.wantResource || strings.Join(permissions, ",") != tt.wantDelete {
+				t.Errorf("RequiredPermissions() = %s, %v, %v, want %s, [%s]", resource, permissions, ok, tt.wantResource, tt.wantDelete)
+			}
+
+			// The generated repository marks rows deleted
+			dir := t.TempDir()
+			if err := NewFileGenerator(nil, zap.NewNop(), config).generateRepository(tt.table, table, dir); err != nil {
+				t.Fatalf("generateRepository() error = %v", err)
+			}
+			data, err := os.ReadFile(filepath.Join(dir, tt.table+"_repository.go"))
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if got := strings.Contains(string(data), `Update("deleted_at", time.Now())`); got != tt.wantSoftDelete {
+				t.Errorf("generated repository soft deletes = %v, want %v", got, tt.wantSoftDelete)
+			}
+		})
+	}
+}
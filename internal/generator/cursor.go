@@ -0,0 +1,418 @@
+package generator
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Count modes of list endpoints
+const (
+	// CountExact counts the matching rows on every request
+	CountExact = "exact"
+	// CountEstimate reads the planner's row estimate of unfiltered lists
+	// from pg_class.reltuples and leaves filtered lists without a total
+	CountEstimate = "estimate"
+	// CountNone never counts
+	CountNone = "none"
+)
+
+var (
+	// ErrInvalidCursor is returned for cursors that were not issued by
+	// this server or belong to another sort order
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrNoPrimaryKey is returned for tables that cannot be paginated with
+	// cursors because their rows have no unique order
+	ErrNoPrimaryKey = errors.New("table has no primary key")
+)
+
+// SortField is a column a list is ordered by
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// SQL returns the ORDER BY term of the field
+func (f SortField) SQL() string {
+	if f.Desc {
+		return quoteColumn(f.Column) + " DESC"
+	}
+	return quoteColumn(f.Column) + " ASC"
+}
+
+// ParseSort reads a sort specification such as "created_at:desc,name".
+// Fields without a direction are ascending.
+func ParseSort(spec string) []SortField {
+	var fields []SortField
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		column, direction, _ := strings.Cut(part, ":")
+		fields = append(fields, SortField{
+			Column: strings.TrimSpace(column),
+			Desc:   strings.EqualFold(strings.TrimSpace(direction), "desc"),
+		})
+	}
+	return fields
+}
+
+// CursorCodec signs and verifies pagination cursors so clients cannot
+// forge positions in a list
+type CursorCodec struct {
+	key []byte
+}
+
+// NewCursorCodec creates a codec whose key is derived from secret. Without
+// a secret a random key is used, and cursors do not survive a restart.
+func NewCursorCodec(secret string) *CursorCodec {
+	if secret == "" {
+		key := make([]byte, sha256.Size)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("failed to generate cursor key: %v", err))
+		}
+		return &CursorCodec{key: key}
+	}
+
+	sum := sha256.Sum256([]byte("pagination-cursor:" + secret))
+	return &CursorCodec{key: sum[:]}
+}
+
+// cursor is a position in a list: the keyset values of a row and the
+// direction of the page it starts
+type cursor struct {
+	Sort     string    `json:"s"`
+	Values   []*string `json:"v"`
+	Backward bool      `json:"b,omitempty"`
+}
+
+// encode returns the opaque form of a cursor
+func (cc *CursorCodec) encode(cur cursor) string {
+	payload, _ := json.Marshal(cur)
+	mac := hmac.New(sha256.New, cc.key)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// decode verifies and reads a cursor returned by encode
+func (cc *CursorCodec) decode(token string) (*cursor, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	mac := hmac.New(sha256.New, cc.key)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var cur cursor
+	if err := json.Unmarshal(payload, &cur); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cur, nil
+}
+
+// keysetColumn is a column of a keyset order
+type keysetColumn struct {
+	name     string
+	kind     columnKind
+	nullable bool
+	desc     bool
+}
+
+// Keyset orders a table by its sort columns followed by its primary key,
+// so every row has a unique position that a cursor can point to
+type Keyset struct {
+	columns []keysetColumn
+}
+
+// NewKeyset creates the keyset of table for the given sort. Sort fields
+// that are not columns of the table are ignored.
+func NewKeyset(table *TableInfo, sort []SortField) (*Keyset, error) {
+	columns := make(map[string]ColumnInfo, len(table.Columns))
+	for _, column := range table.Columns {
+		columns[column.Name] = column
+	}
+
+	keyset := &Keyset{}
+	seen := make(map[string]bool)
+	add := func(column ColumnInfo, desc bool) {
+		if seen[column.Name] {
+			return
+		}
+		seen[column.Name] = true
+		keyset.columns = append(keyset.columns, keysetColumn{
+			name:     column.Name,
+			kind:     columnKindOf(column.Type),
+			nullable: column.IsNullable,
+			desc:     desc,
+		})
+	}
+
+	for _, field := range sort {
+		if column, ok := columns[field.Column]; ok {
+			add(column, field.Desc)
+		}
+	}
+
+	hasPrimaryKey := false
+	for _, column := range table.Columns {
+		if column.IsPrimaryKey {
+			hasPrimaryKey = true
+			add(column, false)
+		}
+	}
+	if !hasPrimaryKey {
+		return nil, ErrNoPrimaryKey
+	}
+
+	return keyset, nil
+}
+
+// Order returns the ORDER BY clause of the keyset, reversed for backward
+// pages. PostgreSQL puts NULLs last in ascending and first in descending
+// order, so reversing the directions also reverses where NULLs go.
+func (k *Keyset) Order(backward bool) string {
+	parts := make([]string, len(k.columns))
+	for i, column := range k.columns {
+		direction := "ASC"
+		if column.desc != backward {
+			direction = "DESC"
+		}
+		parts[i] = quoteColumn(column.name) + " " + direction
+	}
+	return strings.Join(parts, ", ")
+}
+
+// signature identifies the order a cursor was issued for
+func (k *Keyset) signature() string {
+	parts := make([]string, len(k.columns))
+	for i, column := range k.columns {
+		direction := "asc"
+		if column.desc {
+			direction = "desc"
+		}
+		parts[i] = column.name + ":" + direction
+	}
+	return strings.Join(parts, ",")
+}
+
+// where returns the condition selecting the rows after the position of
+// values in the given direction. It expands the row comparison so each
+// column may have its own direction and hold NULLs:
+//
+//	a > ? OR (a = ? AND b > ?) OR (a = ? AND b = ? AND id > ?)
+func (k *Keyset) where(values []*string, backward bool) (string, []interface{}, error) {
+	typed := make([]interface{}, len(values))
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		v, err := parseFilterValue(k.columns[i].kind, *value)
+		if err != nil {
+			return "", nil, ErrInvalidCursor
+		}
+		typed[i] = v
+	}
+
+	var clauses []string
+	var args []interface{}
+	for i, column := range k.columns {
+		after, afterArgs := column.after(values[i] == nil, typed[i], column.desc != backward)
+		if after == "" {
+			continue
+		}
+
+		conditions := make([]string, 0, i+1)
+		var clauseArgs []interface{}
+		for j := 0; j < i; j++ {
+			name := quoteColumn(k.columns[j].name)
+			if values[j] == nil {
+				conditions = append(conditions, name+" IS NULL")
+			} else {
+				conditions = append(conditions, name+" = ?")
+				clauseArgs = append(clauseArgs, typed[j])
+			}
+		}
+		conditions = append(conditions, after)
+		clauseArgs = append(clauseArgs, afterArgs...)
+
+		clauses = append(clauses, "("+strings.Join(conditions, " AND ")+")")
+		args = append(args, clauseArgs...)
+	}
+
+	if len(clauses) == 0 {
+		return "1 = 0", nil, nil
+	}
+	return strings.Join(clauses, " OR "), args, nil
+}
+
+// after returns the condition selecting the values of the column that
+// sort after value, or "" when none do
+func (c keysetColumn) after(isNull bool, value interface{}, desc bool) (string, []interface{}) {
+	name := quoteColumn(c.name)
+	switch {
+	case !desc && isNull:
+		// NULLs sort last
+		return "", nil
+	case !desc && c.nullable:
+		return "(" + name + " > ? OR " + name + " IS NULL)", []interface{}{value}
+	case !desc:
+		return name + " > ?", []interface{}{value}
+	case isNull:
+		// NULLs sort first
+		return name + " IS NOT NULL", nil
+	default:
+		return name + " < ?", []interface{}{value}
+	}
+}
+
+// values returns the keyset values of a row
+func (k *Keyset) values(row map[string]interface{}) []*string {
+	values := make([]*string, len(k.columns))
+	for i, column := range k.columns {
+		values[i] = cursorValue(row[column.name])
+	}
+	return values
+}
+
+// cursorValue returns the text form of a column value, nil for NULL
+func cursorValue(value interface{}) *string {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		text = v.Format(time.RFC3339Nano)
+	case []byte:
+		text = string(v)
+	case [16]byte:
+		text = uuid.UUID(v).String()
+	default:
+		text = fmt.Sprint(v)
+	}
+	return &text
+}
+
+// KeysetPage is one page of a list paginated with cursors
+type KeysetPage struct {
+	Rows []map[string]interface{}
+	// Next and Prev are the cursors of the following and preceding
+	// pages, empty when there are none
+	Next    string
+	Prev    string
+	HasNext bool
+	HasPrev bool
+}
+
+// Page fetches up to limit rows of query in keyset order, starting at the
+// position of token or at the start of the list when token is empty.
+// Tokens that were not issued for this keyset return ErrInvalidCursor.
+func (k *Keyset) Page(query *gorm.DB, codec *CursorCodec, token string, limit int) (*KeysetPage, error) {
+	var cur *cursor
+	if token != "" {
+		decoded, err := codec.decode(token)
+		if err != nil {
+			return nil, err
+		}
+		if decoded.Sort != k.signature() || len(decoded.Values) != len(k.columns) {
+			return nil, ErrInvalidCursor
+		}
+		cur = decoded
+	}
+
+	backward := cur != nil && cur.Backward
+	if cur != nil {
+		condition, args, err := k.where(cur.Values, backward)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(condition, args...)
+	}
+
+	// One more row than the page tells whether another page follows
+	var rows []map[string]interface{}
+	if err := query.Order(k.Order(backward)).Limit(limit + 1).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	more := len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+
+	page := &KeysetPage{Rows: rows}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+		page.HasPrev = more
+		page.HasNext = true
+	} else {
+		page.HasNext = more
+		page.HasPrev = cur != nil
+	}
+
+	if len(rows) > 0 {
+		if page.HasNext {
+			page.Next = codec.encode(cursor{Sort: k.signature(), Values: k.values(rows[len(rows)-1])})
+		}
+		if page.HasPrev {
+			page.Prev = codec.encode(cursor{Sort: k.signature(), Values: k.values(rows[0]), Backward: true})
+		}
+	}
+
+	return page, nil
+}
+
+// CountRows returns the total of a list for the given count mode, or nil
+// when it is not counted. estimated reports whether the total is the
+// planner's estimate; tables that were never analyzed are counted exactly.
+func CountRows(query *gorm.DB, table string, mode string, filtered bool) (total *int64, estimated bool, err error) {
+	switch mode {
+	case CountNone:
+		return nil, false, nil
+	case CountEstimate:
+		if filtered {
+			return nil, false, nil
+		}
+		var estimate int64
+		err := query.Session(&gorm.Session{NewDB: true}).
+			Raw("SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass(?)", quoteColumn(table)).
+			Scan(&estimate).Error
+		if err != nil {
+			return nil, false, err
+		}
+		if estimate >= 0 {
+			return &estimate, true, nil
+		}
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, false, err
+	}
+	return &count, false, nil
+}
@@ -0,0 +1,381 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// memoryTable answers the keyset queries of Page from rows held in memory.
+// It understands the conditions where builds and PostgreSQL's NULL order.
+type memoryTable struct {
+	rows []map[string]interface{}
+}
+
+// open returns a query on the table. No database is connected; the query
+// callback reads the rows instead.
+func (m *memoryTable) open(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	if err := db.Callback().Query().Replace("gorm:query", m.query); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+	return db.Table("items")
+}
+
+func (m *memoryTable) query(db *gorm.DB) {
+	rows := make([]map[string]interface{}, 0, len(m.rows))
+	for _, row := range m.rows {
+		matches := true
+		if where, ok := db.Statement.Clauses["WHERE"].Expression.(clause.Where); ok {
+			for _, expr := range where.Exprs {
+				e := expr.(clause.Expr)
+				cond := &sqlCondition{tokens: tokenize(e.SQL), vars: e.Vars, row: row}
+				if !cond.or() {
+					matches = false
+				}
+			}
+		}
+		if matches {
+			rows = append(rows, row)
+		}
+	}
+
+	if orderBy, ok := db.Statement.Clauses["ORDER BY"].Expression.(clause.OrderBy); ok {
+		var terms []string
+		for _, column := range orderBy.Columns {
+			terms = append(terms, strings.Split(column.Column.Name, ", ")...)
+		}
+		sort.SliceStable(rows, func(i, j int) bool {
+			for _, term := range terms {
+				name, direction, _ := strings.Cut(term, " ")
+				name = strings.Trim(name, `"`)
+				if c := compareNullsLast(rows[i][name], rows[j][name]); c != 0 {
+					return (c < 0) == (direction == "ASC")
+				}
+			}
+			return false
+		})
+	}
+
+	if limit, ok := db.Statement.Clauses["LIMIT"].Expression.(clause.Limit); ok && limit.Limit != nil && len(rows) > *limit.Limit {
+		rows = rows[:*limit.Limit]
+	}
+
+	*db.Statement.Dest.(*[]map[string]interface{}) = rows
+}
+
+// compareNullsLast orders values as an ascending PostgreSQL order does
+func compareNullsLast(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	switch a := a.(type) {
+	case int64:
+		b := b.(int64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	default:
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+}
+
+// tokenize splits a condition into parentheses, quoted columns, ? and
+// words
+func tokenize(sql string) []string {
+	sql = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(sql)
+	return strings.Fields(sql)
+}
+
+// sqlCondition evaluates a condition on one row. Comparisons with NULL are
+// false, which matches SQL as the conditions have no NOT.
+type sqlCondition struct {
+	tokens []string
+	vars   []interface{}
+	row    map[string]interface{}
+}
+
+func (c *sqlCondition) next() string {
+	token := c.tokens[0]
+	c.tokens = c.tokens[1:]
+	return token
+}
+
+func (c *sqlCondition) peek(token string) bool {
+	return len(c.tokens) > 0 && c.tokens[0] == token
+}
+
+func (c *sqlCondition) or() bool {
+	result := c.and()
+	for c.peek("OR") {
+		c.next()
+		// Evaluated first so the variables are consumed in order
+		right := c.and()
+		result = result || right
+	}
+	return result
+}
+
+func (c *sqlCondition) and() bool {
+	result := c.comparison()
+	for c.peek("AND") {
+		c.next()
+		right := c.comparison()
+		result = result && right
+	}
+	return result
+}
+
+func (c *sqlCondition) comparison() bool {
+	left := c.next()
+	if left == "(" {
+		result := c.or()
+		c.next()
+		return result
+	}
+	if left == "1" {
+		c.next()
+		return c.next() == "1"
+	}
+
+	value := c.row[strings.Trim(left, `"`)]
+	switch op := c.next(); op {
+	case "IS":
+		if c.peek("NOT") {
+			c.next()
+			c.next()
+			return value != nil
+		}
+		c.next()
+		return value == nil
+	default:
+		c.next()
+		arg := c.vars[0]
+		c.vars = c.vars[1:]
+		if value == nil || arg == nil {
+			return false
+		}
+		cmp := compareNullsLast(value, arg)
+		switch op {
+		case "=":
+			return cmp == 0
+		case ">":
+			return cmp > 0
+		default:
+			return cmp < 0
+		}
+	}
+}
+
+// keysetTestTable has nullable sort columns, which sort last
+var keysetTestTable = testTable("items", "id integer pk", "score integer null", "name text null")
+
+func keysetTestRows() []map[string]interface{} {
+	scores := []interface{}{int64(3), nil, int64(1), int64(3), nil, int64(2), int64(1), nil, int64(5)}
+	names := []interface{}{"b", "a", nil, "c", nil, "a", "b", "c", nil}
+	rows := make([]map[string]interface{}, len(scores))
+	for i := range scores {
+		rows[i] = map[string]interface{}{"id": int64(i + 1), "score": scores[i], "name": names[i]}
+	}
+	return rows
+}
+
+// sortedIDs returns the ids of rows in the order of sort followed by id
+func sortedIDs(rows []map[string]interface{}, fields []SortField) []int64 {
+	sorted := append([]map[string]interface{}(nil), rows...)
+	fields = append(fields, SortField{Column: "id"})
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for _, field := range fields {
+			if c := compareNullsLast(sorted[i][field.Column], sorted[j][field.Column]); c != 0 {
+				return (c < 0) != field.Desc
+			}
+		}
+		return false
+	})
+	return pageIDs(sorted)
+}
+
+func pageIDs(rows []map[string]interface{}) []int64 {
+	ids := make([]int64, len(rows))
+	for i, row := range rows {
+		ids[i] = row["id"].(int64)
+	}
+	return ids
+}
+
+func TestKeysetPage(t *testing.T) {
+	table := &memoryTable{rows: keysetTestRows()}
+	codec := NewCursorCodec("secret")
+
+	for _, spec := range []string{"", "score", "score:desc", "name:desc,score", "score:desc,name"} {
+		for _, limit := range []int{1, 2, 4, 20} {
+			t.Run(fmt.Sprintf("%s by %d", spec, limit), func(t *testing.T) {
+				fields := ParseSort(spec)
+				keyset, err := NewKeyset(keysetTestTable, fields)
+				if err != nil {
+					t.Fatalf("NewKeyset() error = %v", err)
+				}
+				want := sortedIDs(table.rows, fields)
+
+				// Walk forward to the last page
+				var pages []*KeysetPage
+				var forward []int64
+				token := ""
+				for {
+					page, err := keyset.Page(table.open(t), codec, token, limit)
+					if err != nil {
+						t.Fatalf("Page() error = %v", err)
+					}
+					if page.HasPrev != (len(pages) > 0) {
+						t.Errorf("page %d HasPrev = %v", len(pages), page.HasPrev)
+					}
+					pages = append(pages, page)
+					forward = append(forward, pageIDs(page.Rows)...)
+					if !page.HasNext {
+						break
+					}
+					if len(pages) > len(want) {
+						t.Fatal("Page() does not reach the end of the list")
+					}
+					token = page.Next
+				}
+				if !reflect.DeepEqual(forward, want) {
+					t.Fatalf("forward pages = %v, want %v", forward, want)
+				}
+
+				// Walk back from the last page, which must return the same
+				// pages
+				last := pages[len(pages)-1]
+				for i := len(pages) - 2; i >= 0; i-- {
+					if last.Prev == "" {
+						t.Fatalf("page %d has no previous cursor", i+1)
+					}
+					page, err := keyset.Page(table.open(t), codec, last.Prev, limit)
+					if err != nil {
+						t.Fatalf("Page() backward error = %v", err)
+					}
+					if got, want := pageIDs(page.Rows), pageIDs(pages[i].Rows); !reflect.DeepEqual(got, want) {
+						t.Fatalf("backward page %d = %v, want %v", i, got, want)
+					}
+					if !page.HasNext || page.HasPrev != (i > 0) {
+						t.Errorf("backward page %d HasNext = %v, HasPrev = %v", i, page.HasNext, page.HasPrev)
+					}
+					last = page
+				}
+			})
+		}
+	}
+}
+
+func TestKeysetPageRejectsForeignCursors(t *testing.T) {
+	table := &memoryTable{rows: keysetTestRows()}
+	codec := NewCursorCodec("secret")
+
+	byScore, err := NewKeyset(keysetTestTable, ParseSort("score"))
+	if err != nil {
+		t.Fatalf("NewKeyset() error = %v", err)
+	}
+	byName, err := NewKeyset(keysetTestTable, ParseSort("name"))
+	if err != nil {
+		t.Fatalf("NewKeyset() error = %v", err)
+	}
+	page, err := byScore.Page(table.open(t), codec, "", 2)
+	if err != nil {
+		t.Fatalf("Page() error = %v", err)
+	}
+
+	text := "not a number"
+	tests := []struct {
+		name   string
+		keyset *Keyset
+		token  string
+	}{
+		{name: "other sort order", keyset: byName, token: page.Next},
+		{name: "other key", keyset: byScore, token: NewCursorCodec("other").encode(cursor{Sort: byScore.signature(), Values: make([]*string, 2)})},
+		{name: "tampered", keyset: byScore, token: "x" + page.Next},
+		{name: "malformed", keyset: byScore, token: "cursor"},
+		{name: "value of the wrong type", keyset: byScore, token: codec.encode(cursor{Sort: byScore.signature(), Values: []*string{&text, &text}})},
+		{name: "wrong number of values", keyset: byScore, token: codec.encode(cursor{Sort: byScore.signature(), Values: []*string{nil}})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.keyset.Page(table.open(t), codec, tt.token, 2); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("Page() error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestKeysetWhere(t *testing.T) {
+	keyset, err := NewKeyset(keysetTestTable, ParseSort("score:desc,name"))
+	if err != nil {
+		t.Fatalf("NewKeyset() error = %v", err)
+	}
+	three, b, id := "3", "b", "4"
+
+	tests := []struct {
+		name     string
+		values   []*string
+		backward bool
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "values",
+			values:   []*string{&three, &b, &id},
+			wantSQL:  `("score" < ?) OR ("score" = ? AND ("name" > ? OR "name" IS NULL)) OR ("score" = ? AND "name" = ? AND "id" > ?)`,
+			wantArgs: []interface{}{int64(3), int64(3), "b", int64(3), "b", int64(4)},
+		},
+		{
+			name:     "NULLs",
+			values:   []*string{nil, nil, &id},
+			wantSQL:  `("score" IS NOT NULL) OR ("score" IS NULL AND "name" IS NULL AND "id" > ?)`,
+			wantArgs: []interface{}{int64(4)},
+		},
+		{
+			name:     "NULLs backward",
+			values:   []*string{nil, nil, &id},
+			backward: true,
+			wantSQL:  `("score" IS NULL AND "name" IS NOT NULL) OR ("score" IS NULL AND "name" IS NULL AND "id" < ?)`,
+			wantArgs: []interface{}{int64(4)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := keyset.where(tt.values, tt.backward)
+			if err != nil {
+				t.Fatalf("where() error = %v", err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("where() = %s, want %s", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("where() args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package generator
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// fieldTestColumns include each column the default policy recognises
+var fieldTestColumns = []string{
+	"id bigint pk default",
+	"email text",
+	"password text",
+	"api_key_hash text",
+	"refresh_token text",
+	"is_admin boolean",
+	"plan text",
+	"created_at timestamp default",
+	"updated_at timestamp default",
+	"deleted_at timestamp",
+}
+
+// fieldTestConfig configures the fields of the configured table
+var fieldTestConfig = &GeneratorConfig{
+	Global: &GlobalConfig{},
+	Tables: map[string]*TableConfig{
+		"configured": {
+			Fields: &FieldsConfig{
+				Hidden:    []string{"plan"},
+				ReadOnly:  []string{"is_admin"},
+				WriteOnce: []string{"email"},
+				// Configured, so password is no longer hidden by default
+				AdminOnly: []string{"password"},
+			},
+		},
+	},
+}
+
+// fieldSettings lists the columns of table with each setting of policy
+func fieldSettings(policy *FieldPolicy, table *TableInfo) map[string][]string {
+	settings := map[string][]string{}
+	for _, column := range table.Columns {
+		name := column.Name
+		if !policy.Readable(name) {
+			settings["hidden"] = append(settings["hidden"], name)
+		}
+		if policy.Readable(name) && !policy.Creatable(name) {
+			settings["read_only"] = append(settings["read_only"], name)
+		}
+		if policy.Creatable(name) && !policy.Updatable(name) {
+			settings["write_once"] = append(settings["write_once"], name)
+		}
+		if policy.AdminOnly(name) {
+			settings["admin_only"] = append(settings["admin_only"], name)
+		}
+	}
+	return settings
+}
+
+func TestFieldPolicy(t *testing.T) {
+	tests := []struct {
+		name  string
+		table *TableInfo
+		want  map[string][]string
+	}{
+		{
+			name:  "defaults",
+			table: testTable("accounts", fieldTestColumns...),
+			want: map[string][]string{
+				"hidden":     {"password", "api_key_hash", "refresh_token"},
+				"read_only":  {"id", "created_at", "updated_at", "deleted_at"},
+				"admin_only": {"is_admin"},
+			},
+		},
+		{
+			name:  "configured",
+			table: testTable("configured", fieldTestColumns...),
+			want: map[string][]string{
+				"hidden":     {"api_key_hash", "refresh_token", "plan"},
+				"read_only":  {"id", "is_admin", "created_at", "updated_at", "deleted_at"},
+				"write_once": {"email"},
+				"admin_only": {"password"},
+			},
+		},
+		{
+			name:  "primary keys without a default are set by clients",
+			table: testTable("user_roles", "user_id bigint pk", "role_id bigint pk"),
+			want:  map[string][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fieldSettings(fieldTestConfig.FieldPolicy(tt.table), tt.table)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FieldPolicy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldPolicyVisible(t *testing.T) {
+	table := testTable("configured", fieldTestColumns...)
+	visible := fieldTestConfig.FieldPolicy(table).visible(table)
+
+	var columns []string
+	for _, column := range visible.Columns {
+		columns = append(columns, column.Name)
+	}
+	want := []string{"id", "email", "password", "is_admin", "created_at", "updated_at", "deleted_at"}
+	if !reflect.DeepEqual(columns, want) {
+		t.Errorf("visible() columns = %v, want %v", columns, want)
+	}
+	if len(table.Columns) != 10 {
+		t.Errorf("visible() changed the columns of the table")
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	g := NewCRUDHandlerGenerator(nil, zap.NewNop(), fieldTestConfig)
+	table := testTable("configured", fieldTestColumns...)
+
+	data := map[string]interface{}{
+		"id":            1,
+		"email":         "user@example.com",
+		"password":      "secret",
+		"api_key_hash":  "hash",
+		"plan":          "pro",
+		"unknown":       true,
+		"created_at":    "2024-05-01",
+		"refresh_token": "token",
+	}
+
+	tests := []struct {
+		name   string
+		create bool
+		admin  bool
+		want   []string
+	}{
+		{name: "create", create: true, want: []string{"api_key_hash", "created_at", "id", "password", "plan", "refresh_token", "unknown"}},
+		{name: "create as admin", create: true, admin: true, want: []string{"api_key_hash", "created_at", "id", "plan", "refresh_token", "unknown"}},
+		{name: "update", want: []string{"api_key_hash", "created_at", "email", "id", "password", "plan", "refresh_token", "unknown"}},
+		{name: "update as admin", admin: true, want: []string{"api_key_hash", "created_at", "email", "id", "plan", "refresh_token", "unknown"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Set("is_admin", tt.admin)
+
+			errs := g.writeErrors(c, data, table, tt.create)
+			fields := make([]string, 0, len(errs))
+			for field := range errs {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			if !reflect.DeepEqual(fields, tt.want) {
+				t.Errorf("writeErrors() fields = %v, want %v", fields, tt.want)
+			}
+		})
+	}
+
+	// Hidden columns are unknown rather than revealed as read only
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if msg := g.writeErrors(c, data, table, true)["plan"]; msg != "Unknown field" {
+		t.Errorf("writeErrors() of a hidden field = %q, want Unknown field", msg)
+	}
+}
+
+func TestConceal(t *testing.T) {
+	g := NewCRUDHandlerGenerator(nil, zap.NewNop(), fieldTestConfig)
+	rows := []map[string]interface{}{
+		{"id": 1, "email": "a@example.com", "password": "x", "refresh_token": "y", "plan": "pro"},
+		{"id": 2, "api_key_hash": "z"},
+	}
+
+	g.conceal(rows, testTable("configured", fieldTestColumns...))
+
+	want := []map[string]interface{}{
+		{"id": 1, "email": "a@example.com", "password": "x"},
+		{"id": 2},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("conceal() = %v, want %v", rows, want)
+	}
+}
@@ -6,9 +6,12 @@ import (
 	"path/filepath"
 	"strings"
 	"text/template"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+
+	"go-mobile-backend-template/internal/services/auth"
 )
 
 // FileGenerator handles file-based code generation
@@ -124,6 +127,9 @@ func (fg *FileGenerator) generateRepository(tableName string, tableInfo *TableIn
 
 import (
 	"context"
+{{- if .SoftDelete}}
+	"time"
+{{- end}}
 	"gorm.io/gorm"
 )
 
@@ -154,7 +160,7 @@ func (r *{{.LowerName}}Repository) Create(ctx context.Context, {{.LowerName}} *{
 // GetByID gets a {{.LowerName}} by ID
 func (r *{{.LowerName}}Repository) GetByID(ctx context.Context, id uint) (*{{.StructName}}, error) {
 	var {{.LowerName}} {{.StructName}}
-	err := r.db.WithContext(ctx).First(&{{.LowerName}}, id).Error
+	err := r.records(ctx).First(&{{.LowerName}}, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -167,12 +173,12 @@ func (r *{{.LowerName}}Repository) GetAll(ctx context.Context, limit, offset int
 	var total int64
 
 	// Get total count
-	if err := r.db.WithContext(ctx).Model(&{{.StructName}}{}).Count(&total).Error; err != nil {
+	if err := r.records(ctx).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get data with pagination
-	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&{{.LowerName}}s).Error
+	err := r.records(ctx).Limit(limit).Offset(offset).Find(&{{.LowerName}}s).Error
 	return {{.LowerName}}s, total, err
 }
 
@@ -180,12 +186,34 @@ func (r *{{.LowerName}}Repository) GetAll(ctx context.Context, limit, offset int
 func (r *{{.LowerName}}Repository) Update(ctx context.Context, {{.LowerName}} *{{.StructName}}) error {
 	return r.db.WithContext(ctx).Save({{.LowerName}}).Error
 }
+{{if .SoftDelete}}
+// Delete marks a {{.LowerName}} as deleted by ID
+func (r *{{.LowerName}}Repository) Delete(ctx context.Context, id uint) error {
+	result := r.records(ctx).Where("id = ?", id).Update("deleted_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
 
+// records returns the query of the {{.LowerName}}s that are not deleted
+func (r *{{.LowerName}}Repository) records(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&{{.StructName}}{}).Where("deleted_at IS NULL")
+}
+{{else}}
 // Delete deletes a {{.LowerName}} by ID
 func (r *{{.LowerName}}Repository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&{{.StructName}}{}, id).Error
 }
-`
+
+// records returns the query of the {{.LowerName}}s
+func (r *{{.LowerName}}Repository) records(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&{{.StructName}}{})
+}
+{{end}}`
 
 	t, err := template.New("repository").Parse(tmpl)
 	if err != nil {
@@ -202,6 +230,7 @@ func (r *{{.LowerName}}Repository) Delete(ctx context.Context, id uint) error {
 		"StructName": fg.toPascalCase(tableName),
 		"LowerName":  fg.toCamelCase(tableName),
 		"TableName":  tableName,
+		"SoftDelete": fg.config.SoftDeletes(tableInfo),
 	})
 }
 
@@ -263,13 +292,16 @@ type PaginationInfo struct {
 	}
 	defer file.Close()
 
-	// Check if any column uses time.Time
-	columns := fg.generateColumnInfo(tableInfo.Columns)
+	// Check if any field uses time.Time
+	columns := fg.generateResponseColumns(tableInfo)
+	createColumns := fg.generateCreateColumns(tableInfo)
+	updateColumns := fg.generateUpdateColumns(tableInfo)
 	hasTimeFields := false
-	for _, col := range columns {
-		if col["GoType"] == "time.Time" {
-			hasTimeFields = true
-			break
+	for _, fields := range [][]map[string]interface{}{columns, createColumns, updateColumns} {
+		for _, col := range fields {
+			if strings.TrimPrefix(col["GoType"].(string), "*") == "time.Time" {
+				hasTimeFields = true
+			}
 		}
 	}
 
@@ -278,8 +310,8 @@ type PaginationInfo struct {
 		"StructName":    fg.toPascalCase(tableName),
 		"TableName":     tableName,
 		"Columns":       columns,
-		"CreateColumns": fg.generateCreateColumns(tableInfo.Columns),
-		"UpdateColumns": fg.generateUpdateColumns(tableInfo.Columns),
+		"CreateColumns": createColumns,
+		"UpdateColumns": updateColumns,
 		"HasTimeFields": hasTimeFields,
 	})
 }
@@ -300,6 +332,9 @@ import (
 	"gorm.io/gorm"
 
 	"go-mobile-backend-template/internal/db/repository/generated"
+{{- if .PermissionKey}}
+	"go-mobile-backend-template/internal/services/auth"
+{{- end}}
 	"go-mobile-backend-template/internal/utils"
 )
 
@@ -307,8 +342,45 @@ import (
 type Handler struct {
 	{{.LowerName}}Repo generated.{{.StructName}}Repository
 	logger             *zap.Logger
+{{- if .PermissionKey}}
+	permissions        *auth.PermissionResolver
+{{- end}}
+}
+{{if .PermissionKey}}
+// NewHandler creates a new {{.TableName}} handler. Writes change the
+// permissions of users, so they invalidate those cached by permissions,
+// which may be nil.
+func NewHandler(db *gorm.DB, logger *zap.Logger, permissions *auth.PermissionResolver) *Handler {
+	return &Handler{
+		{{.LowerName}}Repo: generated.New{{.StructName}}Repository(db),
+		logger:             logger,
+		permissions:        permissions,
+	}
 }
 
+// usersGrantedBy returns the users whose permissions depend on the
+// {{.TableName}} rows of key
+func (h *Handler) usersGrantedBy(ctx context.Context, key uint) []uint {
+	if h.permissions == nil {
+		return nil
+	}
+	userIDs, err := h.permissions.UsersGrantedBy(ctx, "{{.TableName}}", key)
+	if err != nil {
+		h.logger.Error("Failed to get users granted by {{.TableName}}", zap.Error(err))
+	}
+	return userIDs
+}
+
+// invalidatePermissions drops the cached permissions of users
+func (h *Handler) invalidatePermissions(ctx context.Context, userIDs []uint) {
+	if h.permissions == nil {
+		return
+	}
+	if err := h.permissions.Invalidate(ctx, userIDs...); err != nil {
+		h.logger.Error("Failed to invalidate permissions", zap.Error(err))
+	}
+}
+{{else}}
 // NewHandler creates a new {{.TableName}} handler
 func NewHandler(db *gorm.DB, logger *zap.Logger) *Handler {
 	return &Handler{
@@ -316,7 +388,7 @@ func NewHandler(db *gorm.DB, logger *zap.Logger) *Handler {
 		logger:             logger,
 	}
 }
-
+{{end}}
 // Create{{.StructName}} creates a new {{.TableName}}
 // @Summary Create {{.TableName}}
 // @Description Create a new {{.TableName}} record
@@ -337,17 +409,28 @@ func (h *Handler) Create{{.StructName}}(c *gin.Context) {
 	}
 
 	{{.LowerName}} := &generated.{{.StructName}}{
-{{range .CreateColumns}}
+{{range .CreateColumns}}{{if not .AdminOnly}}
 		{{.FieldName}}: req.{{.FieldName}},
-{{end}}
+{{end}}{{end}}
 	}
-
+{{range .CreateColumns}}{{if .AdminOnly}}
+	if req.{{.FieldName}} != nil {
+		if !c.GetBool("is_admin") {
+			utils.ErrorResponse(c, http.StatusForbidden, "Only admins may set {{.JSONTag}}")
+			return
+		}
+		{{$.LowerName}}.{{.FieldName}} = *req.{{.FieldName}}
+	}
+{{end}}{{end}}
 	ctx := context.Background()
 	if err := h.{{.LowerName}}Repo.Create(ctx, {{.LowerName}}); err != nil {
 		h.logger.Error("Failed to create {{.TableName}}", zap.Error(err))
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create {{.TableName}}")
 		return
 	}
+{{- if .PermissionKey}}
+	h.invalidatePermissions(ctx, h.usersGrantedBy(ctx, {{.LowerName}}.{{.PermissionKey}}))
+{{- end}}
 
 	response := {{.StructName}}Response{
 {{range .Columns}}
@@ -499,9 +582,22 @@ func (h *Handler) Update{{.StructName}}(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get {{.TableName}}")
 		return
 	}
+{{- if .PermissionKey}}
+
+	// Users granted permissions by the row before the update may lose them
+	affected := h.usersGrantedBy(ctx, {{.LowerName}}.{{.PermissionKey}})
+{{- end}}
 
 {{range .UpdateColumns}}
-	{{if ne .FieldName "ID"}}
+	{{if .AdminOnly}}
+	if req.{{.FieldName}} != nil {
+		if !c.GetBool("is_admin") {
+			utils.ErrorResponse(c, http.StatusForbidden, "Only admins may set {{.JSONTag}}")
+			return
+		}
+		{{$.LowerName}}.{{.FieldName}} = *req.{{.FieldName}}
+	}
+	{{else if ne .FieldName "ID"}}
 	{{$.LowerName}}.{{.FieldName}} = req.{{.FieldName}}
 	{{end}}
 {{end}}
@@ -511,6 +607,9 @@ func (h *Handler) Update{{.StructName}}(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update {{.TableName}}")
 		return
 	}
+{{- if .PermissionKey}}
+	h.invalidatePermissions(ctx, append(affected, h.usersGrantedBy(ctx, {{.LowerName}}.{{.PermissionKey}})...))
+{{- end}}
 
 	response := {{.StructName}}Response{
 {{range .Columns}}
@@ -543,6 +642,10 @@ func (h *Handler) Delete{{.StructName}}(c *gin.Context) {
 	}
 
 	ctx := context.Background()
+{{- if .PermissionKey}}
+	// Collect the users first; the assignments go with the rows
+	affected := h.usersGrantedBy(ctx, uint(id))
+{{- end}}
 	if err := h.{{.LowerName}}Repo.Delete(ctx, uint(id)); err != nil {
 		if err == gorm.ErrRecordNotFound {
 			utils.ErrorResponse(c, http.StatusNotFound, "{{.TableName}} not found")
@@ -552,6 +655,9 @@ func (h *Handler) Delete{{.StructName}}(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete {{.TableName}}")
 		return
 	}
+{{- if .PermissionKey}}
+	h.invalidatePermissions(ctx, affected)
+{{- end}}
 
 	utils.SuccessResponse(c, http.StatusOK, "{{.TableName}} deleted successfully", nil)
 }
@@ -573,9 +679,10 @@ func (h *Handler) Delete{{.StructName}}(c *gin.Context) {
 		"StructName":    fg.toPascalCase(tableName),
 		"LowerName":     fg.toCamelCase(tableName),
 		"TableName":     tableName,
-		"Columns":       fg.generateColumnInfo(tableInfo.Columns),
-		"CreateColumns": fg.generateCreateColumns(tableInfo.Columns),
-		"UpdateColumns": fg.generateUpdateColumns(tableInfo.Columns),
+		"Columns":       fg.generateResponseColumns(tableInfo),
+		"CreateColumns": fg.generateCreateColumns(tableInfo),
+		"UpdateColumns": fg.generateUpdateColumns(tableInfo),
+		"PermissionKey": fg.permissionKey(tableName),
 	})
 }
 
@@ -586,35 +693,35 @@ func (fg *FileGenerator) generateRoutes(tableName string, tableInfo *TableInfo,
 	tmpl := `package {{.PackageName}}
 
 import (
+{{- if .RateLimit}}
+	"time"
+{{end}}
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"go-mobile-backend-template/internal/middleware"
-	authService "go-mobile-backend-template/internal/services/auth"
-	"go-mobile-backend-template/pkg/config"
 )
 
 // RegisterRoutes registers {{.TableName}} routes
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cfg *config.Config) {
-	handler := NewHandler(db, logger)
-	
-	// Initialize JWT service for auth middleware
-	jwtService := authService.NewJWTService(
-		cfg.JWT.Secret,
-		cfg.JWT.AccessTokenExpireInt,
-		cfg.JWT.RefreshTokenExpireInt,
-	)
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, guard *middleware.RouteGuard) {
+	handler := NewHandler(db, logger{{if .PermissionKey}}, guard.Permissions(){{end}})
 
 	// {{.TableName}} routes (all protected)
 	{{.LowerName}}Routes := router.Group("/{{.TableName}}")
-	{{.LowerName}}Routes.Use(middleware.AuthMiddleware(jwtService))
+	{{.LowerName}}Routes.Use(guard.Authenticate())
+{{- if .RateLimit}}
+	{{.LowerName}}Routes.Use(guard.Limit("{{.TableName}}", {{.RateLimit.Requests}}, {{.RateLimit.Window}}))
+{{- end}}
+{{- if .AuditLog}}
+	{{.LowerName}}Routes.Use(guard.Audit())
+{{- end}}
 	{
-		{{.LowerName}}Routes.POST("", handler.Create{{.StructName}})
-		{{.LowerName}}Routes.GET("", handler.GetAll{{.StructName}}s)
-		{{.LowerName}}Routes.GET("/:id", handler.Get{{.StructName}})
-		{{.LowerName}}Routes.PUT("/:id", handler.Update{{.StructName}})
-		{{.LowerName}}Routes.DELETE("/:id", handler.Delete{{.StructName}})
+		{{.LowerName}}Routes.POST("", {{index .Permissions "create"}}handler.Create{{.StructName}})
+		{{.LowerName}}Routes.GET("", {{index .Permissions "list"}}handler.GetAll{{.StructName}}s)
+		{{.LowerName}}Routes.GET("/:id", {{index .Permissions "get"}}handler.Get{{.StructName}})
+		{{.LowerName}}Routes.PUT("/:id", {{index .Permissions "update"}}handler.Update{{.StructName}})
+		{{.LowerName}}Routes.DELETE("/:id", {{index .Permissions "delete"}}handler.Delete{{.StructName}})
 	}
 }
 `
@@ -630,14 +737,74 @@ func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cf
 	}
 	defer file.Close()
 
+	// Middleware is written out from the table's security configuration
+	security := fg.config.GetTableConfig(tableName).Security
+	permissions := make(map[string]string)
+	var rateLimit map[string]string
+	auditLog := false
+	if security != nil {
+		if security.RBAC != nil {
+			for _, endpointType := range []string{"create", "list", "get", "update", "delete"} {
+				args := []string{fmt.Sprintf("%q", security.RBAC.ResourceFor(tableName))}
+				for _, permission := range security.RBAC.Actions(endpointType) {
+					args = append(args, fmt.Sprintf("%q", permission))
+				}
+				permissions[endpointType] = fmt.Sprintf("guard.Require(%s), ", strings.Join(args, ", "))
+			}
+		}
+		if security.RateLimit != nil && security.RateLimit.Requests > 0 && security.RateLimit.Window > 0 {
+			rateLimit = map[string]string{
+				"Requests": fmt.Sprint(security.RateLimit.Allowance()),
+				"Window":   goDuration(security.RateLimit.Window),
+			}
+		}
+		auditLog = security.AuditLog
+	}
+
 	return t.Execute(file, map[string]interface{}{
-		"PackageName": tableName,
-		"StructName":  fg.toPascalCase(tableName),
-		"LowerName":   fg.toCamelCase(tableName),
-		"TableName":   tableName,
+		"PackageName":   tableName,
+		"StructName":    fg.toPascalCase(tableName),
+		"LowerName":     fg.toCamelCase(tableName),
+		"TableName":     tableName,
+		"Permissions":   permissions,
+		"RateLimit":     rateLimit,
+		"AuditLog":      auditLog,
+		"PermissionKey": fg.permissionKey(tableName),
 	})
 }
 
+// permissionKey returns the model field of the column whose value
+// auth.PermissionResolver.UsersGrantedBy takes, or "" for tables that grant
+// no permissions
+func (fg *FileGenerator) permissionKey(tableName string) string {
+	column, ok := auth.PermissionKeyColumn(tableName)
+	if !ok {
+		return ""
+	}
+	return fg.toPascalCase(column)
+}
+
+// goDuration returns the Go expression of a duration, such as 5 *
+// time.Minute
+func goDuration(d time.Duration) string {
+	for _, unit := range []struct {
+		size time.Duration
+		name string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+	} {
+		if d >= unit.size && d%unit.size == 0 {
+			if d == unit.size {
+				return unit.name
+			}
+			return fmt.Sprintf("%d * %s", d/unit.size, unit.name)
+		}
+	}
+	return fmt.Sprintf("time.Duration(%d)", d)
+}
+
 // Helper functions
 func (fg *FileGenerator) toPascalCase(s string) string {
 	return strings.Title(strings.ReplaceAll(s, "_", ""))
@@ -672,48 +839,66 @@ func (fg *FileGenerator) generateColumnInfo(columns []ColumnInfo) []map[string]i
 	return result
 }
 
-func (fg *FileGenerator) generateCreateColumns(columns []ColumnInfo) []map[string]interface{} {
+// generateResponseColumns returns the columns of table that responses may
+// return
+func (fg *FileGenerator) generateResponseColumns(table *TableInfo) []map[string]interface{} {
+	return fg.generateColumnInfo(fg.config.FieldPolicy(table).visible(table).Columns)
+}
+
+func (fg *FileGenerator) generateCreateColumns(table *TableInfo) []map[string]interface{} {
 	var result []map[string]interface{}
+	policy := fg.config.FieldPolicy(table)
 
-	for _, col := range columns {
-		// Skip auto-generated fields for create
-		if col.Name == "id" || col.Name == "created_at" || col.Name == "updated_at" {
+	for _, col := range table.Columns {
+		// Skip hidden and read only fields, such as ids and timestamps
+		if !policy.Creatable(col.Name) {
 			continue
 		}
 
-		result = append(result, map[string]interface{}{
-			"FieldName":  fg.toPascalCase(col.Name),
-			"GoType":     fg.getGoType(col.Type),
-			"JSONTag":    fg.getJSONTag(col.Name),
-			"BindingTag": fg.getBindingTag(col),
-			"Comment":    col.Comment,
-		})
+		result = append(result, fg.requestColumn(col, policy.AdminOnly(col.Name), fg.getBindingTag(col)))
 	}
 
 	return result
 }
 
-func (fg *FileGenerator) generateUpdateColumns(columns []ColumnInfo) []map[string]interface{} {
+func (fg *FileGenerator) generateUpdateColumns(table *TableInfo) []map[string]interface{} {
 	var result []map[string]interface{}
+	policy := fg.config.FieldPolicy(table)
 
-	for _, col := range columns {
-		// Skip auto-generated fields for update
-		if col.Name == "id" || col.Name == "created_at" || col.Name == "updated_at" {
+	for _, col := range table.Columns {
+		// Skip hidden, read only and write once fields
+		if !policy.Updatable(col.Name) {
 			continue
 		}
 
-		result = append(result, map[string]interface{}{
-			"FieldName":  fg.toPascalCase(col.Name),
-			"GoType":     fg.getGoType(col.Type),
-			"JSONTag":    fg.getJSONTag(col.Name),
-			"BindingTag": fg.getUpdateBindingTag(col),
-			"Comment":    col.Comment,
-		})
+		result = append(result, fg.requestColumn(col, policy.AdminOnly(col.Name), fg.getUpdateBindingTag(col)))
 	}
 
 	return result
 }
 
+// requestColumn returns the template data of a request field. Admin only
+// fields are pointers, so handlers can tell whether they were sent.
+func (fg *FileGenerator) requestColumn(col ColumnInfo, adminOnly bool, bindingTag string) map[string]interface{} {
+	goType := fg.getGoType(col.Type)
+	if adminOnly {
+		goType = "*" + goType
+		bindingTag = "omitempty"
+		if col.MaxLength != nil && *col.MaxLength > 0 {
+			bindingTag += fmt.Sprintf(",max=%d", *col.MaxLength)
+		}
+	}
+
+	return map[string]interface{}{
+		"FieldName":  fg.toPascalCase(col.Name),
+		"GoType":     goType,
+		"JSONTag":    fg.getJSONTag(col.Name),
+		"BindingTag": bindingTag,
+		"Comment":    col.Comment,
+		"AdminOnly":  adminOnly,
+	}
+}
+
 func (fg *FileGenerator) getGoType(dbType string) string {
 	switch {
 	case strings.Contains(dbType, "varchar"), strings.Contains(dbType, "text"), strings.Contains(dbType, "char"):
@@ -0,0 +1,303 @@
+package generator
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-mobile-backend-template/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// maxFilterValues bounds the values of an in or nin filter
+const maxFilterValues = 100
+
+// filterParam matches field[op] query parameters
+var filterParam = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\[([a-z]+)\]$`)
+
+// columnKind groups column types by the filters they accept
+type columnKind int
+
+const (
+	kindText columnKind = iota
+	kindInteger
+	kindNumber
+	kindBool
+	kindTime
+	kindUUID
+	kindOther
+)
+
+// columnKindOf returns the kind of a PostgreSQL data type
+func columnKindOf(dbType string) columnKind {
+	dbType = strings.ToLower(dbType)
+	switch {
+	case dbType == "smallint" || dbType == "integer" || dbType == "bigint" ||
+		dbType == "int" || dbType == "int2" || dbType == "int4" || dbType == "int8" ||
+		strings.HasSuffix(dbType, "serial"):
+		return kindInteger
+	case dbType == "real" || dbType == "double precision" || dbType == "float4" ||
+		dbType == "float8" || dbType == "numeric" || dbType == "decimal":
+		return kindNumber
+	case dbType == "boolean" || dbType == "bool":
+		return kindBool
+	case dbType == "date" || strings.HasPrefix(dbType, "timestamp") || strings.HasPrefix(dbType, "time"):
+		return kindTime
+	case dbType == "uuid":
+		return kindUUID
+	case dbType == "text" || strings.HasPrefix(dbType, "character") ||
+		dbType == "varchar" || dbType == "char" || dbType == "citext":
+		return kindText
+	default:
+		return kindOther
+	}
+}
+
+// filterOperator is a filter the generated handlers know how to compile
+type filterOperator struct {
+	// sql is the condition, with %s for the column
+	sql string
+	// kinds are the column kinds the operator applies to, nil for all
+	kinds []columnKind
+	// ranged operators may be used on the date_ranges fields
+	ranged bool
+}
+
+var (
+	comparableKinds = []columnKind{kindText, kindInteger, kindNumber, kindBool, kindTime, kindUUID}
+	orderedKinds    = []columnKind{kindInteger, kindNumber, kindTime}
+	listedKinds     = []columnKind{kindText, kindInteger, kindNumber, kindUUID}
+)
+
+// filterOperators are the operators of the filter grammar. Configured
+// operators enable them; operators this table does not contain are
+// rejected.
+var filterOperators = map[string]filterOperator{
+	"eq":      {sql: "%s = ?", kinds: comparableKinds},
+	"ne":      {sql: "%s <> ?", kinds: comparableKinds},
+	"gt":      {sql: "%s > ?", kinds: orderedKinds, ranged: true},
+	"gte":     {sql: "%s >= ?", kinds: orderedKinds, ranged: true},
+	"lt":      {sql: "%s < ?", kinds: orderedKinds, ranged: true},
+	"lte":     {sql: "%s <= ?", kinds: orderedKinds, ranged: true},
+	"between": {sql: "%s BETWEEN ? AND ?", kinds: orderedKinds, ranged: true},
+	"like":    {sql: "%s LIKE ?", kinds: []columnKind{kindText}},
+	"ilike":   {sql: "%s ILIKE ?", kinds: []columnKind{kindText}},
+	"in":      {sql: "%s IN ?", kinds: listedKinds},
+	"nin":     {sql: "%s NOT IN ?", kinds: listedKinds},
+	"null":    {ranged: true},
+	"nnull":   {ranged: true},
+}
+
+// applies reports whether the operator can be used on a column of kind
+func (o filterOperator) applies(kind columnKind) bool {
+	if o.kinds == nil {
+		return true
+	}
+	for _, k := range o.kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter is a condition on a column parsed from the query string
+type Filter struct {
+	Column   string
+	Operator string
+	Values   []interface{}
+}
+
+// SQL returns the parameterized condition of the filter
+func (f Filter) SQL() (string, []interface{}) {
+	column := quoteColumn(f.Column)
+
+	switch f.Operator {
+	case "null", "nnull":
+		isNull := f.Values[0].(bool)
+		if f.Operator == "nnull" {
+			isNull = !isNull
+		}
+		if isNull {
+			return column + " IS NULL", nil
+		}
+		return column + " IS NOT NULL", nil
+	case "in", "nin":
+		return fmt.Sprintf(filterOperators[f.Operator].sql, column), []interface{}{f.Values}
+	default:
+		return fmt.Sprintf(filterOperators[f.Operator].sql, column), f.Values
+	}
+}
+
+// quoteColumn quotes a column name for SQL
+func quoteColumn(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// ParseFilters reads the filters of a list request. field=value compares
+// for equality and field[op]=value uses one of the configured operators:
+// in and nin take comma separated values, between takes two, and null and
+// nnull take true or false. Values are checked against the column type.
+// Plain parameters that are not columns, and those in reserved, are left
+// to the handler. Errors are keyed by parameter.
+func ParseFilters(params url.Values, table *TableInfo, config *FilteringConfig, reserved map[string]bool) ([]Filter, utils.ValidationErrors) {
+	errs := utils.NewValidationErrors()
+	if config == nil {
+		return nil, errs
+	}
+
+	columns := make(map[string]ColumnInfo, len(table.Columns))
+	for _, column := range table.Columns {
+		columns[column.Name] = column
+	}
+
+	// Parameters are read in order so errors and SQL are stable
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var filters []Filter
+	for _, name := range names {
+		field, op := name, "eq"
+		if match := filterParam.FindStringSubmatch(name); match != nil {
+			field, op = match[1], match[2]
+		} else if _, ok := columns[name]; !ok || reserved[name] {
+			continue
+		}
+
+		column, ok := columns[field]
+		if !ok {
+			errs.Add(name, fmt.Sprintf("Unknown field %s", field))
+			continue
+		}
+
+		operator, ok := filterOperators[op]
+		if _, configured := config.Operators[op]; !ok || !configured {
+			errs.Add(name, fmt.Sprintf("Unsupported operator %s", op))
+			continue
+		}
+
+		allowed := contains(config.AllowedFields, field)
+		if !allowed && !(operator.ranged && contains(config.DateRanges, field)) {
+			errs.Add(name, fmt.Sprintf("Filtering on %s with %s is not allowed", field, op))
+			continue
+		}
+
+		kind := columnKindOf(column.Type)
+		if !operator.applies(kind) {
+			errs.Add(name, fmt.Sprintf("Operator %s does not apply to %s", op, field))
+			continue
+		}
+
+		for _, raw := range params[name] {
+			values, err := parseFilterValues(op, kind, raw)
+			if err != nil {
+				errs.Add(name, err.Error())
+				break
+			}
+			filters = append(filters, Filter{Column: field, Operator: op, Values: values})
+		}
+	}
+
+	return filters, errs
+}
+
+// parseFilterValues converts the raw value of a filter for its operator and
+// column kind
+func parseFilterValues(op string, kind columnKind, raw string) ([]interface{}, error) {
+	switch op {
+	case "null", "nnull":
+		if raw == "" {
+			return []interface{}{true}, nil
+		}
+		isNull, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("Expected true or false")
+		}
+		return []interface{}{isNull}, nil
+
+	case "in", "nin", "between":
+		parts := strings.Split(raw, ",")
+		if op == "between" && len(parts) != 2 {
+			return nil, fmt.Errorf("Expected two comma separated values")
+		}
+		if len(parts) > maxFilterValues {
+			return nil, fmt.Errorf("At most %d values are allowed", maxFilterValues)
+		}
+		values := make([]interface{}, 0, len(parts))
+		for _, part := range parts {
+			value, err := parseFilterValue(kind, strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+
+	case "like", "ilike":
+		return []interface{}{raw}, nil
+
+	default:
+		value, err := parseFilterValue(kind, raw)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{value}, nil
+	}
+}
+
+// parseFilterValue converts one value to the type of its column
+func parseFilterValue(kind columnKind, raw string) (interface{}, error) {
+	switch kind {
+	case kindInteger:
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Expected an integer")
+		}
+		return value, nil
+	case kindNumber:
+		// Kept as text so numeric columns are compared without rounding
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, fmt.Errorf("Expected a number")
+		}
+		return raw, nil
+	case kindBool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("Expected true or false")
+		}
+		return value, nil
+	case kindTime:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+			if value, err := time.Parse(layout, raw); err == nil {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("Expected an RFC 3339 time or a date")
+	case kindUUID:
+		value, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("Expected a UUID")
+		}
+		return value.String(), nil
+	default:
+		return raw, nil
+	}
+}
+
+// contains reports whether slice holds item
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package generator
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// filterTestTable is a table with a column of each filterable type
+var filterTestTable = testTable("posts",
+	"id bigint pk",
+	"title character varying",
+	"views integer",
+	"rating numeric",
+	"published boolean",
+	"created_at timestamp with time zone",
+	"author_id uuid",
+	"secret text",
+)
+
+func TestParseFilters(t *testing.T) {
+	created := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	config := &FilteringConfig{
+		AllowedFields: []string{"id", "title", "views", "rating", "published", "author_id"},
+		DateRanges:    []string{"created_at"},
+		Operators: map[string]string{
+			"eq": "=", "ne": "!=", "gt": ">", "gte": ">=", "lt": "<", "lte": "<=",
+			"between": "BETWEEN", "like": "LIKE", "in": "IN", "null": "IS NULL",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		reserved map[string]bool
+		want     []Filter
+		wantErrs []string
+	}{
+		{
+			name:  "plain parameter is equality",
+			query: "views=10",
+			want:  []Filter{{Column: "views", Operator: "eq", Values: []interface{}{int64(10)}}},
+		},
+		{
+			name:  "operators are read in parameter order",
+			query: "views[gte]=5&title[like]=go%25&published=true",
+			want: []Filter{
+				{Column: "published", Operator: "eq", Values: []interface{}{true}},
+				{Column: "title", Operator: "like", Values: []interface{}{"go%"}},
+				{Column: "views", Operator: "gte", Values: []interface{}{int64(5)}},
+			},
+		},
+		{
+			name:  "in takes comma separated values",
+			query: "id[in]=1, 2,3",
+			want:  []Filter{{Column: "id", Operator: "in", Values: []interface{}{int64(1), int64(2), int64(3)}}},
+		},
+		{
+			name:  "date range fields take ranged operators",
+			query: "created_at[between]=2024-05-01,2024-06-01",
+			want: []Filter{{Column: "created_at", Operator: "between", Values: []interface{}{
+				created, created.AddDate(0, 1, 0),
+			}}},
+		},
+		{
+			name:  "null without a value is true",
+			query: "created_at[null]=",
+			want:  []Filter{{Column: "created_at", Operator: "null", Values: []interface{}{true}}},
+		},
+		{
+			name:  "numbers and uuids keep their text",
+			query: "rating[gt]=4.5&author_id=6BA7B810-9DAD-11D1-80B4-00C04FD430C8",
+			want: []Filter{
+				{Column: "author_id", Operator: "eq", Values: []interface{}{"6ba7b810-9dad-11d1-80b4-00c04fd430c8"}},
+				{Column: "rating", Operator: "gt", Values: []interface{}{"4.5"}},
+			},
+		},
+		{
+			name:     "reserved and unknown plain parameters are left to the handler",
+			query:    "page=2&limit=10&search=go&views=1",
+			reserved: map[string]bool{"views": true},
+		},
+		{
+			name:     "unknown field",
+			query:    "missing[eq]=1",
+			wantErrs: []string{"missing[eq]"},
+		},
+		{
+			name:     "operator not in the grammar or not configured",
+			query:    "views[regex]=1&title[ilike]=go",
+			wantErrs: []string{"title[ilike]", "views[regex]"},
+		},
+		{
+			name:     "field not allowed",
+			query:    "secret=x&created_at[eq]=2024-05-01",
+			wantErrs: []string{"created_at[eq]", "secret"},
+		},
+		{
+			name:     "operator does not apply to the column",
+			query:    "title[gt]=a&published[in]=true",
+			wantErrs: []string{"published[in]", "title[gt]"},
+		},
+		{
+			name:     "values must match the column type",
+			query:    "views=ten&rating[lt]=high&author_id=nope&published=maybe&created_at[gt]=yesterday",
+			wantErrs: []string{"author_id", "created_at[gt]", "published", "rating[lt]", "views"},
+		},
+		{
+			name:     "between takes two values",
+			query:    "views[between]=1,2,3",
+			wantErrs: []string{"views[between]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery() error = %v", err)
+			}
+
+			filters, errs := ParseFilters(params, filterTestTable, config, tt.reserved)
+			if len(errs) != len(tt.wantErrs) {
+				t.Fatalf("ParseFilters() errors = %v, want %v", errs, tt.wantErrs)
+			}
+			for _, param := range tt.wantErrs {
+				if _, ok := errs[param]; !ok {
+					t.Errorf("ParseFilters() has no error for %s, errors = %v", param, errs)
+				}
+			}
+			if len(tt.wantErrs) == 0 && !reflect.DeepEqual(filters, tt.want) {
+				t.Errorf("ParseFilters() = %#v, want %#v", filters, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFiltersWithoutConfig(t *testing.T) {
+	params := url.Values{"views": {"1"}, "bad[op]": {"x"}}
+	filters, errs := ParseFilters(params, filterTestTable, nil, nil)
+	if filters != nil || errs.HasErrors() {
+		t.Errorf("ParseFilters() = %v, %v, want no filters or errors", filters, errs)
+	}
+}
+
+func TestFilterSQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   Filter
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "comparison",
+			filter:   Filter{Column: "views", Operator: "gte", Values: []interface{}{int64(5)}},
+			wantSQL:  `"views" >= ?`,
+			wantArgs: []interface{}{int64(5)},
+		},
+		{
+			name:     "between",
+			filter:   Filter{Column: "views", Operator: "between", Values: []interface{}{int64(1), int64(9)}},
+			wantSQL:  `"views" BETWEEN ? AND ?`,
+			wantArgs: []interface{}{int64(1), int64(9)},
+		},
+		{
+			name:     "in binds the values as one list",
+			filter:   Filter{Column: "id", Operator: "in", Values: []interface{}{int64(1), int64(2)}},
+			wantSQL:  `"id" IN ?`,
+			wantArgs: []interface{}{[]interface{}{int64(1), int64(2)}},
+		},
+		{
+			name:     "not in",
+			filter:   Filter{Column: "id", Operator: "nin", Values: []interface{}{int64(3)}},
+			wantSQL:  `"id" NOT IN ?`,
+			wantArgs: []interface{}{[]interface{}{int64(3)}},
+		},
+		{
+			name:    "null",
+			filter:  Filter{Column: "deleted_at", Operator: "null", Values: []interface{}{true}},
+			wantSQL: `"deleted_at" IS NULL`,
+		},
+		{
+			name:    "null false",
+			filter:  Filter{Column: "deleted_at", Operator: "null", Values: []interface{}{false}},
+			wantSQL: `"deleted_at" IS NOT NULL`,
+		},
+		{
+			name:    "not null",
+			filter:  Filter{Column: "deleted_at", Operator: "nnull", Values: []interface{}{true}},
+			wantSQL: `"deleted_at" IS NOT NULL`,
+		},
+		{
+			name:     "quotes in column names are escaped",
+			filter:   Filter{Column: `a"b`, Operator: "eq", Values: []interface{}{"x"}},
+			wantSQL:  `"a""b" = ?`,
+			wantArgs: []interface{}{"x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := tt.filter.SQL()
+			if sql != tt.wantSQL {
+				t.Errorf("SQL() = %q, want %q", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("SQL() args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package generator
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"text/template"
 
@@ -152,16 +153,17 @@ import (
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
-	"go-mobile-backend-template/pkg/config"
+	"go-mobile-backend-template/internal/middleware"
 {{range .Tables}}
 	"go-mobile-backend-template/internal/api/v1/{{.}}"
 {{end}}
 )
 
-// RegisterGeneratedRoutes registers all auto-generated API routes
-func RegisterGeneratedRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, cfg *config.Config) {
+// RegisterGeneratedRoutes registers all auto-generated API routes, protected
+// by guard as their tables are configured
+func RegisterGeneratedRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.Logger, guard *middleware.RouteGuard) {
 {{range .Tables}}
-	{{.}}.RegisterRoutes(router, db, logger, cfg)
+	{{.}}.RegisterRoutes(router, db, logger, guard)
 {{end}}
 }
 `
@@ -184,6 +186,7 @@ func RegisterGeneratedRoutes(router *gin.RouterGroup, db *gorm.DB, logger *zap.L
 			tableNames = append(tableNames, tableName)
 		}
 	}
+	sort.Strings(tableNames)
 
 	return t.Execute(file, map[string]interface{}{
 		"Tables": tableNames,
@@ -522,7 +525,7 @@ func (g *APIGenerator) generateExportEndpoint(table *TableInfo, basePath string,
 // Helper methods for generating endpoint components
 
 func (g *APIGenerator) getMiddlewareForEndpoint(endpointType string, config *TableConfig) []string {
-	middleware := []string{"Logger", "Recovery", "CORS", "SecurityHeaders"}
+	middleware := []string{"Logger", "Recovery", "CORS", "SecurityHeaders", "Auth"}
 
 	if config.Security != nil {
 		if config.Security.RBAC != nil {
@@ -751,9 +754,38 @@ func (g *APIGenerator) generateStatsResponseSchema(table *TableInfo) interface{}
 }
 
 func (g *APIGenerator) generateRelationshipEndpoints(table *TableInfo, relationship string, config *TableConfig) ([]*GeneratedEndpoint, error) {
-	// This would generate relationship-specific endpoints
-	// For now, return empty slice
-	return []*GeneratedEndpoint{}, nil
+	path := fmt.Sprintf("/api/v1/%s/:id/%s", strings.ToLower(table.Name), relationship)
+	name := g.toCamelCase(table.Name) + g.toCamelCase(relationship)
+
+	endpoints := []*GeneratedEndpoint{
+		{
+			Method:      "GET",
+			Path:        path,
+			Handler:     "List" + name,
+			Middleware:  g.getMiddlewareForEndpoint("list", config),
+			Description: fmt.Sprintf("List the %s of a %s record", relationship, strings.ToLower(table.Name)),
+		},
+		{
+			Method:      "POST",
+			Path:        path,
+			Handler:     "Link" + name,
+			Middleware:  g.getMiddlewareForEndpoint("update", config),
+			Description: fmt.Sprintf("Link %s to a %s record", relationship, strings.ToLower(table.Name)),
+		},
+		{
+			Method:      "DELETE",
+			Path:        path,
+			Handler:     "Unlink" + name,
+			Middleware:  g.getMiddlewareForEndpoint("update", config),
+			Description: fmt.Sprintf("Unlink %s from a %s record", relationship, strings.ToLower(table.Name)),
+		},
+	}
+	for _, endpoint := range endpoints {
+		endpoint.Security = config.Security
+		endpoint.Tags = []string{table.Name}
+	}
+
+	return endpoints, nil
 }
 
 // Utility methods
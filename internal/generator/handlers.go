@@ -1,12 +1,15 @@
 package generator
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"go-mobile-backend-template/internal/middleware"
 	"go-mobile-backend-template/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -16,17 +19,24 @@ import (
 
 // CRUDHandlerGenerator generates CRUD handlers for tables
 type CRUDHandlerGenerator struct {
-	db     *gorm.DB
-	logger *zap.Logger
-	config *GeneratorConfig
+	db        *gorm.DB
+	logger    *zap.Logger
+	config    *GeneratorConfig
+	cursors   *CursorCodec
+	relations *relationCatalog
+	// guard checks the permissions of the related tables a request
+	// expands
+	guard *middleware.RouteGuard
 }
 
 // NewCRUDHandlerGenerator creates a new CRUD handler generator
 func NewCRUDHandlerGenerator(db *gorm.DB, logger *zap.Logger, config *GeneratorConfig) *CRUDHandlerGenerator {
 	return &CRUDHandlerGenerator{
-		db:     db,
-		logger: logger,
-		config: config,
+		db:        db,
+		logger:    logger,
+		config:    config,
+		cursors:   NewCursorCodec(config.CursorSecret),
+		relations: newRelationCatalog(NewSchemaAnalyzer(db, logger)),
 	}
 }
 
@@ -82,78 +92,16 @@ func (g *CRUDHandlerGenerator) generateHandler(table *TableInfo, endpointType st
 // generateListHandler generates a list handler
 func (g *CRUDHandlerGenerator) generateListHandler(table *TableInfo, config *TableConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Parse pagination parameters
-		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-		limit, _ := strconv.Atoi(c.DefaultQuery("limit", fmt.Sprintf("%d", config.Pagination.DefaultLimit)))
-
-		if limit > config.Pagination.MaxLimit {
-			limit = config.Pagination.MaxLimit
-		}
-
-		offset := (page - 1) * limit
-
-		// Parse sorting parameters
-		sort := c.Query("sort")
-		order := c.Query("order")
-		if order == "" {
-			order = "asc"
-		}
-
 		// Build query
-		query := g.db.Table(table.Name)
+		query := g.records(g.db, table)
 
 		// Apply filters
-		if err := g.applyFilters(query, c, config); err != nil {
-			g.logger.Error("Failed to apply filters", zap.Error(err))
-			c.JSON(http.StatusBadRequest, utils.ErrorResponseData("Invalid filter parameters"))
+		query, filters, ok := g.applyFilters(query, c, table, config)
+		if !ok {
 			return
 		}
 
-		// Apply sorting
-		if sort != "" {
-			if config.Sorting != nil && g.isAllowedSortField(sort, config.Sorting.AllowedFields) {
-				query = query.Order(fmt.Sprintf("%s %s", sort, order))
-			}
-		} else if config.Sorting != nil && config.Sorting.DefaultSort != "" {
-			query = query.Order(config.Sorting.DefaultSort)
-		}
-
-		// Get total count
-		var total int64
-		if err := query.Count(&total).Error; err != nil {
-			g.logger.Error("Failed to count records", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to count records"))
-			return
-		}
-
-		// Apply pagination
-		query = query.Offset(offset).Limit(limit)
-
-		// Execute query
-		var results []map[string]interface{}
-		if err := query.Find(&results).Error; err != nil {
-			g.logger.Error("Failed to fetch records", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to fetch records"))
-			return
-		}
-
-		// Calculate pagination info
-		totalPages := int((total + int64(limit) - 1) / int64(limit))
-		hasNext := page < totalPages
-		hasPrev := page > 1
-
-		// Return response
-		c.JSON(http.StatusOK, utils.SuccessResponseData("Records retrieved successfully", gin.H{
-			"data": results,
-			"pagination": gin.H{
-				"page":        page,
-				"limit":       limit,
-				"total":       total,
-				"total_pages": totalPages,
-				"has_next":    hasNext,
-				"has_prev":    hasPrev,
-			},
-		}))
+		g.respondList(c, query, table, config, filters > 0, "Records retrieved successfully", "Failed to fetch records")
 	}
 }
 
@@ -168,6 +116,9 @@ func (g *CRUDHandlerGenerator) generateCreateHandler(table *TableInfo, config *T
 		}
 
 		// Validate data
+		if !g.checkWrite(c, data, table, true) {
+			return
+		}
 		if err := g.validateData(data, table, config, "create"); err != nil {
 			c.JSON(http.StatusBadRequest, utils.ErrorResponseData(err.Error()))
 			return
@@ -186,6 +137,7 @@ func (g *CRUDHandlerGenerator) generateCreateHandler(table *TableInfo, config *T
 			c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to create record"))
 			return
 		}
+		g.conceal([]map[string]interface{}{data}, table)
 
 		c.JSON(http.StatusCreated, utils.SuccessResponseData("Record created successfully", gin.H{
 			"data": data,
@@ -202,14 +154,15 @@ func (g *CRUDHandlerGenerator) generateGetHandler(table *TableInfo, config *Tabl
 			return
 		}
 
-		// Build query
-		query := g.db.Table(table.Name)
-
-		// Apply joins if configured
-		if err := g.applyJoins(query, table, config); err != nil {
-			g.logger.Error("Failed to apply joins", zap.Error(err))
+		// Relationships to embed
+		expansions, ok := g.expansions(c, table)
+		if !ok {
+			return
 		}
 
+		// Build query
+		query := g.records(g.db, table)
+
 		// Find record by ID
 		var result map[string]interface{}
 		if err := query.Where("id = ?", id).First(&result).Error; err != nil {
@@ -222,6 +175,11 @@ func (g *CRUDHandlerGenerator) generateGetHandler(table *TableInfo, config *Tabl
 			return
 		}
 
+		if !g.expandRows(c, []map[string]interface{}{result}, expansions, "Failed to fetch record") {
+			return
+		}
+		g.conceal([]map[string]interface{}{result}, table)
+
 		c.JSON(http.StatusOK, utils.SuccessResponseData("Record retrieved successfully", gin.H{
 			"data": result,
 		}))
@@ -245,6 +203,9 @@ func (g *CRUDHandlerGenerator) generateUpdateHandler(table *TableInfo, config *T
 		}
 
 		// Validate data
+		if !g.checkWrite(c, data, table, false) {
+			return
+		}
 		if err := g.validateData(data, table, config, "update"); err != nil {
 			c.JSON(http.StatusBadRequest, utils.ErrorResponseData(err.Error()))
 			return
@@ -256,7 +217,7 @@ func (g *CRUDHandlerGenerator) generateUpdateHandler(table *TableInfo, config *T
 		}
 
 		// Update record
-		result := g.db.Table(table.Name).Where("id = ?", id).Updates(data)
+		result := g.records(g.db, table).Where("id = ?", id).Updates(data)
 		if result.Error != nil {
 			g.logger.Error("Failed to update record", zap.Error(result.Error))
 			c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to update record"))
@@ -270,9 +231,10 @@ func (g *CRUDHandlerGenerator) generateUpdateHandler(table *TableInfo, config *T
 
 		// Fetch updated record
 		var updatedRecord map[string]interface{}
-		if err := g.db.Table(table.Name).Where("id = ?", id).First(&updatedRecord).Error; err != nil {
+		if err := g.records(g.db, table).Where("id = ?", id).First(&updatedRecord).Error; err != nil {
 			g.logger.Error("Failed to fetch updated record", zap.Error(err))
 		}
+		g.conceal([]map[string]interface{}{updatedRecord}, table)
 
 		c.JSON(http.StatusOK, utils.SuccessResponseData("Record updated successfully", gin.H{
 			"data": updatedRecord,
@@ -290,9 +252,10 @@ func (g *CRUDHandlerGenerator) generateDeleteHandler(table *TableInfo, config *T
 		}
 
 		// Check if soft delete is enabled
-		if config.Security != nil && config.Security.SoftDelete {
-			// Soft delete - update deleted_at timestamp
-			result := g.db.Table(table.Name).Where("id = ?", id).Update("deleted_at", time.Now())
+		if g.config.SoftDeletes(table) {
+			// Soft delete - update deleted_at timestamp of a record that is
+			// not deleted yet
+			result := g.records(g.db, table).Where("id = ?", id).Update("deleted_at", time.Now())
 			if result.Error != nil {
 				g.logger.Error("Failed to soft delete record", zap.Error(result.Error))
 				c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to delete record"))
@@ -350,6 +313,10 @@ func (g *CRUDHandlerGenerator) generateBulkHandler(table *TableInfo, config *Tab
 		switch request.Operation {
 		case "create":
 			for _, record := range request.Data {
+				if errs := g.writeErrors(c, record, table, true); errs.HasErrors() {
+					errors = append(errors, fmt.Sprintf("Validation error: %s", describeErrors(errs)))
+					continue
+				}
 				if err := g.validateData(record, table, config, "create"); err != nil {
 					errors = append(errors, fmt.Sprintf("Validation error: %s", err.Error()))
 					continue
@@ -370,6 +337,14 @@ func (g *CRUDHandlerGenerator) generateBulkHandler(table *TableInfo, config *Tab
 
 		case "update":
 			for _, record := range request.Data {
+				// The id selects the record and is not written
+				id := record["id"]
+				delete(record, "id")
+
+				if errs := g.writeErrors(c, record, table, false); errs.HasErrors() {
+					errors = append(errors, fmt.Sprintf("Validation error: %s", describeErrors(errs)))
+					continue
+				}
 				if err := g.validateData(record, table, config, "update"); err != nil {
 					errors = append(errors, fmt.Sprintf("Validation error: %s", err.Error()))
 					continue
@@ -379,7 +354,7 @@ func (g *CRUDHandlerGenerator) generateBulkHandler(table *TableInfo, config *Tab
 					record["updated_at"] = time.Now()
 				}
 
-				if err := tx.Table(table.Name).Where("id = ?", record["id"]).Updates(record).Error; err != nil {
+				if err := g.records(tx, table).Where("id = ?", id).Updates(record).Error; err != nil {
 					errors = append(errors, fmt.Sprintf("Failed to update record: %s", err.Error()))
 					continue
 				}
@@ -388,8 +363,18 @@ func (g *CRUDHandlerGenerator) generateBulkHandler(table *TableInfo, config *Tab
 
 		case "delete":
 			if request.Where != nil {
-				// Delete by conditions
-				result := tx.Table(table.Name).Where(request.Where).Delete(nil)
+				// Delete by conditions, which may only use readable columns
+				policy := g.config.FieldPolicy(table)
+				for field := range request.Where {
+					if !g.hasColumn(table, field) || !policy.Readable(field) {
+						errors = append(errors, fmt.Sprintf("Unknown field %s", field))
+					}
+				}
+				if len(errors) > 0 {
+					break
+				}
+
+				result := g.remove(g.records(tx, table).Where(request.Where), table)
 				if result.Error != nil {
 					errors = append(errors, fmt.Sprintf("Failed to delete records: %s", result.Error.Error()))
 				} else {
@@ -399,7 +384,7 @@ func (g *CRUDHandlerGenerator) generateBulkHandler(table *TableInfo, config *Tab
 				// Delete by IDs
 				for _, record := range request.Data {
 					if id, exists := record["id"]; exists {
-						result := tx.Table(table.Name).Where("id = ?", id).Delete(nil)
+						result := g.remove(g.records(tx, table).Where("id = ?", id), table)
 						if result.Error != nil {
 							errors = append(errors, fmt.Sprintf("Failed to delete record %v: %s", id, result.Error.Error()))
 							continue
@@ -438,25 +423,22 @@ func (g *CRUDHandlerGenerator) generateSearchHandler(table *TableInfo, config *T
 			return
 		}
 
-		// Parse pagination parameters
-		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-		limit, _ := strconv.Atoi(c.DefaultQuery("limit", fmt.Sprintf("%d", config.Pagination.DefaultLimit)))
-
-		if limit > config.Pagination.MaxLimit {
-			limit = config.Pagination.MaxLimit
-		}
-
-		offset := (page - 1) * limit
-
 		// Build search query
-		dbQuery := g.db.Table(table.Name)
+		dbQuery, _, ok := g.applyFilters(g.records(g.db, table), c, table, config)
+		if !ok {
+			return
+		}
 
 		// Add search conditions for text fields
 		if config.Filtering != nil && len(config.Filtering.TextSearch) > 0 {
 			var conditions []string
 			var args []interface{}
 
+			policy := g.config.FieldPolicy(table)
 			for _, field := range config.Filtering.TextSearch {
+				if !policy.Readable(field) {
+					continue
+				}
 				conditions = append(conditions, fmt.Sprintf("%s ILIKE ?", field))
 				args = append(args, "%"+query+"%")
 			}
@@ -466,41 +448,7 @@ func (g *CRUDHandlerGenerator) generateSearchHandler(table *TableInfo, config *T
 			}
 		}
 
-		// Get total count
-		var total int64
-		if err := dbQuery.Count(&total).Error; err != nil {
-			g.logger.Error("Failed to count search results", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to search records"))
-			return
-		}
-
-		// Apply pagination
-		dbQuery = dbQuery.Offset(offset).Limit(limit)
-
-		// Execute search
-		var results []map[string]interface{}
-		if err := dbQuery.Find(&results).Error; err != nil {
-			g.logger.Error("Failed to execute search", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to search records"))
-			return
-		}
-
-		// Calculate pagination info
-		totalPages := int((total + int64(limit) - 1) / int64(limit))
-		hasNext := page < totalPages
-		hasPrev := page > 1
-
-		c.JSON(http.StatusOK, utils.SuccessResponseData("Search completed", gin.H{
-			"data": results,
-			"pagination": gin.H{
-				"page":        page,
-				"limit":       limit,
-				"total":       total,
-				"total_pages": totalPages,
-				"has_next":    hasNext,
-				"has_prev":    hasPrev,
-			},
-		}))
+		g.respondList(c, dbQuery, table, config, true, "Search completed", "Failed to search records")
 	}
 }
 
@@ -509,7 +457,7 @@ func (g *CRUDHandlerGenerator) generateStatsHandler(table *TableInfo, config *Ta
 	return func(c *gin.Context) {
 		// Get total count
 		var total int64
-		if err := g.db.Table(table.Name).Count(&total).Error; err != nil {
+		if err := g.records(g.db, table).Count(&total).Error; err != nil {
 			g.logger.Error("Failed to get total count", zap.Error(err))
 			c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to get statistics"))
 			return
@@ -517,8 +465,8 @@ func (g *CRUDHandlerGenerator) generateStatsHandler(table *TableInfo, config *Ta
 
 		// Get active count (if status field exists)
 		var active int64
-		if g.hasColumn(table, "status") {
-			if err := g.db.Table(table.Name).Where("status = ?", "active").Count(&active).Error; err != nil {
+		if g.hasColumn(table, "status") && g.config.FieldPolicy(table).Readable("status") {
+			if err := g.records(g.db, table).Where("status = ?", "active").Count(&active).Error; err != nil {
 				g.logger.Warn("Failed to get active count", zap.Error(err))
 			}
 		}
@@ -541,19 +489,27 @@ func (g *CRUDHandlerGenerator) generateExportHandler(table *TableInfo, config *T
 		fields := c.Query("fields")
 
 		// Build query
-		query := g.db.Table(table.Name)
+		query := g.records(g.db, table)
 
 		// Apply filters
-		if err := g.applyFilters(query, c, config); err != nil {
-			g.logger.Error("Failed to apply filters", zap.Error(err))
-			c.JSON(http.StatusBadRequest, utils.ErrorResponseData("Invalid filter parameters"))
+		query, _, ok := g.applyFilters(query, c, table, config)
+		if !ok {
 			return
 		}
 
 		// Select specific fields if requested
 		if fields != "" {
-			fieldList := strings.Split(fields, ",")
-			query = query.Select(fieldList)
+			policy := g.config.FieldPolicy(table)
+			var columns []string
+			for _, field := range strings.Split(fields, ",") {
+				field = strings.TrimSpace(field)
+				if !g.hasColumn(table, field) || !policy.Readable(field) {
+					c.JSON(http.StatusBadRequest, utils.ErrorResponseData(fmt.Sprintf("Unknown field %s", field)))
+					return
+				}
+				columns = append(columns, quoteColumn(field))
+			}
+			query = query.Select(columns)
 		}
 
 		// Execute query
@@ -563,6 +519,7 @@ func (g *CRUDHandlerGenerator) generateExportHandler(table *TableInfo, config *T
 			c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to export records"))
 			return
 		}
+		g.conceal(results, table)
 
 		// Set appropriate headers
 		switch format {
@@ -591,29 +548,375 @@ func (g *CRUDHandlerGenerator) generateExportHandler(table *TableInfo, config *T
 
 // Helper methods
 
-func (g *CRUDHandlerGenerator) applyFilters(query *gorm.DB, c *gin.Context, config *TableConfig) error {
-	if config.Filtering == nil {
+// applyFilters adds the filters of the request to query and returns how
+// many it added. Invalid filters are answered with 400 and the errors of
+// each parameter, and false is returned.
+func (g *CRUDHandlerGenerator) applyFilters(query *gorm.DB, c *gin.Context, table *TableInfo, config *TableConfig) (*gorm.DB, int, bool) {
+	visible := g.config.FieldPolicy(table).visible(table)
+	filters, errs := ParseFilters(c.Request.URL.Query(), visible, config.Filtering, g.reservedParams(config))
+	if errs.HasErrors() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid filter parameters",
+			"details": errs,
+		})
+		return nil, 0, false
+	}
+
+	for _, filter := range filters {
+		condition, args := filter.SQL()
+		query = query.Where(condition, args...)
+	}
+
+	return query, len(filters), true
+}
+
+// respondList answers a list request with a page of query. Lists are
+// paginated with cursors when they are enabled for the table and no page
+// is requested, and by page and limit otherwise. filtered tells the count
+// whether query selects only some of the table's rows; lists of tables
+// that soft delete always do, as they leave out deleted rows.
+func (g *CRUDHandlerGenerator) respondList(c *gin.Context, query *gorm.DB, table *TableInfo, config *TableConfig, filtered bool, success, failure string) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", fmt.Sprintf("%d", config.Pagination.DefaultLimit)))
+	if limit > config.Pagination.MaxLimit {
+		limit = config.Pagination.MaxLimit
+	}
+	if limit < 1 {
+		limit = config.Pagination.DefaultLimit
+	}
+
+	expansions, ok := g.expansions(c, table)
+	if !ok {
+		return
+	}
+
+	// The query is counted and fetched separately
+	query = query.Session(&gorm.Session{})
+
+	total, estimated, err := CountRows(query, table.Name, config.Pagination.Count, filtered || g.config.SoftDeletes(table))
+	if err != nil {
+		g.logger.Error("Failed to count records", zap.String("table", table.Name), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponseData(failure))
+		return
+	}
+
+	pagination := gin.H{
+		"limit": limit,
+	}
+	if total != nil {
+		pagination["total"] = *total
+		pagination["total_estimated"] = estimated
+	}
+
+	// Hidden columns cannot order the list, or cursors would reveal them
+	visible := g.config.FieldPolicy(table).visible(table)
+	sort := g.sortFields(c, visible, config)
+	keyset, keysetErr := NewKeyset(visible, sort)
+
+	// Paginate with cursors
+	if _, paged := c.GetQuery("page"); config.Pagination.EnableCursor && keysetErr == nil && !paged {
+		page, err := keyset.Page(query, g.cursors, c.Query(g.cursorParam(config)), limit)
+		if errors.Is(err, ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponseData("Invalid cursor"))
+			return
+		}
+		if err != nil {
+			g.logger.Error("Failed to fetch records", zap.String("table", table.Name), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponseData(failure))
+			return
+		}
+
+		if !g.expandRows(c, page.Rows, expansions, failure) {
+			return
+		}
+		g.conceal(page.Rows, table)
+
+		pagination["has_next"] = page.HasNext
+		pagination["has_prev"] = page.HasPrev
+		pagination["next_cursor"] = nullableCursor(page.Next)
+		pagination["prev_cursor"] = nullableCursor(page.Prev)
+
+		c.JSON(http.StatusOK, utils.SuccessResponseData(success, gin.H{
+			"data":       page.Rows,
+			"pagination": pagination,
+		}))
+		return
+	}
+
+	// Paginate by page
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+
+	if keysetErr == nil {
+		query = query.Order(keyset.Order(false))
+	} else {
+		for _, field := range sort {
+			query = query.Order(field.SQL())
+		}
+	}
+
+	// One more row than the page tells whether another page follows
+	var results []map[string]interface{}
+	if err := query.Offset((page - 1) * limit).Limit(limit + 1).Find(&results).Error; err != nil {
+		g.logger.Error("Failed to fetch records", zap.String("table", table.Name), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponseData(failure))
+		return
+	}
+
+	hasNext := len(results) > limit
+	if hasNext {
+		results = results[:limit]
+	}
+
+	if !g.expandRows(c, results, expansions, failure) {
+		return
+	}
+	g.conceal(results, table)
+
+	pagination["page"] = page
+	pagination["has_next"] = hasNext
+	pagination["has_prev"] = page > 1
+	if total != nil {
+		pagination["total_pages"] = int((*total + int64(limit) - 1) / int64(limit))
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponseData(success, gin.H{
+		"data":       results,
+		"pagination": pagination,
+	}))
+}
+
+// sortFields returns the order of a list request: the allowed fields of
+// sort, each ascending or as order says unless it names a direction, or
+// else the table's default sort
+func (g *CRUDHandlerGenerator) sortFields(c *gin.Context, table *TableInfo, config *TableConfig) []SortField {
+	if config.Sorting == nil {
 		return nil
 	}
 
-	for _, field := range config.Filtering.AllowedFields {
-		value := c.Query(field)
-		if value == "" {
-			continue
+	var fields []SortField
+	if spec := c.Query("sort"); spec != "" {
+		requested := ParseSort(spec)
+		if !strings.Contains(spec, ":") {
+			desc := strings.EqualFold(c.Query("order"), "desc")
+			for i := range requested {
+				requested[i].Desc = desc
+			}
+		}
+		if !config.Sorting.MultiSort && len(requested) > 1 {
+			requested = requested[:1]
 		}
+		for _, field := range requested {
+			if g.isAllowedSortField(field.Column, config.Sorting.AllowedFields) && g.hasColumn(table, field.Column) {
+				fields = append(fields, field)
+			}
+		}
+	}
 
-		// Apply filter based on field type
-		// For now, use ILIKE for all fields - in production you'd check field types
-		query = query.Where(fmt.Sprintf("%s ILIKE ?", field), "%"+value+"%")
+	if len(fields) == 0 {
+		for _, field := range ParseSort(config.Sorting.DefaultSort) {
+			if g.hasColumn(table, field.Column) {
+				fields = append(fields, field)
+			}
+		}
 	}
 
-	return nil
+	return fields
 }
 
-func (g *CRUDHandlerGenerator) applyJoins(query *gorm.DB, table *TableInfo, config *TableConfig) error {
-	// This would apply joins based on foreign keys
-	// For now, return nil
-	return nil
+// cursorParam returns the query parameter holding the cursor
+func (g *CRUDHandlerGenerator) cursorParam(config *TableConfig) string {
+	if config.Pagination != nil && config.Pagination.CursorParam != "" {
+		return config.Pagination.CursorParam
+	}
+	return "cursor"
+}
+
+// nullableCursor returns nil for an empty cursor so it is sent as null
+func nullableCursor(cursor string) interface{} {
+	if cursor == "" {
+		return nil
+	}
+	return cursor
+}
+
+// reservedParams are the query parameters of list handlers that are never
+// filters, even on tables with columns of the same name
+func (g *CRUDHandlerGenerator) reservedParams(config *TableConfig) map[string]bool {
+	reserved := map[string]bool{
+		"page": true, "limit": true, "sort": true, "order": true,
+		"q": true, "format": true, "fields": true, "expand": true,
+		g.cursorParam(config): true,
+	}
+	if config.Pagination != nil {
+		for _, param := range []string{
+			config.Pagination.PageParam,
+			config.Pagination.LimitParam,
+			config.Pagination.SortParam,
+			config.Pagination.OrderParam,
+		} {
+			if param != "" {
+				reserved[param] = true
+			}
+		}
+	}
+	return reserved
+}
+
+// expansions reads the relationships the request asks to embed. Invalid
+// ones are answered with 400 and the errors, and relationships whose
+// records the caller may not read with 403; false is returned then.
+func (g *CRUDHandlerGenerator) expansions(c *gin.Context, table *TableInfo) ([]*expansion, bool) {
+	expansions, errs := g.parseExpand(c.Query("expand"), table)
+	if errs.HasErrors() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid expand parameter",
+			"details": errs,
+		})
+		return nil, false
+	}
+	if !g.authorizeExpansions(c, expansions) {
+		return nil, false
+	}
+	return expansions, true
+}
+
+// authorizeExpansions checks that the caller may read the related records
+// of expansions and of their nested expansions, with the get or list
+// permissions of the related table
+func (g *CRUDHandlerGenerator) authorizeExpansions(c *gin.Context, expansions []*expansion) bool {
+	for _, exp := range expansions {
+		resource, actions, ok := g.config.RequiredPermissions(exp.relation.Table.Name, exp.relation.readEndpoint())
+		if ok {
+			if g.guard == nil {
+				c.JSON(http.StatusForbidden, utils.ErrorResponseData("Insufficient permissions"))
+				return false
+			}
+			if !g.guard.Allowed(c, resource, actions...) {
+				return false
+			}
+		}
+		if !g.authorizeExpansions(c, exp.children) {
+			return false
+		}
+	}
+	return true
+}
+
+// expandRows embeds the related rows of expansions in rows. Failures are
+// answered with an error response, and false is returned.
+func (g *CRUDHandlerGenerator) expandRows(c *gin.Context, rows []map[string]interface{}, expansions []*expansion, failure string) bool {
+	err := g.expand(rows, expansions)
+	if errors.Is(err, errExpandTooLarge) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponseData("Too many related records to expand, use the relationship endpoint"))
+		return false
+	}
+	if err != nil {
+		g.logger.Error("Failed to expand relationships", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponseData(failure))
+		return false
+	}
+	return true
+}
+
+// records returns the query of the rows of table on db. Rows of tables
+// that soft delete are left out once deleted.
+func (g *CRUDHandlerGenerator) records(db *gorm.DB, table *TableInfo) *gorm.DB {
+	query := db.Table(table.Name)
+	if g.config.SoftDeletes(table) {
+		query = query.Where(`"deleted_at" IS NULL`)
+	}
+	return query
+}
+
+// remove deletes the rows of query, a query of records, setting their
+// deleted_at column when the table soft deletes
+func (g *CRUDHandlerGenerator) remove(query *gorm.DB, table *TableInfo) *gorm.DB {
+	if g.config.SoftDeletes(table) {
+		return query.Update("deleted_at", time.Now())
+	}
+	return query.Delete(nil)
+}
+
+// conceal removes the columns of table that may not be returned from rows
+func (g *CRUDHandlerGenerator) conceal(rows []map[string]interface{}, table *TableInfo) {
+	policy := g.config.FieldPolicy(table)
+	for _, row := range rows {
+		for column := range row {
+			if !policy.Readable(column) {
+				delete(row, column)
+			}
+		}
+	}
+}
+
+// writeErrors returns the errors of the fields of data the request may not
+// write to table when creating or updating a record. Hidden fields are
+// unknown, like fields that are not columns of the table.
+func (g *CRUDHandlerGenerator) writeErrors(c *gin.Context, data map[string]interface{}, table *TableInfo, create bool) utils.ValidationErrors {
+	policy := g.config.FieldPolicy(table)
+	errs := utils.NewValidationErrors()
+	for field := range data {
+		switch {
+		case !g.hasColumn(table, field) || !policy.Readable(field):
+			errs.Add(field, "Unknown field")
+		case !policy.Creatable(field):
+			errs.Add(field, "Field is read only")
+		case !create && !policy.Updatable(field):
+			errs.Add(field, "Field can only be set when the record is created")
+		case policy.AdminOnly(field) && !c.GetBool("is_admin"):
+			errs.Add(field, "Only admins may set this field")
+		}
+	}
+	return errs
+}
+
+// checkWrite answers 400 with the errors of the fields of data the request
+// may not write, and returns false
+func (g *CRUDHandlerGenerator) checkWrite(c *gin.Context, data map[string]interface{}, table *TableInfo, create bool) bool {
+	if errs := g.writeErrors(c, data, table, create); errs.HasErrors() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid fields",
+			"details": errs,
+		})
+		return false
+	}
+	return true
+}
+
+// describeErrors returns the field errors as one message, in field order
+func describeErrors(errs utils.ValidationErrors) string {
+	fields := make([]string, 0, len(errs))
+	for field := range errs {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field + ": " + errs[field]
+	}
+	return strings.Join(messages, "; ")
+}
+
+// findRecord fetches the record of the id path parameter. Missing records
+// are answered with 404, and false is returned.
+func (g *CRUDHandlerGenerator) findRecord(c *gin.Context, table *TableInfo) (map[string]interface{}, bool) {
+	var record map[string]interface{}
+	if err := g.records(g.db, table).Where("id = ?", c.Param("id")).First(&record).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, utils.ErrorResponseData("Record not found"))
+			return nil, false
+		}
+		g.logger.Error("Failed to fetch record", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to fetch record"))
+		return nil, false
+	}
+	return record, true
 }
 
 func (g *CRUDHandlerGenerator) validateData(data map[string]interface{}, table *TableInfo, config *TableConfig, operation string) error {
@@ -0,0 +1,35 @@
+package generator
+
+import "strings"
+
+// testTable returns a table whose columns are given as a name, a type and
+// any of the flags pk, null and default, e.g. "created_at timestamp with
+// time zone default"
+func testTable(name string, columns ...string) *TableInfo {
+	table := &TableInfo{Name: name}
+	for _, spec := range columns {
+		words := strings.Fields(spec)
+		column := ColumnInfo{Name: words[0]}
+
+		typeWords := words[1:]
+	flags:
+		for len(typeWords) > 1 {
+			switch typeWords[len(typeWords)-1] {
+			case "pk":
+				column.IsPrimaryKey = true
+			case "null":
+				column.IsNullable = true
+			case "default":
+				value := "default"
+				column.DefaultValue = &value
+			default:
+				break flags
+			}
+			typeWords = typeWords[:len(typeWords)-1]
+		}
+		column.Type = strings.Join(typeWords, " ")
+
+		table.Columns = append(table.Columns, column)
+	}
+	return table
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"go-mobile-backend-template/internal/middleware"
 	"go-mobile-backend-template/pkg/config"
 
 	"github.com/gin-gonic/gin"
@@ -18,6 +19,7 @@ type APIGeneratorMain struct {
 	config         *GeneratorConfig
 	schemaAnalyzer *SchemaAnalyzer
 	routeGen       *RouteGenerator
+	guard          *middleware.RouteGuard
 }
 
 // NewAPIGeneratorMain creates a new main generator instance
@@ -38,6 +40,12 @@ func NewAPIGeneratorMain(db *gorm.DB, logger *zap.Logger, cfg *config.Config) *A
 	}
 }
 
+// SetGuard sets the guard that protects the routes of GenerateForTable
+func (g *APIGeneratorMain) SetGuard(guard *middleware.RouteGuard) {
+	g.guard = guard
+	g.routeGen.SetGuard(guard)
+}
+
 // GenerateAll generates all APIs using file-based approach
 func (g *APIGeneratorMain) GenerateAll() error {
 	if !g.config.Enabled {
@@ -283,6 +291,7 @@ func (g *APIGeneratorMain) UpdateConfig(config *GeneratorConfig) error {
 
 	g.config = config
 	g.routeGen = NewRouteGenerator(g.db, g.logger, config)
+	g.routeGen.SetGuard(g.guard)
 
 	return nil
 }
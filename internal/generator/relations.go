@@ -0,0 +1,407 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go-mobile-backend-template/internal/utils"
+)
+
+// Relation kinds
+const (
+	// RelationBelongsTo follows a foreign key of the table, e.g. files.user
+	RelationBelongsTo = "belongs_to"
+	// RelationHasMany follows foreign keys referencing the table, e.g.
+	// users.files
+	RelationHasMany = "has_many"
+	// RelationManyToMany follows a join table, e.g. users.roles through
+	// user_roles
+	RelationManyToMany = "many_to_many"
+)
+
+const (
+	// maxExpandDepth bounds the nesting of expand, e.g. files.user.roles
+	// is 3 deep
+	maxExpandDepth = 3
+
+	// maxExpandRows bounds the rows loaded for one relation of an expand
+	maxExpandRows = 1000
+)
+
+// errExpandTooLarge is returned when an expansion would load more than
+// maxExpandRows rows
+var errExpandTooLarge = errors.New("too many related rows to expand")
+
+// Relation is a relationship between two tables found from their foreign
+// keys
+type Relation struct {
+	Name string
+	Kind string
+	// Table is the related table
+	Table *TableInfo
+	// Key is the column of the table the relationship starts from that
+	// matches RelatedKey of the related table, directly or through Join
+	Key        string
+	RelatedKey string
+	// Join is the join table of a many to many relationship, with the
+	// columns referencing Key and RelatedKey
+	Join           *TableInfo
+	JoinKey        string
+	JoinRelatedKey string
+}
+
+// relationCatalog resolves relationship names to relations from the
+// foreign keys of the database's tables
+type relationCatalog struct {
+	analyzer *SchemaAnalyzer
+	mu       sync.Mutex
+	tables   map[string]*TableInfo
+	loaded   bool
+}
+
+func newRelationCatalog(analyzer *SchemaAnalyzer) *relationCatalog {
+	return &relationCatalog{
+		analyzer: analyzer,
+		tables:   make(map[string]*TableInfo),
+	}
+}
+
+// add makes tables known to the catalog, replacing earlier versions
+func (rc *relationCatalog) add(tables []*TableInfo) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for _, table := range tables {
+		rc.tables[table.Name] = table
+	}
+}
+
+// load discovers the tables the first time it is called. The catalog
+// mutex must be held.
+func (rc *relationCatalog) load() error {
+	if rc.loaded {
+		return nil
+	}
+
+	tables, err := rc.analyzer.DiscoverTables()
+	if err != nil {
+		return fmt.Errorf("failed to discover tables: %w", err)
+	}
+	for _, table := range tables {
+		if _, ok := rc.tables[table.Name]; !ok {
+			rc.tables[table.Name] = table
+		}
+	}
+	rc.loaded = true
+	return nil
+}
+
+// resolve returns the relation name of table. A foreign key of table whose
+// column is name_id or that references a table called name is a belongs to
+// relation; a table called name with a foreign key to table is a has many
+// relation; and a table with foreign keys to both table and a table called
+// name joins them many to many.
+func (rc *relationCatalog) resolve(table *TableInfo, name string) (*Relation, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if err := rc.load(); err != nil {
+		return nil, err
+	}
+	tables := rc.tables
+
+	// A foreign key named after the relationship wins over one that only
+	// references the table of that name
+	for _, byColumn := range []bool{true, false} {
+		for _, fk := range table.ForeignKeys {
+			if byColumn && strings.TrimSuffix(fk.Column, "_id") != name ||
+				!byColumn && fk.RefTable != name {
+				continue
+			}
+			if related, ok := tables[fk.RefTable]; ok {
+				return &Relation{
+					Name:       name,
+					Kind:       RelationBelongsTo,
+					Table:      related,
+					Key:        fk.Column,
+					RelatedKey: fk.RefColumn,
+				}, nil
+			}
+		}
+	}
+
+	related, ok := tables[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown relationship %s of %s", name, table.Name)
+	}
+
+	// Tables may reference another more than once, e.g. user_roles.user_id
+	// and user_roles.assigned_by; required columns are preferred as they
+	// hold the relationship rather than metadata about it
+	for _, required := range []bool{true, false} {
+		for _, fk := range related.ForeignKeys {
+			if fk.RefTable == table.Name && (!required || isRequired(related, fk.Column)) {
+				return &Relation{
+					Name:       name,
+					Kind:       RelationHasMany,
+					Table:      related,
+					Key:        fk.RefColumn,
+					RelatedKey: fk.Column,
+				}, nil
+			}
+		}
+	}
+
+	// Join tables are tried in name order so the choice is stable
+	names := make([]string, 0, len(tables))
+	for joinName := range tables {
+		names = append(names, joinName)
+	}
+	sort.Strings(names)
+
+	for _, required := range []bool{true, false} {
+		for _, joinName := range names {
+			join := tables[joinName]
+			if join.Name == table.Name || join.Name == related.Name {
+				continue
+			}
+			for _, local := range join.ForeignKeys {
+				if local.RefTable != table.Name || required && !isRequired(join, local.Column) {
+					continue
+				}
+				for _, remote := range join.ForeignKeys {
+					if remote.RefTable != related.Name || remote.Column == local.Column ||
+						required && !isRequired(join, remote.Column) {
+						continue
+					}
+					return &Relation{
+						Name:           name,
+						Kind:           RelationManyToMany,
+						Table:          related,
+						Key:            local.RefColumn,
+						RelatedKey:     remote.RefColumn,
+						Join:           join,
+						JoinKey:        local.Column,
+						JoinRelatedKey: remote.Column,
+					}, nil
+				}
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no foreign key relates %s to %s", table.Name, name)
+}
+
+// isRequired reports whether column of table is NOT NULL
+func isRequired(table *TableInfo, column string) bool {
+	for _, col := range table.Columns {
+		if col.Name == column {
+			return !col.IsNullable
+		}
+	}
+	return false
+}
+
+// expansion is a relation to embed in rows and the relations to embed in
+// its rows
+type expansion struct {
+	relation *Relation
+	children []*expansion
+}
+
+// readEndpoint returns the endpoint type whose permissions reading the
+// related records of r requires: get for the one record of a belongs to
+// relation and list otherwise
+func (r *Relation) readEndpoint() string {
+	if r.Kind == RelationBelongsTo {
+		return "get"
+	}
+	return "list"
+}
+
+// parseExpand reads the expand parameter of a request, such as
+// "user,roles.permissions", into the expansions of table. Each name must
+// be a configured relationship of the table it is expanded from.
+func (g *CRUDHandlerGenerator) parseExpand(spec string, table *TableInfo) ([]*expansion, utils.ValidationErrors) {
+	errs := utils.NewValidationErrors()
+	if spec == "" {
+		return nil, errs
+	}
+
+	var expansions []*expansion
+	for _, path := range strings.Split(spec, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		names := strings.Split(path, ".")
+		if len(names) > maxExpandDepth {
+			errs.Add("expand", fmt.Sprintf("%s is nested deeper than %d", path, maxExpandDepth))
+			continue
+		}
+
+		level, from := &expansions, table
+		for _, name := range names {
+			var found *expansion
+			for _, existing := range *level {
+				if existing.relation.Name == name {
+					found = existing
+					break
+				}
+			}
+
+			if found == nil {
+				if !contains(g.config.GetTableConfig(from.Name).Relationships, name) {
+					errs.Add("expand", fmt.Sprintf("%s is not a relationship of %s", name, from.Name))
+					break
+				}
+				relation, err := g.relations.resolve(from, name)
+				if err != nil {
+					errs.Add("expand", fmt.Sprintf("%s is not a relationship of %s", name, from.Name))
+					break
+				}
+				found = &expansion{relation: relation}
+				*level = append(*level, found)
+			}
+
+			level, from = &found.children, found.relation.Table
+		}
+	}
+
+	return expansions, errs
+}
+
+// expand embeds the related rows of each expansion in rows: a row or nil
+// for belongs to relations and a list otherwise. Each relation is loaded
+// with one query for all rows, two for many to many relations. Hidden
+// columns of the related rows are removed once their own expansions are
+// embedded; those of rows are left to the caller.
+func (g *CRUDHandlerGenerator) expand(rows []map[string]interface{}, expansions []*expansion) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	for _, e := range expansions {
+		relation := e.relation
+
+		// Collect the distinct keys of the rows
+		var keys []interface{}
+		seen := make(map[string]bool)
+		for _, row := range rows {
+			key := relationKey(row[relation.Key])
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			keys = append(keys, row[relation.Key])
+		}
+
+		var related []map[string]interface{}
+		grouped := make(map[string][]map[string]interface{})
+
+		if len(keys) > 0 {
+			switch relation.Kind {
+			case RelationBelongsTo, RelationHasMany:
+				if err := g.loadRelated(relation, keys, &related); err != nil {
+					return err
+				}
+				for _, row := range related {
+					key := relationKey(row[relation.RelatedKey])
+					grouped[key] = append(grouped[key], row)
+				}
+
+			case RelationManyToMany:
+				var links []map[string]interface{}
+				err := g.records(g.db, relation.Join).
+					Select(quoteColumn(relation.JoinKey), quoteColumn(relation.JoinRelatedKey)).
+					Where(quoteColumn(relation.JoinKey)+" IN ?", keys).
+					Limit(maxExpandRows + 1).
+					Find(&links).Error
+				if err != nil {
+					return err
+				}
+				if len(links) > maxExpandRows {
+					return errExpandTooLarge
+				}
+
+				var relatedKeys []interface{}
+				relatedSeen := make(map[string]bool)
+				for _, link := range links {
+					key := relationKey(link[relation.JoinRelatedKey])
+					if key != "" && !relatedSeen[key] {
+						relatedSeen[key] = true
+						relatedKeys = append(relatedKeys, link[relation.JoinRelatedKey])
+					}
+				}
+
+				if len(relatedKeys) > 0 {
+					if err := g.loadRelated(relation, relatedKeys, &related); err != nil {
+						return err
+					}
+				}
+				byKey := make(map[string]map[string]interface{}, len(related))
+				for _, row := range related {
+					byKey[relationKey(row[relation.RelatedKey])] = row
+				}
+				for _, link := range links {
+					if row, ok := byKey[relationKey(link[relation.JoinRelatedKey])]; ok {
+						key := relationKey(link[relation.JoinKey])
+						grouped[key] = append(grouped[key], row)
+					}
+				}
+			}
+		}
+
+		for _, row := range rows {
+			matches := grouped[relationKey(row[relation.Key])]
+			if relation.Kind == RelationBelongsTo {
+				if len(matches) > 0 {
+					row[relation.Name] = matches[0]
+				} else {
+					row[relation.Name] = nil
+				}
+				continue
+			}
+			if matches == nil {
+				matches = []map[string]interface{}{}
+			}
+			row[relation.Name] = matches
+		}
+
+		if err := g.expand(related, e.children); err != nil {
+			return err
+		}
+		g.conceal(related, relation.Table)
+	}
+
+	return nil
+}
+
+// loadRelated loads the rows of the related table whose RelatedKey is one
+// of keys
+func (g *CRUDHandlerGenerator) loadRelated(relation *Relation, keys []interface{}, related *[]map[string]interface{}) error {
+	err := g.records(g.db, relation.Table).
+		Where(quoteColumn(relation.RelatedKey)+" IN ?", keys).
+		Limit(maxExpandRows + 1).
+		Find(related).Error
+	if err != nil {
+		return err
+	}
+	if len(*related) > maxExpandRows {
+		return errExpandTooLarge
+	}
+	return nil
+}
+
+// relationKey returns the text form of a key value so keys of different Go
+// types match, or "" for NULL
+func relationKey(value interface{}) string {
+	if text := cursorValue(value); text != nil {
+		return *text
+	}
+	return ""
+}
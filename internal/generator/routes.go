@@ -1,14 +1,22 @@
 package generator
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"go-mobile-backend-template/internal/middleware"
+	"go-mobile-backend-template/internal/services/auth"
+	"go-mobile-backend-template/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // RouteGenerator generates routes for generated endpoints
@@ -17,6 +25,7 @@ type RouteGenerator struct {
 	logger     *zap.Logger
 	config     *GeneratorConfig
 	handlerGen *CRUDHandlerGenerator
+	guard      *middleware.RouteGuard
 }
 
 // NewRouteGenerator creates a new route generator
@@ -29,10 +38,20 @@ func NewRouteGenerator(db *gorm.DB, logger *zap.Logger, config *GeneratorConfig)
 	}
 }
 
+// SetGuard sets the guard that protects the generated routes. Routes are
+// not generated without one.
+func (g *RouteGenerator) SetGuard(guard *middleware.RouteGuard) {
+	g.guard = guard
+	g.handlerGen.guard = guard
+}
+
 // GenerateRoutes generates all routes for discovered tables
 func (g *RouteGenerator) GenerateRoutes(router *gin.Engine, tables []*TableInfo) error {
 	g.logger.Info("Generating routes for tables", zap.Int("count", len(tables)))
 
+	// Relationships resolve against these tables
+	g.handlerGen.relations.add(tables)
+
 	// Create API v1 group
 	apiV1 := router.Group("/api/v1")
 
@@ -117,67 +136,68 @@ func (g *RouteGenerator) generateTableRoutes(router *gin.RouterGroup, table *Tab
 // registerRoute registers a specific route
 func (g *RouteGenerator) registerRoute(router *gin.RouterGroup, table *TableInfo, endpointType string, handler gin.HandlerFunc, config *TableConfig) error {
 	tableName := strings.ToLower(table.Name)
+	handlers := g.endpointHandlers(table, endpointType, handler)
 
 	switch endpointType {
 	case "list":
-		router.GET("", handler)
+		router.GET("", handlers...)
 		g.logger.Debug("Registered route",
 			zap.String("method", "GET"),
 			zap.String("path", "/api/v1/"+tableName),
 			zap.String("handler", "List"+g.toCamelCase(table.Name)))
 
 	case "create":
-		router.POST("", handler)
+		router.POST("", handlers...)
 		g.logger.Debug("Registered route",
 			zap.String("method", "POST"),
 			zap.String("path", "/api/v1/"+tableName),
 			zap.String("handler", "Create"+g.toCamelCase(table.Name)))
 
 	case "get":
-		router.GET("/:id", handler)
+		router.GET("/:id", handlers...)
 		g.logger.Debug("Registered route",
 			zap.String("method", "GET"),
 			zap.String("path", "/api/v1/"+tableName+"/:id"),
 			zap.String("handler", "Get"+g.toCamelCase(table.Name)))
 
 	case "update":
-		router.PUT("/:id", handler)
-		router.PATCH("/:id", handler) // Also support PATCH for partial updates
+		router.PUT("/:id", handlers...)
+		router.PATCH("/:id", handlers...) // Also support PATCH for partial updates
 		g.logger.Debug("Registered route",
 			zap.String("method", "PUT/PATCH"),
 			zap.String("path", "/api/v1/"+tableName+"/:id"),
 			zap.String("handler", "Update"+g.toCamelCase(table.Name)))
 
 	case "delete":
-		router.DELETE("/:id", handler)
+		router.DELETE("/:id", handlers...)
 		g.logger.Debug("Registered route",
 			zap.String("method", "DELETE"),
 			zap.String("path", "/api/v1/"+tableName+"/:id"),
 			zap.String("handler", "Delete"+g.toCamelCase(table.Name)))
 
 	case "bulk":
-		router.POST("/bulk", handler)
+		router.POST("/bulk", handlers...)
 		g.logger.Debug("Registered route",
 			zap.String("method", "POST"),
 			zap.String("path", "/api/v1/"+tableName+"/bulk"),
 			zap.String("handler", "Bulk"+g.toCamelCase(table.Name)))
 
 	case "search":
-		router.GET("/search", handler)
+		router.GET("/search", handlers...)
 		g.logger.Debug("Registered route",
 			zap.String("method", "GET"),
 			zap.String("path", "/api/v1/"+tableName+"/search"),
 			zap.String("handler", "Search"+g.toCamelCase(table.Name)))
 
 	case "stats":
-		router.GET("/stats", handler)
+		router.GET("/stats", handlers...)
 		g.logger.Debug("Registered route",
 			zap.String("method", "GET"),
 			zap.String("path", "/api/v1/"+tableName+"/stats"),
 			zap.String("handler", "Stats"+g.toCamelCase(table.Name)))
 
 	case "export":
-		router.GET("/export", handler)
+		router.GET("/export", handlers...)
 		g.logger.Debug("Registered route",
 			zap.String("method", "GET"),
 			zap.String("path", "/api/v1/"+tableName+"/export"),
@@ -204,19 +224,22 @@ func (g *RouteGenerator) generateRelationshipRoutes(router *gin.RouterGroup, tab
 			continue
 		}
 
-		// Generate relationship handlers
-		handlers, err := g.generateRelationshipHandlers(table, relationship, config)
+		relation, err := g.handlerGen.relations.resolve(table, relationship)
 		if err != nil {
-			g.logger.Error("Failed to generate relationship handlers",
+			g.logger.Error("Failed to resolve relationship",
 				zap.String("table", table.Name),
 				zap.String("relationship", relationship),
 				zap.Error(err))
 			continue
 		}
 
-		// Register relationship routes
+		// Generate relationship handlers
+		handlers := g.generateRelationshipHandlers(table, relation, config)
+
+		// Register relationship routes, with the permissions of both the
+		// record and the related records they read or change
 		for method, handler := range handlers {
-			relGroup.Handle(method, "", handler)
+			relGroup.Handle(method, "", g.relationshipHandlers(table, relation, method, handler)...)
 			g.logger.Debug("Registered relationship route",
 				zap.String("method", method),
 				zap.String("path", "/api/v1/"+strings.ToLower(table.Name)+"/:id/"+relationship),
@@ -228,73 +251,461 @@ func (g *RouteGenerator) generateRelationshipRoutes(router *gin.RouterGroup, tab
 	return nil
 }
 
-// generateRelationshipHandlers generates handlers for table relationships
-func (g *RouteGenerator) generateRelationshipHandlers(table *TableInfo, relationship string, config *TableConfig) (map[string]gin.HandlerFunc, error) {
+// generateRelationshipHandlers generates handlers for a table relationship
+func (g *RouteGenerator) generateRelationshipHandlers(table *TableInfo, relation *Relation, config *TableConfig) map[string]gin.HandlerFunc {
 	handlers := make(map[string]gin.HandlerFunc)
 
 	// Generate list relationship handler
-	handlers["GET"] = g.generateListRelationshipHandler(table, relationship, config)
+	handlers["GET"] = g.generateListRelationshipHandler(table, relation, config)
 
 	// Generate create relationship handler
-	handlers["POST"] = g.generateCreateRelationshipHandler(table, relationship, config)
+	handlers["POST"] = g.generateCreateRelationshipHandler(table, relation, config)
 
 	// Generate delete relationship handler
-	handlers["DELETE"] = g.generateDeleteRelationshipHandler(table, relationship, config)
+	handlers["DELETE"] = g.generateDeleteRelationshipHandler(table, relation, config)
 
-	return handlers, nil
+	return handlers
 }
 
-// generateListRelationshipHandler generates a handler to list related records
-func (g *RouteGenerator) generateListRelationshipHandler(table *TableInfo, relationship string, config *TableConfig) gin.HandlerFunc {
+// relationshipHandlers returns the handlers of a relationship route. Reading
+// needs the get permissions of the record and the get or list ones of the
+// related table. Changes need the update permissions of the record and, for
+// the rows they write, the create or delete permissions of a many to many
+// relation's join table, or the create or update ones of a has many
+// relation's related table.
+func (g *RouteGenerator) relationshipHandlers(table *TableInfo, relation *Relation, method string, handler gin.HandlerFunc) []gin.HandlerFunc {
+	if method == http.MethodGet {
+		handlers := g.requirePermissions(table, "get")
+		handlers = append(handlers, g.requirePermissions(relation.Table, relation.readEndpoint())...)
+		return append(handlers, handler)
+	}
+
+	handlers := g.requirePermissions(table, "update")
+	switch {
+	case relation.Kind == RelationManyToMany && method == http.MethodPost:
+		handlers = append(handlers, g.requirePermissions(relation.Join, "create")...)
+	case relation.Kind == RelationManyToMany && method == http.MethodDelete:
+		handlers = append(handlers, g.requirePermissions(relation.Join, "delete")...)
+	case relation.Kind == RelationHasMany && method == http.MethodPost:
+		handlers = append(handlers, g.requirePermissions(relation.Table, "create")...)
+	case relation.Kind == RelationHasMany && method == http.MethodDelete:
+		handlers = append(handlers, g.requirePermissions(relation.Table, "update")...)
+	}
+	return append(handlers, handler)
+}
+
+// generateListRelationshipHandler generates a handler to list related
+// records. Has many and many to many relations are listed like the related
+// table, with its filters, sorting and pagination; a belongs to relation
+// returns its record or null.
+func (g *RouteGenerator) generateListRelationshipHandler(table *TableInfo, relation *Relation, config *TableConfig) gin.HandlerFunc {
+	relatedConfig := g.config.GetTableConfig(relation.Table.Name)
+
 	return func(c *gin.Context) {
-		// This would implement listing related records
-		// For now, return a placeholder response
-		c.JSON(200, gin.H{
-			"message": fmt.Sprintf("List %s for %s", relationship, table.Name),
-			"data":    []interface{}{},
-		})
+		parent, ok := g.handlerGen.findRecord(c, table)
+		if !ok {
+			return
+		}
+
+		query := g.handlerGen.records(g.db, relation.Table)
+
+		switch relation.Kind {
+		case RelationBelongsTo:
+			expansions, ok := g.handlerGen.expansions(c, relation.Table)
+			if !ok {
+				return
+			}
+
+			var related map[string]interface{}
+			if key := parent[relation.Key]; key != nil {
+				err := query.Where(quoteColumn(relation.RelatedKey)+" = ?", key).Take(&related).Error
+				if err != nil && err != gorm.ErrRecordNotFound {
+					g.logger.Error("Failed to fetch related record", zap.Error(err))
+					c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to fetch record"))
+					return
+				}
+			}
+
+			if related != nil && !g.handlerGen.expandRows(c, []map[string]interface{}{related}, expansions, "Failed to fetch record") {
+				return
+			}
+			g.handlerGen.conceal([]map[string]interface{}{related}, relation.Table)
+
+			c.JSON(http.StatusOK, utils.SuccessResponseData("Record retrieved successfully", gin.H{
+				"data": related,
+			}))
+			return
+
+		case RelationHasMany:
+			query = query.Where(quoteColumn(relation.RelatedKey)+" = ?", parent[relation.Key])
+
+		case RelationManyToMany:
+			linked := g.handlerGen.records(g.db, relation.Join).
+				Select(quoteColumn(relation.JoinRelatedKey)).
+				Where(quoteColumn(relation.JoinKey)+" = ?", parent[relation.Key])
+			query = query.Where(quoteColumn(relation.RelatedKey)+" IN (?)", linked)
+		}
+
+		query, _, ok = g.handlerGen.applyFilters(query, c, relation.Table, relatedConfig)
+		if !ok {
+			return
+		}
+
+		g.handlerGen.respondList(c, query, relation.Table, relatedConfig, true, "Records retrieved successfully", "Failed to fetch records")
 	}
 }
 
-// generateCreateRelationshipHandler generates a handler to create relationships
-func (g *RouteGenerator) generateCreateRelationshipHandler(table *TableInfo, relationship string, config *TableConfig) gin.HandlerFunc {
+// generateCreateRelationshipHandler generates a handler to create
+// relationships. Many to many relations link the records whose ids are
+// posted, has many relations create a related record from the body, and
+// a belongs to relation points the record at the posted id.
+func (g *RouteGenerator) generateCreateRelationshipHandler(table *TableInfo, relation *Relation, config *TableConfig) gin.HandlerFunc {
+	relatedConfig := g.config.GetTableConfig(relation.Table.Name)
+
 	return func(c *gin.Context) {
-		// This would implement creating relationships
-		// For now, return a placeholder response
-		c.JSON(201, gin.H{
-			"message": fmt.Sprintf("Create %s for %s", relationship, table.Name),
-			"data":    gin.H{},
-		})
+		parent, ok := g.handlerGen.findRecord(c, table)
+		if !ok {
+			return
+		}
+
+		switch relation.Kind {
+		case RelationManyToMany:
+			ids, ok := g.relationIDs(c, relation.Table, "id")
+			if !ok {
+				return
+			}
+
+			keys, ok := g.relatedKeys(c, relation, ids)
+			if !ok {
+				return
+			}
+
+			links := make([]map[string]interface{}, len(keys))
+			for i, key := range keys {
+				links[i] = map[string]interface{}{
+					relation.JoinKey:        parent[relation.Key],
+					relation.JoinRelatedKey: key,
+				}
+			}
+
+			result := g.db.Table(relation.Join.Name).Clauses(clause.OnConflict{DoNothing: true}).Create(&links)
+			if result.Error != nil {
+				g.logger.Error("Failed to link records", zap.Error(result.Error))
+				c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to link records"))
+				return
+			}
+			g.invalidatePermissions(c.Request.Context(), relation, parent[relation.Key], keys)
+
+			c.JSON(http.StatusCreated, utils.SuccessResponseData("Records linked successfully", gin.H{
+				"linked": result.RowsAffected,
+			}))
+
+		case RelationHasMany:
+			var data map[string]interface{}
+			if err := c.ShouldBindJSON(&data); err != nil {
+				c.JSON(http.StatusBadRequest, utils.ErrorResponseData("Invalid request body"))
+				return
+			}
+			if !g.handlerGen.checkWrite(c, data, relation.Table, true) {
+				return
+			}
+			data[relation.RelatedKey] = parent[relation.Key]
+
+			if err := g.handlerGen.validateData(data, relation.Table, relatedConfig, "create"); err != nil {
+				c.JSON(http.StatusBadRequest, utils.ErrorResponseData(err.Error()))
+				return
+			}
+
+			if relatedConfig.Security != nil && relatedConfig.Security.Timestamps {
+				now := time.Now()
+				data["created_at"] = now
+				data["updated_at"] = now
+			}
+
+			if err := g.db.Table(relation.Table.Name).Create(&data).Error; err != nil {
+				g.logger.Error("Failed to create record", zap.Error(err))
+				c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to create record"))
+				return
+			}
+			g.handlerGen.conceal([]map[string]interface{}{data}, relation.Table)
+
+			c.JSON(http.StatusCreated, utils.SuccessResponseData("Record created successfully", gin.H{
+				"data": data,
+			}))
+
+		case RelationBelongsTo:
+			if !g.handlerGen.checkWrite(c, map[string]interface{}{relation.Key: nil}, table, false) {
+				return
+			}
+			ids, ok := g.relationIDs(c, relation.Table, "id")
+			if !ok {
+				return
+			}
+			if len(ids) != 1 {
+				c.JSON(http.StatusBadRequest, utils.ErrorResponseData("Exactly one id is required"))
+				return
+			}
+
+			keys, ok := g.relatedKeys(c, relation, ids)
+			if !ok {
+				return
+			}
+
+			err := g.db.Table(table.Name).Where("id = ?", c.Param("id")).Update(relation.Key, keys[0]).Error
+			if err != nil {
+				g.logger.Error("Failed to update relationship", zap.Error(err))
+				c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to update relationship"))
+				return
+			}
+
+			c.JSON(http.StatusOK, utils.SuccessResponseData("Relationship updated successfully", gin.H{
+				relation.Key: keys[0],
+			}))
+		}
 	}
 }
 
-// generateDeleteRelationshipHandler generates a handler to delete relationships
-func (g *RouteGenerator) generateDeleteRelationshipHandler(table *TableInfo, relationship string, config *TableConfig) gin.HandlerFunc {
+// generateDeleteRelationshipHandler generates a handler to delete
+// relationships. Many to many relations unlink the records whose ids are
+// given, and has many and belongs to relations clear the foreign key when
+// it is nullable. Related records are never deleted.
+func (g *RouteGenerator) generateDeleteRelationshipHandler(table *TableInfo, relation *Relation, config *TableConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// This would implement deleting relationships
-		// For now, return a placeholder response
-		c.JSON(204, nil)
+		parent, ok := g.handlerGen.findRecord(c, table)
+		if !ok {
+			return
+		}
+
+		var result *gorm.DB
+		var unlinked []interface{}
+		switch relation.Kind {
+		case RelationManyToMany:
+			ids, ok := g.relationIDs(c, relation.Table, "id")
+			if !ok {
+				return
+			}
+			keys, ok := g.relatedKeys(c, relation, ids)
+			if !ok {
+				return
+			}
+			// Links are removed outright, even from join tables that soft
+			// delete, so they can be made again
+			result = g.db.Table(relation.Join.Name).
+				Where(quoteColumn(relation.JoinKey)+" = ?", parent[relation.Key]).
+				Where(quoteColumn(relation.JoinRelatedKey)+" IN ?", keys).
+				Delete(nil)
+			unlinked = keys
+
+		case RelationHasMany:
+			if !g.handlerGen.checkWrite(c, map[string]interface{}{relation.RelatedKey: nil}, relation.Table, false) ||
+				!g.nullable(c, relation.Table, relation.RelatedKey) {
+				return
+			}
+			ids, ok := g.relationIDs(c, relation.Table, "id")
+			if !ok {
+				return
+			}
+			result = g.db.Table(relation.Table.Name).
+				Where(quoteColumn(relation.RelatedKey)+" = ?", parent[relation.Key]).
+				Where("id IN ?", ids).
+				Update(relation.RelatedKey, nil)
+
+		case RelationBelongsTo:
+			if !g.handlerGen.checkWrite(c, map[string]interface{}{relation.Key: nil}, table, false) ||
+				!g.nullable(c, table, relation.Key) {
+				return
+			}
+			result = g.db.Table(table.Name).Where("id = ?", c.Param("id")).Update(relation.Key, nil)
+		}
+
+		if result.Error != nil {
+			g.logger.Error("Failed to unlink records", zap.Error(result.Error))
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to unlink records"))
+			return
+		}
+		if len(unlinked) > 0 {
+			g.invalidatePermissions(c.Request.Context(), relation, parent[relation.Key], unlinked)
+		}
+
+		c.JSON(http.StatusOK, utils.SuccessResponseData("Records unlinked successfully", gin.H{
+			"unlinked": result.RowsAffected,
+		}))
+	}
+}
+
+// invalidatePermissions drops the cached permissions that linking or
+// unlinking parentKey and keys in relation's join table changes, when the
+// join table assigns roles or permissions
+func (g *RouteGenerator) invalidatePermissions(ctx context.Context, relation *Relation, parentKey interface{}, keys []interface{}) {
+	column, ok := auth.PermissionKeyColumn(relation.Join.Name)
+	if !ok || g.guard == nil || g.guard.Permissions() == nil {
+		return
+	}
+	resolver := g.guard.Permissions()
+
+	affected := keys
+	if relation.JoinKey == column {
+		affected = []interface{}{parentKey}
+	}
+
+	var users []uint
+	for _, key := range affected {
+		id, err := strconv.ParseUint(relationKey(key), 10, 64)
+		if err != nil {
+			g.logger.Error("Invalid key in permission join table",
+				zap.String("table", relation.Join.Name),
+				zap.Any("key", key))
+			continue
+		}
+		granted, err := resolver.UsersGrantedBy(ctx, relation.Join.Name, uint(id))
+		if err != nil {
+			g.logger.Error("Failed to find users with changed permissions",
+				zap.String("table", relation.Join.Name),
+				zap.Error(err))
+			continue
+		}
+		users = append(users, granted...)
+	}
+
+	if err := resolver.Invalidate(ctx, users...); err != nil {
+		g.logger.Error("Failed to invalidate cached permissions",
+			zap.String("table", relation.Join.Name),
+			zap.Error(err))
 	}
 }
 
-// applyTableMiddleware applies middleware specific to a table
+// relationIDs reads the ids a relationship request names, from "id" or
+// "ids" in the JSON body or a comma separated ids query parameter, and
+// converts them to the type of column. Invalid ids are answered with 400,
+// and false is returned.
+func (g *RouteGenerator) relationIDs(c *gin.Context, table *TableInfo, column string) ([]interface{}, bool) {
+	var raw []string
+	if ids := c.Query("ids"); ids != "" {
+		raw = strings.Split(ids, ",")
+	} else if c.Request.ContentLength != 0 {
+		var body struct {
+			ID  json.Number   `json:"id"`
+			IDs []json.Number `json:"ids"`
+		}
+		decoder := json.NewDecoder(c.Request.Body)
+		decoder.UseNumber()
+		if err := decoder.Decode(&body); err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponseData("Invalid request body"))
+			return nil, false
+		}
+		if body.ID != "" {
+			raw = append(raw, body.ID.String())
+		}
+		for _, id := range body.IDs {
+			raw = append(raw, id.String())
+		}
+	}
+
+	if len(raw) == 0 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponseData("id or ids is required"))
+		return nil, false
+	}
+	if len(raw) > maxFilterValues {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponseData(fmt.Sprintf("At most %d ids are allowed", maxFilterValues)))
+		return nil, false
+	}
+
+	kind := kindOther
+	for _, col := range table.Columns {
+		if col.Name == column {
+			kind = columnKindOf(col.Type)
+		}
+	}
+
+	ids := make([]interface{}, 0, len(raw))
+	for _, id := range raw {
+		value, err := parseFilterValue(kind, strings.TrimSpace(id))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponseData("Invalid id: "+err.Error()))
+			return nil, false
+		}
+		ids = append(ids, value)
+	}
+
+	return ids, true
+}
+
+// relatedKeys returns the keys the relation matches of the related records
+// with the given ids. Missing records are answered with 404, and false is
+// returned.
+func (g *RouteGenerator) relatedKeys(c *gin.Context, relation *Relation, ids []interface{}) ([]interface{}, bool) {
+	var rows []map[string]interface{}
+	err := g.handlerGen.records(g.db, relation.Table).
+		Select(quoteColumn(relation.RelatedKey)).
+		Where("id IN ?", ids).
+		Find(&rows).Error
+	if err != nil {
+		g.logger.Error("Failed to fetch related records", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponseData("Failed to fetch related records"))
+		return nil, false
+	}
+
+	keys := make([]interface{}, 0, len(rows))
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		key := relationKey(row[relation.RelatedKey])
+		if key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, row[relation.RelatedKey])
+		}
+	}
+
+	if len(rows) < len(uniqueKeys(ids)) {
+		c.JSON(http.StatusNotFound, utils.ErrorResponseData("Related record not found"))
+		return nil, false
+	}
+
+	return keys, true
+}
+
+// nullable reports whether the foreign key column can be cleared. Columns
+// that cannot are answered with 409, and false is returned.
+func (g *RouteGenerator) nullable(c *gin.Context, table *TableInfo, column string) bool {
+	for _, col := range table.Columns {
+		if col.Name == column && col.IsNullable {
+			return true
+		}
+	}
+	c.JSON(http.StatusConflict, utils.ErrorResponseData(fmt.Sprintf("%s.%s cannot be cleared, delete the record instead", table.Name, column)))
+	return false
+}
+
+// uniqueKeys returns the distinct values of keys
+func uniqueKeys(keys []interface{}) map[string]bool {
+	unique := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		unique[relationKey(key)] = true
+	}
+	return unique
+}
+
+// applyTableMiddleware applies middleware specific to a table: requests
+// are authenticated, then rate limited and audited as the table's
+// security is configured
 func (g *RouteGenerator) applyTableMiddleware(router *gin.RouterGroup, table *TableInfo, config *TableConfig) error {
-	// Apply rate limiting
-	if config.Security != nil && config.Security.RateLimit != nil {
-		// This would apply rate limiting middleware
-		// For now, we'll skip it as it requires Redis setup
+	if g.guard == nil {
+		return fmt.Errorf("no route guard is set")
+	}
+
+	router.Use(g.guard.Authenticate())
+
+	if config.Security == nil {
+		return nil
 	}
 
-	// Apply RBAC middleware
-	if config.Security != nil && config.Security.RBAC != nil {
-		// This would apply RBAC middleware
-		// For now, we'll skip it as it requires authentication setup
+	// Apply rate limiting
+	if limit := config.Security.RateLimit; limit != nil && limit.Requests > 0 && limit.Window > 0 {
+		router.Use(g.guard.Limit(table.Name, limit.Allowance(), limit.Window))
 	}
 
 	// Apply audit logging
-	if config.Security != nil && config.Security.AuditLog {
-		// This would apply audit logging middleware
-		// For now, we'll skip it as it requires database setup
+	if config.Security.AuditLog {
+		router.Use(g.guard.Audit())
 	}
 
 	return nil
@@ -302,8 +713,26 @@ func (g *RouteGenerator) applyTableMiddleware(router *gin.RouterGroup, table *Ta
 
 // applyRelationshipMiddleware applies middleware specific to relationships
 func (g *RouteGenerator) applyRelationshipMiddleware(router *gin.RouterGroup, table *TableInfo, relationship string, config *TableConfig) error {
-	// Apply the same middleware as the parent table
-	return g.applyTableMiddleware(router, table, config)
+	// Relationship groups are nested in the table group and already use
+	// its middleware
+	return nil
+}
+
+// endpointHandlers returns the handlers of an endpoint of table: the
+// permission check of its endpoint type when RBAC is configured, then
+// handler
+func (g *RouteGenerator) endpointHandlers(table *TableInfo, endpointType string, handler gin.HandlerFunc) []gin.HandlerFunc {
+	return append(g.requirePermissions(table, endpointType), handler)
+}
+
+// requirePermissions returns the permission check of an endpoint type of
+// table, or none when the table has no RBAC configured
+func (g *RouteGenerator) requirePermissions(table *TableInfo, endpointType string) []gin.HandlerFunc {
+	resource, actions, ok := g.config.RequiredPermissions(table.Name, endpointType)
+	if !ok {
+		return nil
+	}
+	return []gin.HandlerFunc{g.guard.Require(resource, actions...)}
 }
 
 // Utility methods
@@ -156,9 +156,9 @@ export interface {{.StructName}}PaginationResponse {
 	t := template.Must(template.New("types").Parse(tmpl))
 
 	// Prepare template data
-	columns := tg.generateColumnInfo(table.Columns)
-	createColumns := tg.generateCreateColumns(table.Columns)
-	updateColumns := tg.generateUpdateColumns(table.Columns)
+	columns := tg.generateColumnInfo(table)
+	createColumns := tg.generateCreateColumns(table)
+	updateColumns := tg.generateUpdateColumns(table)
 
 	data := map[string]interface{}{
 		"TableName":     table.Name,
@@ -503,9 +503,14 @@ func (tg *TypeScriptGenerator) toPascalCase(s string) string {
 	return strings.Join(words, "")
 }
 
-func (tg *TypeScriptGenerator) generateColumnInfo(columns []ColumnInfo) []map[string]interface{} {
+func (tg *TypeScriptGenerator) generateColumnInfo(table *TableInfo) []map[string]interface{} {
 	var result []map[string]interface{}
-	for _, col := range columns {
+	policy := tg.config.FieldPolicy(table)
+	for _, col := range table.Columns {
+		// Hidden fields are never returned
+		if !policy.Readable(col.Name) {
+			continue
+		}
 		result = append(result, map[string]interface{}{
 			"FieldName":      tg.toPascalCase(col.Name),
 			"TypeScriptType": tg.getTypeScriptType(col),
@@ -516,39 +521,53 @@ func (tg *TypeScriptGenerator) generateColumnInfo(columns []ColumnInfo) []map[st
 	return result
 }
 
-func (tg *TypeScriptGenerator) generateCreateColumns(columns []ColumnInfo) []map[string]interface{} {
+func (tg *TypeScriptGenerator) generateCreateColumns(table *TableInfo) []map[string]interface{} {
 	var result []map[string]interface{}
-	for _, col := range columns {
-		// Skip auto-generated fields for create requests
-		if col.IsPrimaryKey || col.Name == "created_at" || col.Name == "updated_at" {
+	policy := tg.config.FieldPolicy(table)
+	for _, col := range table.Columns {
+		// Skip hidden and read only fields, such as ids and timestamps
+		if !policy.Creatable(col.Name) {
 			continue
 		}
 		result = append(result, map[string]interface{}{
 			"FieldName":      tg.toPascalCase(col.Name),
 			"TypeScriptType": tg.getTypeScriptType(col),
-			"IsOptional":     col.IsNullable,
-			"Comment":        col.Comment,
+			"IsOptional":     col.IsNullable || policy.AdminOnly(col.Name),
+			"Comment":        tg.fieldComment(col, policy),
 		})
 	}
 	return result
 }
 
-func (tg *TypeScriptGenerator) generateUpdateColumns(columns []ColumnInfo) []map[string]interface{} {
+func (tg *TypeScriptGenerator) generateUpdateColumns(table *TableInfo) []map[string]interface{} {
 	var result []map[string]interface{}
-	for _, col := range columns {
-		// Skip auto-generated fields for update requests
-		if col.IsPrimaryKey || col.Name == "created_at" || col.Name == "updated_at" {
+	policy := tg.config.FieldPolicy(table)
+	for _, col := range table.Columns {
+		// Skip hidden, read only and write once fields
+		if !policy.Updatable(col.Name) {
 			continue
 		}
 		result = append(result, map[string]interface{}{
 			"FieldName":      tg.toPascalCase(col.Name),
 			"TypeScriptType": tg.getTypeScriptType(col),
-			"Comment":        col.Comment,
+			"Comment":        tg.fieldComment(col, policy),
 		})
 	}
 	return result
 }
 
+// fieldComment returns the comment of a request field, noting fields only
+// admins may set
+func (tg *TypeScriptGenerator) fieldComment(col ColumnInfo, policy *FieldPolicy) string {
+	if !policy.AdminOnly(col.Name) {
+		return col.Comment
+	}
+	if col.Comment == "" {
+		return "Admin only"
+	}
+	return col.Comment + " (admin only)"
+}
+
 func (tg *TypeScriptGenerator) getTypeScriptType(col ColumnInfo) string {
 	switch col.Type {
 	case "integer", "bigint", "smallint":
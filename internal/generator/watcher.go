@@ -96,9 +96,12 @@ func (sw *SchemaWatcher) watchLoop(ctx context.Context) {
 // checkForChanges checks if the database schema has changed
 func (sw *SchemaWatcher) checkForChanges() error {
 	sw.mu.RLock()
-	defer sw.mu.RUnlock()
+	running := sw.isRunning
+	lastChecksum := sw.lastChecksum
+	onChange := sw.onChange
+	sw.mu.RUnlock()
 
-	if !sw.isRunning {
+	if !running {
 		return nil
 	}
 
@@ -107,9 +110,9 @@ func (sw *SchemaWatcher) checkForChanges() error {
 		return fmt.Errorf("failed to get current schema checksum: %w", err)
 	}
 
-	if currentChecksum != sw.lastChecksum {
+	if currentChecksum != lastChecksum {
 		sw.logger.Info("Schema change detected",
-			zap.String("old_checksum", sw.lastChecksum),
+			zap.String("old_checksum", lastChecksum),
 			zap.String("new_checksum", currentChecksum))
 
 		// Update the checksum
@@ -118,8 +121,8 @@ func (sw *SchemaWatcher) checkForChanges() error {
 		sw.mu.Unlock()
 
 		// Trigger regeneration
-		if sw.onChange != nil {
-			if err := sw.onChange(); err != nil {
+		if onChange != nil {
+			if err := onChange(); err != nil {
 				sw.logger.Error("Failed to regenerate APIs after schema change", zap.Error(err))
 				return err
 			}
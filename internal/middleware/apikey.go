@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-mobile-backend-template/internal/services/auth"
+	"go-mobile-backend-template/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AuthTypeAPIKey is the "auth_type" context value for API key requests
+const AuthTypeAPIKey = "api_key"
+
+// APIKeyMiddleware authenticates requests carrying an API key in the
+// X-API-Key header or as "Authorization: ApiKey <key>". Requests without a
+// key pass through untouched so JWT authentication still applies. Each key
+// may make api_keys.rate_limit requests per rateWindow.
+func APIKeyMiddleware(apiKeys *auth.APIKeyService, limiter *RateLimiter, rateWindow time.Duration, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plaintext := extractAPIKey(c)
+		if plaintext == "" {
+			c.Next()
+			return
+		}
+
+		principal, err := apiKeys.Authenticate(c.Request.Context(), plaintext)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired API key")
+			c.Abort()
+			return
+		}
+
+		key := principal.Key
+		if key.RateLimit > 0 {
+			allowed, remaining, resetAt := limiter.Allow(c.Request.Context(),
+				"api_key:"+strconv.FormatUint(uint64(key.ID), 10), key.RateLimit, rateWindow)
+
+			c.Header("X-RateLimit-Limit", strconv.Itoa(key.RateLimit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			c.Header("X-RateLimit-Reset", strconv.FormatInt(resetAt.Unix(), 10))
+
+			if !allowed {
+				logger.Warn("API key rate limit exceeded",
+					zap.Uint("api_key_id", key.ID),
+					zap.Int("limit", key.RateLimit),
+				)
+				utils.ErrorResponse(c, http.StatusTooManyRequests, "Rate limit exceeded")
+				c.Abort()
+				return
+			}
+		}
+
+		// API keys never carry admin rights; what they may do is limited by
+		// their scopes and the permissions of their owner
+		c.Set("auth_type", AuthTypeAPIKey)
+		c.Set("api_key", principal)
+		c.Set("is_admin", false)
+		if principal.User != nil {
+			c.Set("user_id", principal.User.ID)
+			c.Set("user_email", principal.User.Email)
+		}
+
+		c.Next()
+	}
+}
+
+// RejectAPIKey refuses requests authenticated with an API key, for endpoints
+// that must only be used by a signed-in user such as key management
+func RejectAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsAPIKeyRequest(c) {
+			utils.ErrorResponse(c, http.StatusForbidden, "This endpoint cannot be used with an API key")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope refuses API key requests whose key lacks the resource:action
+// scope. Other requests pass, so endpoints open to every signed-in user can
+// still limit what keys do with them.
+func RequireScope(resource, action string, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal, ok := APIKeyPrincipal(c); ok && !principal.HasScope(resource, action) {
+			logger.Warn("API key lacks required scope",
+				zap.Uint("api_key_id", principal.Key.ID),
+				zap.String("resource", resource),
+				zap.String("action", action),
+			)
+			utils.ErrorResponse(c, http.StatusForbidden, "API key lacks required scope")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// IsAPIKeyRequest reports whether the request was authenticated with an API key
+func IsAPIKeyRequest(c *gin.Context) bool {
+	return c.GetString("auth_type") == AuthTypeAPIKey
+}
+
+// APIKeyPrincipal returns the API key principal of the request, if any
+func APIKeyPrincipal(c *gin.Context) (*auth.APIKeyPrincipal, bool) {
+	value, exists := c.Get("api_key")
+	if !exists {
+		return nil, false
+	}
+	principal, ok := value.(*auth.APIKeyPrincipal)
+	return principal, ok
+}
+
+// extractAPIKey reads the API key from the request headers
+func extractAPIKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+
+	scheme, key, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "ApiKey") {
+		return strings.TrimSpace(key)
+	}
+
+	return ""
+}
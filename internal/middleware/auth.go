@@ -10,9 +10,15 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware validates JWT tokens
+// AuthMiddleware validates JWT tokens. Requests already authenticated by
+// APIKeyMiddleware are passed through.
 func AuthMiddleware(jwtService *auth.JWTService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if IsAPIKeyRequest(c) {
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			utils.ErrorResponse(c, http.StatusUnauthorized, "Authorization header required")
@@ -42,6 +48,14 @@ func AuthMiddleware(jwtService *auth.JWTService) gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Set("is_admin", claims.IsAdmin)
+		c.Set("session_id", claims.SessionID)
+		c.Set("token_id", claims.ID)
+		if claims.ExpiresAt != nil {
+			c.Set("token_expires_at", claims.ExpiresAt.Time)
+		}
+		if claims.Roles != nil || claims.Scopes != nil {
+			c.Set("token_permissions", &auth.EffectivePermissions{Roles: claims.Roles, Permissions: claims.Scopes})
+		}
 
 		c.Next()
 	}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"go-mobile-backend-template/internal/utils"
@@ -25,17 +26,94 @@ type RateLimitConfig struct {
 type RateLimiter struct {
 	redis  *cache.RedisClient
 	logger *zap.Logger
+
+	// local counts requests when Redis is not configured. Limits are then
+	// per instance rather than shared.
+	mu    sync.Mutex
+	local map[string]*localWindow
+}
+
+// localWindow is an in-process fixed rate limit window
+type localWindow struct {
+	count   int
+	resetAt time.Time
 }
 
-// NewRateLimiter creates a new rate limiter
+// NewRateLimiter creates a new rate limiter. redis may be nil, in which case
+// limits are counted in process.
 func NewRateLimiter(redis *cache.RedisClient, logger *zap.Logger) *RateLimiter {
 	return &RateLimiter{
 		redis:  redis,
 		logger: logger,
+		local:  make(map[string]*localWindow),
+	}
+}
+
+// Allow counts a request against key and reports whether it is within limit
+// requests per window, along with the remaining requests and window reset
+// time. Counter errors fail open.
+func (rl *RateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, int, time.Time) {
+	if rl.redis == nil {
+		return rl.allowLocal(key, limit, window)
+	}
+
+	rateLimitKey := fmt.Sprintf("rate_limit:%s", key)
+	count, err := rl.redis.Increment(ctx, rateLimitKey)
+	if err != nil {
+		rl.logger.Error("Failed to update rate limit counter", zap.Error(err))
+		return true, limit, time.Now().Add(window)
+	}
+
+	if count == 1 {
+		if err := rl.redis.Expire(ctx, rateLimitKey, window); err != nil {
+			rl.logger.Error("Failed to set rate limit window", zap.Error(err))
+		}
+	}
+
+	ttl, err := rl.redis.GetClient().TTL(ctx, rateLimitKey).Result()
+	if err != nil || ttl < 0 {
+		ttl = window
+	}
+
+	remaining := limit - int(count)
+	if remaining < 0 {
+		remaining = 0
 	}
+
+	return int(count) <= limit, remaining, time.Now().Add(ttl)
 }
 
-// RateLimit middleware that limits requests per IP
+// allowLocal is Allow for limiters without Redis
+func (rl *RateLimiter) allowLocal(key string, limit int, window time.Duration) (bool, int, time.Time) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	w, ok := rl.local[key]
+	if !ok || now.After(w.resetAt) {
+		// Drop expired windows now and then so idle keys do not accumulate
+		if len(rl.local) > 10000 {
+			for k, old := range rl.local {
+				if now.After(old.resetAt) {
+					delete(rl.local, k)
+				}
+			}
+		}
+		w = &localWindow{resetAt: now.Add(window)}
+		rl.local[key] = w
+	}
+
+	w.count++
+	remaining := limit - w.count
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return w.count <= limit, remaining, w.resetAt
+}
+
+// RateLimit middleware limits requests per key of config.KeyFunc. Requests
+// without a key are not limited.
 func (rl *RateLimiter) RateLimit(config RateLimitConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		key := config.KeyFunc(c)
@@ -44,62 +122,22 @@ func (rl *RateLimiter) RateLimit(config RateLimitConfig) gin.HandlerFunc {
 			return
 		}
 
-		// Create rate limit key
-		rateLimitKey := fmt.Sprintf("rate_limit:%s", key)
-
-		// Get current count
-		current, err := rl.redis.Get(context.Background(), rateLimitKey)
-		if err != nil {
-			// If key doesn't exist, start counting
-			current = "0"
-		}
+		allowed, remaining, resetAt := rl.Allow(c.Request.Context(), key, config.Requests, config.Window)
 
-		count, err := strconv.Atoi(current)
-		if err != nil {
-			count = 0
-		}
+		c.Header("X-RateLimit-Limit", strconv.Itoa(config.Requests))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(resetAt.Unix(), 10))
 
-		// Check if limit exceeded
-		if count >= config.Requests {
+		if !allowed {
 			rl.logger.Warn("Rate limit exceeded",
 				zap.String("key", key),
-				zap.Int("count", count),
 				zap.Int("limit", config.Requests),
 			)
-
-			c.Header("X-RateLimit-Limit", strconv.Itoa(config.Requests))
-			c.Header("X-RateLimit-Remaining", "0")
-			c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(config.Window).Unix(), 10))
-
 			utils.ErrorResponse(c, http.StatusTooManyRequests, "Rate limit exceeded")
 			c.Abort()
 			return
 		}
 
-		// Increment counter
-		if count == 0 {
-			// First request in window
-			err = rl.redis.Set(context.Background(), rateLimitKey, "1", config.Window)
-		} else {
-			// Increment existing counter
-			_, err = rl.redis.GetClient().Incr(context.Background(), rateLimitKey).Result()
-		}
-
-		if err != nil {
-			rl.logger.Error("Failed to update rate limit counter", zap.Error(err))
-			// Don't block request on Redis errors
-		}
-
-		// Set response headers
-		remaining := config.Requests - count - 1
-		if remaining < 0 {
-			remaining = 0
-		}
-
-		c.Header("X-RateLimit-Limit", strconv.Itoa(config.Requests))
-		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
-		c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(config.Window).Unix(), 10))
-
 		c.Next()
 	}
 }
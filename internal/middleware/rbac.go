@@ -2,70 +2,101 @@ package middleware
 
 import (
 	"net/http"
-	"strings"
 
-	"go-mobile-backend-template/internal/db/repository"
+	"go-mobile-backend-template/internal/services/auth"
 	"go-mobile-backend-template/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"gorm.io/gorm"
 )
 
-// RequirePermission middleware checks if user has required permission
-func RequirePermission(resource, action string, db *gorm.DB, logger *zap.Logger) gin.HandlerFunc {
+// RequirePermission middleware checks if user has required permission. API
+// keys must also carry a matching scope, and act with no more rights than
+// their owner. Permissions embedded in the access token are tried first;
+// the resolver decides when they do not grant the permission, so grants
+// take effect before the token is refreshed.
+func RequirePermission(resource, action string, permissions *auth.PermissionResolver, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get user ID from context (set by auth middleware)
-		userID, exists := c.Get("user_id")
-		if !exists {
-			logger.Warn("User ID not found in context")
-			utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		if !checkPermission(c, resource, action, permissions, logger) {
 			c.Abort()
 			return
 		}
+		c.Next()
+	}
+}
 
-		uid, ok := userID.(uint)
-		if !ok {
-			logger.Warn("Invalid user ID type in context")
-			utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
-			c.Abort()
-			return
+// checkPermission reports whether the request may perform action on
+// resource, answering it with an error when it may not
+func checkPermission(c *gin.Context, resource, action string, permissions *auth.PermissionResolver, logger *zap.Logger) bool {
+	if principal, ok := APIKeyPrincipal(c); ok {
+		if !principal.HasScope(resource, action) {
+			logger.Warn("API key lacks required scope",
+				zap.Uint("api_key_id", principal.Key.ID),
+				zap.String("resource", resource),
+				zap.String("action", action),
+			)
+			utils.ErrorResponse(c, http.StatusForbidden, "API key lacks required scope")
+			return false
 		}
 
-		// Check if user is admin (admins have all permissions)
-		isAdmin, exists := c.Get("is_admin")
-		if exists && isAdmin.(bool) {
-			c.Next()
-			return
+		// Service keys are not bound to a user, so their scopes decide
+		if principal.User == nil {
+			return true
 		}
 
-		// Check permission
-		permRepo := repository.NewPermissionRepository(db)
-		hasPermission, err := permRepo.CheckUserPermission(c.Request.Context(), uid, resource, action)
-		if err != nil {
-			logger.Error("Failed to check permission", zap.Error(err))
-			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check permissions")
-			c.Abort()
-			return
+		if principal.User.IsAdmin {
+			return true
 		}
+	}
 
-		if !hasPermission {
-			logger.Warn("User lacks required permission",
-				zap.Uint("user_id", uid),
-				zap.String("resource", resource),
-				zap.String("action", action),
-			)
-			utils.ErrorResponse(c, http.StatusForbidden, "Insufficient permissions")
-			c.Abort()
-			return
-		}
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("User ID not found in context")
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return false
+	}
 
-		c.Next()
+	uid, ok := userID.(uint)
+	if !ok {
+		logger.Warn("Invalid user ID type in context")
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return false
 	}
+
+	// Check if user is admin (admins have all permissions)
+	isAdmin, exists := c.Get("is_admin")
+	if exists && isAdmin.(bool) {
+		return true
+	}
+
+	if embedded, ok := TokenPermissions(c); ok && embedded.Allows(resource, action) {
+		return true
+	}
+
+	// Check permission
+	hasPermission, err := permissions.Allowed(c.Request.Context(), uid, resource, action)
+	if err != nil {
+		logger.Error("Failed to check permission", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check permissions")
+		return false
+	}
+
+	if !hasPermission {
+		logger.Warn("User lacks required permission",
+			zap.Uint("user_id", uid),
+			zap.String("resource", resource),
+			zap.String("action", action),
+		)
+		utils.ErrorResponse(c, http.StatusForbidden, "Insufficient permissions")
+		return false
+	}
+
+	return true
 }
 
 // RequireRole middleware checks if user has required role
-func RequireRole(roleName string, db *gorm.DB, logger *zap.Logger) gin.HandlerFunc {
+func RequireRole(roleName string, permissions *auth.PermissionResolver, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get user ID from context
 		userID, exists := c.Get("user_id")
@@ -93,9 +124,13 @@ func RequireRole(roleName string, db *gorm.DB, logger *zap.Logger) gin.HandlerFu
 			}
 		}
 
+		if embedded, ok := TokenPermissions(c); ok && embedded.HasRole(roleName) {
+			c.Next()
+			return
+		}
+
 		// Check role
-		roleRepo := repository.NewRoleRepository(db)
-		roles, err := roleRepo.GetUserRoles(c.Request.Context(), uid)
+		resolved, err := permissions.Resolve(c.Request.Context(), uid)
 		if err != nil {
 			logger.Error("Failed to get user roles", zap.Error(err))
 			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check roles")
@@ -103,16 +138,7 @@ func RequireRole(roleName string, db *gorm.DB, logger *zap.Logger) gin.HandlerFu
 			return
 		}
 
-		// Check if user has the required role
-		hasRole := false
-		for _, role := range roles {
-			if strings.EqualFold(role.Name, roleName) {
-				hasRole = true
-				break
-			}
-		}
-
-		if !hasRole {
+		if !resolved.HasRole(roleName) {
 			logger.Warn("User lacks required role",
 				zap.Uint("user_id", uid),
 				zap.String("required_role", roleName),
@@ -126,6 +152,17 @@ func RequireRole(roleName string, db *gorm.DB, logger *zap.Logger) gin.HandlerFu
 	}
 }
 
+// TokenPermissions returns the roles and permissions embedded in the access
+// token of the request, if any
+func TokenPermissions(c *gin.Context) (*auth.EffectivePermissions, bool) {
+	value, exists := c.Get("token_permissions")
+	if !exists {
+		return nil, false
+	}
+	permissions, ok := value.(*auth.EffectivePermissions)
+	return permissions, ok
+}
+
 // RequireAdmin middleware ensures user is an admin
 func RequireAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
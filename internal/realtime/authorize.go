@@ -0,0 +1,234 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go-mobile-backend-template/internal/services/auth"
+)
+
+// userChannelPrefix starts the private channel of a user, e.g. "user:42"
+const userChannelPrefix = "user:"
+
+// Channel authorization errors
+var (
+	ErrChannelForbidden = errors.New("not authorized for channel")
+	ErrChannelReadOnly  = errors.New("channel does not accept client messages")
+)
+
+// Subscriber is the authenticated user a channel is authorized for
+type Subscriber struct {
+	UserID  uint
+	IsAdmin bool
+	// Permissions embedded in the access token, if any
+	Permissions *auth.EffectivePermissions
+	// APIKey is set for requests authenticated with an API key, whose
+	// Scopes limit the subscriber to less than its owner may do. Keys
+	// without an owner have no UserID.
+	APIKey bool
+	Scopes []string
+}
+
+// ChannelRule restricts the channels matching Pattern. Users with the
+// Resource:Action permission receive every message. With OwnerColumn set,
+// other users may subscribe too but only receive database rows whose
+// OwnerColumn is their user ID; without it they are refused.
+type ChannelRule struct {
+	// Pattern is a channel name, or a prefix followed by "*" such as "db:*"
+	Pattern     string
+	Resource    string
+	Action      string
+	OwnerColumn string
+	// ReadOnly channels only carry messages sent by the server
+	ReadOnly bool
+}
+
+// matches reports whether the rule applies to channel
+func (r *ChannelRule) matches(channel string) bool {
+	if prefix, ok := strings.CutSuffix(r.Pattern, "*"); ok {
+		return strings.HasPrefix(channel, prefix)
+	}
+	return channel == r.Pattern
+}
+
+// DefaultChannelRules protect the channels of DBStreamer. Table changes
+// require database:read, except that users see changes to their own files.
+func DefaultChannelRules() []ChannelRule {
+	return []ChannelRule{
+		{Pattern: "db:files", Resource: "database", Action: "read", OwnerColumn: "user_id", ReadOnly: true},
+		{Pattern: "db:*", Resource: "database", Action: "read", ReadOnly: true},
+	}
+}
+
+// RowFilter reports whether a message on a channel may be delivered to a
+// subscriber
+type RowFilter func(message *Message) bool
+
+// OwnerFilter passes database change messages whose row has column set to
+// userID
+func OwnerFilter(column string, userID uint) RowFilter {
+	return func(message *Message) bool {
+		row, ok := message.Payload["data"].(map[string]interface{})
+		if !ok {
+			return false
+		}
+		return matchesUserID(row[column], userID)
+	}
+}
+
+// matchesUserID compares a JSON decoded ID with userID
+func matchesUserID(value interface{}, userID uint) bool {
+	switch v := value.(type) {
+	case float64:
+		return v == float64(userID)
+	case json.Number:
+		return v.String() == strconv.FormatUint(uint64(userID), 10)
+	case string:
+		return v == strconv.FormatUint(uint64(userID), 10)
+	case uint:
+		return v == userID
+	case int:
+		return v >= 0 && uint(v) == userID
+	case int64:
+		return v >= 0 && uint64(v) == uint64(userID)
+	default:
+		return false
+	}
+}
+
+// UserChannel returns the private channel of a user. Only that user may
+// subscribe to it.
+func UserChannel(userID uint) string {
+	return userChannelPrefix + strconv.FormatUint(uint64(userID), 10)
+}
+
+// ChannelAuthorizer decides which channels a user may subscribe and publish
+// to. Rules are checked in order and the first match applies; channels no
+// rule matches are open to every authenticated user.
+type ChannelAuthorizer struct {
+	rules       []ChannelRule
+	permissions *auth.PermissionResolver
+}
+
+// NewChannelAuthorizer creates a channel authorizer. Without a permission
+// resolver only admins and permissions embedded in tokens are considered.
+func NewChannelAuthorizer(rules []ChannelRule, permissions *auth.PermissionResolver) *ChannelAuthorizer {
+	return &ChannelAuthorizer{
+		rules:       rules,
+		permissions: permissions,
+	}
+}
+
+// Authorize checks that sub may subscribe to channel. The returned filter
+// is nil when every message may be delivered.
+func (a *ChannelAuthorizer) Authorize(ctx context.Context, sub Subscriber, channel string) (RowFilter, error) {
+	if owner, ok := channelOwner(channel); ok {
+		if owner == 0 || owner != sub.UserID {
+			return nil, ErrChannelForbidden
+		}
+		return nil, nil
+	}
+
+	rule := a.match(channel)
+	if rule == nil {
+		return nil, nil
+	}
+
+	allowed, err := a.allowed(ctx, sub, rule.Resource, rule.Action)
+	if err != nil {
+		return nil, err
+	}
+	if allowed {
+		return nil, nil
+	}
+
+	if rule.OwnerColumn != "" {
+		return OwnerFilter(rule.OwnerColumn, sub.UserID), nil
+	}
+	return nil, ErrChannelForbidden
+}
+
+// AuthorizePublish checks that sub may send messages to channel. Users
+// only publish to channels they fully receive.
+func (a *ChannelAuthorizer) AuthorizePublish(ctx context.Context, sub Subscriber, channel string) error {
+	if rule := a.match(channel); rule != nil && rule.ReadOnly {
+		return ErrChannelReadOnly
+	}
+
+	filter, err := a.Authorize(ctx, sub, channel)
+	if err != nil {
+		return err
+	}
+	if filter != nil {
+		return ErrChannelForbidden
+	}
+	return nil
+}
+
+// RowFilterColumns returns the columns of database rows that the filters
+// of channel read
+func (a *ChannelAuthorizer) RowFilterColumns(channel string) []string {
+	if rule := a.match(channel); rule != nil && rule.OwnerColumn != "" {
+		return []string{rule.OwnerColumn}
+	}
+	return nil
+}
+
+// match returns the first rule for channel, or nil
+func (a *ChannelAuthorizer) match(channel string) *ChannelRule {
+	for i := range a.rules {
+		if a.rules[i].matches(channel) {
+			return &a.rules[i]
+		}
+	}
+	return nil
+}
+
+// allowed checks a permission the way RequirePermission does: API keys
+// need the scope, admins have every permission, then the token's embedded
+// permissions and finally the resolver decide
+func (a *ChannelAuthorizer) allowed(ctx context.Context, sub Subscriber, resource, action string) (bool, error) {
+	if sub.APIKey {
+		if !auth.ScopesAllow(sub.Scopes, resource, action) {
+			return false, nil
+		}
+		// Service keys are not bound to a user, so their scopes decide
+		if sub.UserID == 0 {
+			return true, nil
+		}
+	}
+	if sub.IsAdmin {
+		return true, nil
+	}
+	if sub.Permissions != nil && sub.Permissions.Allows(resource, action) {
+		return true, nil
+	}
+	if a.permissions == nil || sub.UserID == 0 {
+		return false, nil
+	}
+
+	allowed, err := a.permissions.Allowed(ctx, sub.UserID, resource, action)
+	if err != nil {
+		return false, fmt.Errorf("failed to check permission: %w", err)
+	}
+	return allowed, nil
+}
+
+// channelOwner returns the user of a private user channel
+func channelOwner(channel string) (uint, bool) {
+	id, ok := strings.CutPrefix(channel, userChannelPrefix)
+	if !ok {
+		return 0, false
+	}
+
+	userID, err := strconv.ParseUint(id, 10, 0)
+	if err != nil {
+		// Malformed user channels belong to nobody
+		return 0, true
+	}
+	return uint(userID), true
+}
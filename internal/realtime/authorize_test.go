@@ -0,0 +1,49 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-mobile-backend-template/internal/services/auth"
+)
+
+func TestChannelAuthorizerAPIKeys(t *testing.T) {
+	authorizer := NewChannelAuthorizer(DefaultChannelRules(), nil)
+
+	tests := []struct {
+		name       string
+		sub        Subscriber
+		channel    string
+		wantErr    error
+		wantFilter bool
+	}{
+		{name: "admin user", sub: Subscriber{UserID: 1, IsAdmin: true}, channel: "db:orders"},
+		{name: "user without permission", sub: Subscriber{UserID: 5}, channel: "db:orders", wantErr: ErrChannelForbidden},
+		{name: "service key with scope", sub: Subscriber{APIKey: true, Scopes: []string{"database:read"}}, channel: "db:orders"},
+		{name: "service key without scope", sub: Subscriber{APIKey: true, Scopes: []string{"files:read"}}, channel: "db:orders", wantErr: ErrChannelForbidden},
+		{name: "service key with wildcard scope", sub: Subscriber{APIKey: true, Scopes: []string{"database:*"}}, channel: "db:orders"},
+		// A key never does more than its owner may
+		{name: "user key with scope its owner lacks", sub: Subscriber{UserID: 5, APIKey: true, Scopes: []string{"database:read"}}, channel: "db:orders", wantErr: ErrChannelForbidden},
+		// The scopes of a user key and its owner's permissions intersect
+		{name: "user key with every scope", sub: Subscriber{UserID: 5, APIKey: true, Scopes: []string{"*:*"}, Permissions: &auth.EffectivePermissions{Permissions: []string{"database:read"}}}, channel: "db:orders"},
+		{name: "user key with a scope its owner has through a wildcard", sub: Subscriber{UserID: 5, APIKey: true, Scopes: []string{"*:read"}, Permissions: &auth.EffectivePermissions{Permissions: []string{"database:*"}}}, channel: "db:orders"},
+		{name: "user key without a scope its owner has", sub: Subscriber{UserID: 5, APIKey: true, Scopes: []string{"files:*"}, Permissions: &auth.EffectivePermissions{Permissions: []string{"*:*"}}}, channel: "db:orders", wantErr: ErrChannelForbidden},
+		{name: "user key on owned rows", sub: Subscriber{UserID: 5, APIKey: true}, channel: "db:files", wantFilter: true},
+		{name: "user key on its user channel", sub: Subscriber{UserID: 5, APIKey: true}, channel: "user:5"},
+		{name: "service key on a malformed user channel", sub: Subscriber{APIKey: true, Scopes: []string{"database:read"}}, channel: "user:abc", wantErr: ErrChannelForbidden},
+		{name: "service key on a user channel", sub: Subscriber{APIKey: true, Scopes: []string{"database:read"}}, channel: "user:0", wantErr: ErrChannelForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := authorizer.Authorize(context.Background(), tt.sub, tt.channel)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authorize() error = %v, want %v", err, tt.wantErr)
+			}
+			if (filter != nil) != tt.wantFilter {
+				t.Errorf("Authorize() filter = %v, want filter %v", filter != nil, tt.wantFilter)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Backplane connects the hubs of all instances. Messages published on any
+// instance are delivered to every instance, presence is shared and expires
+// unless refreshed, and each instance reports its stats so they can be
+// aggregated across the cluster.
+type Backplane interface {
+	// Publish delivers message to the subscriber of every instance,
+	// including this one
+	Publish(ctx context.Context, message *Message) error
+	// Subscribe sets the function receiving published messages. The hub
+	// calls it once when it is created.
+	Subscribe(deliver func(*Message)) error
+
+	// SetPresence stores the presence of a user's devices on one instance,
+	// which expires after ttl unless set again
+	SetPresence(ctx context.Context, nodeID string, info *PresenceInfo, ttl time.Duration) error
+	// GetPresence returns the presence of a user merged across instances,
+	// or nil if there is none
+	GetPresence(ctx context.Context, userID uint) (*PresenceInfo, error)
+	// ListPresence returns the merged presence of every user
+	ListPresence(ctx context.Context) (map[uint]*PresenceInfo, error)
+
+	// ReportStats stores the stats of one instance for ttl
+	ReportStats(ctx context.Context, stats *NodeStats, ttl time.Duration) error
+	// ListStats returns the latest stats of every live instance
+	ListStats(ctx context.Context) ([]*NodeStats, error)
+
+	// Close stops delivering messages
+	Close() error
+}
+
+// NodeStats are the connection and delivery counts of one hub instance
+type NodeStats struct {
+	NodeID     string                    `json:"node_id"`
+	Clients    int                       `json:"clients"`
+	Rooms      map[string]int            `json:"rooms"`
+	Queued     int                       `json:"queued"`
+	Channels   map[string]ChannelMetrics `json:"channels,omitempty"`
+	ReportedAt time.Time                 `json:"reported_at"`
+}
+
+// LocalBackplane keeps everything in process. It is the default and only
+// suits a single instance.
+type LocalBackplane struct {
+	mu       sync.RWMutex
+	deliver  func(*Message)
+	presence map[presenceKey]localPresence
+	stats    map[string]localStats
+}
+
+// presenceKey identifies the presence of a user on one instance
+type presenceKey struct {
+	userID uint
+	nodeID string
+}
+
+// localPresence is presence that expires
+type localPresence struct {
+	info      PresenceInfo
+	expiresAt time.Time
+}
+
+// localStats are node stats that expire
+type localStats struct {
+	stats     NodeStats
+	expiresAt time.Time
+}
+
+// NewLocalBackplane creates an in-process backplane
+func NewLocalBackplane() *LocalBackplane {
+	return &LocalBackplane{
+		presence: make(map[presenceKey]localPresence),
+		stats:    make(map[string]localStats),
+	}
+}
+
+// Publish hands the message straight to the subscriber
+func (b *LocalBackplane) Publish(ctx context.Context, message *Message) error {
+	b.mu.RLock()
+	deliver := b.deliver
+	b.mu.RUnlock()
+
+	if deliver != nil {
+		deliver(message)
+	}
+	return nil
+}
+
+// Subscribe sets the function receiving published messages
+func (b *LocalBackplane) Subscribe(deliver func(*Message)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.deliver = deliver
+	return nil
+}
+
+// SetPresence stores a copy of info until ttl passes
+func (b *LocalBackplane) SetPresence(ctx context.Context, nodeID string, info *PresenceInfo, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range b.presence {
+		if now.After(entry.expiresAt) {
+			delete(b.presence, key)
+		}
+	}
+
+	stored := *info
+	stored.Devices = append([]DevicePresence(nil), info.Devices...)
+	stored.Channels = append([]string(nil), info.Channels...)
+	b.presence[presenceKey{userID: info.UserID, nodeID: nodeID}] = localPresence{info: stored, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// GetPresence returns the merged presence of a user, or nil if there is none
+func (b *LocalBackplane) GetPresence(ctx context.Context, userID uint) (*PresenceInfo, error) {
+	return b.listPresence(userID)[userID], nil
+}
+
+// ListPresence returns the merged presence of every user
+func (b *LocalBackplane) ListPresence(ctx context.Context) (map[uint]*PresenceInfo, error) {
+	return b.listPresence(0), nil
+}
+
+// listPresence merges the live presence records of one user, or of every
+// user when userID is 0
+func (b *LocalBackplane) listPresence(userID uint) map[uint]*PresenceInfo {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	now := time.Now()
+	records := make(map[uint][]*PresenceInfo)
+	for key, entry := range b.presence {
+		if now.After(entry.expiresAt) || (userID != 0 && key.userID != userID) {
+			continue
+		}
+		info := entry.info
+		info.Devices = append([]DevicePresence(nil), entry.info.Devices...)
+		records[key.userID] = append(records[key.userID], &info)
+	}
+
+	presence := make(map[uint]*PresenceInfo, len(records))
+	for id, userRecords := range records {
+		presence[id] = mergePresence(userRecords)
+	}
+	return presence
+}
+
+// ReportStats stores a copy of stats until ttl passes
+func (b *LocalBackplane) ReportStats(ctx context.Context, stats *NodeStats, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stats[stats.NodeID] = localStats{stats: *stats, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// ListStats returns the stats of every instance that reported recently
+func (b *LocalBackplane) ListStats(ctx context.Context) ([]*NodeStats, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	now := time.Now()
+	nodes := make([]*NodeStats, 0, len(b.stats))
+	for _, entry := range b.stats {
+		if now.After(entry.expiresAt) {
+			continue
+		}
+		stats := entry.stats
+		nodes = append(nodes, &stats)
+	}
+	return nodes, nil
+}
+
+// Close stops delivering messages
+func (b *LocalBackplane) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.deliver = nil
+	return nil
+}
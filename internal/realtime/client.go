@@ -1,9 +1,14 @@
 package realtime
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 )
@@ -30,46 +35,83 @@ type Client struct {
 	// Hub this client belongs to
 	hub *Hub
 
-	// Buffered channel of outbound messages
-	send chan []byte
+	// Outbound messages waiting to be written
+	queue *sendQueue
+
+	// Closed once the hub has registered the client
+	registered chan struct{}
+
+	// Messages awaiting acknowledgement and slots for RPC calls in flight
+	deliveries deliveries
+	rpcSlots   chan struct{}
+
+	// ID identifies the connection as one of its user's devices
+	ID string
 
 	// User information
-	UserID    uint
+	Subscriber
 	Username  string
 	UserAgent string
 
-	// Room/channel this client is subscribed to
-	Room string
+	// RemoteAddr is the address of the request that connected the client.
+	// RPC handlers that call HTTP endpoints send it along with the access
+	// token.
+	RemoteAddr string
+
+	// The client's current access token and its expiry. It starts as the
+	// token of the request that connected the client and is replaced by
+	// auth messages.
+	accessToken    string
+	tokenExpiresAt time.Time
+	tokenMu        sync.RWMutex
+
+	// Channels this client is subscribed to and the filter restricting the
+	// messages delivered from each, nil for all. Guarded by the hub mutex.
+	subscriptions map[string]RowFilter
 
 	logger *zap.Logger
 }
 
 // ClientMessage represents an incoming message from client
 type ClientMessage struct {
+	// ID is echoed in the acknowledgement of the message. On an rpc
+	// message it correlates the call with its rpc_result, and on an ack
+	// message it is the ID of the message acknowledged.
+	ID      string                 `json:"id,omitempty"`
 	Type    string                 `json:"type"`
 	Event   string                 `json:"event"`
+	Method  string                 `json:"method,omitempty"`
 	Channel string                 `json:"channel,omitempty"`
 	Payload map[string]interface{} `json:"payload,omitempty"`
+
+	// LastEventID or Since (RFC 3339) on a subscribe message replay the
+	// channel's stored messages after that point
+	LastEventID string `json:"last_event_id,omitempty"`
+	Since       string `json:"since,omitempty"`
 }
 
 // NewClient creates a new WebSocket client
-func NewClient(conn *websocket.Conn, hub *Hub, userID uint, username string, room string, userAgent string, logger *zap.Logger) *Client {
+func NewClient(conn *websocket.Conn, hub *Hub, subscriber Subscriber, username string, userAgent string, logger *zap.Logger) *Client {
 	return &Client{
-		conn:      conn,
-		hub:       hub,
-		send:      make(chan []byte, 256),
-		UserID:    userID,
-		Username:  username,
-		Room:      room,
-		UserAgent: userAgent,
-		logger:    logger,
+		conn:          conn,
+		hub:           hub,
+		queue:         newSendQueue(hub.queueSize, hub.queuePolicy),
+		registered:    make(chan struct{}),
+		deliveries:    deliveries{pending: make(map[string]*pendingAck)},
+		rpcSlots:      make(chan struct{}, maxConcurrentRPC),
+		ID:            uuid.NewString(),
+		Subscriber:    subscriber,
+		Username:      username,
+		UserAgent:     userAgent,
+		subscriptions: make(map[string]RowFilter),
+		logger:        logger,
 	}
 }
 
 // ReadPump pumps messages from the websocket connection to the hub
 func (c *Client) ReadPump() {
 	defer func() {
-		c.hub.unregister <- c
+		c.hub.UnregisterClient(c)
 		c.conn.Close()
 	}()
 
@@ -97,7 +139,7 @@ func (c *Client) ReadPump() {
 		}
 
 		// Handle different message types
-		c.handleMessage(&clientMsg)
+		c.HandleMessage(&clientMsg)
 	}
 }
 
@@ -111,28 +153,32 @@ func (c *Client) WritePump() {
 
 	for {
 		select {
-		case message, ok := <-c.send:
+		case <-c.queue.ready:
+			messages, open := c.queue.drain()
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if !ok {
-				// The hub closed the channel
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-				return
-			}
-
-			w, err := c.conn.NextWriter(websocket.TextMessage)
-			if err != nil {
-				return
-			}
-			w.Write(message)
 
-			// Add queued messages to the current websocket message
-			n := len(c.send)
-			for i := 0; i < n; i++ {
-				w.Write([]byte{'\n'})
-				w.Write(<-c.send)
+			if len(messages) > 0 {
+				w, err := c.conn.NextWriter(websocket.TextMessage)
+				if err != nil {
+					return
+				}
+
+				// Send the queued messages as one websocket message
+				for i, message := range messages {
+					if i > 0 {
+						w.Write([]byte{'\n'})
+					}
+					w.Write(message)
+				}
+
+				if err := w.Close(); err != nil {
+					return
+				}
 			}
 
-			if err := w.Close(); err != nil {
+			if !open {
+				// The hub closed the queue
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
 			}
 
@@ -145,7 +191,9 @@ func (c *Client) WritePump() {
 	}
 }
 
-func (c *Client) handleMessage(msg *ClientMessage) {
+// HandleMessage handles a message from the client. Transports other than
+// WebSocket call it for the messages they receive.
+func (c *Client) HandleMessage(msg *ClientMessage) {
 	switch msg.Type {
 	case "subscribe":
 		c.handleSubscribe(msg)
@@ -155,63 +203,152 @@ func (c *Client) handleMessage(msg *ClientMessage) {
 		c.handleBroadcast(msg)
 	case "presence":
 		c.handlePresence(msg)
+	case "typing":
+		c.handleTyping(msg)
+	case "activity":
+		c.handleActivity(msg)
+	case "rpc":
+		c.handleRPC(msg)
+	case "ack":
+		c.handleAck(msg)
+	case "auth":
+		c.handleAuth(msg)
 	default:
 		c.logger.Warn("Unknown message type", zap.String("type", msg.Type))
 	}
 }
 
-func (c *Client) handleSubscribe(msg *ClientMessage) {
-	if msg.Channel == "" {
+// SetAccessToken sets the token the client's RPC calls are made with and
+// when it expires
+func (c *Client) SetAccessToken(token string, expiresAt time.Time) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	c.accessToken = token
+	c.tokenExpiresAt = expiresAt
+}
+
+// AccessToken returns the client's current access token and its expiry
+func (c *Client) AccessToken() (string, time.Time) {
+	c.tokenMu.RLock()
+	defer c.tokenMu.RUnlock()
+	return c.accessToken, c.tokenExpiresAt
+}
+
+// handleAuth replaces the client's access token with the one in the payload,
+// so RPC calls keep working after the connecting token expires. The new
+// token must be valid and belong to the same user; the client's channels
+// stay as they are.
+func (c *Client) handleAuth(msg *ClientMessage) {
+	token, _ := msg.Payload["token"].(string)
+	if token == "" {
+		c.sendAuthResult(msg.ID, time.Time{}, errTokenRequired)
+		return
+	}
+
+	claims, err := c.hub.validateToken(token)
+	if err == nil && claims.UserID != c.UserID {
+		err = errTokenUserMismatch
+	}
+	if err != nil {
+		c.logger.Warn("Client token refresh refused",
+			zap.Uint("user_id", c.UserID),
+			zap.Error(err),
+		)
+		c.sendAuthResult(msg.ID, time.Time{}, err)
 		return
 	}
 
-	c.hub.mu.Lock()
-	defer c.hub.mu.Unlock()
+	var expiresAt time.Time
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	c.SetAccessToken(token, expiresAt)
+	c.sendAuthResult(msg.ID, expiresAt, nil)
+}
 
-	// Add to new room
-	if c.hub.rooms[msg.Channel] == nil {
-		c.hub.rooms[msg.Channel] = make(map[*Client]bool)
+// sendAuthResult answers an auth message, echoing its id
+func (c *Client) sendAuthResult(id string, expiresAt time.Time, err error) {
+	response := map[string]interface{}{
+		"type":    "auth",
+		"success": err == nil,
+	}
+	if id != "" {
+		response["id"] = id
+	}
+	if err != nil {
+		response["error"] = authError(err)
+	} else if !expiresAt.IsZero() {
+		response["expires_at"] = expiresAt
 	}
-	c.hub.rooms[msg.Channel][c] = true
 
-	// Remove from old room if different
-	if c.Room != "" && c.Room != msg.Channel && c.hub.rooms[c.Room] != nil {
-		delete(c.hub.rooms[c.Room], c)
-		if len(c.hub.rooms[c.Room]) == 0 {
-			delete(c.hub.rooms, c.Room)
-		}
+	data, _ := json.Marshal(response)
+	c.hub.sendTo(c, outgoing{data: data, priority: PriorityHigh})
+}
+
+// authError returns the message shown to clients for a refused token
+func authError(err error) string {
+	switch {
+	case errors.Is(err, errTokenRequired):
+		return "Token required"
+	case errors.Is(err, errTokenUserMismatch):
+		return "Token belongs to another user"
+	case errors.Is(err, ErrTokenRefreshUnsupported):
+		return "Token refresh not supported"
+	default:
+		return "Invalid token"
 	}
+}
 
-	c.Room = msg.Channel
+func (c *Client) handleSubscribe(msg *ClientMessage) {
+	if msg.Channel == "" {
+		c.acknowledge("subscribed", msg, errChannelRequired)
+		return
+	}
+
+	query, replay, err := ReplayQuery(msg.LastEventID, msg.Since)
+	if err != nil {
+		c.acknowledge("subscribed", msg, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	filter, err := c.hub.AuthorizeChannel(ctx, c.Subscriber, msg.Channel)
+	cancel()
+	if err == nil {
+		err = c.hub.subscribe(c, msg.Channel, filter)
+	}
+	if err != nil {
+		c.logger.Warn("Client subscription refused",
+			zap.Uint("user_id", c.UserID),
+			zap.String("channel", msg.Channel),
+			zap.Error(err),
+		)
+		c.acknowledge("subscribed", msg, err)
+		return
+	}
 
 	c.logger.Info("Client subscribed to channel",
 		zap.Uint("user_id", c.UserID),
 		zap.String("channel", msg.Channel),
 	)
 
-	// Send confirmation
-	response := map[string]interface{}{
-		"type":    "subscribed",
-		"channel": msg.Channel,
-		"success": true,
+	c.acknowledge("subscribed", msg, nil)
+	c.hub.announcePresence(c, msg.Channel)
+
+	if replay {
+		c.hub.Replay(c, []string{msg.Channel}, query)
 	}
-	data, _ := json.Marshal(response)
-	c.send <- data
 }
 
 func (c *Client) handleUnsubscribe(msg *ClientMessage) {
 	if msg.Channel == "" {
+		c.acknowledge("unsubscribed", msg, errChannelRequired)
 		return
 	}
 
-	c.hub.mu.Lock()
-	defer c.hub.mu.Unlock()
-
-	if c.hub.rooms[msg.Channel] != nil {
-		delete(c.hub.rooms[msg.Channel], c)
-		if len(c.hub.rooms[msg.Channel]) == 0 {
-			delete(c.hub.rooms, msg.Channel)
-		}
+	if err := c.hub.unsubscribe(c, msg.Channel); err != nil {
+		c.acknowledge("unsubscribed", msg, err)
+		return
 	}
 
 	c.logger.Info("Client unsubscribed from channel",
@@ -219,17 +356,90 @@ func (c *Client) handleUnsubscribe(msg *ClientMessage) {
 		zap.String("channel", msg.Channel),
 	)
 
-	// Send confirmation
+	c.acknowledge("unsubscribed", msg, nil)
+}
+
+// Subscriptions returns the channels the client is subscribed to
+func (c *Client) Subscriptions() []string {
+	c.hub.mu.RLock()
+	defer c.hub.mu.RUnlock()
+
+	channels := make([]string, 0, len(c.subscriptions))
+	for channel := range c.subscriptions {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+// acknowledge answers a subscribe or unsubscribe message, echoing its id
+func (c *Client) acknowledge(ackType string, msg *ClientMessage, err error) {
+	c.hub.sendTo(c, outgoing{
+		data:     c.acknowledgement(ackType, msg.ID, msg.Channel, err),
+		priority: PriorityHigh,
+	})
+}
+
+// acknowledgement builds the answer to a subscription change
+func (c *Client) acknowledgement(ackType, id, channel string, err error) []byte {
 	response := map[string]interface{}{
-		"type":    "unsubscribed",
-		"channel": msg.Channel,
-		"success": true,
+		"type":    ackType,
+		"channel": channel,
+		"success": err == nil,
+	}
+	if id != "" {
+		response["id"] = id
 	}
+	if err != nil {
+		response["error"] = subscriptionError(err)
+	}
+
 	data, _ := json.Marshal(response)
-	c.send <- data
+	return data
+}
+
+// subscriptionError returns the message shown to clients for err
+func subscriptionError(err error) string {
+	switch {
+	case errors.Is(err, errChannelRequired):
+		return "Channel required"
+	case errors.Is(err, ErrChannelForbidden):
+		return "Not authorized for channel"
+	case errors.Is(err, ErrSubscriptionLimit):
+		return "Subscription limit reached"
+	case errors.Is(err, ErrNotSubscribed):
+		return "Not subscribed to channel"
+	case errors.Is(err, ErrInvalidReplay):
+		return "Invalid last_event_id or since"
+	default:
+		return "Subscription failed"
+	}
 }
 
 func (c *Client) handleBroadcast(msg *ClientMessage) {
+	// A broadcast without a channel reaches every client, so only admins
+	// may send one
+	if msg.Channel == "" && !c.IsAdmin {
+		c.logger.Warn("Client not authorized to broadcast to all clients",
+			zap.Uint("user_id", c.UserID),
+		)
+		return
+	}
+
+	if msg.Channel != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+		err := c.hub.AuthorizePublish(ctx, c.Subscriber, msg.Channel)
+		cancel()
+		if err != nil {
+			c.logger.Warn("Client not authorized to publish",
+				zap.Uint("user_id", c.UserID),
+				zap.String("channel", msg.Channel),
+				zap.Error(err),
+			)
+			return
+		}
+	}
+
 	// Create broadcast message
 	broadcastMsg := &Message{
 		Type:      "broadcast",
@@ -240,22 +450,66 @@ func (c *Client) handleBroadcast(msg *ClientMessage) {
 		Timestamp: time.Now(),
 	}
 
-	c.hub.broadcast <- broadcastMsg
+	c.hub.publish(broadcastMsg)
 }
 
+// handlePresence sets the user's status on all of their devices. The
+// payload holds a status (online, away or busy) and an optional custom
+// text.
 func (c *Client) handlePresence(msg *ClientMessage) {
 	status, ok := msg.Payload["status"].(string)
 	if !ok {
 		return
 	}
+	text, _ := msg.Payload["text"].(string)
+
+	// Store and send the presence change to those who see it
+	if err := c.hub.SetStatus(c.UserID, status, text); err != nil {
+		c.logger.Warn("Presence change refused",
+			zap.Uint("user_id", c.UserID),
+			zap.String("status", status),
+			zap.Error(err),
+		)
+	}
+}
 
-	c.hub.mu.Lock()
-	if info, exists := c.hub.presence[c.UserID]; exists {
-		info.Status = status
-		info.LastSeen = time.Now()
+// handleTyping tells the other members of a channel that the user started
+// or stopped typing. Indicators expire after typingTimeout unless they are
+// sent again.
+func (c *Client) handleTyping(msg *ClientMessage) {
+	typing, ok := msg.Payload["typing"].(bool)
+	if !ok {
+		typing = true
 	}
-	c.hub.mu.Unlock()
 
-	// Broadcast presence change
-	c.hub.broadcastPresenceUpdate(c.UserID, status)
+	payload := map[string]interface{}{
+		"typing": typing,
+	}
+	if typing {
+		payload["expires_at"] = time.Now().Add(typingTimeout)
+	}
+
+	if err := c.hub.publishChannelEvent(c, "typing", msg.Channel, "typing", payload); err != nil {
+		c.logger.Warn("Typing event refused",
+			zap.Uint("user_id", c.UserID),
+			zap.String("channel", msg.Channel),
+			zap.Error(err),
+		)
+	}
+}
+
+// handleActivity sends an ephemeral event, such as what the user is
+// viewing, to the other members of a channel
+func (c *Client) handleActivity(msg *ClientMessage) {
+	if msg.Event == "" {
+		return
+	}
+
+	if err := c.hub.publishChannelEvent(c, "activity", msg.Channel, msg.Event, msg.Payload); err != nil {
+		c.logger.Warn("Activity event refused",
+			zap.Uint("user_id", c.UserID),
+			zap.String("channel", msg.Channel),
+			zap.Error(err),
+		)
+	}
 }
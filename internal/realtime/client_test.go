@@ -0,0 +1,128 @@
+package realtime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go-mobile-backend-template/internal/services/auth"
+
+	"go.uber.org/zap"
+)
+
+// registeredClient returns a client of userID that the hub sends to,
+// without a connection
+func registeredClient(t *testing.T, hub *Hub, userID uint) *Client {
+	t.Helper()
+	client := NewClient(nil, hub, Subscriber{UserID: userID}, "", "", zap.NewNop())
+	hub.mu.Lock()
+	hub.clients[client] = true
+	hub.mu.Unlock()
+	return client
+}
+
+// lastMessage decodes the last message queued for client
+func lastMessage(t *testing.T, client *Client) map[string]interface{} {
+	t.Helper()
+	messages, _ := client.queue.drain()
+	if len(messages) == 0 {
+		t.Fatal("no message queued for the client")
+	}
+	var message map[string]interface{}
+	if err := json.Unmarshal(messages[len(messages)-1], &message); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	return message
+}
+
+func TestClientAuthReplacesAccessToken(t *testing.T) {
+	jwtService := auth.NewJWTService("test-secret", 15, 60, auth.JWTOptions{})
+	own, err := jwtService.GenerateAccessToken(1, "user@example.com", false, 1)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+	other, err := jwtService.GenerateAccessToken(2, "other@example.com", false, 2)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		validator TokenValidator
+		token     string
+		wantError string
+	}{
+		{name: "token of the same user", validator: jwtService.ValidateToken, token: own},
+		{name: "token of another user", validator: jwtService.ValidateToken, token: other, wantError: "Token belongs to another user"},
+		{name: "invalid token", validator: jwtService.ValidateToken, token: "not-a-token", wantError: "Invalid token"},
+		{name: "missing token", validator: jwtService.ValidateToken, wantError: "Token required"},
+		{name: "no validator", token: own, wantError: "Token refresh not supported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub, err := NewHubWithConfig(zap.NewNop(), HubConfig{})
+			if err != nil {
+				t.Fatalf("NewHubWithConfig() error = %v", err)
+			}
+			if tt.validator != nil {
+				hub.SetTokenValidator(tt.validator)
+			}
+			client := registeredClient(t, hub, 1)
+			client.SetAccessToken("connect-token", time.Now())
+
+			client.HandleMessage(&ClientMessage{ID: "7", Type: "auth", Payload: map[string]interface{}{"token": tt.token}})
+
+			reply := lastMessage(t, client)
+			if reply["type"] != "auth" || reply["id"] != "7" {
+				t.Fatalf("reply = %v, want an auth reply to 7", reply)
+			}
+
+			token, expiresAt := client.AccessToken()
+			if tt.wantError == "" {
+				if reply["success"] != true || reply["expires_at"] == nil {
+					t.Fatalf("reply = %v, want success with expires_at", reply)
+				}
+				if token != tt.token || !expiresAt.After(time.Now()) {
+					t.Errorf("AccessToken() = %q, %v, want the new token and its expiry", token, expiresAt)
+				}
+				return
+			}
+
+			if reply["success"] != false || reply["error"] != tt.wantError {
+				t.Fatalf("reply = %v, want error %q", reply, tt.wantError)
+			}
+			if token != "connect-token" {
+				t.Errorf("AccessToken() = %q after a refused refresh, want the connecting token", token)
+			}
+		})
+	}
+}
+
+func TestClientBroadcastToAllClients(t *testing.T) {
+	tests := []struct {
+		name    string
+		isAdmin bool
+		want    int
+	}{
+		{name: "user", want: 0},
+		{name: "admin", isAdmin: true, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub, err := NewHubWithConfig(zap.NewNop(), HubConfig{})
+			if err != nil {
+				t.Fatalf("NewHubWithConfig() error = %v", err)
+			}
+			client := registeredClient(t, hub, 1)
+			client.IsAdmin = tt.isAdmin
+
+			client.HandleMessage(&ClientMessage{Type: "broadcast", Event: "hello", Payload: map[string]interface{}{"text": "hi"}})
+
+			if got := len(hub.broadcast); got != tt.want {
+				t.Errorf("broadcasts published = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,9 +1,14 @@
 package realtime
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/lib/pq"
@@ -11,13 +16,139 @@ import (
 	"gorm.io/gorm"
 )
 
-// DBStreamer handles database change notifications
+// notifyPayloadLimit is the largest notification the triggers send with
+// full rows. pg_notify rejects payloads of 8000 bytes or more; bigger
+// changes are sent as their key columns and the row is fetched.
+const notifyPayloadLimit = 7900
+
+// dbStreamerLockKey is the Postgres advisory lock held by the replica that
+// streams database changes
+const dbStreamerLockKey int64 = 0x7265616c74696d65
+
+// defaultLockRetry is how often a DBStreamer tries to take the streamer lock
+// and, once it holds it, checks that it still does
+const defaultLockRetry = 5 * time.Second
+
+// secretColumns are never emitted, whatever the column policy says
+var secretColumns = map[string][]string{
+	"users":                     {"password"},
+	"sessions":                  {"token", "refresh_token"},
+	"refresh_tokens":            {"token"},
+	"user_2fa":                  {"secret", "backup_codes"},
+	"oauth_providers":           {"access_token", "refresh_token"},
+	"password_reset_tokens":     {"token"},
+	"email_verification_tokens": {"token"},
+	"api_keys":                  {"key_hash"},
+	"jwt_signing_keys":          {"private_key"},
+}
+
+// ColumnPolicy limits the columns of a table that are streamed. With Allow
+// set only those columns are sent; Deny columns are never sent.
+type ColumnPolicy struct {
+	Allow []string
+	Deny  []string
+}
+
+// allows reports whether column may be streamed
+func (p *ColumnPolicy) allows(column string) bool {
+	for _, denied := range p.Deny {
+		if denied == column {
+			return false
+		}
+	}
+	if len(p.Allow) == 0 {
+		return true
+	}
+	for _, allowed := range p.Allow {
+		if allowed == column {
+			return true
+		}
+	}
+	return false
+}
+
+// filterRow returns row without the columns the policy hides
+func (p *ColumnPolicy) filterRow(row map[string]interface{}) map[string]interface{} {
+	if row == nil {
+		return nil
+	}
+	filtered := make(map[string]interface{}, len(row))
+	for column, value := range row {
+		if p.allows(column) {
+			filtered[column] = value
+		}
+	}
+	return filtered
+}
+
+// filterColumns returns columns without the ones the policy hides
+func (p *ColumnPolicy) filterColumns(columns []string) []string {
+	filtered := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if p.allows(column) {
+			filtered = append(filtered, column)
+		}
+	}
+	return filtered
+}
+
+// ChangeSource delivers the row changes of watched tables to a DBStreamer.
+// NotifySource uses triggers and LISTEN/NOTIFY; ReplicationSource reads a
+// logical replication slot.
+type ChangeSource interface {
+	// Watch prepares a table so its changes are delivered. Sources that
+	// cannot send a whole row send keyColumns instead.
+	Watch(ctx context.Context, table string, keyColumns []string) error
+	// Start delivers changes to emit until ctx ends
+	Start(ctx context.Context, emit func(context.Context, *DBChangeEvent)) error
+	// Stop releases the source's connections
+	Stop()
+}
+
+// StreamerLock elects the one replica whose DBStreamer reads its source, so
+// changes are broadcast once however many replicas share the backplane
+type StreamerLock interface {
+	// Acquire takes the lock without waiting and reports whether it is held
+	Acquire(ctx context.Context) (bool, error)
+	// Held reports whether the lock is still held
+	Held(ctx context.Context) bool
+	// Release gives up the lock
+	Release()
+}
+
+// DBStreamerConfig configures a DBStreamer
+type DBStreamerConfig struct {
+	// Source delivers the changes. It defaults to a NotifySource on DSN.
+	Source ChangeSource
+	// Lock elects the streaming replica. It defaults to a Postgres
+	// advisory lock.
+	Lock StreamerLock
+	// LockRetry is how often the lock is tried and, while held, checked.
+	// It defaults to 5 seconds.
+	LockRetry time.Duration
+	// DSN is the connection string the default source listens on
+	DSN string
+	// Columns limits the streamed columns of each table. Secret columns
+	// such as users.password are always hidden.
+	Columns map[string]ColumnPolicy
+}
+
+// DBStreamer broadcasts the changes of watched tables to db:* channels. Every
+// replica watches the tables, but only the one holding the streamer lock
+// reads the source.
 type DBStreamer struct {
-	db       *gorm.DB
-	hub      *Hub
-	listener *pq.Listener
-	logger   *zap.Logger
-	tables   map[string]bool // Tables to watch
+	db        *gorm.DB
+	hub       *Hub
+	source    ChangeSource
+	lock      StreamerLock
+	lockRetry time.Duration
+	logger    *zap.Logger
+
+	mu       sync.RWMutex
+	tables   map[string][]string     // Primary keys of the tables to watch
+	policies map[string]ColumnPolicy // Streamed columns per table
+	cancel   context.CancelFunc
+	done     chan struct{}
 }
 
 // DBChangeEvent represents a database change event
@@ -26,79 +157,491 @@ type DBChangeEvent struct {
 	Operation string                 `json:"operation"` // INSERT, UPDATE, DELETE
 	Data      map[string]interface{} `json:"data"`
 	OldData   map[string]interface{} `json:"old_data,omitempty"`
-	Timestamp time.Time              `json:"timestamp"`
+	// Changed lists the columns an UPDATE changed
+	Changed []string `json:"changed,omitempty"`
+	// Key holds the primary key of the row and the columns row filters
+	// read when Truncated is set
+	Key map[string]interface{} `json:"key,omitempty"`
+	// Truncated notifications carried only the key because the row was too
+	// large to send
+	Truncated bool      `json:"truncated,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
 }
 
 // NewDBStreamer creates a new database streamer
-func NewDBStreamer(db *gorm.DB, hub *Hub, logger *zap.Logger) *DBStreamer {
+func NewDBStreamer(db *gorm.DB, hub *Hub, cfg DBStreamerConfig, logger *zap.Logger) *DBStreamer {
+	policies := make(map[string]ColumnPolicy)
+	for table, policy := range cfg.Columns {
+		policies[table] = policy
+	}
+	for table, columns := range secretColumns {
+		policy := policies[table]
+		policy.Deny = append(append([]string{}, policy.Deny...), columns...)
+		policies[table] = policy
+	}
+
+	source := cfg.Source
+	if source == nil {
+		source = NewNotifySource(db, cfg.DSN, logger)
+	}
+
+	lock := cfg.Lock
+	if lock == nil {
+		lock = NewAdvisoryLock(db, dbStreamerLockKey)
+	}
+
+	lockRetry := cfg.LockRetry
+	if lockRetry <= 0 {
+		lockRetry = defaultLockRetry
+	}
+
 	return &DBStreamer{
+		db:        db,
+		hub:       hub,
+		source:    source,
+		lock:      lock,
+		lockRetry: lockRetry,
+		logger:    logger,
+		tables:    make(map[string][]string),
+		policies:  policies,
+	}
+}
+
+// WatchTable adds a table to watch for changes. The hub's channel rules
+// decide which columns are sent with rows too large to notify, so set its
+// authorizer first.
+func (s *DBStreamer) WatchTable(tableName string) error {
+	primaryKey, err := s.primaryKey(tableName)
+	if err != nil {
+		return err
+	}
+
+	if err := s.source.Watch(context.Background(), tableName, s.keyColumns(tableName, primaryKey)); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.tables[tableName] = primaryKey
+	s.mu.Unlock()
+
+	s.logger.Info("Watching table for changes", zap.String("table", tableName))
+	return nil
+}
+
+// keyColumns returns the columns sent instead of a row too large to notify:
+// its primary key, which the row is fetched by, and the columns the row
+// filters of its channels read, as deleted rows cannot be fetched
+func (s *DBStreamer) keyColumns(tableName string, primaryKey []string) []string {
+	columns := append([]string{}, primaryKey...)
+	if s.hub == nil {
+		return columns
+	}
+
+	seen := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		seen[column] = true
+	}
+	for _, channel := range []string{"db:" + tableName, "db:*"} {
+		for _, column := range s.hub.RowFilterColumns(channel) {
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		}
+	}
+	return columns
+}
+
+// primaryKey returns the primary key columns of a table
+func (s *DBStreamer) primaryKey(tableName string) ([]string, error) {
+	var columns []string
+	err := s.db.Raw(`
+		SELECT a.attname
+		FROM pg_index i
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+		WHERE i.indrelid = ?::regclass AND i.indisprimary
+		ORDER BY array_position(i.indkey::int2[], a.attnum)
+	`, pq.QuoteIdentifier(tableName)).Scan(&columns).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get primary key of %s: %w", tableName, err)
+	}
+	return columns, nil
+}
+
+// Start begins streaming database changes once this replica holds the
+// streamer lock. Replicas without the lock keep trying, and take over when
+// the holder stops or loses its database connection.
+func (s *DBStreamer) Start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+
+	s.mu.Lock()
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	s.mu.Unlock()
+
+	go s.electLoop(ctx)
+
+	return nil
+}
+
+// electLoop tries to take the streamer lock until ctx ends, and streams
+// changes while it is held
+func (s *DBStreamer) electLoop(ctx context.Context) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.lockRetry)
+	defer ticker.Stop()
+
+	for {
+		s.lead(ctx, ticker.C)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// lead reads the source while this replica holds the streamer lock, checking
+// the lock on every tick. It returns at once when the lock is held elsewhere.
+func (s *DBStreamer) lead(ctx context.Context, tick <-chan time.Time) {
+	held, err := s.lock.Acquire(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error("Failed to take database streamer lock", zap.Error(err))
+		}
+		return
+	}
+	if !held {
+		return
+	}
+	defer s.lock.Release()
+
+	sourceCtx, cancel := context.WithCancel(ctx)
+	if err := s.source.Start(sourceCtx, s.broadcastDBChange); err != nil {
+		cancel()
+		s.logger.Error("Failed to start database change source", zap.Error(err))
+		return
+	}
+	defer s.source.Stop()
+	defer cancel()
+
+	s.logger.Info("Streaming database changes on this replica")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			if !s.lock.Held(ctx) {
+				if ctx.Err() == nil {
+					s.logger.Warn("Lost database streamer lock")
+				}
+				return
+			}
+		}
+	}
+}
+
+func (s *DBStreamer) broadcastDBChange(ctx context.Context, event *DBChangeEvent) {
+	s.mu.RLock()
+	primaryKey, watched := s.tables[event.Table]
+	policy := s.policies[event.Table]
+	s.mu.RUnlock()
+
+	// Only broadcast if table is being watched
+	if !watched {
+		return
+	}
+
+	if event.Truncated {
+		if err := s.fetchRow(ctx, event, primaryKey); err != nil {
+			s.logger.Error("Failed to fetch changed row",
+				zap.String("table", event.Table),
+				zap.Error(err),
+			)
+			return
+		}
+	}
+
+	s.logger.Debug("Broadcasting database change",
+		zap.String("table", event.Table),
+		zap.String("operation", event.Operation),
+	)
+
+	// Broadcast to specific table channel
+	channelName := fmt.Sprintf("db:%s", event.Table)
+	payload := map[string]interface{}{
+		"table":     event.Table,
+		"operation": event.Operation,
+		"data":      policy.filterRow(event.Data),
+		"timestamp": event.Timestamp,
+	}
+
+	if event.Operation == "UPDATE" {
+		changed := policy.filterColumns(event.Changed)
+		payload["changed"] = changed
+
+		// Old rows are not fetched for truncated notifications
+		if event.OldData != nil {
+			oldData := policy.filterRow(event.OldData)
+			payload["old_data"] = oldData
+
+			diff := make(map[string]interface{}, len(changed))
+			for _, column := range changed {
+				diff[column] = map[string]interface{}{
+					"old": oldData[column],
+					"new": event.Data[column],
+				}
+			}
+			payload["diff"] = diff
+		}
+	}
+
+	s.hub.BroadcastToChannel(channelName, "db_change", payload)
+
+	// Also broadcast to general db:* channel
+	s.hub.BroadcastToChannel("db:*", "db_change", payload)
+}
+
+// fetchRow fills in the row of a notification that only carried its key
+// columns, finding it by primaryKey. Deleted rows cannot be fetched, so
+// their key columns are sent as the data. The fetched row may include later
+// changes.
+func (s *DBStreamer) fetchRow(ctx context.Context, event *DBChangeEvent, primaryKey []string) error {
+	columns := make([]string, 0, len(primaryKey))
+	for _, column := range primaryKey {
+		if _, ok := event.Key[column]; ok {
+			columns = append(columns, column)
+		}
+	}
+	if event.Operation == "DELETE" || len(columns) == 0 {
+		event.Data = event.Key
+		return nil
+	}
+
+	conditions := make([]string, 0, len(columns))
+	args := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		conditions = append(conditions, pq.QuoteIdentifier(column)+" = ?")
+		args = append(args, event.Key[column])
+	}
+
+	query := fmt.Sprintf("SELECT to_jsonb(t) FROM %s t WHERE %s",
+		pq.QuoteIdentifier(event.Table), strings.Join(conditions, " AND "))
+
+	var data []byte
+	if err := s.db.WithContext(ctx).Raw(query, args...).Row().Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Deleted since the notification was sent
+			event.Data = event.Key
+			return nil
+		}
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(&event.Data)
+}
+
+// Stop stops streaming, releases the streamer lock and waits for both
+func (s *DBStreamer) Stop() {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// AdvisoryLock is a StreamerLock held as a Postgres session advisory lock on
+// a dedicated connection. The lock is released when that connection closes,
+// so a replica that crashes or loses the database hands it over.
+type AdvisoryLock struct {
+	db  *gorm.DB
+	key int64
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// NewAdvisoryLock creates a lock on the advisory lock key
+func NewAdvisoryLock(db *gorm.DB, key int64) *AdvisoryLock {
+	return &AdvisoryLock{
+		db:  db,
+		key: key,
+	}
+}
+
+// Acquire tries to take the lock on a new connection
+func (l *AdvisoryLock) Acquire(ctx context.Context) (bool, error) {
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return false, err
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to open lock connection: %w", err)
+	}
+
+	var held bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&held); err != nil {
+		conn.Close()
+		return false, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+	if !held {
+		conn.Close()
+		return false, nil
+	}
+
+	l.mu.Lock()
+	l.conn = conn
+	l.mu.Unlock()
+
+	return true, nil
+}
+
+// Held checks that the lock's connection is still open
+func (l *AdvisoryLock) Held(ctx context.Context) bool {
+	l.mu.Lock()
+	conn := l.conn
+	l.mu.Unlock()
+
+	if conn == nil {
+		return false
+	}
+
+	var one int
+	return conn.QueryRowContext(ctx, "SELECT 1").Scan(&one) == nil
+}
+
+// Release unlocks and closes the lock's connection
+func (l *AdvisoryLock) Release() {
+	l.mu.Lock()
+	conn := l.conn
+	l.conn = nil
+	l.mu.Unlock()
+
+	if conn == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key)
+	conn.Close()
+}
+
+// NotifySource delivers changes through triggers that call pg_notify on
+// every watched table, received with LISTEN. It is the default source.
+// Changes made while it is not listening are lost.
+type NotifySource struct {
+	db       *gorm.DB
+	dsn      string
+	listener *pq.Listener
+	logger   *zap.Logger
+}
+
+// NewNotifySource creates a source listening on the database at dsn
+func NewNotifySource(db *gorm.DB, dsn string, logger *zap.Logger) *NotifySource {
+	return &NotifySource{
 		db:     db,
-		hub:    hub,
+		dsn:    dsn,
 		logger: logger,
-		tables: make(map[string]bool),
 	}
 }
 
-// WatchTable adds a table to watch for changes
-func (s *DBStreamer) WatchTable(tableName string) error {
-	s.tables[tableName] = true
+// Watch creates the triggers notifying changes to a table
+func (s *NotifySource) Watch(ctx context.Context, tableName string, keyColumns []string) error {
+	// The row's key columns, sent instead of the row when it is too large
+	keyPairs := make([]string, 0, len(keyColumns))
+	for _, column := range keyColumns {
+		keyPairs = append(keyPairs, fmt.Sprintf("%s, row_data -> %s", pq.QuoteLiteral(column), pq.QuoteLiteral(column)))
+	}
 
 	// Create trigger function if not exists
 	triggerFunc := fmt.Sprintf(`
-		CREATE OR REPLACE FUNCTION notify_%s_changes()
+		CREATE OR REPLACE FUNCTION %s()
 		RETURNS trigger AS $$
 		DECLARE
-			data json;
-			notification json;
+			new_row jsonb;
+			old_row jsonb;
+			row_data jsonb;
+			changed jsonb;
+			notification jsonb;
 		BEGIN
-			IF (TG_OP = 'DELETE') THEN
-				data = row_to_json(OLD);
+			IF (TG_OP <> 'INSERT') THEN
+				old_row = to_jsonb(OLD);
+			END IF;
+			IF (TG_OP <> 'DELETE') THEN
+				new_row = to_jsonb(NEW);
+			END IF;
+			row_data = COALESCE(new_row, old_row);
+
+			IF (TG_OP = 'UPDATE') THEN
+				SELECT COALESCE(jsonb_agg(n.key), '[]'::jsonb) INTO changed
+				FROM jsonb_each(new_row) n
+				WHERE n.value IS DISTINCT FROM old_row -> n.key;
 			ELSE
-				data = row_to_json(NEW);
+				old_row = NULL;
 			END IF;
-			
-			notification = json_build_object(
+
+			notification = jsonb_build_object(
 				'table', TG_TABLE_NAME,
 				'operation', TG_OP,
-				'data', data,
+				'data', row_data,
+				'old_data', old_row,
+				'changed', changed,
 				'timestamp', NOW()
 			);
-			
+
+			IF octet_length(notification::text) > %d THEN
+				notification = jsonb_build_object(
+					'table', TG_TABLE_NAME,
+					'operation', TG_OP,
+					'key', jsonb_build_object(%s),
+					'changed', changed,
+					'truncated', true,
+					'timestamp', NOW()
+				);
+			END IF;
+
 			PERFORM pg_notify('db_changes', notification::text);
 			RETURN NULL;
 		END;
 		$$ LANGUAGE plpgsql;
-	`, tableName)
+	`, pq.QuoteIdentifier("notify_"+tableName+"_changes"), notifyPayloadLimit, strings.Join(keyPairs, ", "))
 
-	if err := s.db.Exec(triggerFunc).Error; err != nil {
+	if err := s.db.WithContext(ctx).Exec(triggerFunc).Error; err != nil {
 		return fmt.Errorf("failed to create trigger function: %w", err)
 	}
 
 	// Create triggers for INSERT, UPDATE, DELETE
 	for _, op := range []string{"INSERT", "UPDATE", "DELETE"} {
-		triggerName := fmt.Sprintf("%s_%s_notify", tableName, op)
+		triggerName := pq.QuoteIdentifier(fmt.Sprintf("%s_%s_notify", tableName, strings.ToLower(op)))
+		table := pq.QuoteIdentifier(tableName)
 		trigger := fmt.Sprintf(`
 			DROP TRIGGER IF EXISTS %s ON %s;
 			CREATE TRIGGER %s
 			AFTER %s ON %s
-			FOR EACH ROW EXECUTE FUNCTION notify_%s_changes();
-		`, triggerName, tableName, triggerName, op, tableName, tableName)
+			FOR EACH ROW EXECUTE FUNCTION %s();
+		`, triggerName, table, triggerName, op, table, pq.QuoteIdentifier("notify_"+tableName+"_changes"))
 
-		if err := s.db.Exec(trigger).Error; err != nil {
+		if err := s.db.WithContext(ctx).Exec(trigger).Error; err != nil {
 			return fmt.Errorf("failed to create %s trigger: %w", op, err)
 		}
 	}
 
-	s.logger.Info("Watching table for changes", zap.String("table", tableName))
 	return nil
 }
 
-// Start begins listening for database changes
-func (s *DBStreamer) Start(ctx context.Context) error {
-	// For PostgreSQL, we need to construct connection string
-	// This is a simplified version - in production, get from config
-	connStr := "host=localhost port=5433 user=app password=secret dbname=myapp sslmode=disable"
-
+// Start listens for notifications and passes them to emit
+func (s *NotifySource) Start(ctx context.Context, emit func(context.Context, *DBChangeEvent)) error {
 	// Create listener
 	reportProblem := func(ev pq.ListenerEventType, err error) {
 		if err != nil {
@@ -106,85 +649,59 @@ func (s *DBStreamer) Start(ctx context.Context) error {
 		}
 	}
 
-	s.listener = pq.NewListener(connStr, 10*time.Second, time.Minute, reportProblem)
+	listener := pq.NewListener(s.dsn, 10*time.Second, time.Minute, reportProblem)
 
 	// Listen on channel
-	if err := s.listener.Listen("db_changes"); err != nil {
+	if err := listener.Listen("db_changes"); err != nil {
+		listener.Close()
 		return fmt.Errorf("failed to listen on channel: %w", err)
 	}
+	s.listener = listener
 
 	s.logger.Info("Database change listener started")
 
 	// Start listening for notifications
-	go s.listenLoop(ctx)
+	go s.listenLoop(ctx, listener, emit)
 
 	return nil
 }
 
-func (s *DBStreamer) listenLoop(ctx context.Context) {
+func (s *NotifySource) listenLoop(ctx context.Context, listener *pq.Listener, emit func(context.Context, *DBChangeEvent)) {
 	for {
 		select {
 		case <-ctx.Done():
 			s.logger.Info("Stopping database change listener")
-			s.listener.Close()
+			listener.Close()
 			return
 
-		case notification := <-s.listener.Notify:
+		case notification := <-listener.Notify:
 			if notification == nil {
 				continue
 			}
 
-			// Parse notification
+			// Parse notification. Numbers stay exact so large keys can be
+			// looked up.
 			var event DBChangeEvent
-			if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+			decoder := json.NewDecoder(bytes.NewReader([]byte(notification.Extra)))
+			decoder.UseNumber()
+			if err := decoder.Decode(&event); err != nil {
 				s.logger.Error("Failed to parse notification", zap.Error(err))
 				continue
 			}
 
-			// Broadcast to WebSocket clients
-			s.broadcastDBChange(&event)
+			emit(ctx, &event)
 
 		case <-time.After(90 * time.Second):
 			// Ping to check connection
-			if err := s.listener.Ping(); err != nil {
+			if err := listener.Ping(); err != nil {
 				s.logger.Error("Listener ping failed", zap.Error(err))
 			}
 		}
 	}
 }
 
-func (s *DBStreamer) broadcastDBChange(event *DBChangeEvent) {
-	// Only broadcast if table is being watched
-	if !s.tables[event.Table] {
-		return
-	}
-
-	s.logger.Debug("Broadcasting database change",
-		zap.String("table", event.Table),
-		zap.String("operation", event.Operation),
-	)
-
-	// Broadcast to specific table channel
-	channelName := fmt.Sprintf("db:%s", event.Table)
-	payload := map[string]interface{}{
-		"table":     event.Table,
-		"operation": event.Operation,
-		"data":      event.Data,
-		"timestamp": event.Timestamp,
-	}
-
-	if event.OldData != nil {
-		payload["old_data"] = event.OldData
-	}
-
-	s.hub.BroadcastToChannel(channelName, "db_change", payload)
-
-	// Also broadcast to general db:* channel
-	s.hub.BroadcastToChannel("db:*", "db_change", payload)
-}
-
-// Stop stops the database change listener
-func (s *DBStreamer) Stop() {
+// Stop stops the listener
+func (s *NotifySource) Stop() {
 	if s.listener != nil {
 		s.listener.Close()
 	}
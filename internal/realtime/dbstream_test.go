@@ -0,0 +1,246 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// memoryLocks stands in for the database all replicas take the streamer
+// lock in
+type memoryLocks struct {
+	mu     sync.Mutex
+	holder StreamerLock
+}
+
+// replicaLock is one replica's handle on memoryLocks
+type replicaLock struct {
+	locks *memoryLocks
+	lost  bool
+}
+
+func (l *replicaLock) Acquire(ctx context.Context) (bool, error) {
+	l.locks.mu.Lock()
+	defer l.locks.mu.Unlock()
+	if l.locks.holder != nil && l.locks.holder != StreamerLock(l) {
+		return false, nil
+	}
+	l.locks.holder = l
+	l.lost = false
+	return true, nil
+}
+
+func (l *replicaLock) Held(ctx context.Context) bool {
+	l.locks.mu.Lock()
+	defer l.locks.mu.Unlock()
+	return !l.lost && l.locks.holder == StreamerLock(l)
+}
+
+func (l *replicaLock) Release() {
+	l.locks.mu.Lock()
+	defer l.locks.mu.Unlock()
+	if l.locks.holder == StreamerLock(l) {
+		l.locks.holder = nil
+	}
+}
+
+// lose makes Held fail as a lost database connection would. Other replicas
+// can take the lock once the holder releases it.
+func (l *replicaLock) lose() {
+	l.locks.mu.Lock()
+	defer l.locks.mu.Unlock()
+	l.lost = true
+}
+
+// countingSource is a ChangeSource that records whether it is running
+type countingSource struct {
+	mu      sync.Mutex
+	running bool
+	starts  int
+}
+
+func (s *countingSource) Watch(ctx context.Context, table string, keyColumns []string) error {
+	return nil
+}
+
+func (s *countingSource) Start(ctx context.Context, emit func(context.Context, *DBChangeEvent)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = true
+	s.starts++
+	return nil
+}
+
+func (s *countingSource) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = false
+}
+
+func (s *countingSource) state() (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running, s.starts
+}
+
+type replica struct {
+	streamer *DBStreamer
+	source   *countingSource
+	lock     *replicaLock
+}
+
+func newReplicas(n int) []*replica {
+	locks := &memoryLocks{}
+	replicas := make([]*replica, n)
+	for i := range replicas {
+		source := &countingSource{}
+		lock := &replicaLock{locks: locks}
+		streamer := NewDBStreamer(nil, nil, DBStreamerConfig{
+			Source:    source,
+			Lock:      lock,
+			LockRetry: 5 * time.Millisecond,
+		}, zap.NewNop())
+		replicas[i] = &replica{streamer: streamer, source: source, lock: lock}
+	}
+	return replicas
+}
+
+// waitForLeader waits until exactly one replica's source runs and returns it
+func waitForLeader(t *testing.T, replicas []*replica) *replica {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		var leaders []*replica
+		for _, r := range replicas {
+			if running, _ := r.source.state(); running {
+				leaders = append(leaders, r)
+			}
+		}
+		if len(leaders) > 1 {
+			t.Fatalf("%d replicas stream changes, want 1", len(leaders))
+		}
+		if len(leaders) == 1 {
+			return leaders[0]
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("no replica streams changes")
+	return nil
+}
+
+func TestDBStreamerElectsOneReplica(t *testing.T) {
+	replicas := newReplicas(3)
+	for _, r := range replicas {
+		if err := r.streamer.Start(context.Background()); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		defer r.streamer.Stop()
+	}
+
+	leader := waitForLeader(t, replicas)
+
+	// Let the other replicas retry a few times
+	time.Sleep(30 * time.Millisecond)
+	if next := waitForLeader(t, replicas); next != leader {
+		t.Fatal("leadership moved while the leader held the lock")
+	}
+
+	// A stopped leader hands over to another replica
+	leader.streamer.Stop()
+	if running, _ := leader.source.state(); running {
+		t.Fatal("stopped replica still streams changes")
+	}
+	remaining := make([]*replica, 0, len(replicas)-1)
+	for _, r := range replicas {
+		if r != leader {
+			remaining = append(remaining, r)
+		}
+	}
+	waitForLeader(t, remaining)
+}
+
+func TestDBStreamerStopsWhenLockIsLost(t *testing.T) {
+	replicas := newReplicas(2)
+	for _, r := range replicas {
+		if err := r.streamer.Start(context.Background()); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		defer r.streamer.Stop()
+	}
+
+	leader := waitForLeader(t, replicas)
+	leader.lock.lose()
+
+	// The old leader stops its source on its next check, and one replica
+	// streams again, whichever took the lock
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, starts := leader.source.state(); starts > 1 {
+			break
+		}
+		if other := waitForLeader(t, replicas); other != leader {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("replica kept streaming after losing the lock")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDBStreamerKeyColumns(t *testing.T) {
+	hub, err := NewHubWithConfig(zap.NewNop(), HubConfig{})
+	if err != nil {
+		t.Fatalf("NewHubWithConfig() error = %v", err)
+	}
+	streamer := NewDBStreamer(nil, hub, DBStreamerConfig{Source: &countingSource{}, Lock: &replicaLock{locks: &memoryLocks{}}}, zap.NewNop())
+
+	tests := []struct {
+		table      string
+		primaryKey []string
+		want       []string
+	}{
+		{table: "files", primaryKey: []string{"id"}, want: []string{"id", "user_id"}},
+		{table: "files", primaryKey: []string{"user_id", "name"}, want: []string{"user_id", "name"}},
+		{table: "orders", primaryKey: []string{"id"}, want: []string{"id"}},
+	}
+
+	for _, tt := range tests {
+		if got := streamer.keyColumns(tt.table, tt.primaryKey); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("keyColumns(%s, %v) = %v, want %v", tt.table, tt.primaryKey, got, tt.want)
+		}
+	}
+}
+
+func TestDBStreamerTruncatedDeleteReachesOwner(t *testing.T) {
+	hub, err := NewHubWithConfig(zap.NewNop(), HubConfig{})
+	if err != nil {
+		t.Fatalf("NewHubWithConfig() error = %v", err)
+	}
+	streamer := NewDBStreamer(nil, hub, DBStreamerConfig{Source: &countingSource{}, Lock: &replicaLock{locks: &memoryLocks{}}}, zap.NewNop())
+	streamer.tables["files"] = []string{"id"}
+
+	// A row too large to notify, sent as its key columns
+	streamer.broadcastDBChange(context.Background(), &DBChangeEvent{
+		Table:     "files",
+		Operation: "DELETE",
+		Key:       map[string]interface{}{"id": json.Number("3"), "user_id": json.Number("5")},
+		Truncated: true,
+	})
+
+	message := <-hub.broadcast
+	if message.Channel != "db:files" {
+		t.Fatalf("message channel = %s, want db:files", message.Channel)
+	}
+	if !OwnerFilter("user_id", 5)(message) {
+		t.Error("owner filter dropped the owner's truncated delete")
+	}
+	if OwnerFilter("user_id", 6)(message) {
+		t.Error("owner filter passed another user's truncated delete")
+	}
+}
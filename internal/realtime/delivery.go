@@ -0,0 +1,119 @@
+package realtime
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultAckTimeout is how long a client has to acknowledge a message
+	// before it is sent again
+	defaultAckTimeout = 10 * time.Second
+
+	// defaultAckRetries is how many times an unacknowledged message is sent
+	// again before the hub gives up
+	defaultAckRetries = 3
+
+	// ackCheckInterval is how often unacknowledged messages are checked
+	ackCheckInterval = time.Second
+)
+
+// pendingAck is a message sent to a client that has not acknowledged it
+type pendingAck struct {
+	channel  string
+	item     outgoing
+	retries  int
+	deadline time.Time
+}
+
+// deliveries are the messages a client has yet to acknowledge, by message
+// ID
+type deliveries struct {
+	mu      sync.Mutex
+	pending map[string]*pendingAck
+}
+
+// expectAck waits for client to acknowledge a message it was sent
+func (h *Hub) expectAck(client *Client, channel string, item outgoing) {
+	client.deliveries.mu.Lock()
+	defer client.deliveries.mu.Unlock()
+
+	client.deliveries.pending[item.ackID] = &pendingAck{
+		channel:  channel,
+		item:     item,
+		deadline: time.Now().Add(h.ackTimeout),
+	}
+}
+
+// handleAck records that the client received the message with the given
+// ID
+func (c *Client) handleAck(msg *ClientMessage) {
+	c.deliveries.mu.Lock()
+	pending, ok := c.deliveries.pending[msg.ID]
+	delete(c.deliveries.pending, msg.ID)
+	c.deliveries.mu.Unlock()
+
+	if ok {
+		c.hub.recordMetrics(pending.channel, ChannelMetrics{Acked: 1})
+	}
+}
+
+// retryDeliveries sends unacknowledged messages again until the hub stops
+func (h *Hub) retryDeliveries() {
+	ticker := time.NewTicker(ackCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			h.checkDeliveries(time.Now())
+		case <-h.stop:
+			return
+		}
+	}
+}
+
+// checkDeliveries sends each message whose acknowledgement is overdue
+// again, or gives up on it once its retries are used
+func (h *Hub) checkDeliveries(now time.Time) {
+	h.mu.RLock()
+	clients := make([]*Client, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.RUnlock()
+
+	for _, client := range clients {
+		var resend []*pendingAck
+
+		client.deliveries.mu.Lock()
+		for id, pending := range client.deliveries.pending {
+			if now.Before(pending.deadline) {
+				continue
+			}
+			if pending.retries >= h.ackRetries {
+				delete(client.deliveries.pending, id)
+				h.recordMetrics(pending.channel, ChannelMetrics{Unacked: 1})
+				h.logger.Warn("Message not acknowledged",
+					zap.Uint("user_id", client.UserID),
+					zap.String("channel", pending.channel),
+					zap.String("message_id", id),
+				)
+				continue
+			}
+			pending.retries++
+			pending.deadline = now.Add(h.ackTimeout)
+			resend = append(resend, pending)
+		}
+		client.deliveries.mu.Unlock()
+
+		for _, pending := range resend {
+			if client.queue.push(pending.item) == pushClosed {
+				break
+			}
+			h.recordMetrics(pending.channel, ChannelMetrics{Retried: 1})
+		}
+	}
+}
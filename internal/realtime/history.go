@@ -0,0 +1,292 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultHistorySize is the number of messages kept per channel
+	defaultHistorySize = 100
+
+	// defaultHistoryTTL is how long the history of an idle channel is kept
+	defaultHistoryTTL = 24 * time.Hour
+)
+
+// History stores the recent messages of each channel so clients can catch
+// up after reconnecting. Message IDs have the form "<unix ms>-<sequence>"
+// and increase with every message, so an ID from one channel can be used
+// as the starting point in another.
+type History interface {
+	// Append stores a channel message and sets its ID
+	Append(ctx context.Context, message *Message) error
+	// Read returns stored messages of a channel, oldest first
+	Read(ctx context.Context, channel string, query HistoryQuery) ([]*Message, error)
+}
+
+// HistoryQuery selects messages from a channel's history. With After or
+// Since the oldest matching messages are returned, otherwise the newest
+// ones before Before.
+type HistoryQuery struct {
+	// After returns messages following this ID
+	After string
+	// Since returns messages published at or after this time
+	Since time.Time
+	// Before returns messages preceding this ID
+	Before string
+	// Limit caps the number of messages, up to the history size
+	Limit int
+}
+
+// forward reports whether the query reads from a starting point onwards
+func (q HistoryQuery) forward() bool {
+	return q.After != "" || !q.Since.IsZero()
+}
+
+// messageID is a parsed message ID
+type messageID struct {
+	ms  uint64
+	seq uint64
+}
+
+// parseMessageID parses an ID of the form "<unix ms>-<sequence>"
+func parseMessageID(id string) (messageID, error) {
+	ms, seq, ok := strings.Cut(id, "-")
+	if !ok {
+		return messageID{}, fmt.Errorf("invalid message id %q", id)
+	}
+
+	var (
+		parsed messageID
+		err    error
+	)
+	if parsed.ms, err = strconv.ParseUint(ms, 10, 64); err != nil {
+		return messageID{}, fmt.Errorf("invalid message id %q", id)
+	}
+	if parsed.seq, err = strconv.ParseUint(seq, 10, 64); err != nil {
+		return messageID{}, fmt.Errorf("invalid message id %q", id)
+	}
+	return parsed, nil
+}
+
+// ErrInvalidReplay is returned for a malformed replay starting point
+var ErrInvalidReplay = errors.New("invalid replay position")
+
+// ReplayQuery builds the history query replaying messages after
+// lastEventID or since an RFC 3339 time. It reports false when neither is
+// set.
+func ReplayQuery(lastEventID, since string) (HistoryQuery, bool, error) {
+	var query HistoryQuery
+
+	if lastEventID != "" {
+		if err := ValidateMessageID(lastEventID); err != nil {
+			return query, false, ErrInvalidReplay
+		}
+		query.After = lastEventID
+	}
+
+	if since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return query, false, ErrInvalidReplay
+		}
+		query.Since = t
+	}
+
+	return query, query.forward(), nil
+}
+
+// ValidateMessageID checks that id is a message ID
+func ValidateMessageID(id string) error {
+	_, err := parseMessageID(id)
+	return err
+}
+
+// EventID returns the history ID of an encoded channel message, or "" for
+// anything else a client is sent, such as acknowledgements
+func EventID(data []byte) string {
+	var message struct {
+		ID        string    `json:"id"`
+		Timestamp time.Time `json:"timestamp"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil || message.Timestamp.IsZero() {
+		return ""
+	}
+	if ValidateMessageID(message.ID) != nil {
+		return ""
+	}
+	return message.ID
+}
+
+// before reports whether id sorts before other
+func (id messageID) before(other messageID) bool {
+	return id.ms < other.ms || (id.ms == other.ms && id.seq < other.seq)
+}
+
+func (id messageID) String() string {
+	return strconv.FormatUint(id.ms, 10) + "-" + strconv.FormatUint(id.seq, 10)
+}
+
+// sortMessages orders messages by ID. Messages without a valid ID go first.
+func sortMessages(messages []*Message) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		a, errA := parseMessageID(messages[i].ID)
+		b, errB := parseMessageID(messages[j].ID)
+		if errA != nil || errB != nil {
+			return errA != nil && errB == nil
+		}
+		return a.before(b)
+	})
+}
+
+// LocalHistory keeps a ring buffer of messages per channel in process. It
+// suits a single instance.
+type LocalHistory struct {
+	size int
+	ttl  time.Duration
+
+	mu       sync.Mutex
+	last     messageID
+	channels map[string]*localChannelHistory
+	// prunedAt is when idle channels were last dropped
+	prunedAt time.Time
+}
+
+// localChannelHistory is the ring buffer of one channel
+type localChannelHistory struct {
+	messages  []*Message
+	ids       []messageID
+	next      int
+	updatedAt time.Time
+}
+
+// NewLocalHistory creates an in-process history keeping size messages per
+// channel for channels active within ttl
+func NewLocalHistory(size int, ttl time.Duration) *LocalHistory {
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+	if ttl <= 0 {
+		ttl = defaultHistoryTTL
+	}
+
+	return &LocalHistory{
+		size:     size,
+		ttl:      ttl,
+		channels: make(map[string]*localChannelHistory),
+	}
+}
+
+// Append stores a copy of message and sets its ID
+func (h *LocalHistory) Append(ctx context.Context, message *Message) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	id := messageID{ms: uint64(now.UnixMilli())}
+	if !h.last.before(id) {
+		id = messageID{ms: h.last.ms, seq: h.last.seq + 1}
+	}
+	h.last = id
+	message.ID = id.String()
+
+	// Drop channels that have been idle for longer than the TTL. Reads
+	// ignore them already, so this only frees memory and is done once per
+	// TTL rather than on every append.
+	if now.Sub(h.prunedAt) > h.ttl {
+		for channel, entry := range h.channels {
+			if now.Sub(entry.updatedAt) > h.ttl {
+				delete(h.channels, channel)
+			}
+		}
+		h.prunedAt = now
+	}
+
+	entry, ok := h.channels[message.Channel]
+	if !ok || now.Sub(entry.updatedAt) > h.ttl {
+		entry = &localChannelHistory{}
+		h.channels[message.Channel] = entry
+	}
+
+	stored := *message
+	if len(entry.messages) < h.size {
+		entry.messages = append(entry.messages, &stored)
+		entry.ids = append(entry.ids, id)
+	} else {
+		entry.messages[entry.next] = &stored
+		entry.ids[entry.next] = id
+		entry.next = (entry.next + 1) % h.size
+	}
+	entry.updatedAt = now
+
+	return nil
+}
+
+// Read returns stored messages of a channel, oldest first
+func (h *LocalHistory) Read(ctx context.Context, channel string, query HistoryQuery) ([]*Message, error) {
+	var after, before messageID
+	var err error
+	if query.After != "" {
+		if after, err = parseMessageID(query.After); err != nil {
+			return nil, err
+		}
+	}
+	if query.Before != "" {
+		if before, err = parseMessageID(query.Before); err != nil {
+			return nil, err
+		}
+	}
+
+	limit := query.Limit
+	if limit <= 0 || limit > h.size {
+		limit = h.size
+	}
+	since := uint64(0)
+	if !query.Since.IsZero() {
+		since = uint64(query.Since.UnixMilli())
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry, ok := h.channels[channel]
+	if !ok || time.Since(entry.updatedAt) > h.ttl {
+		return []*Message{}, nil
+	}
+
+	// Walk the ring from the oldest message
+	matched := make([]*Message, 0, len(entry.messages))
+	for i := 0; i < len(entry.messages); i++ {
+		index := (entry.next + i) % len(entry.messages)
+		id := entry.ids[index]
+
+		if query.After != "" && !after.before(id) {
+			continue
+		}
+		if id.ms < since {
+			continue
+		}
+		if query.Before != "" && !id.before(before) {
+			continue
+		}
+
+		message := *entry.messages[index]
+		matched = append(matched, &message)
+	}
+
+	if len(matched) > limit {
+		if query.forward() {
+			matched = matched[:limit]
+		} else {
+			matched = matched[len(matched)-limit:]
+		}
+	}
+	return matched, nil
+}
@@ -0,0 +1,36 @@
+package realtime
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLocalHistoryDropsIdleChannels(t *testing.T) {
+	ctx := context.Background()
+	ttl := 20 * time.Millisecond
+	history := NewLocalHistory(10, ttl)
+
+	history.Append(ctx, &Message{Channel: "idle", Event: "old"})
+	history.Append(ctx, &Message{Channel: "active", Event: "old"})
+	time.Sleep(2 * ttl)
+
+	// Appending to one channel drops the others that went idle and starts
+	// the expired channel afresh
+	history.Append(ctx, &Message{Channel: "active", Event: "new"})
+	if _, ok := history.channels["idle"]; ok {
+		t.Error("Append() kept an idle channel")
+	}
+	messages, err := history.Read(ctx, "active", HistoryQuery{})
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(messages) != 1 || messages[0].Event != "new" {
+		t.Errorf("Read() = %d messages, want only the new one", len(messages))
+	}
+
+	history.Append(ctx, &Message{Channel: "other", Event: "new"})
+	if len(history.channels) != 2 {
+		t.Errorf("history has %d channels, want active and other", len(history.channels))
+	}
+}
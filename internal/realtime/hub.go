@@ -1,19 +1,101 @@
 package realtime
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
+	"go-mobile-backend-template/internal/services/auth"
+
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
-// Hub maintains the set of active clients and broadcasts messages
+const (
+	// backplaneTimeout bounds every backplane call made by the hub
+	backplaneTimeout = 5 * time.Second
+
+	// defaultMaxSubscriptions is the number of channels a client may join
+	defaultMaxSubscriptions = 50
+
+	// broadcastBuffer is the number of messages from the backplane waiting
+	// for local delivery
+	broadcastBuffer = 1024
+
+	// Channel metrics of messages sent to every client and of presence
+	// updates
+	metricsAll      = "*"
+	metricsPresence = "presence"
+)
+
+// Subscription errors
+var (
+	ErrSubscriptionLimit = errors.New("subscription limit reached")
+	ErrNotSubscribed     = errors.New("not subscribed to channel")
+	errChannelRequired   = errors.New("channel required")
+)
+
+// Token refresh errors
+var (
+	ErrTokenRefreshUnsupported = errors.New("token refresh not supported")
+	errTokenRequired           = errors.New("token required")
+	errTokenUserMismatch       = errors.New("token belongs to another user")
+)
+
+// TokenValidator validates an access token a client sends to replace the
+// one it connected with
+type TokenValidator func(token string) (*auth.Claims, error)
+
+// HubConfig configures a Hub
+type HubConfig struct {
+	// Backplane connects the hub to the hubs of other instances. Defaults
+	// to an in-process backplane.
+	Backplane Backplane
+	// PresenceTTL is how long the presence of a connected user lasts
+	// without a refresh. The hub refreshes it and reports its stats three
+	// times per TTL.
+	PresenceTTL time.Duration
+	// Authorizer decides which channels clients may join. Defaults to
+	// DefaultChannelRules without a permission resolver.
+	Authorizer *ChannelAuthorizer
+	// MaxSubscriptions is the number of channels a client may join at once
+	MaxSubscriptions int
+	// History stores channel messages for replay. Defaults to an in-process
+	// ring buffer per channel.
+	History History
+	// PresenceChannels are the channels whose members see each other's
+	// presence, as names or prefixes followed by "*"
+	PresenceChannels []string
+	// Contacts returns the other users who see a user's presence. Users
+	// always see the presence of their own devices.
+	Contacts ContactsFunc
+	// QueueSize is the number of messages queued for each client. Defaults
+	// to 256.
+	QueueSize int
+	// SlowConsumerPolicy decides what happens when a client's queue is
+	// full. Defaults to PolicyDropOldest.
+	SlowConsumerPolicy SlowConsumerPolicy
+	// AckTimeout is how long clients have to acknowledge a message that
+	// requires it before it is sent again, up to AckRetries times. Retries
+	// default to 3; a negative number disables them.
+	AckTimeout time.Duration
+	AckRetries int
+	// RPCTimeout bounds the calls clients make to RPC handlers
+	RPCTimeout time.Duration
+}
+
+// Hub maintains the set of active clients and broadcasts messages. Messages
+// go through the backplane so they reach clients on every instance.
 type Hub struct {
 	// Registered clients
 	clients map[*Client]bool
 
-	// Inbound messages from clients
+	// Messages received from the backplane for local delivery. They are
+	// delivered apart from Run so that large fan-outs do not hold up
+	// registrations.
 	broadcast chan *Message
 
 	// Register requests from clients
@@ -22,62 +104,250 @@ type Hub struct {
 	// Unregister requests from clients
 	unregister chan *Client
 
-	// Rooms/channels for topic-based broadcasting
+	// Subscribers of each room/channel for topic-based broadcasting
 	rooms map[string]map[*Client]bool
 
-	// Presence tracking
-	presence map[uint]*PresenceInfo
+	// Presence of users connected to this instance. The shared presence of
+	// all users lives in the backplane.
+	presence map[uint]*userPresence
+
+	// Who sees presence updates
+	presenceChannels []string
+	contacts         ContactsFunc
+
+	// Cross-instance messaging, presence and stats
+	backplane   Backplane
+	nodeID      string
+	presenceTTL time.Duration
+
+	// Recent messages of each channel
+	history History
+
+	// Clients on transports other than WebSocket
+	sessions   map[string]*Session
+	sessionsMu sync.Mutex
+
+	// Channel authorization and limits for clients
+	authorizer       *ChannelAuthorizer
+	maxSubscriptions int
+
+	// Validates the tokens clients refresh with auth messages
+	tokenValidator TokenValidator
+
+	// Send queue of each client
+	queueSize   int
+	queuePolicy SlowConsumerPolicy
+
+	// Delivery counts of each channel
+	metrics   map[string]*ChannelMetrics
+	metricsMu sync.Mutex
+
+	// Redelivery of messages clients must acknowledge
+	ackTimeout time.Duration
+	ackRetries int
+
+	// Methods clients may call
+	rpcHandlers map[string]RPCHandler
+	rpcTimeout  time.Duration
+	rpcMu       sync.RWMutex
 
 	// Mutex for concurrent access
 	mu sync.RWMutex
 
+	// Shutdown signalling
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
 	logger *zap.Logger
 }
 
-// Message represents a real-time message
+// Message represents a real-time message. Channel messages carry an ID
+// from the channel history.
 type Message struct {
+	ID        string                 `json:"id,omitempty"`
 	Type      string                 `json:"type"`
 	Channel   string                 `json:"channel,omitempty"`
 	Event     string                 `json:"event"`
 	Payload   map[string]interface{} `json:"payload"`
 	UserID    uint                   `json:"user_id,omitempty"`
 	Timestamp time.Time              `json:"timestamp"`
+	// Replay marks messages sent again from the history
+	Replay bool `json:"replay,omitempty"`
+	// Scope addresses presence updates between instances
+	Scope *PresenceScope `json:"scope,omitempty"`
+	// Priority orders the message in the queues of clients. Presence,
+	// typing and activity updates default to low.
+	Priority Priority `json:"priority,omitempty"`
+	// CoalesceKey lets a newer message of the channel with the same key
+	// replace this one in the queue of a slow client
+	CoalesceKey string `json:"coalesce_key,omitempty"`
+	// AckRequired asks clients to acknowledge the message by its ID. It is
+	// sent again to those who do not.
+	AckRequired bool `json:"ack_required,omitempty"`
+}
+
+// BroadcastOptions change how a channel message is queued for clients and
+// whether they must acknowledge it
+type BroadcastOptions struct {
+	Priority    Priority
+	CoalesceKey string
+	RequireAck  bool
+}
+
+// priority returns the priority of the message in client queues
+func (m *Message) priority() Priority {
+	if m.Priority != PriorityNormal {
+		return m.Priority
+	}
+	switch m.Type {
+	case "presence", "typing", "activity":
+		return PriorityLow
+	}
+	return PriorityNormal
+}
+
+// coalesceKey returns what the message is about. Under PolicyCoalesce a
+// newer message with the same key replaces a queued one.
+func (m *Message) coalesceKey() string {
+	switch m.Type {
+	case "presence":
+		return fmt.Sprintf("presence:%d", m.UserID)
+	case "typing":
+		return fmt.Sprintf("typing:%s:%d", m.Channel, m.UserID)
+	case "activity":
+		return fmt.Sprintf("activity:%s:%d:%s", m.Channel, m.UserID, m.Event)
+	}
+	if m.CoalesceKey != "" {
+		return fmt.Sprintf("key:%s:%s", m.Channel, m.CoalesceKey)
+	}
+	return ""
 }
 
-// PresenceInfo tracks user presence
-type PresenceInfo struct {
-	UserID     uint      `json:"user_id"`
-	Username   string    `json:"username"`
-	Status     string    `json:"status"` // online, away, busy
-	LastSeen   time.Time `json:"last_seen"`
-	DeviceInfo string    `json:"device_info,omitempty"`
+// encode marshals the message for client queues
+func (m *Message) encode() (outgoing, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return outgoing{}, err
+	}
+	item := outgoing{data: data, priority: m.priority(), key: m.coalesceKey()}
+	if m.AckRequired {
+		item.ackID = m.ID
+	}
+	return item, nil
 }
 
-// NewHub creates a new Hub
+// NewHub creates a new Hub for a single instance
 func NewHub(logger *zap.Logger) *Hub {
-	return &Hub{
-		clients:    make(map[*Client]bool),
-		broadcast:  make(chan *Message, 256),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
-		rooms:      make(map[string]map[*Client]bool),
-		presence:   make(map[uint]*PresenceInfo),
-		logger:     logger,
+	hub, _ := NewHubWithConfig(logger, HubConfig{})
+	return hub
+}
+
+// NewHubWithConfig creates a new Hub and subscribes it to the backplane
+func NewHubWithConfig(logger *zap.Logger, cfg HubConfig) (*Hub, error) {
+	if cfg.Backplane == nil {
+		cfg.Backplane = NewLocalBackplane()
+	}
+	if cfg.PresenceTTL <= 0 {
+		cfg.PresenceTTL = defaultPresenceTTL
+	}
+	if cfg.Authorizer == nil {
+		cfg.Authorizer = NewChannelAuthorizer(DefaultChannelRules(), nil)
+	}
+	if cfg.MaxSubscriptions <= 0 {
+		cfg.MaxSubscriptions = defaultMaxSubscriptions
+	}
+	if cfg.History == nil {
+		cfg.History = NewLocalHistory(defaultHistorySize, defaultHistoryTTL)
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	if cfg.SlowConsumerPolicy == "" {
+		cfg.SlowConsumerPolicy = PolicyDropOldest
+	}
+	if err := validatePolicy(cfg.SlowConsumerPolicy); err != nil {
+		return nil, err
+	}
+	if cfg.AckTimeout <= 0 {
+		cfg.AckTimeout = defaultAckTimeout
+	}
+	if cfg.AckRetries < 0 {
+		cfg.AckRetries = 0
+	} else if cfg.AckRetries == 0 {
+		cfg.AckRetries = defaultAckRetries
+	}
+	if cfg.RPCTimeout <= 0 {
+		cfg.RPCTimeout = defaultRPCTimeout
 	}
+
+	h := &Hub{
+		clients:          make(map[*Client]bool),
+		broadcast:        make(chan *Message, broadcastBuffer),
+		register:         make(chan *Client),
+		unregister:       make(chan *Client),
+		rooms:            make(map[string]map[*Client]bool),
+		presence:         make(map[uint]*userPresence),
+		presenceChannels: cfg.PresenceChannels,
+		contacts:         cfg.Contacts,
+		backplane:        cfg.Backplane,
+		nodeID:           uuid.NewString(),
+		presenceTTL:      cfg.PresenceTTL,
+		history:          cfg.History,
+		sessions:         make(map[string]*Session),
+		authorizer:       cfg.Authorizer,
+		maxSubscriptions: cfg.MaxSubscriptions,
+		queueSize:        cfg.QueueSize,
+		queuePolicy:      cfg.SlowConsumerPolicy,
+		metrics:          make(map[string]*ChannelMetrics),
+		ackTimeout:       cfg.AckTimeout,
+		ackRetries:       cfg.AckRetries,
+		rpcHandlers:      make(map[string]RPCHandler),
+		rpcTimeout:       cfg.RPCTimeout,
+		stop:             make(chan struct{}),
+		done:             make(chan struct{}),
+		logger:           logger,
+	}
+
+	if err := h.backplane.Subscribe(h.receive); err != nil {
+		return nil, err
+	}
+
+	return h, nil
 }
 
 // Run starts the hub
 func (h *Hub) Run() {
+	defer close(h.done)
+
+	go h.heartbeat()
+	go h.dispatch()
+	go h.retryDeliveries()
+
 	for {
 		select {
+		case <-h.stop:
+			h.closeAllClients()
+			return
+
 		case client := <-h.register:
 			h.registerClient(client)
 
 		case client := <-h.unregister:
 			h.unregisterClient(client)
+		}
+	}
+}
 
+// dispatch delivers messages from the backplane to local clients, in the
+// order received, until the hub stops
+func (h *Hub) dispatch() {
+	for {
+		select {
 		case message := <-h.broadcast:
 			h.broadcastMessage(message)
+		case <-h.stop:
+			return
 		}
 	}
 }
@@ -87,32 +357,16 @@ func (h *Hub) registerClient(client *Client) {
 	defer h.mu.Unlock()
 
 	h.clients[client] = true
+	close(client.registered)
 
-	// Subscribe to default room if specified
-	if client.Room != "" {
-		if h.rooms[client.Room] == nil {
-			h.rooms[client.Room] = make(map[*Client]bool)
-		}
-		h.rooms[client.Room][client] = true
-	}
-
-	// Update presence
-	if client.UserID > 0 {
-		h.presence[client.UserID] = &PresenceInfo{
-			UserID:     client.UserID,
-			Username:   client.Username,
-			Status:     "online",
-			LastSeen:   time.Now(),
-			DeviceInfo: client.UserAgent,
-		}
-
-		// Broadcast presence update
-		h.broadcastPresenceUpdate(client.UserID, "online")
+	// Join the channels authorized in RegisterClient
+	for channel := range client.subscriptions {
+		h.joinRoom(client, channel)
 	}
 
 	h.logger.Info("Client registered",
 		zap.Uint("user_id", client.UserID),
-		zap.String("room", client.Room),
+		zap.Int("subscriptions", len(client.subscriptions)),
 		zap.Int("total_clients", len(h.clients)),
 	)
 }
@@ -123,104 +377,330 @@ func (h *Hub) unregisterClient(client *Client) {
 
 	if _, ok := h.clients[client]; ok {
 		delete(h.clients, client)
-		close(client.send)
-
-		// Remove from room
-		if client.Room != "" && h.rooms[client.Room] != nil {
-			delete(h.rooms[client.Room], client)
-			if len(h.rooms[client.Room]) == 0 {
-				delete(h.rooms, client.Room)
-			}
-		}
+		client.queue.close()
 
-		// Update presence
-		if client.UserID > 0 {
-			if info, ok := h.presence[client.UserID]; ok {
-				info.Status = "offline"
-				info.LastSeen = time.Now()
-				h.broadcastPresenceUpdate(client.UserID, "offline")
-			}
+		// Leave every subscribed room
+		for channel := range client.subscriptions {
+			h.leaveRoom(client, channel)
 		}
 
 		h.logger.Info("Client unregistered",
 			zap.Uint("user_id", client.UserID),
-			zap.String("room", client.Room),
+			zap.Int("subscriptions", len(client.subscriptions)),
 			zap.Int("total_clients", len(h.clients)),
 		)
+		client.subscriptions = make(map[string]RowFilter)
 	}
 }
 
-func (h *Hub) broadcastMessage(message *Message) {
+// subscribe adds a registered client to channel, replacing its filter if
+// it is already subscribed
+func (h *Hub) subscribe(client *Client, channel string, filter RowFilter) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.clients[client] {
+		return ErrNotSubscribed
+	}
+
+	if _, ok := client.subscriptions[channel]; !ok && len(client.subscriptions) >= h.maxSubscriptions {
+		return ErrSubscriptionLimit
+	}
+
+	client.subscriptions[channel] = filter
+	h.joinRoom(client, channel)
+	return nil
+}
+
+// unsubscribe removes a client from channel
+func (h *Hub) unsubscribe(client *Client, channel string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := client.subscriptions[channel]; !ok {
+		return ErrNotSubscribed
+	}
+
+	delete(client.subscriptions, channel)
+	h.leaveRoom(client, channel)
+	return nil
+}
+
+// joinRoom adds a client to the subscribers of channel. The caller holds
+// the write lock.
+func (h *Hub) joinRoom(client *Client, channel string) {
+	if h.rooms[channel] == nil {
+		h.rooms[channel] = make(map[*Client]bool)
+	}
+	h.rooms[channel][client] = true
+}
+
+// leaveRoom removes a client from the subscribers of channel and drops the
+// room once it is empty. The caller holds the write lock.
+func (h *Hub) leaveRoom(client *Client, channel string) {
+	if h.rooms[channel] == nil {
+		return
+	}
+	delete(h.rooms[channel], client)
+	if len(h.rooms[channel]) == 0 {
+		delete(h.rooms, channel)
+	}
+}
+
+// sendTo queues a message for a registered client without blocking. It
+// reports whether the message was queued without dropping another.
+func (h *Hub) sendTo(client *Client, item outgoing) bool {
+	h.mu.RLock()
+	registered := h.clients[client]
+	h.mu.RUnlock()
+
+	if !registered {
+		return false
+	}
+
+	switch client.queue.push(item) {
+	case pushQueued, pushCoalesced:
+		return true
+	case pushOverflow:
+		h.logger.Warn("Disconnecting slow client", zap.Uint("user_id", client.UserID))
+	}
+	return false
+}
+
+// deliver queues a message for each recipient and counts the results under
+// channel
+func (h *Hub) deliver(channel string, recipients []*Client, item outgoing) {
+	var counts ChannelMetrics
+	for _, client := range recipients {
+		result := client.queue.push(item)
+		counts.record(result)
+		switch result {
+		case pushOverflow:
+			h.logger.Warn("Disconnecting slow client",
+				zap.Uint("user_id", client.UserID),
+				zap.String("channel", channel),
+			)
+		case pushQueued, pushEvicted, pushRejected, pushCoalesced:
+			if item.ackID != "" {
+				h.expectAck(client, channel, item)
+			}
+		}
+	}
+	h.recordMetrics(channel, counts)
+}
+
+// recordMetrics adds counts to the metrics of channel
+func (h *Hub) recordMetrics(channel string, counts ChannelMetrics) {
+	if counts == (ChannelMetrics{}) {
+		return
+	}
+
+	h.metricsMu.Lock()
+	defer h.metricsMu.Unlock()
+
+	metrics, ok := h.metrics[channel]
+	if !ok {
+		metrics = &ChannelMetrics{}
+		h.metrics[channel] = metrics
+	}
+	metrics.add(counts)
+}
+
+// pruneMetrics drops the counts of channels without local subscribers
+func (h *Hub) pruneMetrics() {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
+	h.metricsMu.Lock()
+	defer h.metricsMu.Unlock()
+
+	for channel := range h.metrics {
+		if _, ok := h.rooms[channel]; !ok && channel != metricsAll && channel != metricsPresence {
+			delete(h.metrics, channel)
+		}
+	}
+}
+
+// closeAllClients disconnects every registered client
+func (h *Hub) closeAllClients() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for client := range h.clients {
+		// Closing the queue makes WritePump send a close frame
+		client.queue.close()
+	}
+
+	h.logger.Info("Closed all WebSocket clients", zap.Int("total_clients", len(h.clients)))
 
-	messageData, err := json.Marshal(message)
+	h.clients = make(map[*Client]bool)
+	h.rooms = make(map[string]map[*Client]bool)
+}
+
+// Shutdown stops the hub, disconnects all clients and closes the backplane.
+// It waits for Run to return or for the context to expire, whichever comes
+// first.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.stopOnce.Do(func() {
+		close(h.stop)
+	})
+
+	select {
+	case <-h.done:
+		return h.backplane.Close()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// broadcastMessage queues a message for the local clients it is addressed
+// to. Recipients are collected under the read lock and queued after it is
+// released.
+func (h *Hub) broadcastMessage(message *Message) {
+	if message.Scope != nil {
+		h.deliverPresence(message)
+		return
+	}
+
+	item, err := message.encode()
 	if err != nil {
 		h.logger.Error("Failed to marshal message", zap.Error(err))
 		return
 	}
 
-	// Broadcast to specific channel/room
+	h.mu.RLock()
+	var recipients []*Client
 	if message.Channel != "" {
-		if clients, ok := h.rooms[message.Channel]; ok {
-			for client := range clients {
-				select {
-				case client.send <- messageData:
-				default:
-					// Client send buffer is full, skip
-					h.logger.Warn("Client send buffer full",
-						zap.Uint("user_id", client.UserID),
-						zap.String("channel", message.Channel),
-					)
-				}
+		// Broadcast to specific channel/room
+		for client := range h.rooms[message.Channel] {
+			if filter := client.subscriptions[message.Channel]; filter != nil && !filter(message) {
+				continue
 			}
+			recipients = append(recipients, client)
+		}
+	} else {
+		// Broadcast to all clients
+		recipients = make([]*Client, 0, len(h.clients))
+		for client := range h.clients {
+			recipients = append(recipients, client)
 		}
-		return
 	}
+	h.mu.RUnlock()
 
-	// Broadcast to all clients
-	for client := range h.clients {
+	channel := message.Channel
+	if channel == "" {
+		channel = metricsAll
+	}
+	h.deliver(channel, recipients, item)
+}
+
+// heartbeat refreshes the presence of local users, reports this instance's
+// stats and expires idle sessions until the hub stops
+func (h *Hub) heartbeat() {
+	ticker := time.NewTicker(h.presenceTTL / 3)
+	defer ticker.Stop()
+
+	h.reportStats()
+
+	for {
 		select {
-		case client.send <- messageData:
-		default:
-			h.logger.Warn("Client send buffer full", zap.Uint("user_id", client.UserID))
+		case <-ticker.C:
+			h.refreshPresence()
+			h.reportStats()
+			h.pruneMetrics()
+			h.expireSessions()
+		case <-h.stop:
+			return
 		}
 	}
 }
 
-func (h *Hub) broadcastPresenceUpdate(userID uint, status string) {
-	message := &Message{
-		Type:   "presence",
-		Event:  "status_change",
-		UserID: userID,
-		Payload: map[string]interface{}{
-			"user_id": userID,
-			"status":  status,
-		},
-		Timestamp: time.Now(),
+// reportStats publishes this instance's stats to the backplane
+func (h *Hub) reportStats() {
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+
+	if err := h.backplane.ReportStats(ctx, h.localStats(), h.presenceTTL); err != nil {
+		h.logger.Error("Failed to report hub stats", zap.Error(err))
 	}
+}
 
-	messageData, _ := json.Marshal(message)
+// localStats returns the connection and delivery counts of this instance
+func (h *Hub) localStats() *NodeStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 
+	stats := &NodeStats{
+		NodeID:     h.nodeID,
+		Clients:    len(h.clients),
+		Rooms:      make(map[string]int, len(h.rooms)),
+		ReportedAt: time.Now(),
+	}
+	for room, clients := range h.rooms {
+		stats.Rooms[room] = len(clients)
+	}
 	for client := range h.clients {
-		select {
-		case client.send <- messageData:
-		default:
+		stats.Queued += client.queue.len()
+	}
+
+	h.metricsMu.Lock()
+	defer h.metricsMu.Unlock()
+
+	stats.Channels = make(map[string]ChannelMetrics, len(h.metrics))
+	for channel, metrics := range h.metrics {
+		stats.Channels[channel] = *metrics
+	}
+	return stats
+}
+
+// clusterStats returns the stats of every instance, with live numbers for
+// this one
+func (h *Hub) clusterStats() []*NodeStats {
+	local := h.localStats()
+
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+
+	reported, err := h.backplane.ListStats(ctx)
+	if err != nil {
+		h.logger.Error("Failed to list hub stats", zap.Error(err))
+		return []*NodeStats{local}
+	}
+
+	nodes := []*NodeStats{local}
+	for _, stats := range reported {
+		if stats.NodeID != h.nodeID {
+			nodes = append(nodes, stats)
 		}
 	}
+	return nodes
 }
 
 // BroadcastToChannel sends a message to a specific channel
 func (h *Hub) BroadcastToChannel(channel string, event string, payload map[string]interface{}) {
+	h.BroadcastToChannelWithOptions(channel, event, payload, BroadcastOptions{})
+}
+
+// BroadcastToChannelWithOptions sends a message to a specific channel with
+// a priority and coalesce key for client queues. With RequireAck, clients
+// that do not acknowledge the message are sent it again.
+func (h *Hub) BroadcastToChannelWithOptions(channel string, event string, payload map[string]interface{}, opts BroadcastOptions) {
 	message := &Message{
-		Type:      "broadcast",
-		Channel:   channel,
-		Event:     event,
-		Payload:   payload,
-		Timestamp: time.Now(),
+		Type:        "broadcast",
+		Channel:     channel,
+		Event:       event,
+		Payload:     payload,
+		Timestamp:   time.Now(),
+		Priority:    opts.Priority,
+		CoalesceKey: opts.CoalesceKey,
+		AckRequired: opts.RequireAck,
 	}
 
-	h.broadcast <- message
+	h.publish(message)
+}
+
+// BroadcastToUser sends a message to the private channel of a user
+func (h *Hub) BroadcastToUser(userID uint, event string, payload map[string]interface{}) {
+	h.BroadcastToChannel(UserChannel(userID), event, payload)
 }
 
 // BroadcastToAll sends a message to all connected clients
@@ -232,78 +712,270 @@ func (h *Hub) BroadcastToAll(event string, payload map[string]interface{}) {
 		Timestamp: time.Now(),
 	}
 
-	h.broadcast <- message
+	h.publish(message)
+}
+
+// SetAuthorizer replaces the channel authorizer. Clients already in a
+// channel keep their membership.
+func (h *Hub) SetAuthorizer(authorizer *ChannelAuthorizer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.authorizer = authorizer
 }
 
-// GetPresence returns presence information for a user
-func (h *Hub) GetPresence(userID uint) *PresenceInfo {
+// SetTokenValidator sets how the access tokens clients send in auth
+// messages are validated. Without one, clients cannot refresh their token.
+func (h *Hub) SetTokenValidator(validator TokenValidator) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tokenValidator = validator
+}
+
+// validateToken validates a token a client refreshes with
+func (h *Hub) validateToken(token string) (*auth.Claims, error) {
 	h.mu.RLock()
-	defer h.mu.RUnlock()
+	validator := h.tokenValidator
+	h.mu.RUnlock()
 
-	if info, ok := h.presence[userID]; ok {
-		return info
+	if validator == nil {
+		return nil, ErrTokenRefreshUnsupported
 	}
-	return nil
+	return validator(token)
 }
 
-// GetAllPresence returns all presence information
-func (h *Hub) GetAllPresence() map[uint]*PresenceInfo {
+// AuthorizeChannel checks that sub may subscribe to channel and returns the
+// filter for the messages it may receive there
+func (h *Hub) AuthorizeChannel(ctx context.Context, sub Subscriber, channel string) (RowFilter, error) {
 	h.mu.RLock()
-	defer h.mu.RUnlock()
+	authorizer := h.authorizer
+	h.mu.RUnlock()
 
-	presence := make(map[uint]*PresenceInfo)
-	for k, v := range h.presence {
-		presence[k] = v
-	}
-	return presence
+	return authorizer.Authorize(ctx, sub, channel)
 }
 
-// GetOnlineCount returns the number of online users
-func (h *Hub) GetOnlineCount() int {
+// AuthorizePublish checks that sub may send messages to channel
+func (h *Hub) AuthorizePublish(ctx context.Context, sub Subscriber, channel string) error {
 	h.mu.RLock()
-	defer h.mu.RUnlock()
+	authorizer := h.authorizer
+	h.mu.RUnlock()
 
-	count := 0
-	for _, info := range h.presence {
-		if info.Status == "online" {
-			count++
+	return authorizer.AuthorizePublish(ctx, sub, channel)
+}
+
+// RowFilterColumns returns the columns of database rows that the filters
+// of channel read
+func (h *Hub) RowFilterColumns(channel string) []string {
+	h.mu.RLock()
+	authorizer := h.authorizer
+	h.mu.RUnlock()
+
+	return authorizer.RowFilterColumns(channel)
+}
+
+// History returns stored messages of a channel, oldest first
+func (h *Hub) History(ctx context.Context, channel string, query HistoryQuery) ([]*Message, error) {
+	return h.history.Read(ctx, channel, query)
+}
+
+// Replay sends a registered client the stored messages of the given
+// subscribed channels that match query, in ID order. Messages published
+// while replaying may arrive twice; clients ignore IDs they have seen.
+func (h *Hub) Replay(client *Client, channels []string, query HistoryQuery) {
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+
+	var replay []*Message
+	for _, channel := range channels {
+		h.mu.RLock()
+		filter, subscribed := client.subscriptions[channel]
+		h.mu.RUnlock()
+		if !subscribed {
+			continue
+		}
+
+		messages, err := h.history.Read(ctx, channel, query)
+		if err != nil {
+			h.logger.Error("Failed to read message history",
+				zap.String("channel", channel),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		for _, message := range messages {
+			if filter == nil || filter(message) {
+				replay = append(replay, message)
+			}
+		}
+	}
+
+	sortMessages(replay)
+	for _, message := range replay {
+		message.Replay = true
+		item, err := message.encode()
+		if err != nil {
+			continue
+		}
+		if !h.sendTo(client, item) {
+			return
 		}
 	}
-	return count
 }
 
-// GetRoomClients returns the number of clients in a room
+// GetRoomClients returns the number of clients in a room across instances
 func (h *Hub) GetRoomClients(room string) int {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-
-	if clients, ok := h.rooms[room]; ok {
-		return len(clients)
+	count := 0
+	for _, node := range h.clusterStats() {
+		count += node.Rooms[room]
 	}
-	return 0
+	return count
 }
 
-// GetStats returns statistics about the hub
+// GetStats returns statistics about the hubs of every instance, including
+// the number of subscribers of each channel in rooms and the messages sent,
+// dropped, coalesced and slow clients disconnected per channel in channels.
+// Other instances are counted as of their last report.
 func (h *Hub) GetStats() map[string]interface{} {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	nodes := h.clusterStats()
+
+	totalClients := 0
+	totalSubscriptions := 0
+	queued := 0
+	rooms := make(map[string]int)
+	channels := make(map[string]ChannelMetrics)
+	for _, node := range nodes {
+		totalClients += node.Clients
+		queued += node.Queued
+		for room, count := range node.Rooms {
+			rooms[room] += count
+			totalSubscriptions += count
+		}
+		for channel, metrics := range node.Channels {
+			total := channels[channel]
+			total.add(metrics)
+			channels[channel] = total
+		}
+	}
 
-	stats := map[string]interface{}{
-		"total_clients": len(h.clients),
-		"online_users":  h.GetOnlineCount(),
-		"total_rooms":   len(h.rooms),
-		"rooms":         make(map[string]int),
+	return map[string]interface{}{
+		"total_clients":       totalClients,
+		"online_users":        h.GetOnlineCount(),
+		"total_rooms":         len(rooms),
+		"total_subscriptions": totalSubscriptions,
+		"rooms":               rooms,
+		"channels":            channels,
+		"queued_messages":     queued,
+		"node_id":             h.nodeID,
+		"nodes":               nodes,
 	}
+}
 
-	// Add room statistics
-	for room, clients := range h.rooms {
-		stats["rooms"].(map[string]int)[room] = len(clients)
+// publish stores a channel message in the history and sends it through the
+// backplane unless the hub is shutting down
+func (h *Hub) publish(message *Message) {
+	h.send(message, true)
+}
+
+// publishEphemeral sends a message through the backplane without storing it
+func (h *Hub) publishEphemeral(message *Message) {
+	h.send(message, false)
+}
+
+func (h *Hub) send(message *Message, store bool) {
+	select {
+	case <-h.stop:
+		return
+	default:
 	}
 
-	return stats
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+
+	// Channel messages are stored first so they can be replayed as soon
+	// as they are delivered
+	if store && message.Channel != "" {
+		if err := h.history.Append(ctx, message); err != nil {
+			h.logger.Error("Failed to store message history",
+				zap.String("channel", message.Channel),
+				zap.Error(err),
+			)
+		}
+	}
+
+	// Messages are acknowledged by ID, which the history normally assigns
+	if message.AckRequired && message.ID == "" {
+		message.ID = uuid.NewString()
+	}
+
+	if err := h.backplane.Publish(ctx, message); err != nil {
+		h.logger.Error("Failed to publish message",
+			zap.String("channel", message.Channel),
+			zap.Error(err),
+		)
+	}
+}
+
+// receive queues a message from the backplane for local delivery unless the
+// hub is shutting down
+func (h *Hub) receive(message *Message) {
+	select {
+	case h.broadcast <- message:
+	case <-h.stop:
+	}
 }
 
-// RegisterClient registers a new client with the hub
-func (h *Hub) RegisterClient(client *Client) {
-	h.register <- client
+// RegisterClient registers a new client with the hub, subscribes it to
+// channels and adds it to the devices of its user. Every channel is acknowledged like a
+// subscribe message; the client is registered without the channels it may
+// not join.
+func (h *Hub) RegisterClient(client *Client, channels ...string) {
+	for _, channel := range channels {
+		if channel == "" {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+		filter, err := h.AuthorizeChannel(ctx, client.Subscriber, channel)
+		cancel()
+		if err == nil && len(client.subscriptions) >= h.maxSubscriptions {
+			err = ErrSubscriptionLimit
+		}
+		if err == nil {
+			client.subscriptions[channel] = filter
+		}
+
+		// The client is not shared yet, so its queue is written directly
+		client.queue.push(outgoing{
+			data:     client.acknowledgement("subscribed", "", channel, err),
+			priority: PriorityHigh,
+		})
+	}
+
+	select {
+	case h.register <- client:
+	case <-h.stop:
+		client.queue.close()
+		return
+	}
+	// Run registers the client right after receiving it; wait so that
+	// messages sent to it from here on are delivered
+	<-client.registered
+
+	h.connectPresence(client)
+}
+
+// UnregisterClient removes a client from the hub. Its user goes offline
+// once none of their devices is connected.
+func (h *Hub) UnregisterClient(client *Client) {
+	h.mu.RLock()
+	channels := h.userPresenceChannels(client.UserID)
+	h.mu.RUnlock()
+
+	select {
+	case h.unregister <- client:
+	case <-h.stop:
+		return
+	}
+
+	h.disconnectPresence(client, channels)
 }
@@ -0,0 +1,250 @@
+package realtime
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Type OIDs of values decoded as JSON instead of strings
+const (
+	oidBool    = 16
+	oidInt8    = 20
+	oidInt2    = 21
+	oidInt4    = 23
+	oidOID     = 26
+	oidJSON    = 114
+	oidFloat4  = 700
+	oidFloat8  = 701
+	oidNumeric = 1700
+	oidJSONB   = 3802
+)
+
+// pgEpoch is the zero time of Postgres timestamps
+var pgEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// errShortMessage is returned for a pgoutput message that ends early
+var errShortMessage = errors.New("pgoutput message too short")
+
+// pgoutputRelation is a table as described by a pgoutput Relation message
+type pgoutputRelation struct {
+	name    string
+	columns []pgoutputColumn
+}
+
+// pgoutputColumn is a column of a pgoutputRelation
+type pgoutputColumn struct {
+	name    string
+	typeOID uint32
+}
+
+// pgoutputReader reads the fields of a pgoutput message. After the first
+// failed read every read returns zero values and err is set.
+type pgoutputReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *pgoutputReader) readBytes(n int) []byte {
+	if r.err != nil || n < 0 || r.pos+n > len(r.data) {
+		r.err = errShortMessage
+		return nil
+	}
+	value := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return value
+}
+
+func (r *pgoutputReader) readByte() byte {
+	if value := r.readBytes(1); value != nil {
+		return value[0]
+	}
+	return 0
+}
+
+func (r *pgoutputReader) readUint16() uint16 {
+	if value := r.readBytes(2); value != nil {
+		return binary.BigEndian.Uint16(value)
+	}
+	return 0
+}
+
+func (r *pgoutputReader) readUint32() uint32 {
+	if value := r.readBytes(4); value != nil {
+		return binary.BigEndian.Uint32(value)
+	}
+	return 0
+}
+
+func (r *pgoutputReader) readUint64() uint64 {
+	if value := r.readBytes(8); value != nil {
+		return binary.BigEndian.Uint64(value)
+	}
+	return 0
+}
+
+// readString reads a null-terminated string
+func (r *pgoutputReader) readString() string {
+	if r.err != nil {
+		return ""
+	}
+	for i := r.pos; i < len(r.data); i++ {
+		if r.data[i] == 0 {
+			value := string(r.data[r.pos:i])
+			r.pos = i + 1
+			return value
+		}
+	}
+	r.err = errShortMessage
+	return ""
+}
+
+// readTuple reads the column values of a row. Unchanged values that were
+// not sent are taken from old.
+func (r *pgoutputReader) readTuple(relation *pgoutputRelation, old map[string]interface{}) map[string]interface{} {
+	count := int(r.readUint16())
+	row := make(map[string]interface{}, count)
+
+	for i := 0; i < count && r.err == nil; i++ {
+		if i >= len(relation.columns) {
+			r.err = fmt.Errorf("row of %s has more columns than its relation", relation.name)
+			break
+		}
+		column := relation.columns[i]
+
+		switch kind := r.readByte(); kind {
+		case 'n':
+			row[column.name] = nil
+		case 'u':
+			if value, ok := old[column.name]; ok {
+				row[column.name] = value
+			}
+		case 't':
+			length := int(r.readUint32())
+			row[column.name] = pgoutputValue(column.typeOID, string(r.readBytes(length)))
+		default:
+			if r.err == nil {
+				r.err = fmt.Errorf("unknown tuple value kind %q", kind)
+			}
+		}
+	}
+	return row
+}
+
+// pgoutputValue converts a value in Postgres text format to the value
+// to_jsonb would give for common types, and to a string otherwise
+func pgoutputValue(typeOID uint32, text string) interface{} {
+	switch typeOID {
+	case oidBool:
+		return text == "t"
+	case oidInt2, oidInt4, oidInt8, oidOID, oidFloat4, oidFloat8, oidNumeric:
+		// NaN and Infinity are not JSON numbers
+		if json.Valid([]byte(text)) {
+			return json.Number(text)
+		}
+	case oidJSON, oidJSONB:
+		if json.Valid([]byte(text)) {
+			return json.RawMessage(text)
+		}
+	}
+	return text
+}
+
+// decodePgoutput decodes a pgoutput message. It reports true for commits.
+// Relation messages are remembered to decode the rows that follow them.
+func (s *ReplicationSource) decodePgoutput(data []byte) (*DBChangeEvent, bool, error) {
+	r := &pgoutputReader{data: data}
+
+	switch r.readByte() {
+	case 'B':
+		r.readUint64() // Final LSN
+		micros := int64(r.readUint64())
+		s.commitTime = pgEpoch.Add(time.Duration(micros) * time.Microsecond)
+		return nil, false, r.err
+
+	case 'C':
+		return nil, true, r.err
+
+	case 'R':
+		id := r.readUint32()
+		r.readString() // Namespace
+		relation := &pgoutputRelation{name: r.readString()}
+		r.readByte() // Replica identity
+		count := int(r.readUint16())
+		for i := 0; i < count && r.err == nil; i++ {
+			r.readByte() // Flags
+			column := pgoutputColumn{name: r.readString(), typeOID: r.readUint32()}
+			r.readUint32() // Type modifier
+			relation.columns = append(relation.columns, column)
+		}
+		if r.err != nil {
+			return nil, false, r.err
+		}
+		s.relations[id] = relation
+		return nil, false, nil
+
+	case 'I', 'U', 'D':
+		r.pos = 0
+		return s.decodePgoutputRow(r)
+	}
+
+	// Types, origins, truncates and logical messages are not streamed
+	return nil, false, r.err
+}
+
+// decodePgoutputRow decodes an Insert, Update or Delete message
+func (s *ReplicationSource) decodePgoutputRow(r *pgoutputReader) (*DBChangeEvent, bool, error) {
+	kind := r.readByte()
+	id := r.readUint32()
+	relation, ok := s.relations[id]
+	if !ok {
+		if r.err != nil {
+			return nil, false, r.err
+		}
+		return nil, false, fmt.Errorf("row of unknown relation %d", id)
+	}
+
+	timestamp := s.commitTime
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	event := &DBChangeEvent{
+		Table:     relation.name,
+		Timestamp: timestamp,
+	}
+
+	switch kind {
+	case 'I':
+		event.Operation = "INSERT"
+		r.readByte() // 'N'
+		event.Data = r.readTuple(relation, nil)
+
+	case 'U':
+		event.Operation = "UPDATE"
+		// 'O' precedes the full old row, 'K' only its key, and 'N' the new row
+		tuple := r.readByte()
+		var old map[string]interface{}
+		if tuple == 'O' || tuple == 'K' {
+			old = r.readTuple(relation, nil)
+			r.readByte() // 'N'
+		}
+		event.Data = r.readTuple(relation, old)
+		if tuple == 'O' {
+			event.OldData = old
+			event.Changed = changedColumns(old, event.Data)
+		}
+
+	case 'D':
+		event.Operation = "DELETE"
+		r.readByte() // 'O' or 'K'
+		event.Data = r.readTuple(relation, nil)
+	}
+
+	if r.err != nil {
+		return nil, false, r.err
+	}
+	return event, false, nil
+}
@@ -0,0 +1,675 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Presence statuses
+const (
+	StatusOnline  = "online"
+	StatusAway    = "away"
+	StatusBusy    = "busy"
+	StatusOffline = "offline"
+)
+
+const (
+	// defaultPresenceTTL is how long presence survives without a refresh
+	defaultPresenceTTL = time.Minute
+
+	// offlinePresenceTTL is how long an offline user's last seen time is kept
+	offlinePresenceTTL = 24 * time.Hour
+
+	// maxStatusText is the longest custom status text, in characters
+	maxStatusText = 100
+
+	// typingTimeout is how long a typing indicator lasts unless it is sent
+	// again
+	typingTimeout = 5 * time.Second
+)
+
+// Presence errors
+var (
+	ErrInvalidStatus = errors.New("invalid presence status")
+	ErrNotConnected  = errors.New("user not connected")
+)
+
+// ContactsFunc returns the users who see the presence of a user in
+// addition to the members of their presence channels
+type ContactsFunc func(ctx context.Context, userID uint) ([]uint, error)
+
+// PresenceInfo tracks user presence. A user is online while any of their
+// devices is connected, on any instance.
+type PresenceInfo struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Status   string `json:"status"` // online, away, busy, offline
+	// StatusText is a custom status set by the user
+	StatusText      string    `json:"status_text,omitempty"`
+	StatusChangedAt time.Time `json:"status_changed_at"`
+	LastSeen        time.Time `json:"last_seen"`
+	// DeviceInfo is the user agent of the most recently active device
+	DeviceInfo string           `json:"device_info,omitempty"`
+	Devices    []DevicePresence `json:"devices,omitempty"`
+	// Channels are the presence channels the user's devices joined while
+	// online. They decide who else sees the user and are only returned to
+	// admins.
+	Channels []string `json:"channels,omitempty"`
+}
+
+// DevicePresence is one connection of a user
+type DevicePresence struct {
+	ID          string    `json:"id"`
+	DeviceInfo  string    `json:"device_info,omitempty"`
+	ConnectedAt time.Time `json:"connected_at"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// PresenceScope addresses a presence update to the clients subscribed to
+// any of Channels and the clients of Users. It is not sent to clients.
+type PresenceScope struct {
+	Channels []string `json:"channels,omitempty"`
+	Users    []uint   `json:"users,omitempty"`
+}
+
+// userPresence is the presence of a user on this instance, with one device
+// per connected client
+type userPresence struct {
+	info    PresenceInfo
+	devices map[*Client]*DevicePresence
+
+	// Presence channels the user's devices joined while online. Their
+	// members keep receiving the user's updates until the user goes
+	// offline.
+	channels map[string]bool
+}
+
+// snapshot returns the presence of the user's devices on this instance
+func (p *userPresence) snapshot() *PresenceInfo {
+	info := p.info
+	info.Devices = make([]DevicePresence, 0, len(p.devices))
+	for _, device := range p.devices {
+		info.Devices = append(info.Devices, *device)
+	}
+	info.Channels = make([]string, 0, len(p.channels))
+	for channel := range p.channels {
+		info.Channels = append(info.Channels, channel)
+	}
+	sort.Strings(info.Channels)
+	sortDevices(&info)
+	return &info
+}
+
+// sortDevices orders the devices of info by ID and takes the last seen time
+// and device info from the most recently active one
+func sortDevices(info *PresenceInfo) {
+	sort.Slice(info.Devices, func(i, j int) bool {
+		return info.Devices[i].ID < info.Devices[j].ID
+	})
+
+	var latest time.Time
+	for _, device := range info.Devices {
+		if device.LastSeen.After(latest) {
+			latest = device.LastSeen
+			info.DeviceInfo = device.DeviceInfo
+		}
+	}
+	if latest.After(info.LastSeen) {
+		info.LastSeen = latest
+	}
+}
+
+// mergePresence combines the presence records of one user from every
+// instance. The user is offline when no record has devices; otherwise the
+// most recently changed status of a record with devices applies.
+func mergePresence(records []*PresenceInfo) *PresenceInfo {
+	if len(records) == 0 {
+		return nil
+	}
+
+	merged := *records[0]
+	merged.Devices = nil
+	merged.Channels = nil
+	merged.LastSeen = time.Time{}
+
+	channels := make(map[string]bool)
+	var status *PresenceInfo
+	for _, record := range records {
+		merged.Devices = append(merged.Devices, record.Devices...)
+		if len(record.Devices) > 0 {
+			for _, channel := range record.Channels {
+				channels[channel] = true
+			}
+		}
+		if record.LastSeen.After(merged.LastSeen) {
+			merged.LastSeen = record.LastSeen
+			merged.Username = record.Username
+		}
+		if len(record.Devices) > 0 && (status == nil || record.StatusChangedAt.After(status.StatusChangedAt)) {
+			status = record
+		}
+	}
+
+	if status == nil {
+		merged.Status = StatusOffline
+		merged.StatusText = ""
+		merged.DeviceInfo = ""
+		return &merged
+	}
+
+	merged.Status = status.Status
+	merged.StatusText = status.StatusText
+	merged.StatusChangedAt = status.StatusChangedAt
+	for channel := range channels {
+		merged.Channels = append(merged.Channels, channel)
+	}
+	sort.Strings(merged.Channels)
+	sortDevices(&merged)
+	return &merged
+}
+
+// ValidateStatus checks a status a user may set
+func ValidateStatus(status, text string) error {
+	switch status {
+	case StatusOnline, StatusAway, StatusBusy:
+	default:
+		return ErrInvalidStatus
+	}
+	if len([]rune(text)) > maxStatusText {
+		return ErrInvalidStatus
+	}
+	return nil
+}
+
+// isPresenceChannel reports whether members of channel see each other's
+// presence
+func (h *Hub) isPresenceChannel(channel string) bool {
+	for _, pattern := range h.presenceChannels {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(channel, prefix) {
+				return true
+			}
+		} else if channel == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// userPresenceChannels returns the presence channels the local devices of
+// a user joined while online. The caller holds the lock.
+func (h *Hub) userPresenceChannels(userID uint) []string {
+	current, ok := h.presence[userID]
+	if !ok {
+		return nil
+	}
+
+	channels := make([]string, 0, len(current.channels))
+	for channel := range current.channels {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+// presenceScope addresses a presence update of a user to the members of
+// channels, the user's contacts and the user's own devices
+func (h *Hub) presenceScope(userID uint, channels []string) *PresenceScope {
+	scope := &PresenceScope{
+		Channels: channels,
+		Users:    []uint{userID},
+	}
+
+	if h.contacts == nil {
+		return scope
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+	contacts, err := h.contacts(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to get contacts", zap.Uint("user_id", userID), zap.Error(err))
+		return scope
+	}
+	scope.Users = append(scope.Users, contacts...)
+	return scope
+}
+
+// storePresence writes this instance's presence of a user to the backplane
+func (h *Hub) storePresence(info *PresenceInfo, ttl time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+
+	if err := h.backplane.SetPresence(ctx, h.nodeID, info, ttl); err != nil {
+		h.logger.Error("Failed to store presence", zap.Uint("user_id", info.UserID), zap.Error(err))
+	}
+}
+
+// connectPresence adds a registered client as a device of its user. The
+// user comes online, keeping the status set on other devices, when it is
+// their first device on any instance.
+func (h *Hub) connectPresence(client *Client) {
+	if client.UserID == 0 {
+		return
+	}
+
+	existing := h.GetPresence(client.UserID)
+	online := existing != nil && len(existing.Devices) > 0
+	now := time.Now()
+
+	h.mu.Lock()
+	current, ok := h.presence[client.UserID]
+	if !ok {
+		current = &userPresence{
+			info: PresenceInfo{
+				UserID:          client.UserID,
+				Username:        client.Username,
+				Status:          StatusOnline,
+				StatusChangedAt: now,
+			},
+			devices:  make(map[*Client]*DevicePresence),
+			channels: make(map[string]bool),
+		}
+		if online {
+			current.info.Status = existing.Status
+			current.info.StatusText = existing.StatusText
+			current.info.StatusChangedAt = existing.StatusChangedAt
+		}
+		h.presence[client.UserID] = current
+	}
+	current.devices[client] = &DevicePresence{
+		ID:          client.ID,
+		DeviceInfo:  client.UserAgent,
+		ConnectedAt: now,
+		LastSeen:    now,
+	}
+	for channel := range client.subscriptions {
+		if h.isPresenceChannel(channel) {
+			current.channels[channel] = true
+		}
+	}
+	info := current.snapshot()
+	channels := h.userPresenceChannels(client.UserID)
+	h.mu.Unlock()
+
+	h.storePresence(info, h.presenceTTL)
+
+	if !online {
+		h.publishPresence(info, h.presenceScope(client.UserID, channels))
+	}
+}
+
+// disconnectPresence removes a client from the devices of its user. The
+// user goes offline once they have no device left on any instance.
+// channels are the presence channels the user was in before the client
+// left them.
+func (h *Hub) disconnectPresence(client *Client, channels []string) {
+	if client.UserID == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	current, ok := h.presence[client.UserID]
+	if !ok || current.devices[client] == nil {
+		h.mu.Unlock()
+		return
+	}
+	delete(current.devices, client)
+	remaining := len(current.devices)
+	if remaining == 0 {
+		delete(h.presence, client.UserID)
+	}
+	info := current.snapshot()
+	h.mu.Unlock()
+
+	if remaining > 0 {
+		h.storePresence(info, h.presenceTTL)
+		return
+	}
+
+	info.Status = StatusOffline
+	info.StatusText = ""
+	info.StatusChangedAt = time.Now()
+	info.LastSeen = info.StatusChangedAt
+	h.storePresence(info, offlinePresenceTTL)
+
+	// Still online on another instance
+	if merged := h.GetPresence(client.UserID); merged != nil && len(merged.Devices) > 0 {
+		return
+	}
+
+	h.publishPresence(info, h.presenceScope(client.UserID, channels))
+}
+
+// SetStatus sets the status of a connected user on all of their devices
+func (h *Hub) SetStatus(userID uint, status, text string) error {
+	if err := ValidateStatus(status, text); err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	h.mu.Lock()
+	current, ok := h.presence[userID]
+	if !ok {
+		h.mu.Unlock()
+		return ErrNotConnected
+	}
+	current.info.Status = status
+	current.info.StatusText = text
+	current.info.StatusChangedAt = now
+	info := current.snapshot()
+	channels := h.userPresenceChannels(userID)
+	h.mu.Unlock()
+
+	h.storePresence(info, h.presenceTTL)
+	h.publishPresence(info, h.presenceScope(userID, channels))
+	return nil
+}
+
+// touchPresence records activity on a device
+func (h *Hub) touchPresence(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if current, ok := h.presence[client.UserID]; ok {
+		if device := current.devices[client]; device != nil {
+			device.LastSeen = time.Now()
+		}
+	}
+}
+
+// announcePresence tells the members of a presence channel that a client
+// just joined it that its user is here
+func (h *Hub) announcePresence(client *Client, channel string) {
+	if client.UserID == 0 || !h.isPresenceChannel(channel) {
+		return
+	}
+
+	h.mu.Lock()
+	current, ok := h.presence[client.UserID]
+	var info *PresenceInfo
+	if ok {
+		current.channels[channel] = true
+		info = current.snapshot()
+	}
+	h.mu.Unlock()
+
+	if ok {
+		h.publishPresence(info, &PresenceScope{Channels: []string{channel}})
+	}
+}
+
+// publishPresence sends a presence update to the clients in scope on every
+// instance
+func (h *Hub) publishPresence(info *PresenceInfo, scope *PresenceScope) {
+	payload := map[string]interface{}{
+		"user_id":           info.UserID,
+		"username":          info.Username,
+		"status":            info.Status,
+		"status_changed_at": info.StatusChangedAt,
+		"devices":           len(info.Devices),
+		"last_seen":         info.LastSeen,
+	}
+	if info.StatusText != "" {
+		payload["status_text"] = info.StatusText
+	}
+
+	h.publishEphemeral(&Message{
+		Type:      "presence",
+		Event:     "status_change",
+		UserID:    info.UserID,
+		Payload:   payload,
+		Timestamp: time.Now(),
+		Scope:     scope,
+	})
+}
+
+// deliverPresence sends a presence update to the local clients in its
+// scope, and adopts a status the user set on another instance
+func (h *Hub) deliverPresence(message *Message) {
+	h.mu.Lock()
+
+	// Status changes made on another instance apply to local devices too
+	if current, ok := h.presence[message.UserID]; ok {
+		status, _ := message.Payload["status"].(string)
+		changedAt := payloadTime(message.Payload["status_changed_at"])
+		if status != StatusOffline && changedAt.After(current.info.StatusChangedAt) {
+			current.info.Status = status
+			current.info.StatusText, _ = message.Payload["status_text"].(string)
+			current.info.StatusChangedAt = changedAt
+		}
+	}
+
+	recipients := make(map[*Client]bool)
+	for _, channel := range message.Scope.Channels {
+		for client := range h.rooms[channel] {
+			recipients[client] = true
+		}
+	}
+	for _, userID := range message.Scope.Users {
+		if current, ok := h.presence[userID]; ok {
+			for client := range current.devices {
+				if h.clients[client] {
+					recipients[client] = true
+				}
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	if len(recipients) == 0 {
+		return
+	}
+
+	// Clients do not see who else the update was addressed to
+	update := *message
+	update.Scope = nil
+	item, err := update.encode()
+	if err != nil {
+		h.logger.Error("Failed to marshal presence update", zap.Error(err))
+		return
+	}
+
+	clients := make([]*Client, 0, len(recipients))
+	for client := range recipients {
+		clients = append(clients, client)
+	}
+	h.deliver(metricsPresence, clients, item)
+}
+
+// payloadTime reads a time from a message payload, which holds a string
+// once the message went through a remote backplane
+func payloadTime(value interface{}) time.Time {
+	switch v := value.(type) {
+	case time.Time:
+		return v
+	case string:
+		t, _ := time.Parse(time.RFC3339Nano, v)
+		return t
+	}
+	return time.Time{}
+}
+
+// refreshPresence extends the presence of every user connected to this
+// instance
+func (h *Hub) refreshPresence() {
+	h.mu.RLock()
+	presence := make([]*PresenceInfo, 0, len(h.presence))
+	for _, current := range h.presence {
+		presence = append(presence, current.snapshot())
+	}
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+
+	for _, info := range presence {
+		if err := h.backplane.SetPresence(ctx, h.nodeID, info, h.presenceTTL); err != nil {
+			h.logger.Error("Failed to refresh presence", zap.Error(err))
+			return
+		}
+	}
+}
+
+// publishChannelEvent sends an ephemeral event from a client to the other
+// members of a channel it is subscribed to. Events are not kept in the
+// channel history.
+func (h *Hub) publishChannelEvent(client *Client, messageType, channel, event string, payload map[string]interface{}) error {
+	h.mu.RLock()
+	_, subscribed := client.subscriptions[channel]
+	h.mu.RUnlock()
+	if !subscribed {
+		return ErrNotSubscribed
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	err := h.AuthorizePublish(ctx, client.Subscriber, channel)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	if payload == nil {
+		payload = make(map[string]interface{})
+	}
+	payload["user_id"] = client.UserID
+
+	h.touchPresence(client)
+	h.publishEphemeral(&Message{
+		Type:      messageType,
+		Channel:   channel,
+		Event:     event,
+		Payload:   payload,
+		UserID:    client.UserID,
+		Timestamp: time.Now(),
+	})
+	return nil
+}
+
+// GetPresence returns presence information for a user on any instance
+func (h *Hub) GetPresence(userID uint) *PresenceInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+
+	info, err := h.backplane.GetPresence(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to get presence", zap.Uint("user_id", userID), zap.Error(err))
+		return nil
+	}
+	return info
+}
+
+// GetAllPresence returns presence information for users on every instance
+func (h *Hub) GetAllPresence() map[uint]*PresenceInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+
+	presence, err := h.backplane.ListPresence(ctx)
+	if err != nil {
+		h.logger.Error("Failed to list presence", zap.Error(err))
+		return make(map[uint]*PresenceInfo)
+	}
+	return presence
+}
+
+// VisiblePresence returns the presence of userID when viewer sees it, and
+// nil otherwise. Users see themselves, the users they are a contact of and
+// the members of the presence channels they are in; see PresenceScope.
+func (h *Hub) VisiblePresence(viewer, userID uint) *PresenceInfo {
+	info := h.GetPresence(userID)
+	if info == nil {
+		return nil
+	}
+
+	var viewerChannels []string
+	if viewer != userID {
+		if own := h.GetPresence(viewer); own != nil {
+			viewerChannels = own.Channels
+		}
+	}
+
+	if !h.seesPresence(viewer, viewerChannels, info) {
+		return nil
+	}
+	return info.public()
+}
+
+// ListVisiblePresence returns the presence of the users viewer sees on every
+// instance
+func (h *Hub) ListVisiblePresence(viewer uint) map[uint]*PresenceInfo {
+	all := h.GetAllPresence()
+
+	var viewerChannels []string
+	if own := all[viewer]; own != nil {
+		viewerChannels = own.Channels
+	}
+
+	visible := make(map[uint]*PresenceInfo)
+	for userID, info := range all {
+		if h.seesPresence(viewer, viewerChannels, info) {
+			visible[userID] = info.public()
+		}
+	}
+	return visible
+}
+
+// seesPresence reports whether viewer, who is in viewerChannels, sees the
+// presence in info
+func (h *Hub) seesPresence(viewer uint, viewerChannels []string, info *PresenceInfo) bool {
+	if viewer == 0 {
+		return false
+	}
+	if viewer == info.UserID {
+		return true
+	}
+
+	for _, channel := range viewerChannels {
+		for _, shared := range info.Channels {
+			if channel == shared {
+				return true
+			}
+		}
+	}
+
+	if h.contacts == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+	contacts, err := h.contacts(ctx, info.UserID)
+	if err != nil {
+		h.logger.Error("Failed to get contacts", zap.Uint("user_id", info.UserID), zap.Error(err))
+		return false
+	}
+	for _, contact := range contacts {
+		if contact == viewer {
+			return true
+		}
+	}
+	return false
+}
+
+// public returns a copy of the presence without the fields only admins see
+func (p *PresenceInfo) public() *PresenceInfo {
+	info := *p
+	info.Channels = nil
+	return &info
+}
+
+// GetOnlineCount returns the number of online users
+func (h *Hub) GetOnlineCount() int {
+	count := 0
+	for _, info := range h.GetAllPresence() {
+		if info.Status != StatusOffline {
+			count++
+		}
+	}
+	return count
+}
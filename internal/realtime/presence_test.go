@@ -0,0 +1,86 @@
+package realtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestVisiblePresence(t *testing.T) {
+	backplane := NewLocalBackplane()
+	hub, err := NewHubWithConfig(zap.NewNop(), HubConfig{
+		Backplane: backplane,
+		Contacts: func(ctx context.Context, userID uint) ([]uint, error) {
+			// User 4 has user 1 as a contact
+			if userID == 4 {
+				return []uint{1}, nil
+			}
+			return nil, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewHubWithConfig() error = %v", err)
+	}
+
+	online := func(userID uint, channels ...string) *PresenceInfo {
+		return &PresenceInfo{
+			UserID:   userID,
+			Status:   StatusOnline,
+			Devices:  []DevicePresence{{ID: "device"}},
+			Channels: channels,
+		}
+	}
+	records := []*PresenceInfo{
+		online(1, "chat:a"),
+		online(2, "chat:a", "chat:b"),
+		online(3, "chat:b"),
+		online(4),
+		online(5),
+		// Offline users no longer share their channels
+		{UserID: 6, Status: StatusOffline, Channels: []string{"chat:a"}},
+	}
+	for _, record := range records {
+		if err := backplane.SetPresence(context.Background(), "node", record, time.Minute); err != nil {
+			t.Fatalf("SetPresence() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		viewer uint
+		want   []uint
+	}{
+		{name: "self, shared channel and contact", viewer: 1, want: []uint{1, 2, 4}},
+		{name: "members of every shared channel", viewer: 2, want: []uint{1, 2, 3}},
+		{name: "no channels or contacts", viewer: 5, want: []uint{5}},
+		{name: "no user", viewer: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			visible := hub.ListVisiblePresence(tt.viewer)
+			if len(visible) != len(tt.want) {
+				t.Fatalf("ListVisiblePresence() returned %d users, want %v", len(visible), tt.want)
+			}
+			for _, userID := range tt.want {
+				info, ok := visible[userID]
+				if !ok {
+					t.Fatalf("ListVisiblePresence() is missing user %d", userID)
+				}
+				if info.Channels != nil {
+					t.Errorf("ListVisiblePresence() returned the channels of user %d", userID)
+				}
+				if hub.VisiblePresence(tt.viewer, userID) == nil {
+					t.Errorf("VisiblePresence(%d, %d) = nil, want presence", tt.viewer, userID)
+				}
+			}
+			for userID := uint(1); userID <= 6; userID++ {
+				if _, ok := visible[userID]; !ok && hub.VisiblePresence(tt.viewer, userID) != nil {
+					t.Errorf("VisiblePresence(%d, %d) returned presence the viewer does not see", tt.viewer, userID)
+				}
+			}
+		})
+	}
+}
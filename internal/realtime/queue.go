@@ -0,0 +1,274 @@
+package realtime
+
+import (
+	"fmt"
+	"sync"
+)
+
+// SlowConsumerPolicy decides what happens when a client's send queue is
+// full
+type SlowConsumerPolicy string
+
+// Slow consumer policies
+const (
+	// PolicyDropOldest drops the oldest queued message of the lowest
+	// priority to make room
+	PolicyDropOldest SlowConsumerPolicy = "drop_oldest"
+	// PolicyDisconnect disconnects the client once its queue is full
+	PolicyDisconnect SlowConsumerPolicy = "disconnect"
+	// PolicyCoalesce replaces a queued message with a newer one about the
+	// same thing, such as a user's presence, and otherwise drops like
+	// PolicyDropOldest
+	PolicyCoalesce SlowConsumerPolicy = "coalesce"
+)
+
+// defaultQueueSize is the number of messages queued per client
+const defaultQueueSize = 256
+
+// Priority orders the messages queued for a client. Higher priority
+// messages are sent first and dropped last.
+type Priority string
+
+// Message priorities
+const (
+	PriorityLow    Priority = "low"
+	PriorityNormal Priority = ""
+	PriorityHigh   Priority = "high"
+)
+
+// rank returns the sort order of a priority
+func (p Priority) rank() int {
+	switch p {
+	case PriorityLow:
+		return -1
+	case PriorityHigh:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// ParsePriority parses low, normal or high
+func ParsePriority(value string) (Priority, error) {
+	switch value {
+	case "low":
+		return PriorityLow, nil
+	case "", "normal":
+		return PriorityNormal, nil
+	case "high":
+		return PriorityHigh, nil
+	}
+	return PriorityNormal, fmt.Errorf("invalid priority %q", value)
+}
+
+// validatePolicy checks a slow consumer policy
+func validatePolicy(policy SlowConsumerPolicy) error {
+	switch policy {
+	case PolicyDropOldest, PolicyDisconnect, PolicyCoalesce:
+		return nil
+	}
+	return fmt.Errorf("unknown slow consumer policy %q", policy)
+}
+
+// outgoing is an encoded message queued for a client
+type outgoing struct {
+	data     []byte
+	priority Priority
+	// key identifies what the message is about; with PolicyCoalesce a
+	// newer message replaces a queued one with the same key
+	key string
+	// ackID is the ID clients acknowledge the message with, if they must
+	ackID string
+}
+
+// pushResult is what happened to a pushed message
+type pushResult int
+
+const (
+	// pushQueued queued the message
+	pushQueued pushResult = iota
+	// pushEvicted queued the message after dropping an older one
+	pushEvicted
+	// pushRejected dropped the message because the queue held only
+	// higher priority messages
+	pushRejected
+	// pushCoalesced replaced a queued message with the same key
+	pushCoalesced
+	// pushOverflow closed the full queue of a client to disconnect it
+	pushOverflow
+	// pushClosed dropped the message because the client is gone
+	pushClosed
+)
+
+// sendQueue holds the messages waiting to be written to a client. Pushing
+// never blocks; when the queue is full its policy decides what gives way.
+type sendQueue struct {
+	size   int
+	policy SlowConsumerPolicy
+
+	mu     sync.Mutex
+	items  []outgoing
+	closed bool
+
+	// ready is signalled when messages are queued or the queue closes
+	ready chan struct{}
+}
+
+// newSendQueue creates a queue of size messages
+func newSendQueue(size int, policy SlowConsumerPolicy) *sendQueue {
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	if policy == "" {
+		policy = PolicyDropOldest
+	}
+
+	return &sendQueue{
+		size:   size,
+		policy: policy,
+		ready:  make(chan struct{}, 1),
+	}
+}
+
+// signal wakes the consumer without blocking
+func (q *sendQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// push queues a message
+func (q *sendQueue) push(item outgoing) pushResult {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return pushClosed
+	}
+
+	if q.policy == PolicyCoalesce && item.key != "" {
+		for i := range q.items {
+			if q.items[i].key == item.key {
+				q.items[i] = item
+				return pushCoalesced
+			}
+		}
+	}
+
+	result := pushQueued
+	if len(q.items) >= q.size {
+		if q.policy == PolicyDisconnect {
+			q.closed = true
+			q.items = nil
+			q.signal()
+			return pushOverflow
+		}
+
+		// Drop the oldest message of the lowest priority, unless the new
+		// message ranks below everything queued
+		victim := 0
+		for i := range q.items {
+			if q.items[i].priority.rank() < q.items[victim].priority.rank() {
+				victim = i
+			}
+		}
+		if item.priority.rank() < q.items[victim].priority.rank() {
+			return pushRejected
+		}
+		q.items = append(q.items[:victim], q.items[victim+1:]...)
+		result = pushEvicted
+	}
+
+	q.items = append(q.items, item)
+	q.signal()
+	return result
+}
+
+// drain removes every queued message, highest priority first and oldest
+// first within a priority. It reports false once the queue is closed.
+func (q *sendQueue) drain() ([][]byte, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	messages := make([][]byte, 0, len(q.items))
+	for _, priority := range []Priority{PriorityHigh, PriorityNormal, PriorityLow} {
+		for _, item := range q.items {
+			if item.priority.rank() == priority.rank() {
+				messages = append(messages, item.data)
+			}
+		}
+	}
+	q.items = nil
+	if q.closed {
+		// Keep waking readers until they see the queue is closed
+		q.signal()
+	}
+	return messages, !q.closed
+}
+
+// len returns the number of queued messages
+func (q *sendQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// close stops the queue. Messages already queued are still drained.
+func (q *sendQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.closed {
+		q.closed = true
+		q.signal()
+	}
+}
+
+// ChannelMetrics counts the messages sent to the subscribers of a channel
+type ChannelMetrics struct {
+	// Sent is the number of messages queued for subscribers
+	Sent uint64 `json:"sent"`
+	// Dropped is the number of messages lost to full queues
+	Dropped uint64 `json:"dropped"`
+	// Coalesced is the number of queued messages replaced by newer ones
+	Coalesced uint64 `json:"coalesced"`
+	// Disconnected is the number of slow subscribers disconnected
+	Disconnected uint64 `json:"disconnected"`
+	// Acked, Retried and Unacked count the acknowledgements of messages
+	// that require them, the messages sent again for lack of one, and the
+	// messages given up on
+	Acked   uint64 `json:"acked"`
+	Retried uint64 `json:"retried"`
+	Unacked uint64 `json:"unacked"`
+}
+
+// add adds the counts of other
+func (m *ChannelMetrics) add(other ChannelMetrics) {
+	m.Sent += other.Sent
+	m.Dropped += other.Dropped
+	m.Coalesced += other.Coalesced
+	m.Disconnected += other.Disconnected
+	m.Acked += other.Acked
+	m.Retried += other.Retried
+	m.Unacked += other.Unacked
+}
+
+// record counts the result of a push
+func (m *ChannelMetrics) record(result pushResult) {
+	switch result {
+	case pushQueued:
+		m.Sent++
+	case pushEvicted:
+		m.Sent++
+		m.Dropped++
+	case pushRejected:
+		m.Dropped++
+	case pushCoalesced:
+		m.Sent++
+		m.Coalesced++
+	case pushOverflow:
+		m.Dropped++
+		m.Disconnected++
+	}
+}
@@ -0,0 +1,136 @@
+package realtime
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSendQueue(t *testing.T) {
+	type push struct {
+		data     string
+		priority Priority
+		key      string
+		want     pushResult
+	}
+
+	tests := []struct {
+		name     string
+		size     int
+		policy   SlowConsumerPolicy
+		pushes   []push
+		want     []string
+		wantOpen bool
+	}{
+		{
+			name:   "priority order",
+			size:   5,
+			policy: PolicyDropOldest,
+			pushes: []push{
+				{data: "low", priority: PriorityLow},
+				{data: "a"},
+				{data: "high", priority: PriorityHigh},
+				{data: "b"},
+				{data: "urgent", priority: PriorityHigh},
+			},
+			want:     []string{"high", "urgent", "a", "b", "low"},
+			wantOpen: true,
+		},
+		{
+			name:   "drop oldest",
+			size:   2,
+			policy: PolicyDropOldest,
+			pushes: []push{
+				{data: "a"},
+				{data: "b"},
+				{data: "c", want: pushEvicted},
+			},
+			want:     []string{"b", "c"},
+			wantOpen: true,
+		},
+		{
+			name:   "drop oldest of the lowest priority",
+			size:   2,
+			policy: PolicyDropOldest,
+			pushes: []push{
+				{data: "a"},
+				{data: "low", priority: PriorityLow},
+				{data: "b", want: pushEvicted},
+			},
+			want:     []string{"a", "b"},
+			wantOpen: true,
+		},
+		{
+			name:   "reject below everything queued",
+			size:   2,
+			policy: PolicyDropOldest,
+			pushes: []push{
+				{data: "high", priority: PriorityHigh},
+				{data: "a"},
+				{data: "b", want: pushEvicted},
+				{data: "low", priority: PriorityLow, want: pushRejected},
+			},
+			want:     []string{"high", "b"},
+			wantOpen: true,
+		},
+		{
+			name:   "disconnect",
+			size:   2,
+			policy: PolicyDisconnect,
+			pushes: []push{
+				{data: "a"},
+				{data: "b"},
+				{data: "c", want: pushOverflow},
+				{data: "d", want: pushClosed},
+			},
+			want: []string{},
+		},
+		{
+			name:   "coalesce",
+			size:   2,
+			policy: PolicyCoalesce,
+			pushes: []push{
+				{data: "away", key: "presence:1"},
+				{data: "a"},
+				// Replaced in place, so it keeps its position
+				{data: "online", key: "presence:1", want: pushCoalesced},
+				{data: "b", want: pushEvicted},
+			},
+			want:     []string{"a", "b"},
+			wantOpen: true,
+		},
+		{
+			name:   "coalesce only with the coalesce policy",
+			size:   3,
+			policy: PolicyDropOldest,
+			pushes: []push{
+				{data: "away", key: "presence:1"},
+				{data: "online", key: "presence:1"},
+			},
+			want:     []string{"away", "online"},
+			wantOpen: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := newSendQueue(tt.size, tt.policy)
+			for _, p := range tt.pushes {
+				if got := queue.push(outgoing{data: []byte(p.data), priority: p.priority, key: p.key}); got != p.want {
+					t.Errorf("push(%s) = %v, want %v", p.data, got, p.want)
+				}
+			}
+
+			messages, open := queue.drain()
+			got := make([]string, len(messages))
+			for i, message := range messages {
+				got[i] = string(message)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("drain() = %v, want %v", got, tt.want)
+			}
+			if open != tt.wantOpen {
+				t.Errorf("drain() open = %v, want %v", open, tt.wantOpen)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"go-mobile-backend-template/pkg/cache"
+)
+
+// Redis keys and channels used by RedisBackplane
+const (
+	redisMessagesChannel = "realtime:messages"
+	redisPresencePrefix  = "realtime:presence:"
+	redisNodePrefix      = "realtime:node:"
+)
+
+// redisScanCount is the number of keys requested per SCAN round trip
+const redisScanCount = 500
+
+// RedisBackplane shares messages between instances over Redis pub/sub and
+// keeps presence and node stats in Redis keys that expire
+type RedisBackplane struct {
+	client *redis.Client
+	logger *zap.Logger
+
+	mu     sync.Mutex
+	pubsub *redis.PubSub
+	done   chan struct{}
+}
+
+// NewRedisBackplane creates a backplane on an existing Redis connection
+func NewRedisBackplane(redisClient *cache.RedisClient, logger *zap.Logger) *RedisBackplane {
+	return &RedisBackplane{
+		client: redisClient.GetClient(),
+		logger: logger,
+	}
+}
+
+// Publish sends the message to every subscribed instance
+func (b *RedisBackplane) Publish(ctx context.Context, message *Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	if err := b.client.Publish(ctx, redisMessagesChannel, data).Err(); err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+	return nil
+}
+
+// Subscribe starts delivering messages published by any instance
+func (b *RedisBackplane) Subscribe(deliver func(*Message)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.pubsub != nil {
+		return errors.New("backplane already subscribed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pubsub := b.client.Subscribe(ctx, redisMessagesChannel)
+	// Wait for the subscription so no message published after this returns
+	// is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return fmt.Errorf("failed to subscribe to %s: %w", redisMessagesChannel, err)
+	}
+
+	b.pubsub = pubsub
+	b.done = make(chan struct{})
+	go b.receive(pubsub.Channel(), deliver)
+
+	return nil
+}
+
+// receive delivers messages until the subscription is closed
+func (b *RedisBackplane) receive(messages <-chan *redis.Message, deliver func(*Message)) {
+	defer close(b.done)
+
+	for msg := range messages {
+		var message Message
+		if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+			b.logger.Error("Failed to parse backplane message", zap.Error(err))
+			continue
+		}
+		deliver(&message)
+	}
+}
+
+// SetPresence stores info until ttl passes, in a key per user and instance
+func (b *RedisBackplane) SetPresence(ctx context.Context, nodeID string, info *PresenceInfo, ttl time.Duration) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("failed to marshal presence: %w", err)
+	}
+
+	key := redisPresencePrefix + strconv.FormatUint(uint64(info.UserID), 10) + ":" + nodeID
+	if err := b.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store presence: %w", err)
+	}
+	return nil
+}
+
+// GetPresence returns the merged presence of a user, or nil if there is none
+func (b *RedisBackplane) GetPresence(ctx context.Context, userID uint) (*PresenceInfo, error) {
+	values, err := b.scanValues(ctx, redisPresencePrefix+strconv.FormatUint(uint64(userID), 10)+":")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get presence: %w", err)
+	}
+
+	return mergePresence(parsePresence(values)), nil
+}
+
+// ListPresence returns the merged presence of every user
+func (b *RedisBackplane) ListPresence(ctx context.Context) (map[uint]*PresenceInfo, error) {
+	values, err := b.scanValues(ctx, redisPresencePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list presence: %w", err)
+	}
+
+	records := make(map[uint][]*PresenceInfo)
+	for _, info := range parsePresence(values) {
+		records[info.UserID] = append(records[info.UserID], info)
+	}
+
+	presence := make(map[uint]*PresenceInfo, len(records))
+	for userID, userRecords := range records {
+		presence[userID] = mergePresence(userRecords)
+	}
+	return presence, nil
+}
+
+// parsePresence decodes stored presence records, skipping invalid ones
+func parsePresence(values []string) []*PresenceInfo {
+	records := make([]*PresenceInfo, 0, len(values))
+	for _, data := range values {
+		var info PresenceInfo
+		if err := json.Unmarshal([]byte(data), &info); err != nil {
+			continue
+		}
+		records = append(records, &info)
+	}
+	return records
+}
+
+// ReportStats stores the stats of one instance until ttl passes
+func (b *RedisBackplane) ReportStats(ctx context.Context, stats *NodeStats, ttl time.Duration) error {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("failed to marshal node stats: %w", err)
+	}
+
+	if err := b.client.Set(ctx, redisNodePrefix+stats.NodeID, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store node stats: %w", err)
+	}
+	return nil
+}
+
+// ListStats returns the stats of every instance that reported recently
+func (b *RedisBackplane) ListStats(ctx context.Context) ([]*NodeStats, error) {
+	values, err := b.scanValues(ctx, redisNodePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node stats: %w", err)
+	}
+
+	nodes := make([]*NodeStats, 0, len(values))
+	for _, data := range values {
+		var stats NodeStats
+		if err := json.Unmarshal([]byte(data), &stats); err != nil {
+			continue
+		}
+		nodes = append(nodes, &stats)
+	}
+	return nodes, nil
+}
+
+// Close ends the subscription and waits for delivery to stop
+func (b *RedisBackplane) Close() error {
+	b.mu.Lock()
+	pubsub, done := b.pubsub, b.done
+	b.pubsub = nil
+	b.mu.Unlock()
+
+	if pubsub == nil {
+		return nil
+	}
+
+	err := pubsub.Close()
+	<-done
+	return err
+}
+
+// scanValues returns the values of all keys starting with prefix. Keys that
+// expire between SCAN and MGET are skipped.
+func (b *RedisBackplane) scanValues(ctx context.Context, prefix string) ([]string, error) {
+	var (
+		values []string
+		cursor uint64
+	)
+
+	for {
+		keys, next, err := b.client.Scan(ctx, cursor, prefix+"*", redisScanCount).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(keys) > 0 {
+			results, err := b.client.MGet(ctx, keys...).Result()
+			if err != nil {
+				return nil, err
+			}
+			for _, result := range results {
+				if data, ok := result.(string); ok {
+					values = append(values, data)
+				}
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return values, nil
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"go-mobile-backend-template/pkg/cache"
+)
+
+// redisHistoryPrefix starts the Redis stream holding a channel's history
+const redisHistoryPrefix = "realtime:history:"
+
+// RedisHistory keeps the history of each channel in a capped Redis stream,
+// shared by every instance. Stream entry IDs are the message IDs.
+type RedisHistory struct {
+	client *redis.Client
+	size   int
+	ttl    time.Duration
+}
+
+// NewRedisHistory creates a history keeping about size messages per channel
+// for channels active within ttl
+func NewRedisHistory(redisClient *cache.RedisClient, size int, ttl time.Duration) *RedisHistory {
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+	if ttl <= 0 {
+		ttl = defaultHistoryTTL
+	}
+
+	return &RedisHistory{
+		client: redisClient.GetClient(),
+		size:   size,
+		ttl:    ttl,
+	}
+}
+
+// Append adds message to its channel's stream and sets its ID
+func (h *RedisHistory) Append(ctx context.Context, message *Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	key := redisHistoryPrefix + message.Channel
+	pipe := h.client.TxPipeline()
+	add := pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		MaxLen: int64(h.size),
+		Approx: true,
+		Values: map[string]interface{}{"message": data},
+	})
+	pipe.Expire(ctx, key, h.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store message history: %w", err)
+	}
+
+	message.ID = add.Val()
+	return nil
+}
+
+// Read returns stored messages of a channel, oldest first
+func (h *RedisHistory) Read(ctx context.Context, channel string, query HistoryQuery) ([]*Message, error) {
+	for _, id := range []string{query.After, query.Before} {
+		if id != "" {
+			if err := ValidateMessageID(id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	limit := query.Limit
+	if limit <= 0 || limit > h.size {
+		limit = h.size
+	}
+
+	start, end := "-", "+"
+	switch {
+	case query.After != "":
+		start = "(" + query.After
+	case !query.Since.IsZero():
+		start = strconv.FormatInt(query.Since.UnixMilli(), 10) + "-0"
+	}
+	if query.Before != "" {
+		end = "(" + query.Before
+	}
+
+	key := redisHistoryPrefix + channel
+	var (
+		entries []redis.XMessage
+		err     error
+	)
+	if query.forward() {
+		entries, err = h.client.XRangeN(ctx, key, start, end, int64(limit)).Result()
+	} else {
+		entries, err = h.client.XRevRangeN(ctx, key, end, start, int64(limit)).Result()
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message history: %w", err)
+	}
+
+	sinceMs := query.Since.UnixMilli()
+	messages := make([]*Message, 0, len(entries))
+	for _, entry := range entries {
+		data, ok := entry.Values["message"].(string)
+		if !ok {
+			continue
+		}
+
+		var message Message
+		if err := json.Unmarshal([]byte(data), &message); err != nil {
+			continue
+		}
+		message.ID = entry.ID
+
+		// After and Since may both be set; the stream range used After
+		if !query.Since.IsZero() {
+			if id, err := parseMessageID(entry.ID); err == nil && id.ms < uint64(sinceMs) {
+				continue
+			}
+		}
+		messages = append(messages, &message)
+	}
+	return messages, nil
+}
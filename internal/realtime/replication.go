@@ -0,0 +1,453 @@
+package realtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultReplicationSlot is the slot a ReplicationSource reads
+	defaultReplicationSlot = "realtime"
+
+	// defaultPublication is the publication pgoutput sends changes of
+	defaultPublication = "realtime"
+
+	// defaultReplicationPoll is how often an idle slot is read
+	defaultReplicationPoll = time.Second
+
+	// defaultReplicationBatch is the number of changes read at once
+	defaultReplicationBatch = 1000
+)
+
+// ReplicationConfig configures a ReplicationSource
+type ReplicationConfig struct {
+	// Slot is the logical replication slot, created if it does not exist
+	Slot string
+	// Plugin decodes the slot: pgoutput, built into Postgres, or wal2json
+	Plugin string
+	// Publication selects the tables pgoutput sends. It is created if it
+	// does not exist and watched tables are added to it.
+	Publication string
+	// PollInterval is how often the slot is read when it has no backlog
+	PollInterval time.Duration
+	// BatchSize caps the changes read at once. Transactions are never split.
+	BatchSize int
+}
+
+// ReplicationSource delivers changes read from a logical replication slot.
+// Changes are read without consuming them and the slot is only advanced
+// past a transaction once it has been delivered, so changes made while the
+// server is down are delivered when it starts again. The slot keeps WAL
+// until then; drop it when the source is no longer used. The database user
+// needs the REPLICATION attribute.
+type ReplicationSource struct {
+	db     *gorm.DB
+	cfg    ReplicationConfig
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	prepared bool
+	lsn      string // Last LSN the slot was advanced to
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	// Decoding state, only used by the poll loop
+	relations  map[uint32]*pgoutputRelation
+	commitTime time.Time
+}
+
+// replicationChange is one row read from the slot
+type replicationChange struct {
+	lsn  string
+	data []byte
+}
+
+// NewReplicationSource creates a source reading the replication slot in cfg
+func NewReplicationSource(db *gorm.DB, cfg ReplicationConfig, logger *zap.Logger) *ReplicationSource {
+	if cfg.Slot == "" {
+		cfg.Slot = defaultReplicationSlot
+	}
+	if cfg.Plugin == "" {
+		cfg.Plugin = "pgoutput"
+	}
+	if cfg.Publication == "" {
+		cfg.Publication = defaultPublication
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultReplicationPoll
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultReplicationBatch
+	}
+
+	return &ReplicationSource{
+		db:        db,
+		cfg:       cfg,
+		logger:    logger,
+		relations: make(map[uint32]*pgoutputRelation),
+	}
+}
+
+// prepare creates the publication and the slot if they do not exist
+func (s *ReplicationSource) prepare(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.prepared {
+		return nil
+	}
+
+	switch s.cfg.Plugin {
+	case "pgoutput":
+		var exists bool
+		if err := s.db.WithContext(ctx).Raw("SELECT EXISTS (SELECT 1 FROM pg_publication WHERE pubname = ?)", s.cfg.Publication).Scan(&exists).Error; err != nil {
+			return fmt.Errorf("failed to check publication: %w", err)
+		}
+		if !exists {
+			if err := s.db.WithContext(ctx).Exec("CREATE PUBLICATION " + pq.QuoteIdentifier(s.cfg.Publication)).Error; err != nil {
+				return fmt.Errorf("failed to create publication: %w", err)
+			}
+		}
+	case "wal2json":
+	default:
+		return fmt.Errorf("unknown replication plugin %q", s.cfg.Plugin)
+	}
+
+	var exists bool
+	if err := s.db.WithContext(ctx).Raw("SELECT EXISTS (SELECT 1 FROM pg_replication_slots WHERE slot_name = ?)", s.cfg.Slot).Scan(&exists).Error; err != nil {
+		return fmt.Errorf("failed to check replication slot: %w", err)
+	}
+	if !exists {
+		if err := s.db.WithContext(ctx).Exec("SELECT pg_create_logical_replication_slot(?, ?)", s.cfg.Slot, s.cfg.Plugin).Error; err != nil {
+			return fmt.Errorf("failed to create replication slot: %w", err)
+		}
+		s.logger.Info("Created replication slot",
+			zap.String("slot", s.cfg.Slot),
+			zap.String("plugin", s.cfg.Plugin),
+		)
+	}
+
+	s.prepared = true
+	return nil
+}
+
+// Watch adds a table to the publication and logs its full old rows, so
+// updates carry the previous values
+func (s *ReplicationSource) Watch(ctx context.Context, tableName string, keyColumns []string) error {
+	if err := s.prepare(ctx); err != nil {
+		return err
+	}
+
+	table := pq.QuoteIdentifier(tableName)
+
+	if s.cfg.Plugin == "pgoutput" {
+		var published bool
+		err := s.db.WithContext(ctx).Raw(`
+			SELECT EXISTS (
+				SELECT 1 FROM pg_publication_tables
+				WHERE pubname = ? AND schemaname = current_schema() AND tablename = ?
+			)
+		`, s.cfg.Publication, tableName).Scan(&published).Error
+		if err != nil {
+			return fmt.Errorf("failed to check publication tables: %w", err)
+		}
+		if !published {
+			if err := s.db.WithContext(ctx).Exec(fmt.Sprintf("ALTER PUBLICATION %s ADD TABLE %s", pq.QuoteIdentifier(s.cfg.Publication), table)).Error; err != nil {
+				return fmt.Errorf("failed to add table to publication: %w", err)
+			}
+		}
+	}
+
+	var identity string
+	if err := s.db.WithContext(ctx).Raw("SELECT relreplident::text FROM pg_class WHERE oid = ?::regclass", table).Scan(&identity).Error; err != nil {
+		return fmt.Errorf("failed to check replica identity: %w", err)
+	}
+	if identity != "f" {
+		if err := s.db.WithContext(ctx).Exec(fmt.Sprintf("ALTER TABLE %s REPLICA IDENTITY FULL", table)).Error; err != nil {
+			return fmt.Errorf("failed to set replica identity: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Start reads the slot from its last confirmed position and passes the
+// changes to emit
+func (s *ReplicationSource) Start(ctx context.Context, emit func(context.Context, *DBChangeEvent)) error {
+	if err := s.prepare(ctx); err != nil {
+		return err
+	}
+
+	var lsn string
+	if err := s.db.WithContext(ctx).Raw("SELECT COALESCE(confirmed_flush_lsn::text, '') FROM pg_replication_slots WHERE slot_name = ?", s.cfg.Slot).Scan(&lsn).Error; err != nil {
+		return fmt.Errorf("failed to read replication slot: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	s.lsn = lsn
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	s.mu.Unlock()
+
+	s.logger.Info("Database replication stream started",
+		zap.String("slot", s.cfg.Slot),
+		zap.String("plugin", s.cfg.Plugin),
+		zap.String("lsn", lsn),
+	)
+
+	go s.pollLoop(ctx, emit)
+
+	return nil
+}
+
+// LSN returns the position the slot was last advanced to
+func (s *ReplicationSource) LSN() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lsn
+}
+
+// Stop stops reading the slot and waits for the changes being delivered
+func (s *ReplicationSource) Stop() {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+func (s *ReplicationSource) pollLoop(ctx context.Context, emit func(context.Context, *DBChangeEvent)) {
+	defer close(s.done)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Stopping database replication stream")
+			return
+		case <-timer.C:
+		}
+
+		read, err := s.poll(ctx, emit)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Error("Failed to read replication slot", zap.Error(err))
+		}
+
+		// Keep reading while there is a backlog
+		if err == nil && read >= s.cfg.BatchSize {
+			timer.Reset(0)
+		} else {
+			timer.Reset(s.cfg.PollInterval)
+		}
+	}
+}
+
+// poll delivers the next batch of committed transactions and advances the
+// slot past them. It returns the number of rows read.
+func (s *ReplicationSource) poll(ctx context.Context, emit func(context.Context, *DBChangeEvent)) (int, error) {
+	changes, err := s.peek(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		pending   []*DBChangeEvent
+		commitLSN string
+	)
+	for _, change := range changes {
+		var (
+			event  *DBChangeEvent
+			commit bool
+			err    error
+		)
+		if s.cfg.Plugin == "pgoutput" {
+			event, commit, err = s.decodePgoutput(change.data)
+		} else {
+			event, commit, err = s.decodeWal2json(change.data)
+		}
+		if err != nil {
+			s.logger.Error("Failed to decode replication message",
+				zap.String("lsn", change.lsn),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		if event != nil {
+			pending = append(pending, event)
+		}
+		if commit {
+			for _, event := range pending {
+				emit(ctx, event)
+			}
+			pending = nil
+			commitLSN = change.lsn
+		}
+	}
+
+	if commitLSN == "" {
+		return len(changes), nil
+	}
+
+	if err := s.db.WithContext(ctx).Exec("SELECT pg_replication_slot_advance(?, ?::pg_lsn)", s.cfg.Slot, commitLSN).Error; err != nil {
+		return len(changes), fmt.Errorf("failed to advance replication slot: %w", err)
+	}
+
+	s.mu.Lock()
+	s.lsn = commitLSN
+	s.mu.Unlock()
+
+	s.logger.Debug("Advanced replication slot", zap.String("lsn", commitLSN))
+	return len(changes), nil
+}
+
+// peek reads the next changes from the slot without consuming them
+func (s *ReplicationSource) peek(ctx context.Context) ([]replicationChange, error) {
+	db := s.db.WithContext(ctx)
+	if s.cfg.Plugin == "pgoutput" {
+		db = db.Raw(`
+			SELECT lsn::text, data
+			FROM pg_logical_slot_peek_binary_changes(?, NULL, ?, 'proto_version', '1', 'publication_names', ?)
+		`, s.cfg.Slot, s.cfg.BatchSize, s.cfg.Publication)
+	} else {
+		db = db.Raw(`
+			SELECT lsn::text, data
+			FROM pg_logical_slot_peek_changes(?, NULL, ?, 'format-version', '2', 'include-timestamp', 'true')
+		`, s.cfg.Slot, s.cfg.BatchSize)
+	}
+
+	rows, err := db.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []replicationChange
+	for rows.Next() {
+		var change replicationChange
+		if err := rows.Scan(&change.lsn, &change.data); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// wal2jsonColumn is a column value in a wal2json change
+type wal2jsonColumn struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// wal2jsonChange is a change in wal2json format version 2
+type wal2jsonChange struct {
+	Action    string           `json:"action"` // B, C, I, U, D, T or M
+	Timestamp string           `json:"timestamp"`
+	Table     string           `json:"table"`
+	Columns   []wal2jsonColumn `json:"columns"`
+	Identity  []wal2jsonColumn `json:"identity"`
+}
+
+// decodeWal2json decodes a wal2json change. It reports true for commits.
+func (s *ReplicationSource) decodeWal2json(data []byte) (*DBChangeEvent, bool, error) {
+	var change wal2jsonChange
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&change); err != nil {
+		return nil, false, err
+	}
+
+	switch change.Action {
+	case "C":
+		return nil, true, nil
+	case "I", "U", "D":
+	default:
+		return nil, false, nil
+	}
+
+	event := &DBChangeEvent{
+		Table:     change.Table,
+		Timestamp: parseWal2jsonTime(change.Timestamp),
+	}
+
+	var old map[string]interface{}
+	if len(change.Identity) > 0 {
+		old = make(map[string]interface{}, len(change.Identity))
+		for _, column := range change.Identity {
+			old[column.Name] = column.Value
+		}
+	}
+
+	switch change.Action {
+	case "I":
+		event.Operation = "INSERT"
+	case "U":
+		event.Operation = "UPDATE"
+	case "D":
+		event.Operation = "DELETE"
+		event.Data = old
+		return event, false, nil
+	}
+
+	event.Data = make(map[string]interface{}, len(change.Columns))
+	for _, column := range change.Columns {
+		event.Data[column.Name] = column.Value
+	}
+
+	// Only full old rows, logged with REPLICA IDENTITY FULL, can be diffed
+	if event.Operation == "UPDATE" && len(change.Identity) >= len(change.Columns) {
+		fillUnchanged(event.Data, old)
+		event.OldData = old
+		event.Changed = changedColumns(old, event.Data)
+	}
+
+	return event, false, nil
+}
+
+// parseWal2jsonTime parses a wal2json timestamp, falling back to now
+func parseWal2jsonTime(value string) time.Time {
+	for _, layout := range []string{"2006-01-02 15:04:05.999999-07", "2006-01-02 15:04:05.999999-07:00"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
+
+// fillUnchanged copies the old values of columns missing from row, which
+// replication leaves out when a large value was not changed
+func fillUnchanged(row, old map[string]interface{}) {
+	for column, value := range old {
+		if _, ok := row[column]; !ok {
+			row[column] = value
+		}
+	}
+}
+
+// changedColumns returns the columns whose values differ between rows
+func changedColumns(old, row map[string]interface{}) []string {
+	changed := make([]string, 0)
+	for column, value := range row {
+		if !reflect.DeepEqual(old[column], value) {
+			changed = append(changed, column)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,289 @@
+package realtime
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// pgoutputMessage builds pgoutput messages
+type pgoutputMessage []byte
+
+func (m pgoutputMessage) byte(b byte) pgoutputMessage { return append(m, b) }
+
+func (m pgoutputMessage) uint16(v uint16) pgoutputMessage {
+	return binary.BigEndian.AppendUint16(m, v)
+}
+
+func (m pgoutputMessage) uint32(v uint32) pgoutputMessage {
+	return binary.BigEndian.AppendUint32(m, v)
+}
+
+func (m pgoutputMessage) uint64(v uint64) pgoutputMessage {
+	return binary.BigEndian.AppendUint64(m, v)
+}
+
+func (m pgoutputMessage) string(s string) pgoutputMessage { return append(append(m, s...), 0) }
+
+// tuple appends a row. Values are text, nil for NULL, or unchanged.
+func (m pgoutputMessage) tuple(values ...interface{}) pgoutputMessage {
+	m = m.uint16(uint16(len(values)))
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+			m = m.byte('n')
+		case unchangedValue:
+			m = m.byte('u')
+		case string:
+			m = m.byte('t').uint32(uint32(len(v)))
+			m = append(m, v...)
+		}
+	}
+	return m
+}
+
+// unchangedValue is a TOASTed value replication did not send
+type unchangedValue struct{}
+
+// postsRelation is the Relation message of a posts table
+func postsRelation(id uint32) pgoutputMessage {
+	m := pgoutputMessage{}.byte('R').uint32(id).string("public").string("posts").byte('f').uint16(5)
+	for _, column := range []struct {
+		name string
+		oid  uint32
+	}{{"id", oidInt8}, {"title", 25}, {"published", oidBool}, {"meta", oidJSONB}, {"body", 25}} {
+		m = m.byte(0).string(column.name).uint32(column.oid).uint32(0xffffffff)
+	}
+	return m
+}
+
+func TestDecodePgoutput(t *testing.T) {
+	source := NewReplicationSource(nil, ReplicationConfig{}, zap.NewNop())
+	commitTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	decode := func(m pgoutputMessage) (*DBChangeEvent, bool) {
+		t.Helper()
+		event, commit, err := source.decodePgoutput(m)
+		if err != nil {
+			t.Fatalf("decodePgoutput() error = %v", err)
+		}
+		return event, commit
+	}
+
+	if _, commit := decode(postsRelation(7)); commit {
+		t.Fatal("decodePgoutput() reported a commit for a relation")
+	}
+	begin := pgoutputMessage{}.byte('B').uint64(1).uint64(uint64(commitTime.Sub(pgEpoch) / time.Microsecond)).uint32(1)
+	if event, _ := decode(begin); event != nil {
+		t.Fatalf("decodePgoutput() of begin = %+v, want no event", event)
+	}
+
+	tests := []struct {
+		name    string
+		message pgoutputMessage
+		want    *DBChangeEvent
+	}{
+		{
+			name:    "insert",
+			message: pgoutputMessage{}.byte('I').uint32(7).byte('N').tuple("1", "Hello", "t", `{"tags":["go"]}`, nil),
+			want: &DBChangeEvent{
+				Table:     "posts",
+				Operation: "INSERT",
+				Data: map[string]interface{}{
+					"id": json.Number("1"), "title": "Hello", "published": true,
+					"meta": json.RawMessage(`{"tags":["go"]}`), "body": nil,
+				},
+			},
+		},
+		{
+			name: "update with the old row",
+			message: pgoutputMessage{}.byte('U').uint32(7).
+				byte('O').tuple("1", "Hello", "f", "{}", "long body").
+				byte('N').tuple("1", "Hello again", "t", "{}", unchangedValue{}),
+			want: &DBChangeEvent{
+				Table:     "posts",
+				Operation: "UPDATE",
+				Data: map[string]interface{}{
+					"id": json.Number("1"), "title": "Hello again", "published": true,
+					"meta": json.RawMessage("{}"), "body": "long body",
+				},
+				OldData: map[string]interface{}{
+					"id": json.Number("1"), "title": "Hello", "published": false,
+					"meta": json.RawMessage("{}"), "body": "long body",
+				},
+				Changed: []string{"published", "title"},
+			},
+		},
+		{
+			name:    "update without the old row",
+			message: pgoutputMessage{}.byte('U').uint32(7).byte('N').tuple("1", "Hello", "t", "NaN", unchangedValue{}),
+			want: &DBChangeEvent{
+				Table:     "posts",
+				Operation: "UPDATE",
+				Data: map[string]interface{}{
+					"id": json.Number("1"), "title": "Hello", "published": true, "meta": "NaN",
+				},
+			},
+		},
+		{
+			name:    "delete",
+			message: pgoutputMessage{}.byte('D').uint32(7).byte('K').tuple("1", nil, nil, nil, nil),
+			want: &DBChangeEvent{
+				Table:     "posts",
+				Operation: "DELETE",
+				Data: map[string]interface{}{
+					"id": json.Number("1"), "title": nil, "published": nil, "meta": nil, "body": nil,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, commit := decode(tt.message)
+			if commit {
+				t.Error("decodePgoutput() reported a commit for a row")
+			}
+			tt.want.Timestamp = commitTime
+			if !reflect.DeepEqual(event, tt.want) {
+				t.Errorf("decodePgoutput() = %+v, want %+v", event, tt.want)
+			}
+		})
+	}
+
+	if event, commit := decode(pgoutputMessage{}.byte('C').byte(0).uint64(1).uint64(2).uint64(3)); event != nil || !commit {
+		t.Errorf("decodePgoutput() of commit = %+v, %v, want a commit", event, commit)
+	}
+	if event, commit := decode(pgoutputMessage{}.byte('T').uint32(1).byte(0).uint32(7)); event != nil || commit {
+		t.Errorf("decodePgoutput() of truncate = %+v, %v, want nothing", event, commit)
+	}
+}
+
+func TestDecodePgoutputErrors(t *testing.T) {
+	source := NewReplicationSource(nil, ReplicationConfig{}, zap.NewNop())
+	if _, _, err := source.decodePgoutput(postsRelation(7)); err != nil {
+		t.Fatalf("decodePgoutput() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		message pgoutputMessage
+		wantErr error
+	}{
+		{name: "empty", message: pgoutputMessage{}, wantErr: errShortMessage},
+		{name: "short begin", message: pgoutputMessage{}.byte('B').uint32(1), wantErr: errShortMessage},
+		{name: "short relation", message: postsRelation(8)[:20], wantErr: errShortMessage},
+		{name: "unknown relation", message: pgoutputMessage{}.byte('I').uint32(9).byte('N').tuple("1")},
+		{name: "short value", message: pgoutputMessage{}.byte('I').uint32(7).byte('N').uint16(1).byte('t').uint32(10).byte('x'), wantErr: errShortMessage},
+		{name: "unknown value kind", message: pgoutputMessage{}.byte('I').uint32(7).byte('N').uint16(1).byte('x')},
+		{name: "more columns than the relation", message: pgoutputMessage{}.byte('I').uint32(7).byte('N').tuple("1", "a", "t", "{}", "b", "c")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, _, err := source.decodePgoutput(tt.message)
+			if err == nil || event != nil {
+				t.Fatalf("decodePgoutput() = %+v, %v, want an error", event, err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("decodePgoutput() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A relation that failed to decode is not remembered
+	if _, ok := source.relations[8]; ok {
+		t.Error("decodePgoutput() kept a relation that ended early")
+	}
+}
+
+func TestDecodeWal2json(t *testing.T) {
+	source := NewReplicationSource(nil, ReplicationConfig{Plugin: "wal2json"}, zap.NewNop())
+	timestamp := time.Date(2024, 5, 1, 12, 0, 0, 123456000, time.FixedZone("", 2*60*60))
+
+	tests := []struct {
+		name       string
+		data       string
+		want       *DBChangeEvent
+		wantCommit bool
+	}{
+		{
+			name: "insert",
+			data: `{"action":"I","timestamp":"2024-05-01 12:00:00.123456+02","table":"posts",
+				"columns":[{"name":"id","value":1},{"name":"title","value":"Hello"},{"name":"score","value":1.50}]}`,
+			want: &DBChangeEvent{
+				Table:     "posts",
+				Operation: "INSERT",
+				Data:      map[string]interface{}{"id": json.Number("1"), "title": "Hello", "score": json.Number("1.50")},
+				Timestamp: timestamp,
+			},
+		},
+		{
+			name: "update with the full old row",
+			data: `{"action":"U","timestamp":"2024-05-01 12:00:00.123456+02","table":"posts",
+				"columns":[{"name":"id","value":1},{"name":"title","value":"Hello again"}],
+				"identity":[{"name":"id","value":1},{"name":"title","value":"Hello"},{"name":"body","value":"long body"}]}`,
+			want: &DBChangeEvent{
+				Table:     "posts",
+				Operation: "UPDATE",
+				Data:      map[string]interface{}{"id": json.Number("1"), "title": "Hello again", "body": "long body"},
+				OldData:   map[string]interface{}{"id": json.Number("1"), "title": "Hello", "body": "long body"},
+				Changed:   []string{"title"},
+				Timestamp: timestamp,
+			},
+		},
+		{
+			name: "update with the key only",
+			data: `{"action":"U","timestamp":"2024-05-01 12:00:00.123456+02:00","table":"posts",
+				"columns":[{"name":"id","value":1},{"name":"title","value":"Hello again"}],
+				"identity":[{"name":"id","value":1}]}`,
+			want: &DBChangeEvent{
+				Table:     "posts",
+				Operation: "UPDATE",
+				Data:      map[string]interface{}{"id": json.Number("1"), "title": "Hello again"},
+				Timestamp: timestamp,
+			},
+		},
+		{
+			name: "delete",
+			data: `{"action":"D","timestamp":"2024-05-01 12:00:00.123456+02","table":"posts",
+				"identity":[{"name":"id","value":1}]}`,
+			want: &DBChangeEvent{
+				Table:     "posts",
+				Operation: "DELETE",
+				Data:      map[string]interface{}{"id": json.Number("1")},
+				Timestamp: timestamp,
+			},
+		},
+		{name: "begin", data: `{"action":"B"}`},
+		{name: "commit", data: `{"action":"C"}`, wantCommit: true},
+		{name: "message", data: `{"action":"M","prefix":"app","content":"hi"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, commit, err := source.decodeWal2json([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("decodeWal2json() error = %v", err)
+			}
+			if commit != tt.wantCommit {
+				t.Errorf("decodeWal2json() commit = %v, want %v", commit, tt.wantCommit)
+			}
+			if event != nil && tt.want != nil && event.Timestamp.Equal(tt.want.Timestamp) {
+				event.Timestamp = tt.want.Timestamp
+			}
+			if !reflect.DeepEqual(event, tt.want) {
+				t.Errorf("decodeWal2json() = %+v, want %+v", event, tt.want)
+			}
+		})
+	}
+
+	if _, _, err := source.decodeWal2json([]byte(`{"action":`)); err == nil {
+		t.Error("decodeWal2json() of invalid JSON returned no error")
+	}
+}
@@ -0,0 +1,157 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultRPCTimeout bounds a call when the hub sets no timeout
+	defaultRPCTimeout = 30 * time.Second
+
+	// maxConcurrentRPC is the number of calls a client may have in flight
+	maxConcurrentRPC = 8
+)
+
+// RPC error codes
+const (
+	RPCInvalidRequest  = "invalid_request"
+	RPCUnauthorized    = "unauthorized"
+	RPCMethodNotFound  = "method_not_found"
+	RPCTooManyRequests = "too_many_requests"
+	RPCTimeout         = "timeout"
+	RPCInternal        = "internal"
+)
+
+// RPCCall is a request from a client to a method registered on the hub
+type RPCCall struct {
+	// ID correlates the call with its result
+	ID     string
+	Method string
+	Params map[string]interface{}
+	// Client made the call; its Subscriber is the caller's identity
+	Client *Client
+}
+
+// RPCHandler answers calls to a method. The result is sent to the client
+// as JSON. Errors are sent with their code when they are an *RPCError and
+// as internal errors otherwise.
+type RPCHandler func(ctx context.Context, call *RPCCall) (interface{}, error)
+
+// RPCError is an error a handler returns to the caller
+type RPCError struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// NewRPCError creates an error with a code for the caller
+func NewRPCError(code, message string) *RPCError {
+	return &RPCError{Code: code, Message: message}
+}
+
+// HandleRPC registers the handler of a method, replacing any previous one
+func (h *Hub) HandleRPC(method string, handler RPCHandler) {
+	h.rpcMu.Lock()
+	defer h.rpcMu.Unlock()
+	h.rpcHandlers[method] = handler
+}
+
+// rpcHandler returns the handler of a method
+func (h *Hub) rpcHandler(method string) (RPCHandler, bool) {
+	h.rpcMu.RLock()
+	defer h.rpcMu.RUnlock()
+	handler, ok := h.rpcHandlers[method]
+	return handler, ok
+}
+
+// handleRPC runs a call from the client and sends its result. Calls run
+// concurrently, up to maxConcurrentRPC per client, so a slow call does not
+// hold up the client's other messages.
+func (c *Client) handleRPC(msg *ClientMessage) {
+	if msg.ID == "" || msg.Method == "" {
+		c.sendRPCResult(msg.ID, nil, NewRPCError(RPCInvalidRequest, "id and method are required"))
+		return
+	}
+
+	handler, ok := c.hub.rpcHandler(msg.Method)
+	if !ok {
+		c.sendRPCResult(msg.ID, nil, NewRPCError(RPCMethodNotFound, "Unknown method "+msg.Method))
+		return
+	}
+
+	select {
+	case c.rpcSlots <- struct{}{}:
+	default:
+		c.sendRPCResult(msg.ID, nil, NewRPCError(RPCTooManyRequests, "Too many calls in flight"))
+		return
+	}
+
+	call := &RPCCall{
+		ID:     msg.ID,
+		Method: msg.Method,
+		Params: msg.Payload,
+		Client: c,
+	}
+
+	go func() {
+		defer func() { <-c.rpcSlots }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.hub.rpcTimeout)
+		defer cancel()
+
+		result, err := handler(ctx, call)
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = NewRPCError(RPCTimeout, "Call timed out")
+		}
+		if err != nil {
+			c.logger.Warn("RPC call failed",
+				zap.Uint("user_id", c.UserID),
+				zap.String("method", call.Method),
+				zap.Error(err),
+			)
+		}
+		c.sendRPCResult(call.ID, result, err)
+	}()
+}
+
+// sendRPCResult answers a call, echoing its id
+func (c *Client) sendRPCResult(id string, result interface{}, err error) {
+	response := map[string]interface{}{
+		"type":    "rpc_result",
+		"success": err == nil,
+	}
+	if id != "" {
+		response["id"] = id
+	}
+	if err != nil {
+		var rpcErr *RPCError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = NewRPCError(RPCInternal, "Call failed")
+		}
+		response["error"] = rpcErr
+	} else {
+		response["result"] = result
+	}
+
+	data, marshalErr := json.Marshal(response)
+	if marshalErr != nil {
+		c.logger.Error("Failed to marshal RPC result", zap.Error(marshalErr))
+		data, _ = json.Marshal(map[string]interface{}{
+			"type":    "rpc_result",
+			"id":      id,
+			"success": false,
+			"error":   NewRPCError(RPCInternal, "Call failed"),
+		})
+	}
+
+	c.hub.sendTo(c, outgoing{data: data, priority: PriorityHigh})
+}
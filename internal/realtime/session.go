@@ -0,0 +1,190 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// sessionTTL is how long a session survives without being polled or
+// streamed to
+const sessionTTL = time.Minute
+
+// Session is a client on a transport without a persistent socket, such as
+// Server-Sent Events or long polling. It receives the same messages as a
+// WebSocket client and sends messages through the hub with HandleMessage.
+// Sessions that are not read from for a minute are closed.
+type Session struct {
+	ID     string
+	Client *Client
+
+	mu       sync.Mutex
+	lastSeen time.Time
+	readers  int
+}
+
+// touch records that the session is in use
+func (s *Session) touch() {
+	s.mu.Lock()
+	s.lastSeen = time.Now()
+	s.mu.Unlock()
+}
+
+// expired reports whether the session has been idle for longer than ttl
+func (s *Session) expired(ttl time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readers == 0 && time.Since(s.lastSeen) > ttl
+}
+
+// Poll waits up to wait for messages and returns every queued message. It
+// reports false once the hub has dropped the client.
+func (s *Session) Poll(ctx context.Context, wait time.Duration) ([][]byte, bool) {
+	s.mu.Lock()
+	s.readers++
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.readers--
+		s.lastSeen = time.Now()
+		s.mu.Unlock()
+	}()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-s.Client.queue.ready:
+		case <-timer.C:
+			return nil, true
+		case <-ctx.Done():
+			return nil, true
+		}
+
+		// Return everything queued, or keep waiting if a previous read
+		// already took it
+		messages, open := s.Client.queue.drain()
+		if len(messages) > 0 || !open {
+			return messages, open
+		}
+	}
+}
+
+// Stream calls write for every message until the context ends or the hub
+// drops the client, and heartbeat whenever nothing was sent for interval.
+// It returns when write or heartbeat fail.
+func (s *Session) Stream(ctx context.Context, interval time.Duration, write func([]byte) error, heartbeat func() error) {
+	s.mu.Lock()
+	s.readers++
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.readers--
+		s.lastSeen = time.Now()
+		s.mu.Unlock()
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.Client.queue.ready:
+			messages, open := s.Client.queue.drain()
+			for _, message := range messages {
+				if err := write(message); err != nil {
+					return
+				}
+			}
+			if !open {
+				return
+			}
+		case <-ticker.C:
+			if err := heartbeat(); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// OpenSession registers client with the hub like RegisterClient and keeps
+// it as a session until it is closed or expires
+func (h *Hub) OpenSession(client *Client, channels ...string) *Session {
+	session := &Session{
+		ID:       uuid.NewString(),
+		Client:   client,
+		lastSeen: time.Now(),
+	}
+
+	h.RegisterClient(client, channels...)
+
+	h.sessionsMu.Lock()
+	h.sessions[session.ID] = session
+	h.sessionsMu.Unlock()
+
+	return session
+}
+
+// Session returns an open session of a user
+func (h *Hub) Session(id string, userID uint) (*Session, bool) {
+	h.sessionsMu.Lock()
+	session, ok := h.sessions[id]
+	h.sessionsMu.Unlock()
+
+	if !ok || session.Client.UserID != userID {
+		return nil, false
+	}
+	session.touch()
+	return session, true
+}
+
+// CloseSession unregisters the client of a session
+func (h *Hub) CloseSession(id string) {
+	h.sessionsMu.Lock()
+	session, ok := h.sessions[id]
+	delete(h.sessions, id)
+	h.sessionsMu.Unlock()
+
+	if ok {
+		h.UnregisterClient(session.Client)
+	}
+}
+
+// CloseSessions closes every session, ending their event streams and
+// polls. The HTTP server calls it on shutdown so it does not wait for
+// streams that never end.
+func (h *Hub) CloseSessions() {
+	h.sessionsMu.Lock()
+	ids := make([]string, 0, len(h.sessions))
+	for id := range h.sessions {
+		ids = append(ids, id)
+	}
+	h.sessionsMu.Unlock()
+
+	for _, id := range ids {
+		h.CloseSession(id)
+	}
+}
+
+// expireSessions closes sessions nobody has read from within sessionTTL
+func (h *Hub) expireSessions() {
+	h.sessionsMu.Lock()
+	var expired []string
+	for id, session := range h.sessions {
+		if session.expired(sessionTTL) {
+			expired = append(expired, id)
+		}
+	}
+	h.sessionsMu.Unlock()
+
+	for _, id := range expired {
+		h.logger.Info("Realtime session expired", zap.String("session_id", id))
+		h.CloseSession(id)
+	}
+}
@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go-mobile-backend-template/internal/db/repository"
+)
+
+// APIKeyPrefix starts every issued key so keys are easy to recognise in logs
+// and by secret scanners
+const APIKeyPrefix = "gmb"
+
+// apiKeyPrefixLength is the number of characters of a key kept in the clear
+// to identify it in listings
+const apiKeyPrefixLength = len(APIKeyPrefix) + 1 + 8
+
+var (
+	// ErrInvalidAPIKey is returned for unknown, revoked or expired API keys
+	ErrInvalidAPIKey = errors.New("invalid or expired API key")
+	// ErrAPIKeyNotFound is returned when a user does not own the requested key
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	// ErrInvalidScope is returned for scopes not in resource:action form
+	ErrInvalidScope = errors.New("scopes must be in resource:action form")
+)
+
+// APIKeyPrincipal is the identity behind an authenticated API key. User is
+// nil for service keys that are not owned by a user.
+type APIKeyPrincipal struct {
+	Key  *repository.APIKey
+	User *repository.User
+}
+
+// HasScope reports whether the key grants action on resource
+func (p *APIKeyPrincipal) HasScope(resource, action string) bool {
+	return ScopesAllow(p.Key.Scopes, resource, action)
+}
+
+// NormalizeScopes validates scopes and removes duplicates
+func NormalizeScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool, len(scopes))
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		resource, action, ok := strings.Cut(scope, ":")
+		if !ok || resource == "" || action == "" || strings.Contains(action, ":") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		normalized = append(normalized, scope)
+	}
+	return normalized, nil
+}
+
+// APIKeyService issues and authenticates API keys. Keys are only stored as
+// hashes; the plaintext is returned once at creation.
+type APIKeyService struct {
+	keys  repository.APIKeyRepository
+	users repository.UserRepository
+	usage *APIKeyUsageRecorder
+}
+
+// NewAPIKeyService creates a new API key service. usage may be nil to skip
+// last_used_at tracking.
+func NewAPIKeyService(keys repository.APIKeyRepository, users repository.UserRepository, usage *APIKeyUsageRecorder) *APIKeyService {
+	return &APIKeyService{
+		keys:  keys,
+		users: users,
+		usage: usage,
+	}
+}
+
+// Create issues a new key for a user and returns it with its plaintext value
+func (s *APIKeyService) Create(ctx context.Context, userID uint, name string, scopes []string, rateLimit int, expiresAt *time.Time) (*repository.APIKey, string, error) {
+	scopes, err := NormalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := GenerateRandomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	plaintext := APIKeyPrefix + "_" + secret
+
+	key := &repository.APIKey{
+		UserID:    &userID,
+		Name:      name,
+		KeyHash:   HashToken(plaintext),
+		Prefix:    plaintext[:apiKeyPrefixLength],
+		Scopes:    repository.StringArray(scopes),
+		RateLimit: rateLimit,
+		IsActive:  true,
+		ExpiresAt: expiresAt,
+	}
+
+	if err := s.keys.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	return key, plaintext, nil
+}
+
+// List returns the keys owned by a user
+func (s *APIKeyService) List(ctx context.Context, userID uint) ([]*repository.APIKey, error) {
+	return s.keys.GetByUserID(ctx, userID)
+}
+
+// Revoke deactivates a key owned by a user
+func (s *APIKeyService) Revoke(ctx context.Context, userID, keyID uint) error {
+	key, err := s.keys.GetByID(ctx, keyID)
+	if err != nil || key.UserID == nil || *key.UserID != userID {
+		return ErrAPIKeyNotFound
+	}
+
+	return s.keys.Deactivate(ctx, key.ID)
+}
+
+// Authenticate resolves a plaintext key to its principal and records its use
+func (s *APIKeyService) Authenticate(ctx context.Context, plaintext string) (*APIKeyPrincipal, error) {
+	if !strings.HasPrefix(plaintext, APIKeyPrefix+"_") {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.keys.GetByHash(ctx, HashToken(plaintext))
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if !key.IsActive || (key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	principal := &APIKeyPrincipal{Key: key}
+	if key.UserID != nil {
+		user, err := s.users.GetByID(ctx, *key.UserID)
+		if err != nil || !user.IsActive {
+			return nil, ErrInvalidAPIKey
+		}
+		principal.User = user
+	}
+
+	if s.usage != nil {
+		s.usage.Record(key.ID)
+	}
+
+	return principal, nil
+}
+
+// APIKeyUsageRecorder collects key usage in memory and writes last_used_at
+// in batches, so authenticated requests do not each cost a database write
+type APIKeyUsageRecorder struct {
+	keys     repository.APIKeyRepository
+	logger   *zap.Logger
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[uint]time.Time
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewAPIKeyUsageRecorder creates a recorder that flushes every interval
+func NewAPIKeyUsageRecorder(keys repository.APIKeyRepository, logger *zap.Logger, interval time.Duration) *APIKeyUsageRecorder {
+	return &APIKeyUsageRecorder{
+		keys:     keys,
+		logger:   logger,
+		interval: interval,
+		pending:  make(map[uint]time.Time),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Record notes that a key was used now
+func (r *APIKeyUsageRecorder) Record(keyID uint) {
+	r.mu.Lock()
+	r.pending[keyID] = time.Now()
+	r.mu.Unlock()
+}
+
+// Run flushes pending usage every interval until Stop is called
+func (r *APIKeyUsageRecorder) Run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.Flush(context.Background())
+		case <-r.stop:
+			r.Flush(context.Background())
+			return
+		}
+	}
+}
+
+// Stop flushes pending usage and stops Run
+func (r *APIKeyUsageRecorder) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+	<-r.done
+}
+
+// Flush writes pending usage to the database. Failed batches are merged
+// back so they are retried on the next flush.
+func (r *APIKeyUsageRecorder) Flush(ctx context.Context) {
+	r.mu.Lock()
+	batch := r.pending
+	r.pending = make(map[uint]time.Time, len(batch))
+	r.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := r.keys.UpdateLastUsed(ctx, batch); err != nil {
+		r.logger.Error("Failed to record API key usage", zap.Int("keys", len(batch)), zap.Error(err))
+
+		r.mu.Lock()
+		for id, usedAt := range batch {
+			if newer, ok := r.pending[id]; !ok || newer.Before(usedAt) {
+				r.pending[id] = usedAt
+			}
+		}
+		r.mu.Unlock()
+	}
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"go-mobile-backend-template/internal/db/repository"
+	"go-mobile-backend-template/internal/services/mail"
+)
+
+// Email verification errors
+var (
+	ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+	ErrEmailAlreadyVerified     = errors.New("email is already verified")
+)
+
+// EmailVerificationService issues and redeems email verification tokens
+type EmailVerificationService struct {
+	tokens    repository.EmailVerificationTokenRepository
+	users     repository.UserRepository
+	notifier  *Notifier
+	expire    time.Duration
+	verifyURL string
+}
+
+// NewEmailVerificationService creates a new email verification service
+func NewEmailVerificationService(
+	tokens repository.EmailVerificationTokenRepository,
+	users repository.UserRepository,
+	notifier *Notifier,
+	expire time.Duration,
+	verifyURL string,
+) *EmailVerificationService {
+	return &EmailVerificationService{
+		tokens:    tokens,
+		users:     users,
+		notifier:  notifier,
+		expire:    expire,
+		verifyURL: verifyURL,
+	}
+}
+
+// Send emails a verification link for the user's current email address
+func (s *EmailVerificationService) Send(ctx context.Context, user *repository.User) error {
+	if user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+
+	// Only the newest link is valid
+	if err := s.tokens.InvalidateAllForUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	token, err := GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	record := &repository.EmailVerificationToken{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Token:     HashToken(token),
+		ExpiresAt: time.Now().Add(s.expire),
+	}
+	if err := s.tokens.Create(ctx, record); err != nil {
+		return err
+	}
+
+	s.notifier.Send(user.Email, "Verify your email address", mail.TemplateEmailVerification, map[string]interface{}{
+		"Name":      user.Name,
+		"Email":     user.Email,
+		"URL":       withToken(s.verifyURL, token),
+		"ExpiresIn": humanizeDuration(s.expire),
+	})
+
+	return nil
+}
+
+// Verify consumes a verification token and marks the email as verified. A
+// token issued for an address the user has since changed is rejected.
+func (s *EmailVerificationService) Verify(ctx context.Context, token string) (*repository.User, error) {
+	record, err := s.tokens.GetByToken(ctx, HashToken(token))
+	if err != nil || record.Used || time.Now().After(record.ExpiresAt) {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	user, err := s.users.GetByID(ctx, record.UserID)
+	if err != nil || user.Email != record.Email {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	consumed, err := s.tokens.MarkUsed(ctx, record.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	now := time.Now()
+	user.EmailVerified = true
+	user.EmailVerifiedAt = &now
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// withToken appends a token query parameter to a link
+func withToken(link, token string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link + "?token=" + url.QueryEscape(token)
+	}
+
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// humanizeDuration formats a token lifetime for email copy
+func humanizeDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return plural(int(d/(24*time.Hour)), "day")
+	case d >= time.Hour && d%time.Hour == 0:
+		return plural(int(d/time.Hour), "hour")
+	default:
+		return plural(int(d/time.Minute), "minute")
+	}
+}
+
+// plural formats a count with a singular or plural unit
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -1,88 +1,285 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // Claims represents JWT claims
 type Claims struct {
-	UserID  uint   `json:"user_id"`
-	Email   string `json:"email"`
-	IsAdmin bool   `json:"is_admin"`
+	UserID    uint   `json:"user_id"`
+	Email     string `json:"email"`
+	IsAdmin   bool   `json:"is_admin"`
+	SessionID uint   `json:"sid,omitempty"`
+	Type      string `json:"typ,omitempty"`
+	// Roles and Scopes are only present when permissions are embedded in
+	// access tokens, and may be stale by up to the token lifetime
+	Roles  []string `json:"roles,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Token types carried in the typ claim
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	// TokenTypeTwoFactorChallenge marks the short-lived token returned by a
+	// password login that still needs a second factor
+	TokenTypeTwoFactorChallenge = "2fa_challenge"
+)
+
+// ErrInvalidTokenType is returned when a token is used for something other
+// than its type allows
+var ErrInvalidTokenType = errors.New("invalid token type")
+
+// TokenValidator performs additional checks on claims after the token
+// signature and expiry have been verified
+type TokenValidator interface {
+	ValidateClaims(ctx context.Context, claims *Claims) error
+}
+
+// JWTOptions configure a JWTService beyond the shared secret and token
+// lifetimes
+type JWTOptions struct {
+	// Issuer and Audience are the iss and aud claims of issued tokens.
+	// Tokens are only accepted when they carry the same values.
+	Issuer   string
+	Audience string
+	// KeyManager signs tokens with asymmetric keys instead of the shared
+	// secret when set
+	KeyManager *KeyManager
+	// Validators check access and refresh tokens after their signature and
+	// expiry, e.g. against revoked sessions
+	Validators []TokenValidator
+}
+
 // JWTService handles JWT operations
 type JWTService struct {
 	secretKey              string
 	accessTokenExpiration  time.Duration
 	refreshTokenExpiration time.Duration
+	issuer                 string
+	audience               string
+	keyManager             *KeyManager
+	validators             []TokenValidator
 }
 
 // NewJWTService creates a new JWT service
-func NewJWTService(secretKey string, accessExpire, refreshExpire int) *JWTService {
+func NewJWTService(secretKey string, accessExpire, refreshExpire int, opts JWTOptions) *JWTService {
 	return &JWTService{
 		secretKey:              secretKey,
 		accessTokenExpiration:  time.Duration(accessExpire) * time.Minute,
 		refreshTokenExpiration: time.Duration(refreshExpire) * time.Minute,
+		issuer:                 opts.Issuer,
+		audience:               opts.Audience,
+		keyManager:             opts.KeyManager,
+		validators:             opts.Validators,
 	}
 }
 
-// GenerateAccessToken generates a new access token
-func (s *JWTService) GenerateAccessToken(userID uint, email string, isAdmin bool) (string, error) {
-	claims := &Claims{
-		UserID:  userID,
-		Email:   email,
-		IsAdmin: isAdmin,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.accessTokenExpiration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+// GenerateAccessToken generates a new access token bound to a session
+func (s *JWTService) GenerateAccessToken(userID uint, email string, isAdmin bool, sessionID uint) (string, error) {
+	return s.GenerateAccessTokenWithPermissions(userID, email, isAdmin, sessionID, nil)
+}
+
+// GenerateAccessTokenWithPermissions generates a new access token bound to
+// a session that carries the user's roles and permissions, so permission
+// checks need no lookup. permissions may be nil.
+func (s *JWTService) GenerateAccessTokenWithPermissions(userID uint, email string, isAdmin bool, sessionID uint, permissions *EffectivePermissions) (string, error) {
+	claims := s.newClaims(userID, email, isAdmin, sessionID, TokenTypeAccess, s.accessTokenExpiration)
+	if permissions != nil {
+		claims.Roles = permissions.Roles
+		claims.Scopes = permissions.Permissions
 	}
+	return s.sign(claims)
+}
+
+// GenerateRefreshToken generates a new refresh token bound to a session
+func (s *JWTService) GenerateRefreshToken(userID uint, email string, isAdmin bool, sessionID uint) (string, error) {
+	return s.sign(s.newClaims(userID, email, isAdmin, sessionID, TokenTypeRefresh, s.refreshTokenExpiration))
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.secretKey))
+// GenerateTwoFactorChallenge generates a short-lived token that can only be
+// exchanged for a token pair together with a valid second factor
+func (s *JWTService) GenerateTwoFactorChallenge(userID uint, expiresIn time.Duration) (string, error) {
+	return s.sign(s.newClaims(userID, "", false, 0, TokenTypeTwoFactorChallenge, expiresIn))
 }
 
-// GenerateRefreshToken generates a new refresh token
-func (s *JWTService) GenerateRefreshToken(userID uint, email string, isAdmin bool) (string, error) {
+// newClaims builds the claims of a token of the given type with a unique jti
+func (s *JWTService) newClaims(userID uint, email string, isAdmin bool, sessionID uint, tokenType string, expiresIn time.Duration) *Claims {
+	now := time.Now()
+
 	claims := &Claims{
-		UserID:  userID,
-		Email:   email,
-		IsAdmin: isAdmin,
+		UserID:    userID,
+		Email:     email,
+		IsAdmin:   isAdmin,
+		SessionID: sessionID,
+		Type:      tokenType,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.refreshTokenExpiration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ID:        uuid.NewString(),
+			Issuer:    s.issuer,
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
+	if s.audience != "" {
+		claims.Audience = jwt.ClaimStrings{s.audience}
+	}
+
+	return claims
+}
+
+// ValidateTwoFactorChallenge validates a two-factor challenge token and
+// returns its claims
+func (s *JWTService) ValidateTwoFactorChallenge(tokenString string) (*Claims, error) {
+	claims, err := s.parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Type != TokenTypeTwoFactorChallenge {
+		return nil, ErrInvalidTokenType
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.secretKey))
+	return claims, nil
 }
 
-// ValidateToken validates a JWT token and returns the claims
+// ValidateToken validates an access token and returns the claims. Refresh
+// and challenge tokens are rejected.
 func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+	claims, err := s.parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Type != TokenTypeAccess {
+		return nil, ErrInvalidTokenType
+	}
+
+	if err := s.runValidators(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// ValidateRefreshToken validates a refresh token and returns the claims.
+// Only typed refresh tokens are accepted.
+func (s *JWTService) ValidateRefreshToken(tokenString string) (*Claims, error) {
+	claims, err := s.parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Type != TokenTypeRefresh {
+		return nil, ErrInvalidTokenType
+	}
+
+	if err := s.runValidators(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// runValidators runs the service's validators against claims
+func (s *JWTService) runValidators(claims *Claims) error {
+	for _, validator := range s.validators {
+		if err := validator.ValidateClaims(context.Background(), claims); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sign signs claims with the current asymmetric key when the service has a
+// key manager, and with the shared secret otherwise
+func (s *JWTService) sign(claims *Claims) (string, error) {
+	manager := s.keyManager
+	if manager == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(s.secretKey))
+	}
+
+	key := manager.SigningKey()
+	token := jwt.NewWithClaims(key.Method(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// verificationKey returns the key that verifies token. Asymmetric tokens are
+// looked up by kid and must use the algorithm of that key; HS256 tokens use
+// the shared secret unless a key manager has turned them off.
+func (s *JWTService) verificationKey(token *jwt.Token) (interface{}, error) {
+	manager := s.keyManager
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if manager != nil && !manager.AcceptHS256() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(s.secretKey), nil
-	})
+	}
+
+	if manager == nil {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := manager.VerificationKey(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.Public(), nil
+}
+
+// parseToken verifies the signature and expiry of a token and returns its claims
+func (s *JWTService) parseToken(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.verificationKey,
+		jwt.WithValidMethods([]string{AlgorithmHS256, AlgorithmRS256, AlgorithmES256, AlgorithmEdDSA}))
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
 	}
 
-	return nil, fmt.Errorf("invalid token")
+	if err := s.checkIssuer(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// checkIssuer verifies the iss and aud claims against the service's issuer
+// and audience
+func (s *JWTService) checkIssuer(claims *Claims) error {
+	if claims.Issuer != s.issuer {
+		return fmt.Errorf("invalid token issuer")
+	}
+
+	if s.audience == "" {
+		return nil
+	}
+	for _, aud := range claims.Audience {
+		if aud == s.audience {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid token audience")
 }
 
 // GetRefreshTokenExpiration returns the refresh token expiration time
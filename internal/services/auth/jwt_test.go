@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testJWTSecret = "test-secret"
+
+// rejectSession is a TokenValidator that rejects one session
+type rejectSession uint
+
+var errSessionRejected = errors.New("session rejected")
+
+func (r rejectSession) ValidateClaims(ctx context.Context, claims *Claims) error {
+	if claims.SessionID == uint(r) {
+		return errSessionRejected
+	}
+	return nil
+}
+
+func newTestJWTService(opts JWTOptions) *JWTService {
+	return NewJWTService(testJWTSecret, 15, 60, opts)
+}
+
+// signTestClaims signs claims with the shared test secret
+func signTestClaims(t *testing.T, claims *Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	return token
+}
+
+func TestValidateRefreshToken(t *testing.T) {
+	service := newTestJWTService(JWTOptions{Issuer: "backend", Audience: "app"})
+
+	refresh, err := service.GenerateRefreshToken(1, "user@example.com", false, 7)
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken() error = %v", err)
+	}
+	access, err := service.GenerateAccessToken(1, "user@example.com", false, 7)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+
+	// A refresh token from before tokens were typed, with no typ, iss or aud
+	untyped := signTestClaims(t, &Claims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "refresh token", token: refresh},
+		{name: "access token", token: access, wantErr: true},
+		{name: "untyped token", token: untyped, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := service.ValidateRefreshToken(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateRefreshToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && claims.SessionID != 7 {
+				t.Errorf("ValidateRefreshToken() session = %d, want 7", claims.SessionID)
+			}
+		})
+	}
+}
+
+func TestValidateTokenChecksIssuerAndAudience(t *testing.T) {
+	service := newTestJWTService(JWTOptions{Issuer: "backend", Audience: "app"})
+
+	tests := []struct {
+		name    string
+		opts    JWTOptions
+		wantErr bool
+	}{
+		{name: "same issuer and audience", opts: JWTOptions{Issuer: "backend", Audience: "app"}},
+		{name: "other issuer", opts: JWTOptions{Issuer: "other", Audience: "app"}, wantErr: true},
+		{name: "other audience", opts: JWTOptions{Issuer: "backend", Audience: "other"}, wantErr: true},
+		{name: "no issuer or audience", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := newTestJWTService(tt.opts)
+
+			access, err := issuer.GenerateAccessToken(1, "user@example.com", false, 1)
+			if err != nil {
+				t.Fatalf("GenerateAccessToken() error = %v", err)
+			}
+			if _, err := service.ValidateToken(access); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			refresh, err := issuer.GenerateRefreshToken(1, "user@example.com", false, 1)
+			if err != nil {
+				t.Fatalf("GenerateRefreshToken() error = %v", err)
+			}
+			if _, err := service.ValidateRefreshToken(refresh); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRefreshToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorsAreScopedToTheService(t *testing.T) {
+	validated := newTestJWTService(JWTOptions{Validators: []TokenValidator{rejectSession(2)}})
+	plain := newTestJWTService(JWTOptions{})
+
+	for _, sessionID := range []uint{1, 2} {
+		token, err := validated.GenerateAccessToken(1, "user@example.com", false, sessionID)
+		if err != nil {
+			t.Fatalf("GenerateAccessToken() error = %v", err)
+		}
+
+		_, err = validated.ValidateToken(token)
+		if wantErr := sessionID == 2; errors.Is(err, errSessionRejected) != wantErr {
+			t.Errorf("ValidateToken() of session %d error = %v, want rejected %v", sessionID, err, wantErr)
+		}
+
+		// Another service does not run the first service's validators
+		if _, err := plain.ValidateToken(token); err != nil {
+			t.Errorf("ValidateToken() without validators of session %d error = %v", sessionID, err)
+		}
+	}
+}
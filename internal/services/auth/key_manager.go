@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// KeyManagerConfig configures signing key selection and rotation
+type KeyManagerConfig struct {
+	// Algorithm of the keys generated by rotation
+	Algorithm string
+	// Rotation is how long a key signs before the next one takes over.
+	// Zero disables automatic rotation.
+	Rotation time.Duration
+	// Retention is how long a superseded key still verifies tokens. It
+	// should cover the longest token lifetime.
+	Retention time.Duration
+	// Refresh is how often keys are reloaded from the source. New keys are
+	// published two refreshes before they start signing so every instance
+	// and JWKS consumer knows them in time.
+	Refresh time.Duration
+	// AcceptHS256 keeps verifying tokens signed with the shared secret
+	AcceptHS256 bool
+}
+
+// defaultKeyRefresh is used when no refresh interval is configured
+const defaultKeyRefresh = 5 * time.Minute
+
+// keySet is an immutable snapshot of the loaded keys
+type keySet struct {
+	signing   *SigningKey
+	verifying map[string]*SigningKey
+	published []*SigningKey
+}
+
+// KeyManager selects the asymmetric key that signs new tokens and the keys
+// that verify them, and rotates keys on schedule
+type KeyManager struct {
+	source KeySource
+	cfg    KeyManagerConfig
+	logger *zap.Logger
+
+	mu   sync.RWMutex
+	keys *keySet
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewKeyManager loads keys from source, generating the first key if there is
+// none yet
+func NewKeyManager(ctx context.Context, source KeySource, cfg KeyManagerConfig, logger *zap.Logger) (*KeyManager, error) {
+	switch cfg.Algorithm {
+	case AlgorithmRS256, AlgorithmES256, AlgorithmEdDSA:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKey, cfg.Algorithm)
+	}
+
+	if cfg.Refresh <= 0 {
+		cfg.Refresh = defaultKeyRefresh
+	}
+
+	m := &KeyManager{
+		source: source,
+		cfg:    cfg,
+		logger: logger,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	if err := m.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// SigningKey returns the key that signs new tokens
+func (m *KeyManager) SigningKey() *SigningKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.keys.signing
+}
+
+// VerificationKey returns the key with the given kid if it may still verify
+// tokens
+func (m *KeyManager) VerificationKey(kid string) (*SigningKey, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key, ok := m.keys.verifying[kid]
+	return key, ok
+}
+
+// AcceptHS256 reports whether tokens signed with the shared secret are
+// still accepted
+func (m *KeyManager) AcceptHS256() bool {
+	return m.cfg.AcceptHS256
+}
+
+// JWKS returns the public keys other services may use to verify tokens,
+// including keys that will start signing soon
+func (m *KeyManager) JWKS() JWKSet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := JWKSet{Keys: make([]JWK, 0, len(m.keys.published))}
+	for _, key := range m.keys.published {
+		set.Keys = append(set.Keys, key.JWK())
+	}
+	return set
+}
+
+// Refresh reloads keys from the source and generates the next key when
+// rotation is due
+func (m *KeyManager) Refresh(ctx context.Context) error {
+	keys, err := m.source.Load(ctx)
+	if err != nil {
+		return err
+	}
+	sortKeys(keys)
+
+	if next, due := m.nextRotation(keys, time.Now()); due {
+		key, err := GenerateSigningKey(m.cfg.Algorithm, next)
+		if err != nil {
+			return err
+		}
+		if err := m.source.Store(ctx, key); err != nil {
+			return err
+		}
+		m.logger.Info("Generated JWT signing key",
+			zap.String("kid", key.ID),
+			zap.String("algorithm", key.Algorithm),
+			zap.Time("activates_at", key.ActivatesAt),
+		)
+
+		keys = append(keys, key)
+		sortKeys(keys)
+	}
+
+	set, oldest, err := m.selectKeys(keys, time.Now())
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.keys = set
+	m.mu.Unlock()
+
+	// Keys only disappear when rotation is automatic; hand-managed keys are
+	// left for the operator to remove
+	if m.cfg.Rotation > 0 && !oldest.IsZero() {
+		if err := m.source.Prune(ctx, oldest, set.signing.ID); err != nil {
+			m.logger.Warn("Failed to prune retired JWT signing keys", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// Run reloads keys every refresh interval until Stop is called
+func (m *KeyManager) Run() {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.cfg.Refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.Refresh(context.Background()); err != nil {
+				m.logger.Error("Failed to refresh JWT signing keys", zap.Error(err))
+			}
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// Stop stops Run
+func (m *KeyManager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+	<-m.done
+}
+
+// nextRotation reports whether a new key must be generated and when it
+// should start signing
+func (m *KeyManager) nextRotation(keys []*SigningKey, now time.Time) (time.Time, bool) {
+	var latest *SigningKey
+	for _, key := range keys {
+		if key.Algorithm == m.cfg.Algorithm {
+			latest = key
+		}
+	}
+
+	if latest == nil {
+		return now, true
+	}
+	if m.cfg.Rotation <= 0 {
+		return time.Time{}, false
+	}
+
+	next := latest.ActivatesAt.Add(m.cfg.Rotation)
+	if now.Before(next.Add(-m.publishLead())) {
+		return time.Time{}, false
+	}
+	if next.Before(now) {
+		next = now
+	}
+	return next, true
+}
+
+// selectKeys picks the signing key and the keys that still verify. It also
+// returns the activation time of the oldest key still in use.
+func (m *KeyManager) selectKeys(keys []*SigningKey, now time.Time) (*keySet, time.Time, error) {
+	set := &keySet{verifying: make(map[string]*SigningKey)}
+
+	// The newest active key of the configured algorithm signs
+	for _, key := range keys {
+		if key.Algorithm == m.cfg.Algorithm && !key.ActivatesAt.After(now) {
+			set.signing = key
+		}
+	}
+	if set.signing == nil {
+		return nil, time.Time{}, fmt.Errorf("no active %s signing key", m.cfg.Algorithm)
+	}
+
+	var oldest time.Time
+	for i, key := range keys {
+		// A key is retired once the key after it has been signing for longer
+		// than the retention period
+		if i+1 < len(keys) && !keys[i+1].ActivatesAt.After(now) &&
+			now.After(keys[i+1].ActivatesAt.Add(m.cfg.Retention)) && key != set.signing {
+			continue
+		}
+		if key.ActivatesAt.After(now.Add(m.publishLead())) {
+			continue
+		}
+
+		if oldest.IsZero() {
+			oldest = key.ActivatesAt
+		}
+		set.verifying[key.ID] = key
+		set.published = append(set.published, key)
+	}
+
+	return set, oldest, nil
+}
+
+// publishLead is how long before activation a key is published
+func (m *KeyManager) publishLead() time.Duration {
+	return 2 * m.cfg.Refresh
+}
+
+// sortKeys orders keys by activation time, then kid
+func sortKeys(keys []*SigningKey) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].ActivatesAt.Equal(keys[j].ActivatesAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].ActivatesAt.Before(keys[j].ActivatesAt)
+	})
+}
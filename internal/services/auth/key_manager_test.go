@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// memoryKeySource is a KeySource that keeps keys in memory
+type memoryKeySource struct {
+	mu   sync.Mutex
+	keys []*SigningKey
+}
+
+func (s *memoryKeySource) Load(ctx context.Context) ([]*SigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*SigningKey(nil), s.keys...), nil
+}
+
+func (s *memoryKeySource) Store(ctx context.Context, key *SigningKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append(s.keys, key)
+	return nil
+}
+
+func (s *memoryKeySource) Prune(ctx context.Context, before time.Time, keep string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.keys[:0]
+	for _, key := range s.keys {
+		if key.ID == keep || !key.ActivatesAt.Before(before) {
+			kept = append(kept, key)
+		}
+	}
+	s.keys = kept
+	return nil
+}
+
+// testSigningKey generates an ES256 key with a readable kid
+func testSigningKey(t *testing.T, kid string, activatesAt time.Time) *SigningKey {
+	t.Helper()
+	key, err := GenerateSigningKey(AlgorithmES256, activatesAt)
+	if err != nil {
+		t.Fatalf("GenerateSigningKey() error = %v", err)
+	}
+	key.ID = kid
+	return key
+}
+
+// jwksKIDs returns the sorted kids of a JWKS
+func jwksKIDs(set JWKSet) []string {
+	kids := make([]string, len(set.Keys))
+	for i, key := range set.Keys {
+		kids[i] = key.KeyID
+	}
+	sort.Strings(kids)
+	return kids
+}
+
+func TestRetiredKeyGraceWindow(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		// newKeyAge is how long the key that replaced the old one has
+		// been signing
+		newKeyAge time.Duration
+		wantValid bool
+		wantJWKS  []string
+	}{
+		{name: "within the grace window", newKeyAge: 30 * time.Minute, wantValid: true, wantJWKS: []string{"new", "old"}},
+		{name: "after the grace window", newKeyAge: 90 * time.Minute, wantJWKS: []string{"new"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &memoryKeySource{keys: []*SigningKey{testSigningKey(t, "old", now.Add(-2*time.Hour))}}
+			manager, err := NewKeyManager(context.Background(), source, KeyManagerConfig{
+				Algorithm: AlgorithmES256,
+				Retention: time.Hour,
+			}, zap.NewNop())
+			if err != nil {
+				t.Fatalf("NewKeyManager() error = %v", err)
+			}
+			service := NewJWTService(testJWTSecret, 15, 60, JWTOptions{KeyManager: manager})
+
+			token, err := service.GenerateAccessToken(1, "user@example.com", false, 1)
+			if err != nil {
+				t.Fatalf("GenerateAccessToken() error = %v", err)
+			}
+
+			// The old key is replaced by one that has signed for newKeyAge
+			source.Store(context.Background(), testSigningKey(t, "new", now.Add(-tt.newKeyAge)))
+			if err := manager.Refresh(context.Background()); err != nil {
+				t.Fatalf("Refresh() error = %v", err)
+			}
+			if kid := manager.SigningKey().ID; kid != "new" {
+				t.Fatalf("SigningKey() = %s, want new", kid)
+			}
+
+			if _, err := service.ValidateToken(token); (err == nil) != tt.wantValid {
+				t.Errorf("ValidateToken() of an old key token error = %v, want valid %v", err, tt.wantValid)
+			}
+			if kids := jwksKIDs(manager.JWKS()); !reflect.DeepEqual(kids, tt.wantJWKS) {
+				t.Errorf("JWKS() kids = %v, want %v", kids, tt.wantJWKS)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	now := time.Now()
+	source := &memoryKeySource{keys: []*SigningKey{
+		// Replaced longer ago than the retention, so it is pruned
+		testSigningKey(t, "retired", now.Add(-2*time.Hour)),
+		testSigningKey(t, "current", now.Add(-55*time.Minute)),
+	}}
+
+	manager, err := NewKeyManager(context.Background(), source, KeyManagerConfig{
+		Algorithm: AlgorithmES256,
+		Rotation:  time.Hour,
+		Retention: 30 * time.Minute,
+		Refresh:   5 * time.Minute,
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewKeyManager() error = %v", err)
+	}
+
+	// The next key is due in five minutes, within two refreshes, so it is
+	// generated and published but does not sign yet
+	keys, _ := source.Load(context.Background())
+	if len(keys) != 2 {
+		t.Fatalf("source has %d keys after rotation, want current and the next key", len(keys))
+	}
+	var next *SigningKey
+	for _, key := range keys {
+		if key.ID != "current" {
+			next = key
+		}
+	}
+	if next.ID == "retired" {
+		t.Fatal("retired key was not pruned")
+	}
+	if want := now.Add(5 * time.Minute); next.ActivatesAt.Sub(want).Abs() > time.Second {
+		t.Errorf("next key activates at %v, want %v", next.ActivatesAt, want)
+	}
+
+	if kid := manager.SigningKey().ID; kid != "current" {
+		t.Errorf("SigningKey() = %s, want current", kid)
+	}
+	if _, ok := manager.VerificationKey(next.ID); !ok {
+		t.Error("VerificationKey() does not know the next key")
+	}
+	if _, ok := manager.VerificationKey("retired"); ok {
+		t.Error("VerificationKey() still knows the retired key")
+	}
+	want := []string{"current", next.ID}
+	sort.Strings(want)
+	if kids := jwksKIDs(manager.JWKS()); !reflect.DeepEqual(kids, want) {
+		t.Errorf("JWKS() kids = %v, want %v", kids, want)
+	}
+
+	// Refreshing again before the next key activates generates nothing
+	if err := manager.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if keys, _ := source.Load(context.Background()); len(keys) != 2 {
+		t.Errorf("source has %d keys after a second refresh, want 2", len(keys))
+	}
+}
+
+func TestKeyManagerHS256(t *testing.T) {
+	source := &memoryKeySource{keys: []*SigningKey{testSigningKey(t, "current", time.Now().Add(-time.Minute))}}
+	hs256 := NewJWTService(testJWTSecret, 15, 60, JWTOptions{})
+	token, err := hs256.GenerateAccessToken(1, "user@example.com", false, 1)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+
+	for _, accept := range []bool{true, false} {
+		manager, err := NewKeyManager(context.Background(), source, KeyManagerConfig{
+			Algorithm:   AlgorithmES256,
+			AcceptHS256: accept,
+		}, zap.NewNop())
+		if err != nil {
+			t.Fatalf("NewKeyManager() error = %v", err)
+		}
+		service := NewJWTService(testJWTSecret, 15, 60, JWTOptions{KeyManager: manager})
+		if _, err := service.ValidateToken(token); (err == nil) != accept {
+			t.Errorf("ValidateToken() of an HS256 token with AcceptHS256 %v error = %v", accept, err)
+		}
+	}
+}
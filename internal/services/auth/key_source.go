@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go-mobile-backend-template/internal/db/repository"
+)
+
+// KeySource loads and stores JWT signing keys
+type KeySource interface {
+	// Load returns all keys, in any order
+	Load(ctx context.Context) ([]*SigningKey, error)
+	// Store persists a newly generated key
+	Store(ctx context.Context, key *SigningKey) error
+	// Prune deletes keys activated before the given time, except keep
+	Prune(ctx context.Context, before time.Time, keep string) error
+}
+
+// DirKeySource keeps keys as PEM files in a directory. The file name without
+// its .pem extension is the kid and the modification time is the activation
+// time, so operators can add keys by dropping files into the directory.
+type DirKeySource struct {
+	dir string
+}
+
+// NewDirKeySource creates a key source for dir, creating it if needed
+func NewDirKeySource(dir string) (*DirKeySource, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+	return &DirKeySource{dir: dir}, nil
+}
+
+// Load reads every .pem file in the directory
+func (s *DirKeySource) Load(ctx context.Context) ([]*SigningKey, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key directory: %w", err)
+	}
+
+	var keys []*SigningKey
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat key file: %w", err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+
+		key, err := ParseSigningKeyPEM(strings.TrimSuffix(entry.Name(), ".pem"), data, info.ModTime())
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// Store writes the key as <kid>.pem with its activation time as mtime
+func (s *DirKeySource) Store(ctx context.Context, key *SigningKey) error {
+	data, err := key.MarshalPEM()
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(s.dir, key.ID+".pem")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := os.Chtimes(tmp, key.ActivatesAt, key.ActivatesAt); err != nil {
+		return fmt.Errorf("failed to set key activation time: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	return nil
+}
+
+// Prune removes key files activated before the given time
+func (s *DirKeySource) Prune(ctx context.Context, before time.Time, keep string) error {
+	keys, err := s.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if key.ID == keep || !key.ActivatesAt.Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, key.ID+".pem")); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove key file: %w", err)
+		}
+	}
+	return nil
+}
+
+// signingKeySealContext separates the key sealing key from other uses of the
+// JWT secret
+const signingKeySealContext = "jwt-signing-key:"
+
+// DBKeySource keeps keys in the jwt_signing_keys table. Private keys are
+// sealed with AES-GCM under a key derived from the JWT secret, so changing
+// the secret makes stored keys unreadable.
+type DBKeySource struct {
+	repo repository.JWTSigningKeyRepository
+	aead cipher.AEAD
+}
+
+// NewDBKeySource creates a database key source sealing keys with secret
+func NewDBKeySource(repo repository.JWTSigningKeyRepository, secret string) (*DBKeySource, error) {
+	sealKey := sha256.Sum256([]byte(signingKeySealContext + secret))
+
+	block, err := aes.NewCipher(sealKey[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key cipher: %w", err)
+	}
+
+	return &DBKeySource{repo: repo, aead: aead}, nil
+}
+
+// Load reads and unseals every stored key
+func (s *DBKeySource) Load(ctx context.Context) ([]*SigningKey, error) {
+	records, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*SigningKey, 0, len(records))
+	for _, record := range records {
+		data, err := s.open(record.KID, record.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := ParseSigningKeyPEM(record.KID, data, record.ActivatesAt)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// Store seals and inserts a key
+func (s *DBKeySource) Store(ctx context.Context, key *SigningKey) error {
+	data, err := key.MarshalPEM()
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate key nonce: %w", err)
+	}
+	sealed := s.aead.Seal(nonce, nonce, data, []byte(key.ID))
+
+	return s.repo.Create(ctx, &repository.JWTSigningKey{
+		KID:         key.ID,
+		Algorithm:   key.Algorithm,
+		PrivateKey:  base64.StdEncoding.EncodeToString(sealed),
+		ActivatesAt: key.ActivatesAt,
+	})
+}
+
+// Prune deletes keys activated before the given time
+func (s *DBKeySource) Prune(ctx context.Context, before time.Time, keep string) error {
+	records, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if record.KID == keep {
+			return s.repo.DeleteActivatedBefore(ctx, before, record.ID)
+		}
+	}
+	return nil
+}
+
+// open unseals a stored private key. The kid is bound as additional data so
+// sealed keys cannot be swapped between rows.
+func (s *DBKeySource) open(kid, sealed string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < s.aead.NonceSize() {
+		return nil, fmt.Errorf("key %s: malformed sealed key", kid)
+	}
+
+	nonce, ciphertext := raw[:s.aead.NonceSize()], raw[s.aead.NonceSize():]
+	data, err := s.aead.Open(nil, nonce, ciphertext, []byte(kid))
+	if err != nil {
+		return nil, fmt.Errorf("key %s: failed to unseal key, was jwt.secret changed?", kid)
+	}
+	return data, nil
+}
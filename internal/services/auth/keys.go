@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing algorithms supported for JWTs
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// rsaKeyBits is the size of generated RSA keys
+const rsaKeyBits = 2048
+
+// ErrUnsupportedKey is returned for keys that cannot sign JWTs
+var ErrUnsupportedKey = errors.New("unsupported signing key type")
+
+// SigningKey is an asymmetric JWT signing key identified by its kid
+type SigningKey struct {
+	ID          string
+	Algorithm   string
+	Private     crypto.Signer
+	ActivatesAt time.Time
+}
+
+// Method returns the JWT signing method of the key
+func (k *SigningKey) Method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// Public returns the public half of the key
+func (k *SigningKey) Public() crypto.PublicKey {
+	return k.Private.Public()
+}
+
+// GenerateSigningKey creates a new key for algorithm, activating at activatesAt
+func GenerateSigningKey(algorithm string, activatesAt time.Time) (*SigningKey, error) {
+	var (
+		private crypto.Signer
+		err     error
+	)
+
+	switch algorithm {
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgorithmES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKey, algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	suffix, err := GenerateRandomToken(4)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{
+		ID:          activatesAt.UTC().Format("20060102T150405") + "-" + suffix,
+		Algorithm:   algorithm,
+		Private:     private,
+		ActivatesAt: activatesAt,
+	}, nil
+}
+
+// ParseSigningKeyPEM parses a PEM encoded private key (PKCS #8, PKCS #1 or
+// SEC 1) and derives its JWT algorithm from the key type
+func ParseSigningKeyPEM(id string, data []byte, activatesAt time.Time) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s: no PEM data found", id)
+	}
+
+	var (
+		parsed interface{}
+		err    error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %s: failed to parse private key: %w", id, err)
+	}
+
+	algorithm, err := keyAlgorithm(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", id, err)
+	}
+
+	return &SigningKey{
+		ID:          id,
+		Algorithm:   algorithm,
+		Private:     parsed.(crypto.Signer),
+		ActivatesAt: activatesAt,
+	}, nil
+}
+
+// MarshalPEM encodes the private key as PKCS #8 PEM
+func (k *SigningKey) MarshalPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.Private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signing key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// keyAlgorithm returns the JWT algorithm for a parsed private key
+func keyAlgorithm(key interface{}) (string, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return "", fmt.Errorf("%w: RSA keys must be at least 2048 bits", ErrUnsupportedKey)
+		}
+		return AlgorithmRS256, nil
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return "", fmt.Errorf("%w: only P-256 EC keys are supported", ErrUnsupportedKey)
+		}
+		return AlgorithmES256, nil
+	case ed25519.PrivateKey:
+		return AlgorithmEdDSA, nil
+	default:
+		return "", ErrUnsupportedKey
+	}
+}
+
+// JWK is a public key in JSON Web Key form (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JWKSet is a set of public keys as served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK returns the public half of the key as a JWK
+func (k *SigningKey) JWK() JWK {
+	jwk := JWK{
+		KeyID:     k.ID,
+		Use:       "sig",
+		Algorithm: k.Algorithm,
+	}
+
+	switch public := k.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (public.Curve.Params().BitSize + 7) / 8
+		jwk.KeyType = "EC"
+		jwk.Curve = public.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+
+	return jwk
+}
@@ -0,0 +1,274 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-mobile-backend-template/internal/db/repository"
+	"go-mobile-backend-template/pkg/cache"
+	"go-mobile-backend-template/pkg/config"
+)
+
+// LockoutResult describes the locks triggered by a failed login
+type LockoutResult struct {
+	Attempts           int        // consecutive failures of the account
+	AccountLockedUntil *time.Time // set when this failure locked the account
+	IPBlockedFor       time.Duration
+}
+
+// LockoutService tracks failed logins per account and per client IP and
+// locks them out with progressive backoff. Account state lives in the users
+// table; IP state lives in Redis, or in process when Redis is not configured.
+type LockoutService struct {
+	users repository.UserRepository
+	redis *cache.RedisClient
+	cfg   config.Lockout
+
+	mu    sync.Mutex
+	local map[string]*ipFailures
+}
+
+// ipFailures is the in-process failure state of a client IP. users are the
+// accounts whose failed logins were counted against it.
+type ipFailures struct {
+	count        int
+	resetAt      time.Time
+	blockedUntil time.Time
+	users        map[uint]bool
+}
+
+// NewLockoutService creates a new lockout service. redis may be nil.
+func NewLockoutService(users repository.UserRepository, redis *cache.RedisClient, cfg config.Lockout) *LockoutService {
+	return &LockoutService{
+		users: users,
+		redis: redis,
+		cfg:   cfg,
+		local: make(map[string]*ipFailures),
+	}
+}
+
+// IsLocked reports whether the account is currently locked
+func (s *LockoutService) IsLocked(user *repository.User) bool {
+	return user.LockedUntil != nil && time.Now().Before(*user.LockedUntil)
+}
+
+// IPBlockedFor returns how much longer a client IP is blocked, or zero
+func (s *LockoutService) IPBlockedFor(ctx context.Context, ip string) (time.Duration, error) {
+	if s.cfg.IPMaxAttempts <= 0 || ip == "" {
+		return 0, nil
+	}
+
+	if s.redis == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if f, ok := s.local[ip]; ok {
+			if remaining := time.Until(f.blockedUntil); remaining > 0 {
+				return remaining, nil
+			}
+		}
+		return 0, nil
+	}
+
+	ttl, err := s.redis.GetClient().TTL(ctx, ipBlockKey(ip)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// RecordFailure counts a failed login for the client IP and, when user is
+// not nil, for the account. It returns the locks the failure triggered.
+func (s *LockoutService) RecordFailure(ctx context.Context, user *repository.User, ip string) (*LockoutResult, error) {
+	result := &LockoutResult{}
+
+	var userID uint
+	if user != nil {
+		userID = user.ID
+	}
+
+	if user != nil && s.cfg.MaxAttempts > 0 {
+		attempts, err := s.users.IncrementFailedLogins(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		result.Attempts = attempts
+
+		if attempts >= s.cfg.MaxAttempts {
+			until := time.Now().Add(s.lockDuration(attempts - s.cfg.MaxAttempts))
+			if err := s.users.SetLockedUntil(ctx, user.ID, &until); err != nil {
+				return nil, err
+			}
+			result.AccountLockedUntil = &until
+		}
+	}
+
+	if s.cfg.IPMaxAttempts > 0 && ip != "" {
+		blockedFor, err := s.recordIPFailure(ctx, ip, userID)
+		if err != nil {
+			return result, err
+		}
+		result.IPBlockedFor = blockedFor
+	}
+
+	return result, nil
+}
+
+// RecordSuccess records a completed login and clears the account's failures
+func (s *LockoutService) RecordSuccess(ctx context.Context, userID uint) error {
+	return s.users.RecordLogin(ctx, userID, time.Now())
+}
+
+// Unlock clears the failures and lock of an account, and the failure counts
+// and blocks of the IPs its failed logins came from, so neither the account
+// nor its usual IPs keep their backoff
+func (s *LockoutService) Unlock(ctx context.Context, userID uint) error {
+	if err := s.users.ResetLockout(ctx, userID); err != nil {
+		return err
+	}
+
+	if s.redis == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for ip, f := range s.local {
+			if f.users[userID] {
+				delete(s.local, ip)
+			}
+		}
+		return nil
+	}
+
+	key := userFailureIPsKey(userID)
+	ips, err := s.redis.GetClient().SMembers(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{key}
+	for _, ip := range ips {
+		keys = append(keys, ipFailuresKey(ip), ipBlockKey(ip))
+	}
+	return s.redis.Delete(ctx, keys...)
+}
+
+// recordIPFailure counts a failure for ip and blocks it once the limit for
+// the window is reached. The failure count outlives the block so repeated
+// offenders are blocked for longer each time. A non-zero userID records the
+// IP against the account for Unlock.
+func (s *LockoutService) recordIPFailure(ctx context.Context, ip string, userID uint) (time.Duration, error) {
+	window := time.Duration(s.cfg.IPWindow) * time.Minute
+
+	if s.redis == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		now := time.Now()
+		f, ok := s.local[ip]
+		if !ok || now.After(f.resetAt) {
+			s.pruneLocal(now)
+			f = &ipFailures{resetAt: now.Add(window), users: make(map[uint]bool)}
+			s.local[ip] = f
+		}
+
+		if userID != 0 {
+			f.users[userID] = true
+		}
+
+		f.count++
+		if f.count < s.cfg.IPMaxAttempts {
+			return 0, nil
+		}
+
+		blockFor := s.lockDuration(f.count - s.cfg.IPMaxAttempts)
+		f.blockedUntil = now.Add(blockFor)
+		f.resetAt = f.blockedUntil.Add(window)
+		return blockFor, nil
+	}
+
+	key := ipFailuresKey(ip)
+	count, err := s.redis.Increment(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := s.redis.Expire(ctx, key, window); err != nil {
+			return 0, err
+		}
+	}
+
+	var blockFor time.Duration
+	if int(count) >= s.cfg.IPMaxAttempts {
+		blockFor = s.lockDuration(int(count) - s.cfg.IPMaxAttempts)
+		if err := s.redis.Set(ctx, ipBlockKey(ip), "1", blockFor); err != nil {
+			return 0, err
+		}
+		if err := s.redis.Expire(ctx, key, blockFor+window); err != nil {
+			return 0, err
+		}
+	}
+
+	if userID != 0 {
+		usersKey := userFailureIPsKey(userID)
+		if err := s.redis.GetClient().SAdd(ctx, usersKey, ip).Err(); err != nil {
+			return 0, err
+		}
+		if err := s.redis.Expire(ctx, usersKey, blockFor+window); err != nil {
+			return 0, err
+		}
+	}
+
+	return blockFor, nil
+}
+
+// pruneLocal drops expired IP state now and then so it does not grow
+// without bound. Callers hold s.mu.
+func (s *LockoutService) pruneLocal(now time.Time) {
+	if len(s.local) < 10000 {
+		return
+	}
+	for ip, f := range s.local {
+		if now.After(f.resetAt) {
+			delete(s.local, ip)
+		}
+	}
+}
+
+// lockDuration returns the lock for a failure excess failures past the
+// limit: lock_duration * backoff^excess, capped at max_lock_duration
+func (s *LockoutService) lockDuration(excess int) time.Duration {
+	base := time.Duration(s.cfg.LockDuration) * time.Minute
+	maxLock := time.Duration(s.cfg.MaxLockDuration) * time.Minute
+
+	backoff := s.cfg.Backoff
+	if backoff < 1 {
+		backoff = 1
+	}
+
+	lock := time.Duration(float64(base) * math.Pow(backoff, float64(excess)))
+	if maxLock > 0 && (lock > maxLock || lock < 0) {
+		lock = maxLock
+	}
+	return lock
+}
+
+// ipFailuresKey is the Redis key counting failed logins of an IP
+func ipFailuresKey(ip string) string {
+	return "login_failures:" + ip
+}
+
+// ipBlockKey is the Redis key marking an IP as blocked
+func ipBlockKey(ip string) string {
+	return "login_blocked:" + ip
+}
+
+// userFailureIPsKey is the Redis set of IPs with failed logins to an account
+func userFailureIPsKey(userID uint) string {
+	return "login_failure_ips:" + strconv.FormatUint(uint64(userID), 10)
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go-mobile-backend-template/internal/db/repository"
+	"go-mobile-backend-template/pkg/config"
+)
+
+// memoryLockoutUsers implements the UserRepository methods used by
+// LockoutService
+type memoryLockoutUsers struct {
+	repository.UserRepository
+
+	mu          sync.Mutex
+	attempts    map[uint]int
+	lockedUntil map[uint]*time.Time
+}
+
+func newMemoryLockoutUsers() *memoryLockoutUsers {
+	return &memoryLockoutUsers{attempts: make(map[uint]int), lockedUntil: make(map[uint]*time.Time)}
+}
+
+func (r *memoryLockoutUsers) IncrementFailedLogins(ctx context.Context, id uint) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts[id]++
+	return r.attempts[id], nil
+}
+
+func (r *memoryLockoutUsers) SetLockedUntil(ctx context.Context, id uint, until *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lockedUntil[id] = until
+	return nil
+}
+
+func (r *memoryLockoutUsers) ResetLockout(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.attempts, id)
+	delete(r.lockedUntil, id)
+	return nil
+}
+
+func TestLockoutUnlockClearsIPState(t *testing.T) {
+	ctx := context.Background()
+	users := newMemoryLockoutUsers()
+	lockout := NewLockoutService(users, nil, config.Lockout{
+		MaxAttempts:     3,
+		IPMaxAttempts:   3,
+		IPWindow:        15,
+		LockDuration:    1,
+		MaxLockDuration: 60,
+		Backoff:         2,
+	})
+
+	victim := &repository.User{ID: 1}
+	other := &repository.User{ID: 2}
+
+	var result *LockoutResult
+	for i := 0; i < 3; i++ {
+		var err error
+		result, err = lockout.RecordFailure(ctx, victim, "10.0.0.1")
+		if err != nil {
+			t.Fatalf("RecordFailure() error = %v", err)
+		}
+	}
+	if result.AccountLockedUntil == nil || result.IPBlockedFor <= 0 {
+		t.Fatalf("RecordFailure() = %+v, want the account locked and the IP blocked", result)
+	}
+
+	// Failures against another account from another IP are not cleared
+	for i := 0; i < 3; i++ {
+		if _, err := lockout.RecordFailure(ctx, other, "10.0.0.2"); err != nil {
+			t.Fatalf("RecordFailure() error = %v", err)
+		}
+	}
+
+	if err := lockout.Unlock(ctx, victim.ID); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	if users.lockedUntil[victim.ID] != nil || users.attempts[victim.ID] != 0 {
+		t.Errorf("account state after Unlock() = %d attempts, locked until %v", users.attempts[victim.ID], users.lockedUntil[victim.ID])
+	}
+	if blockedFor, _ := lockout.IPBlockedFor(ctx, "10.0.0.1"); blockedFor != 0 {
+		t.Errorf("IPBlockedFor() of the unlocked user's IP = %v, want 0", blockedFor)
+	}
+	if blockedFor, _ := lockout.IPBlockedFor(ctx, "10.0.0.2"); blockedFor <= 0 {
+		t.Error("Unlock() cleared the block of another account's IP")
+	}
+
+	// The backoff starts over: the next failure does not block the IP again
+	result, err := lockout.RecordFailure(ctx, victim, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("RecordFailure() error = %v", err)
+	}
+	if result.IPBlockedFor != 0 || result.AccountLockedUntil != nil {
+		t.Errorf("RecordFailure() after Unlock() = %+v, want no lock", result)
+	}
+}
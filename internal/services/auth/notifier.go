@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go-mobile-backend-template/internal/services/mail"
+)
+
+// Notifier renders templated emails and delivers them in the background so
+// request latency does not depend on the mail server
+type Notifier struct {
+	mailer   mail.Mailer
+	renderer *mail.Renderer
+	logger   *zap.Logger
+}
+
+// NewNotifier creates a new notifier
+func NewNotifier(mailer mail.Mailer, renderer *mail.Renderer, logger *zap.Logger) *Notifier {
+	return &Notifier{
+		mailer:   mailer,
+		renderer: renderer,
+		logger:   logger,
+	}
+}
+
+// Send renders a template and delivers it asynchronously
+func (n *Notifier) Send(to, subject, template string, data interface{}) {
+	msg, err := n.renderer.Render(template, to, subject, data)
+	if err != nil {
+		n.logger.Error("Failed to render email", zap.String("template", template), zap.Error(err))
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		if err := n.mailer.Send(ctx, msg); err != nil {
+			n.logger.Error("Failed to send email", zap.String("template", template), zap.Error(err))
+		}
+	}()
+}
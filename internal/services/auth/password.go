@@ -1,9 +1,18 @@
 package auth
 
 import (
+	"sync"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
+// dummyHash is compared against when there is no real hash to check, so
+// failed logins take the same time whatever the reason
+var (
+	dummyHash     []byte
+	dummyHashOnce sync.Once
+)
+
 // HashPassword hashes a password using bcrypt
 func HashPassword(password string) (string, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -17,3 +26,13 @@ func HashPassword(password string) (string, error) {
 func CheckPassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// SimulatePasswordCheck spends the time of a password check without checking
+// anything. Use it when a login fails before the password is compared so
+// unknown and locked accounts cannot be told apart by response time.
+func SimulatePasswordCheck(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
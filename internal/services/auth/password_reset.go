@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-mobile-backend-template/internal/db/repository"
+	"go-mobile-backend-template/internal/services/mail"
+)
+
+// ErrInvalidResetToken is returned for unknown, used or expired reset tokens
+var ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+
+// PasswordResetService issues and redeems password reset tokens
+type PasswordResetService struct {
+	tokens        repository.PasswordResetTokenRepository
+	users         repository.UserRepository
+	refreshTokens repository.RefreshTokenRepository
+	sessions      *SessionService
+	notifier      *Notifier
+	expire        time.Duration
+	resetURL      string
+}
+
+// NewPasswordResetService creates a new password reset service
+func NewPasswordResetService(
+	tokens repository.PasswordResetTokenRepository,
+	users repository.UserRepository,
+	refreshTokens repository.RefreshTokenRepository,
+	sessions *SessionService,
+	notifier *Notifier,
+	expire time.Duration,
+	resetURL string,
+) *PasswordResetService {
+	return &PasswordResetService{
+		tokens:        tokens,
+		users:         users,
+		refreshTokens: refreshTokens,
+		sessions:      sessions,
+		notifier:      notifier,
+		expire:        expire,
+		resetURL:      resetURL,
+	}
+}
+
+// Request emails a reset link to the account with the given email. Unknown
+// and inactive accounts are ignored so callers cannot probe which emails
+// are registered.
+func (s *PasswordResetService) Request(ctx context.Context, email string) error {
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil || !user.IsActive {
+		return nil
+	}
+
+	// Only the newest link is valid
+	if err := s.tokens.InvalidateAllForUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	token, err := GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	record := &repository.PasswordResetToken{
+		UserID:    user.ID,
+		Token:     HashToken(token),
+		ExpiresAt: time.Now().Add(s.expire),
+	}
+	if err := s.tokens.Create(ctx, record); err != nil {
+		return err
+	}
+
+	s.notifier.Send(user.Email, "Reset your password", mail.TemplatePasswordReset, map[string]interface{}{
+		"Name":      user.Name,
+		"URL":       withToken(s.resetURL, token),
+		"ExpiresIn": humanizeDuration(s.expire),
+	})
+
+	return nil
+}
+
+// Reset sets a new password using a reset token. The token is consumed and
+// every refresh token and session of the user is revoked.
+func (s *PasswordResetService) Reset(ctx context.Context, token, newPassword string) error {
+	record, err := s.tokens.GetByToken(ctx, HashToken(token))
+	if err != nil || record.Used || time.Now().After(record.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.users.GetByID(ctx, record.UserID)
+	if err != nil || !user.IsActive {
+		return ErrInvalidResetToken
+	}
+
+	consumed, err := s.tokens.MarkUsed(ctx, record.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidResetToken
+	}
+
+	hashedPassword, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user.Password = hashedPassword
+	if user.Metadata == nil {
+		user.Metadata = repository.JSONB{}
+	}
+	user.Metadata["password_set"] = true
+	// Proving control of the mailbox lifts a lockout from failed logins
+	user.FailedLoginAttempts = 0
+	user.LockedUntil = nil
+	if err := s.users.Update(ctx, user); err != nil {
+		return err
+	}
+
+	if err := s.refreshTokens.RevokeAllForUser(ctx, user.ID); err != nil {
+		return err
+	}
+	return s.sessions.RevokeAll(ctx, user.ID)
+}
@@ -0,0 +1,302 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go-mobile-backend-template/internal/db/repository"
+	"go-mobile-backend-template/pkg/cache"
+)
+
+// ScopeWildcard matches any resource or action in a permission or scope
+const ScopeWildcard = "*"
+
+// ScopesAllow reports whether any scope grants action on resource. Scopes
+// are resource:action pairs as stored on permissions and API keys; either
+// side may be the wildcard, e.g. "posts:*", "*:read" or "*:*". Every
+// permission check resolves wildcards here.
+func ScopesAllow(scopes []string, resource, action string) bool {
+	for _, scope := range scopes {
+		scopeResource, scopeAction, ok := strings.Cut(scope, ":")
+		if !ok {
+			if scope == ScopeWildcard {
+				return true
+			}
+			continue
+		}
+
+		if (scopeResource == ScopeWildcard || scopeResource == resource) &&
+			(scopeAction == ScopeWildcard || scopeAction == action) {
+			return true
+		}
+	}
+	return false
+}
+
+// EffectivePermissions are the roles of a user and the resource:action
+// permissions granted by them
+type EffectivePermissions struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// Allows reports whether the permissions grant action on resource
+func (p *EffectivePermissions) Allows(resource, action string) bool {
+	return ScopesAllow(p.Permissions, resource, action)
+}
+
+// HasRole reports whether the user has the named role, ignoring case
+func (p *EffectivePermissions) HasRole(name string) bool {
+	for _, role := range p.Roles {
+		if strings.EqualFold(role, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionResolver resolves the effective permissions of users and caches
+// them in Redis, or in process when Redis is not configured. Entries are
+// invalidated when assignments change through RoleRepository and expire
+// after the cache TTL otherwise.
+type PermissionResolver struct {
+	roles repository.RoleRepository
+	redis *cache.RedisClient
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	local map[uint]*cachedPermissions
+}
+
+// cachedPermissions is an in-process cache entry
+type cachedPermissions struct {
+	permissions *EffectivePermissions
+	expiresAt   time.Time
+}
+
+// NewPermissionResolver creates a new permission resolver. redis may be nil.
+func NewPermissionResolver(roles repository.RoleRepository, redis *cache.RedisClient, ttl time.Duration) *PermissionResolver {
+	return &PermissionResolver{
+		roles: roles,
+		redis: redis,
+		ttl:   ttl,
+		local: make(map[uint]*cachedPermissions),
+	}
+}
+
+// Resolve returns the effective permissions of a user
+func (r *PermissionResolver) Resolve(ctx context.Context, userID uint) (*EffectivePermissions, error) {
+	if cached, ok := r.cached(ctx, userID); ok {
+		return cached, nil
+	}
+
+	roles, err := r.roles.GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user roles: %w", err)
+	}
+
+	permissions := &EffectivePermissions{
+		Roles:       make([]string, 0, len(roles)),
+		Permissions: []string{},
+	}
+	seen := make(map[string]bool)
+	for _, role := range roles {
+		permissions.Roles = append(permissions.Roles, role.Name)
+		for _, permission := range role.Permissions {
+			scope := permission.Resource + ":" + permission.Action
+			if !seen[scope] {
+				seen[scope] = true
+				permissions.Permissions = append(permissions.Permissions, scope)
+			}
+		}
+	}
+
+	r.store(ctx, userID, permissions)
+	return permissions, nil
+}
+
+// Allowed reports whether a user may perform action on resource
+func (r *PermissionResolver) Allowed(ctx context.Context, userID uint, resource, action string) (bool, error) {
+	permissions, err := r.Resolve(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return permissions.Allows(resource, action), nil
+}
+
+// Invalidate drops the cached permissions of the given users
+func (r *PermissionResolver) Invalidate(ctx context.Context, userIDs ...uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	if r.redis == nil {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		for _, userID := range userIDs {
+			delete(r.local, userID)
+		}
+		return nil
+	}
+
+	keys := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		keys = append(keys, permissionsKey(userID))
+	}
+	return r.redis.Delete(ctx, keys...)
+}
+
+// InvalidateRole drops the cached permissions of every user with the role
+func (r *PermissionResolver) InvalidateRole(ctx context.Context, roleID uint) error {
+	userIDs, err := r.roles.GetRoleUserIDs(ctx, roleID)
+	if err != nil {
+		return fmt.Errorf("failed to get role users: %w", err)
+	}
+	return r.Invalidate(ctx, userIDs...)
+}
+
+// permissionTables are the tables whose rows grant permissions, with the
+// column that names the user, role or permission a row's change affects
+var permissionTables = map[string]string{
+	"user_roles":       "user_id",
+	"role_permissions": "role_id",
+	"roles":            "id",
+	"permissions":      "id",
+}
+
+// PermissionKeyColumn returns the column of a table whose value
+// UsersGrantedBy takes, and false when the table grants no permissions
+func PermissionKeyColumn(table string) (string, bool) {
+	column, ok := permissionTables[table]
+	return column, ok
+}
+
+// UsersGrantedBy returns the users whose permissions depend on the rows of
+// table whose PermissionKeyColumn is key. Pass them to Invalidate after the
+// rows change; collect them before deleting, as the assignments the rows
+// grant are deleted with them.
+func (r *PermissionResolver) UsersGrantedBy(ctx context.Context, table string, key uint) ([]uint, error) {
+	switch table {
+	case "user_roles":
+		return []uint{key}, nil
+	case "role_permissions", "roles":
+		return r.roles.GetRoleUserIDs(ctx, key)
+	case "permissions":
+		return r.roles.GetPermissionUserIDs(ctx, key)
+	}
+	return nil, nil
+}
+
+// RoleRepository wraps roles so that assignment changes made through it
+// invalidate the affected cache entries
+func (r *PermissionResolver) RoleRepository(roles repository.RoleRepository) repository.RoleRepository {
+	return &invalidatingRoleRepository{RoleRepository: roles, resolver: r}
+}
+
+// cached returns the cached permissions of a user, if any. Cache errors are
+// treated as misses.
+func (r *PermissionResolver) cached(ctx context.Context, userID uint) (*EffectivePermissions, bool) {
+	if r.ttl <= 0 {
+		return nil, false
+	}
+
+	if r.redis == nil {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		entry, ok := r.local[userID]
+		if !ok || time.Now().After(entry.expiresAt) {
+			return nil, false
+		}
+		return entry.permissions, true
+	}
+
+	data, err := r.redis.Get(ctx, permissionsKey(userID))
+	if err != nil {
+		return nil, false
+	}
+
+	var permissions EffectivePermissions
+	if err := json.Unmarshal([]byte(data), &permissions); err != nil {
+		return nil, false
+	}
+	return &permissions, true
+}
+
+// store caches the permissions of a user. A failed write only costs a
+// database lookup on the next request.
+func (r *PermissionResolver) store(ctx context.Context, userID uint, permissions *EffectivePermissions) {
+	if r.ttl <= 0 {
+		return
+	}
+
+	if r.redis == nil {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		now := time.Now()
+		for id, entry := range r.local {
+			if now.After(entry.expiresAt) {
+				delete(r.local, id)
+			}
+		}
+		r.local[userID] = &cachedPermissions{permissions: permissions, expiresAt: now.Add(r.ttl)}
+		return
+	}
+
+	data, err := json.Marshal(permissions)
+	if err != nil {
+		return
+	}
+	_ = r.redis.Set(ctx, permissionsKey(userID), data, r.ttl)
+}
+
+// permissionsKey is the Redis key caching a user's permissions
+func permissionsKey(userID uint) string {
+	return fmt.Sprintf("permissions:%d", userID)
+}
+
+// invalidatingRoleRepository invalidates cached permissions after the role
+// assignments of users change
+type invalidatingRoleRepository struct {
+	repository.RoleRepository
+	resolver *PermissionResolver
+}
+
+func (r *invalidatingRoleRepository) AssignPermissions(ctx context.Context, roleID uint, permissionIDs []uint) error {
+	if err := r.RoleRepository.AssignPermissions(ctx, roleID, permissionIDs); err != nil {
+		return err
+	}
+	return r.resolver.InvalidateRole(ctx, roleID)
+}
+
+func (r *invalidatingRoleRepository) AssignRoleToUser(ctx context.Context, userID, roleID uint, assignedBy *uint) error {
+	if err := r.RoleRepository.AssignRoleToUser(ctx, userID, roleID, assignedBy); err != nil {
+		return err
+	}
+	return r.resolver.Invalidate(ctx, userID)
+}
+
+func (r *invalidatingRoleRepository) RemoveRoleFromUser(ctx context.Context, userID, roleID uint) error {
+	if err := r.RoleRepository.RemoveRoleFromUser(ctx, userID, roleID); err != nil {
+		return err
+	}
+	return r.resolver.Invalidate(ctx, userID)
+}
+
+func (r *invalidatingRoleRepository) Delete(ctx context.Context, id uint) error {
+	// Collect the users first; the assignments go with the role
+	userIDs, err := r.RoleRepository.GetRoleUserIDs(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get role users: %w", err)
+	}
+	if err := r.RoleRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.resolver.Invalidate(ctx, userIDs...)
+}
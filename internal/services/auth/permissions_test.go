@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go-mobile-backend-template/internal/db/repository"
+)
+
+// memoryRoleRepository keeps the role assignments of users in memory. Only
+// the methods the resolver calls are implemented.
+type memoryRoleRepository struct {
+	repository.RoleRepository
+
+	mu        sync.Mutex
+	roles     map[uint]*repository.Role
+	userRoles map[uint][]uint
+}
+
+func (r *memoryRoleRepository) GetUserRoles(ctx context.Context, userID uint) ([]repository.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var roles []repository.Role
+	for _, roleID := range r.userRoles[userID] {
+		if role, ok := r.roles[roleID]; ok {
+			roles = append(roles, *role)
+		}
+	}
+	return roles, nil
+}
+
+func (r *memoryRoleRepository) GetRoleUserIDs(ctx context.Context, roleID uint) ([]uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var userIDs []uint
+	for userID, roleIDs := range r.userRoles {
+		for _, id := range roleIDs {
+			if id == roleID {
+				userIDs = append(userIDs, userID)
+			}
+		}
+	}
+	return userIDs, nil
+}
+
+func (r *memoryRoleRepository) GetPermissionUserIDs(ctx context.Context, permissionID uint) ([]uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var userIDs []uint
+	for userID, roleIDs := range r.userRoles {
+		for _, roleID := range roleIDs {
+			role, ok := r.roles[roleID]
+			if !ok {
+				continue
+			}
+			for _, permission := range role.Permissions {
+				if permission.ID == permissionID {
+					userIDs = append(userIDs, userID)
+				}
+			}
+		}
+	}
+	return userIDs, nil
+}
+
+func TestRevokedPermissionsStopAuthorizing(t *testing.T) {
+	const userID, roleID, permissionID = 1, 10, 100
+
+	tests := []struct {
+		table string
+		key   uint
+		// revoke removes the grant as a write to table would
+		revoke func(r *memoryRoleRepository)
+	}{
+		{table: "user_roles", key: userID, revoke: func(r *memoryRoleRepository) { delete(r.userRoles, userID) }},
+		{table: "role_permissions", key: roleID, revoke: func(r *memoryRoleRepository) { r.roles[roleID].Permissions = nil }},
+		{table: "roles", key: roleID, revoke: func(r *memoryRoleRepository) { delete(r.roles, roleID) }},
+		{table: "permissions", key: permissionID, revoke: func(r *memoryRoleRepository) { r.roles[roleID].Permissions = nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.table, func(t *testing.T) {
+			ctx := context.Background()
+			roles := &memoryRoleRepository{
+				roles: map[uint]*repository.Role{roleID: {
+					ID:          roleID,
+					Name:        "editor",
+					Permissions: []repository.Permission{{ID: permissionID, Resource: "posts", Action: "write"}},
+				}},
+				userRoles: map[uint][]uint{userID: {roleID}, 2: {roleID}},
+			}
+			resolver := NewPermissionResolver(roles, nil, time.Hour)
+
+			if allowed, _ := resolver.Allowed(ctx, userID, "posts", "write"); !allowed {
+				t.Fatal("Allowed() = false before the grant was revoked")
+			}
+
+			// Collected before the write, as the rows may be gone after it
+			userIDs, err := resolver.UsersGrantedBy(ctx, tt.table, tt.key)
+			if err != nil {
+				t.Fatalf("UsersGrantedBy() error = %v", err)
+			}
+			tt.revoke(roles)
+
+			// Cached until invalidated
+			if allowed, _ := resolver.Allowed(ctx, userID, "posts", "write"); !allowed {
+				t.Fatal("Allowed() = false before invalidation, the test does not exercise the cache")
+			}
+			if err := resolver.Invalidate(ctx, userIDs...); err != nil {
+				t.Fatalf("Invalidate() error = %v", err)
+			}
+			if allowed, _ := resolver.Allowed(ctx, userID, "posts", "write"); allowed {
+				t.Errorf("Allowed() = true after revoking through %s", tt.table)
+			}
+		})
+	}
+}
+
+func TestScopesAllow(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		want   bool
+	}{
+		{name: "exact", scopes: []string{"posts:read"}, want: true},
+		{name: "any action", scopes: []string{"posts:*"}, want: true},
+		{name: "any resource", scopes: []string{"*:read"}, want: true},
+		{name: "everything", scopes: []string{"*:*"}, want: true},
+		{name: "bare wildcard", scopes: []string{"*"}, want: true},
+		{name: "one of several", scopes: []string{"files:read", "posts:write", "posts:read"}, want: true},
+		{name: "other action", scopes: []string{"posts:write"}},
+		{name: "other resource", scopes: []string{"files:*"}},
+		{name: "wildcard action of another resource", scopes: []string{"*:write"}},
+		{name: "resource prefix", scopes: []string{"post:read"}},
+		{name: "not resource:action", scopes: []string{"posts"}},
+		{name: "none"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScopesAllow(tt.scopes, "posts", "read"); got != tt.want {
+				t.Errorf("ScopesAllow(%v, posts, read) = %v, want %v", tt.scopes, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-mobile-backend-template/internal/db/repository"
+)
+
+// sessionTouchInterval limits how often last_used_at is written for a session
+const sessionTouchInterval = time.Minute
+
+// Session errors
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionInactive = errors.New("session is no longer active")
+	// ErrRefreshTokenReused is returned when a refresh token that was
+	// already exchanged is presented again
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	// ErrRefreshTokenInvalid is returned for refresh tokens of a session
+	// that has no refresh token bound to it
+	ErrRefreshTokenInvalid = errors.New("refresh token is not bound to the session")
+	// ErrRefreshTokenExchanged is returned when a concurrent refresh
+	// exchanged the same token first
+	ErrRefreshTokenExchanged = errors.New("refresh token was already exchanged")
+)
+
+// SessionMetadata describes the device that opened a session
+type SessionMetadata struct {
+	IPAddress  string
+	UserAgent  string
+	DeviceInfo map[string]interface{}
+}
+
+// SessionService manages device sessions bound to issued tokens
+type SessionService struct {
+	repo repository.SessionRepository
+}
+
+// NewSessionService creates a new session service
+func NewSessionService(repo repository.SessionRepository) *SessionService {
+	return &SessionService{repo: repo}
+}
+
+// Create opens a new active session for a user
+func (s *SessionService) Create(ctx context.Context, userID uint, meta SessionMetadata, expiresAt time.Time) (*repository.Session, error) {
+	token, err := GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &repository.Session{
+		UserID:     userID,
+		Token:      token,
+		DeviceInfo: repository.JSONB(meta.DeviceInfo),
+		IPAddress:  meta.IPAddress,
+		UserAgent:  meta.UserAgent,
+		IsActive:   true,
+		ExpiresAt:  expiresAt,
+		LastUsedAt: now,
+	}
+
+	if err := s.repo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// BindRefreshToken stores the hash of the session's current refresh token
+// and extends the session to the token's expiry
+func (s *SessionService) BindRefreshToken(ctx context.Context, session *repository.Session, refreshToken string, expiresAt time.Time) error {
+	hash := HashToken(refreshToken)
+	session.RefreshToken = &hash
+	session.ExpiresAt = expiresAt
+	session.LastUsedAt = time.Now()
+
+	return s.repo.Update(ctx, session)
+}
+
+// RotateRefreshToken replaces the session's current refresh token
+// previousToken with refreshToken and extends the session to its expiry.
+// When another refresh exchanged previousToken first, nothing is changed
+// and ErrRefreshTokenExchanged is returned.
+func (s *SessionService) RotateRefreshToken(ctx context.Context, session *repository.Session, previousToken, refreshToken string, expiresAt time.Time) error {
+	hash := HashToken(refreshToken)
+	rotated, err := s.repo.RotateRefreshToken(ctx, session.ID, HashToken(previousToken), hash, expiresAt)
+	if err != nil {
+		return err
+	}
+	if !rotated {
+		return ErrRefreshTokenExchanged
+	}
+
+	session.RefreshToken = &hash
+	session.ExpiresAt = expiresAt
+	session.LastUsedAt = time.Now()
+	return nil
+}
+
+// GetActive returns a session if it is still active and unexpired
+func (s *SessionService) GetActive(ctx context.Context, sessionID uint) (*repository.Session, error) {
+	session, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	if !session.IsActive || time.Now().After(session.ExpiresAt) {
+		return nil, ErrSessionInactive
+	}
+
+	return session, nil
+}
+
+// VerifyRefreshToken checks that refreshToken is the current refresh token
+// of an active session and returns that session.
+//
+// A session is a refresh token family: every refresh replaces its current
+// token. A correctly signed token of the session that is not the current
+// one has already been exchanged, so someone else holds a copy of the
+// chain. The session is revoked, which rejects every token issued for it,
+// and ErrRefreshTokenReused is returned. Sessions without a bound refresh
+// token accept none.
+//
+// The token must then be exchanged with RotateRefreshToken, which fails if
+// a concurrent refresh exchanged it in the meantime.
+func (s *SessionService) VerifyRefreshToken(ctx context.Context, sessionID uint, refreshToken string) (*repository.Session, error) {
+	session, err := s.GetActive(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.RefreshToken == nil {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	if *session.RefreshToken != HashToken(refreshToken) {
+		if err := s.repo.Deactivate(ctx, session.ID); err != nil {
+			return nil, fmt.Errorf("failed to revoke reused session: %w", err)
+		}
+		return session, ErrRefreshTokenReused
+	}
+
+	return session, nil
+}
+
+// List returns the active sessions of a user
+func (s *SessionService) List(ctx context.Context, userID uint) ([]*repository.Session, error) {
+	return s.repo.GetActiveByUserID(ctx, userID)
+}
+
+// Revoke deactivates one of the user's sessions
+func (s *SessionService) Revoke(ctx context.Context, userID, sessionID uint) error {
+	session, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	return s.repo.Deactivate(ctx, sessionID)
+}
+
+// RevokeOthers deactivates every session of the user except currentID
+func (s *SessionService) RevokeOthers(ctx context.Context, userID, currentID uint) error {
+	return s.repo.DeactivateAllForUser(ctx, userID, currentID)
+}
+
+// RevokeAll deactivates every session of the user
+func (s *SessionService) RevokeAll(ctx context.Context, userID uint) error {
+	return s.repo.DeactivateAllForUser(ctx, userID, 0)
+}
+
+// ValidateClaims implements TokenValidator. Tokens bound to a session are
+// rejected once the session is deactivated or expired. Tokens issued before
+// sessions existed carry no sid and are left to expire naturally.
+func (s *SessionService) ValidateClaims(ctx context.Context, claims *Claims) error {
+	if claims.SessionID == 0 {
+		return nil
+	}
+
+	session, err := s.GetActive(ctx, claims.SessionID)
+	if err != nil {
+		return err
+	}
+
+	if session.UserID != claims.UserID {
+		return ErrSessionNotFound
+	}
+
+	if time.Since(session.LastUsedAt) > sessionTouchInterval {
+		// Best effort - a failed touch must not reject the request
+		_ = s.repo.Touch(ctx, session.ID, time.Now())
+	}
+
+	return nil
+}
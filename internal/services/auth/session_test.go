@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go-mobile-backend-template/internal/db/repository"
+)
+
+// memorySessionRepository is an in-memory SessionRepository
+type memorySessionRepository struct {
+	mu       sync.Mutex
+	sessions map[uint]*repository.Session
+	nextID   uint
+}
+
+func newMemorySessionRepository() *memorySessionRepository {
+	return &memorySessionRepository{sessions: make(map[uint]*repository.Session)}
+}
+
+func (r *memorySessionRepository) Create(ctx context.Context, session *repository.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	session.ID = r.nextID
+	stored := *session
+	r.sessions[session.ID] = &stored
+	return nil
+}
+
+func (r *memorySessionRepository) GetByID(ctx context.Context, id uint) (*repository.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, errors.New("session not found")
+	}
+	found := *session
+	return &found, nil
+}
+
+func (r *memorySessionRepository) GetActiveByUserID(ctx context.Context, userID uint) ([]*repository.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sessions []*repository.Session
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.IsActive {
+			found := *session
+			sessions = append(sessions, &found)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *memorySessionRepository) Update(ctx context.Context, session *repository.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *session
+	r.sessions[session.ID] = &stored
+	return nil
+}
+
+func (r *memorySessionRepository) RotateRefreshToken(ctx context.Context, id uint, previousHash, hash string, expiresAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[id]
+	if !ok || !session.IsActive || session.RefreshToken == nil || *session.RefreshToken != previousHash {
+		return false, nil
+	}
+	session.RefreshToken = &hash
+	session.ExpiresAt = expiresAt
+	return true, nil
+}
+
+func (r *memorySessionRepository) Touch(ctx context.Context, id uint, usedAt time.Time) error {
+	return nil
+}
+
+func (r *memorySessionRepository) Deactivate(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session, ok := r.sessions[id]; ok {
+		session.IsActive = false
+	}
+	return nil
+}
+
+func (r *memorySessionRepository) DeactivateAllForUser(ctx context.Context, userID uint, exceptID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, session := range r.sessions {
+		if session.UserID == userID && id != exceptID {
+			session.IsActive = false
+		}
+	}
+	return nil
+}
+
+func (r *memorySessionRepository) DeleteExpired(ctx context.Context) error {
+	return nil
+}
+
+// newBoundSession opens a session whose current refresh token is token
+func newBoundSession(t *testing.T, service *SessionService, token string) *repository.Session {
+	t.Helper()
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+	session, err := service.Create(ctx, 1, SessionMetadata{}, expiresAt)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if token != "" {
+		if err := service.BindRefreshToken(ctx, session, token, expiresAt); err != nil {
+			t.Fatalf("BindRefreshToken() error = %v", err)
+		}
+	}
+	return session
+}
+
+func TestVerifyRefreshToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		bound      string
+		presented  string
+		wantErr    error
+		wantActive bool
+	}{
+		{name: "current token", bound: "current", presented: "current", wantActive: true},
+		{name: "exchanged token revokes the session", bound: "current", presented: "exchanged", wantErr: ErrRefreshTokenReused},
+		{name: "session without a bound token", presented: "any", wantErr: ErrRefreshTokenInvalid, wantActive: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemorySessionRepository()
+			service := NewSessionService(repo)
+			session := newBoundSession(t, service, tt.bound)
+
+			_, err := service.VerifyRefreshToken(context.Background(), session.ID, tt.presented)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyRefreshToken() error = %v, want %v", err, tt.wantErr)
+			}
+
+			stored, _ := repo.GetByID(context.Background(), session.ID)
+			if stored.IsActive != tt.wantActive {
+				t.Errorf("session active = %v, want %v", stored.IsActive, tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemorySessionRepository()
+	service := NewSessionService(repo)
+	session := newBoundSession(t, service, "first")
+	expiresAt := time.Now().Add(time.Hour)
+
+	if err := service.RotateRefreshToken(ctx, session, "first", "second", expiresAt); err != nil {
+		t.Fatalf("RotateRefreshToken() error = %v", err)
+	}
+
+	// The exchanged token can no longer be rotated, and presenting it
+	// again is reuse
+	if err := service.RotateRefreshToken(ctx, session, "first", "third", expiresAt); !errors.Is(err, ErrRefreshTokenExchanged) {
+		t.Fatalf("RotateRefreshToken() of an exchanged token error = %v, want %v", err, ErrRefreshTokenExchanged)
+	}
+	if _, err := service.VerifyRefreshToken(ctx, session.ID, "second"); err != nil {
+		t.Fatalf("VerifyRefreshToken() of the current token error = %v", err)
+	}
+	if _, err := service.VerifyRefreshToken(ctx, session.ID, "first"); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("VerifyRefreshToken() of an exchanged token error = %v, want %v", err, ErrRefreshTokenReused)
+	}
+	if _, err := service.VerifyRefreshToken(ctx, session.ID, "second"); !errors.Is(err, ErrSessionInactive) {
+		t.Fatalf("VerifyRefreshToken() after reuse error = %v, want %v", err, ErrSessionInactive)
+	}
+}
+
+func TestConcurrentRefreshRotatesOnce(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemorySessionRepository()
+	service := NewSessionService(repo)
+	session := newBoundSession(t, service, "current")
+	expiresAt := time.Now().Add(time.Hour)
+
+	const refreshes = 8
+	errs := make(chan error, refreshes)
+	var wg sync.WaitGroup
+	for i := 0; i < refreshes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			verified, err := service.VerifyRefreshToken(ctx, session.ID, "current")
+			if err != nil {
+				errs <- err
+				return
+			}
+			errs <- service.RotateRefreshToken(ctx, verified, "current", string(rune('a'+i)), expiresAt)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrRefreshTokenExchanged), errors.Is(err, ErrRefreshTokenReused), errors.Is(err, ErrSessionInactive):
+		default:
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d refreshes succeeded, want 1", succeeded)
+	}
+}
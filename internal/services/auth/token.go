@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// GenerateRandomToken returns a hex-encoded cryptographically random token
+// of n random bytes
+func GenerateRandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken returns the hex-encoded SHA-256 hash of a token. Only hashes of
+// opaque tokens are stored so a database leak does not expose usable values.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go-mobile-backend-template/pkg/cache"
+)
+
+// ErrTokenRevoked is returned for tokens whose jti has been denylisted
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// TokenDenylist rejects individual tokens by jti before they expire. Entries
+// live in Redis, or in process when Redis is not configured, and are dropped
+// once the token would have expired anyway.
+type TokenDenylist struct {
+	redis *cache.RedisClient
+
+	mu    sync.Mutex
+	local map[string]time.Time
+}
+
+// NewTokenDenylist creates a new token denylist. redis may be nil.
+func NewTokenDenylist(redis *cache.RedisClient) *TokenDenylist {
+	return &TokenDenylist{
+		redis: redis,
+		local: make(map[string]time.Time),
+	}
+}
+
+// Revoke denylists the token with the given jti until it expires
+func (d *TokenDenylist) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+
+	if d.redis == nil {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		d.pruneLocked()
+		d.local[jti] = expiresAt
+		return nil
+	}
+
+	return d.redis.Set(ctx, tokenDenylistKey(jti), "1", ttl)
+}
+
+// Consume denylists the token with the given jti until it expires and
+// reports whether this call denylisted it. Tokens meant for a single use,
+// such as two-factor challenges, are accepted by the one caller that
+// consumes them, even among concurrent requests.
+func (d *TokenDenylist) Consume(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return false, nil
+	}
+
+	if d.redis == nil {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		d.pruneLocked()
+		if _, ok := d.local[jti]; ok {
+			return false, nil
+		}
+		d.local[jti] = expiresAt
+		return true, nil
+	}
+
+	return d.redis.SetNX(ctx, tokenDenylistKey(jti), "1", ttl)
+}
+
+// pruneLocked drops the local entries of expired tokens. The mutex must be
+// held.
+func (d *TokenDenylist) pruneLocked() {
+	now := time.Now()
+	for id, until := range d.local {
+		if now.After(until) {
+			delete(d.local, id)
+		}
+	}
+}
+
+// IsRevoked reports whether the token with the given jti is denylisted
+func (d *TokenDenylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+
+	if d.redis == nil {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		until, ok := d.local[jti]
+		return ok && time.Now().Before(until), nil
+	}
+
+	count, err := d.redis.Exists(ctx, tokenDenylistKey(jti))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ValidateClaims implements TokenValidator. Tokens without a jti predate
+// the denylist and are left to expire naturally.
+func (d *TokenDenylist) ValidateClaims(ctx context.Context, claims *Claims) error {
+	revoked, err := d.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// tokenDenylistKey is the Redis key marking a revoked jti
+func tokenDenylistKey(jti string) string {
+	return "token_denylist:" + jti
+}
@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go-mobile-backend-template/internal/db/repository"
+)
+
+// rfc6238Secret is the SHA1 test key from RFC 6238 appendix B,
+// "12345678901234567890", base32 encoded
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// The RFC vectors are 8 digits; 6 digit codes are their last 6 digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	key, err := totpEncoding.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("DecodeString() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		got := totpCode(key, totpStep(time.Unix(tt.unix, 0)))
+		if got != tt.want {
+			t.Errorf("totpCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantOK   bool
+		wantStep uint64
+	}{
+		{name: "current step", secret: rfc6238Secret, code: "050471", wantOK: true, wantStep: totpStep(now)},
+		{name: "previous step within skew", secret: rfc6238Secret, code: totpCodeAt(t, now.Add(-totpPeriod*time.Second)), wantOK: true, wantStep: totpStep(now) - 1},
+		{name: "next step within skew", secret: rfc6238Secret, code: totpCodeAt(t, now.Add(totpPeriod*time.Second)), wantOK: true, wantStep: totpStep(now) + 1},
+		{name: "outside skew", secret: rfc6238Secret, code: totpCodeAt(t, now.Add(-2*totpPeriod*time.Second))},
+		{name: "wrong code", secret: rfc6238Secret, code: "000000"},
+		{name: "wrong length", secret: rfc6238Secret, code: "50471"},
+		{name: "lowercase secret", secret: "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code: "050471", wantOK: true, wantStep: totpStep(now)},
+		{name: "invalid secret", secret: "not base32!", code: "050471"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(tt.secret, tt.code, now)
+			if ok != tt.wantOK {
+				t.Fatalf("ValidateTOTP() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && step != tt.wantStep {
+				t.Errorf("ValidateTOTP() step = %d, want %d", step, tt.wantStep)
+			}
+		})
+	}
+}
+
+// totpCodeAt returns the code for the RFC 6238 test key at time t
+func totpCodeAt(t *testing.T, at time.Time) string {
+	t.Helper()
+	key, err := totpEncoding.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("DecodeString() error = %v", err)
+	}
+	return totpCode(key, totpStep(at))
+}
+
+// memoryTwoFactorRepository is an in-memory TwoFactorRepository whose Use
+// methods are conditional, like the SQL updates they stand in for
+type memoryTwoFactorRepository struct {
+	mu      sync.Mutex
+	records map[uint]*repository.TwoFactor
+}
+
+func newMemoryTwoFactorRepository() *memoryTwoFactorRepository {
+	return &memoryTwoFactorRepository{records: make(map[uint]*repository.TwoFactor)}
+}
+
+func (r *memoryTwoFactorRepository) Create(ctx context.Context, twoFactor *repository.TwoFactor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *twoFactor
+	r.records[twoFactor.UserID] = &stored
+	return nil
+}
+
+func (r *memoryTwoFactorRepository) GetByUserID(ctx context.Context, userID uint) (*repository.TwoFactor, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	twoFactor, ok := r.records[userID]
+	if !ok {
+		return nil, errors.New("two-factor record not found")
+	}
+	found := *twoFactor
+	return &found, nil
+}
+
+func (r *memoryTwoFactorRepository) IsEnabledForUser(ctx context.Context, userID uint) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	twoFactor, ok := r.records[userID]
+	return ok && twoFactor.IsEnabled, nil
+}
+
+func (r *memoryTwoFactorRepository) Update(ctx context.Context, twoFactor *repository.TwoFactor) error {
+	return r.Create(ctx, twoFactor)
+}
+
+func (r *memoryTwoFactorRepository) UseTOTPStep(ctx context.Context, userID uint, stepStart time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	twoFactor, ok := r.records[userID]
+	if !ok || !twoFactor.IsEnabled || (twoFactor.LastUsedAt != nil && !twoFactor.LastUsedAt.Before(stepStart)) {
+		return false, nil
+	}
+	twoFactor.LastUsedAt = &stepStart
+	return true, nil
+}
+
+func (r *memoryTwoFactorRepository) UseBackupCode(ctx context.Context, userID uint, hash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	twoFactor, ok := r.records[userID]
+	if !ok || !twoFactor.IsEnabled {
+		return false, nil
+	}
+	hashes, _ := twoFactor.BackupCodes["hashes"].([]string)
+	for i, stored := range hashes {
+		if stored == hash {
+			remaining := append(append([]string{}, hashes[:i]...), hashes[i+1:]...)
+			twoFactor.BackupCodes = repository.JSONB{"hashes": remaining}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryTwoFactorRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.records, userID)
+	return nil
+}
+
+// enableTwoFactor enrolls and confirms user 1 and returns the secret and
+// backup codes
+func enableTwoFactor(t *testing.T, service *TwoFactorService, repo *memoryTwoFactorRepository) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+	enrollment, err := service.Enroll(ctx, 1, "user@example.com")
+	if err != nil {
+		t.Fatalf("Enroll() error = %v", err)
+	}
+
+	key, _ := totpEncoding.DecodeString(enrollment.Secret)
+	codes, err := service.Confirm(ctx, 1, totpCode(key, totpStep(time.Now())))
+	if err != nil {
+		t.Fatalf("Confirm() error = %v", err)
+	}
+
+	// Pretend the confirming code was used a few steps ago, so the current
+	// step can be used again in the test
+	repo.mu.Lock()
+	usedAt := time.Now().Add(-5 * totpPeriod * time.Second)
+	repo.records[1].LastUsedAt = &usedAt
+	repo.mu.Unlock()
+
+	return enrollment.Secret, codes
+}
+
+func TestTwoFactorVerifyRejectsReplayedTOTP(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryTwoFactorRepository()
+	service := NewTwoFactorService(repo, "Test")
+	secret, _ := enableTwoFactor(t, service, repo)
+
+	key, _ := totpEncoding.DecodeString(secret)
+	code := totpCode(key, totpStep(time.Now()))
+
+	const attempts = 8
+	errs := make(chan error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- service.Verify(ctx, 1, code)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	accepted := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			accepted++
+		case errors.Is(err, ErrInvalidTwoFactorCode):
+		default:
+			t.Fatalf("Verify() unexpected error %v", err)
+		}
+	}
+	if accepted != 1 {
+		t.Fatalf("code accepted %d times, want 1", accepted)
+	}
+
+	// Older steps are rejected once a later one was used
+	previous := totpCode(key, totpStep(time.Now())-1)
+	if err := service.Verify(ctx, 1, previous); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("Verify() of an earlier step error = %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+}
+
+func TestTwoFactorVerifyBackupCodes(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryTwoFactorRepository()
+	service := NewTwoFactorService(repo, "Test")
+	_, codes := enableTwoFactor(t, service, repo)
+
+	if len(codes) != backupCodeCount {
+		t.Fatalf("Confirm() returned %d backup codes, want %d", len(codes), backupCodeCount)
+	}
+
+	tests := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{name: "backup code", code: codes[0]},
+		{name: "backup code reused", code: codes[0], wantErr: ErrInvalidTwoFactorCode},
+		{name: "backup code typed without separator", code: " " + codes[1][:5] + codes[1][6:] + " "},
+		{name: "unknown backup code", code: "aaaaa-bbbbb", wantErr: ErrInvalidTwoFactorCode},
+	}
+
+	// The cases run in order; each depends on the codes used before it
+	for _, tt := range tests {
+		if err := service.Verify(ctx, 1, tt.code); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: Verify() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTwoFactorVerifyNotEnabled(t *testing.T) {
+	service := NewTwoFactorService(newMemoryTwoFactorRepository(), "Test")
+	if err := service.Verify(context.Background(), 1, "123456"); !errors.Is(err, ErrTwoFactorNotEnabled) {
+		t.Fatalf("Verify() error = %v, want %v", err, ErrTwoFactorNotEnabled)
+	}
+}
@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go-mobile-backend-template/internal/db/repository"
+)
+
+// backupCodeCount is the number of one-time backup codes issued on confirmation
+const backupCodeCount = 10
+
+// Two-factor errors
+var (
+	ErrTwoFactorNotEnrolled     = errors.New("two-factor authentication is not enrolled")
+	ErrTwoFactorAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrInvalidTwoFactorCode     = errors.New("invalid two-factor code")
+	ErrTwoFactorChallengeFailed = errors.New("invalid or expired two-factor challenge")
+)
+
+// TwoFactorEnrollment holds the secret a user adds to their authenticator app
+type TwoFactorEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// TwoFactorService manages TOTP enrollment and verification
+type TwoFactorService struct {
+	repo   repository.TwoFactorRepository
+	issuer string
+}
+
+// NewTwoFactorService creates a new two-factor service. The issuer is shown
+// next to the account name in authenticator apps.
+func NewTwoFactorService(repo repository.TwoFactorRepository, issuer string) *TwoFactorService {
+	return &TwoFactorService{
+		repo:   repo,
+		issuer: issuer,
+	}
+}
+
+// IsEnabled reports whether the user has confirmed two-factor authentication
+func (s *TwoFactorService) IsEnabled(ctx context.Context, userID uint) (bool, error) {
+	return s.repo.IsEnabledForUser(ctx, userID)
+}
+
+// Enroll generates a new secret for the user. The secret is not enforced
+// until it is confirmed with a valid code; enrolling again replaces it.
+func (s *TwoFactorService) Enroll(ctx context.Context, userID uint, account string) (*TwoFactorEnrollment, error) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		twoFactor = &repository.TwoFactor{UserID: userID, Secret: secret}
+		if err := s.repo.Create(ctx, twoFactor); err != nil {
+			return nil, err
+		}
+	} else {
+		if twoFactor.IsEnabled {
+			return nil, ErrTwoFactorAlreadyEnabled
+		}
+		twoFactor.Secret = secret
+		twoFactor.BackupCodes = nil
+		twoFactor.LastUsedAt = nil
+		if err := s.repo.Update(ctx, twoFactor); err != nil {
+			return nil, err
+		}
+	}
+
+	return &TwoFactorEnrollment{
+		Secret: secret,
+		URI:    TOTPURI(s.issuer, account, secret),
+	}, nil
+}
+
+// Confirm enables two-factor authentication once the user proves their
+// authenticator produces valid codes. It returns the plaintext backup codes,
+// which are only stored hashed and cannot be shown again.
+func (s *TwoFactorService) Confirm(ctx context.Context, userID uint, code string) ([]string, error) {
+	twoFactor, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if twoFactor.IsEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := ValidateTOTP(twoFactor.Secret, normalizeCode(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateBackupCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	usedAt := totpStepTime(step)
+	twoFactor.IsEnabled = true
+	twoFactor.EnabledAt = &now
+	twoFactor.LastUsedAt = &usedAt
+	twoFactor.BackupCodes = repository.JSONB{"hashes": hashes}
+
+	if err := s.repo.Update(ctx, twoFactor); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Verify checks a TOTP or backup code for a user with two-factor enabled.
+// TOTP codes cannot be replayed and backup codes are consumed on use.
+func (s *TwoFactorService) Verify(ctx context.Context, userID uint, code string) error {
+	twoFactor, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil || !twoFactor.IsEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	code = normalizeCode(code)
+
+	// Codes are used with conditional updates, so concurrent requests
+	// cannot both accept the same code
+	if step, ok := ValidateTOTP(twoFactor.Secret, code, time.Now()); ok {
+		// LastUsedAt holds the start of the last accepted time step
+		used, err := s.repo.UseTOTPStep(ctx, userID, totpStepTime(step))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := s.repo.UseBackupCode(ctx, userID, HashToken(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// Disable turns off two-factor authentication after verifying a current code
+func (s *TwoFactorService) Disable(ctx context.Context, userID uint, code string) error {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	return s.repo.DeleteByUserID(ctx, userID)
+}
+
+// generateBackupCodes returns plaintext backup codes and their hashes
+func generateBackupCodes() ([]string, []string, error) {
+	codes := make([]string, 0, backupCodeCount)
+	hashes := make([]string, 0, backupCodeCount)
+
+	for i := 0; i < backupCodeCount; i++ {
+		raw, err := GenerateRandomToken(5)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, HashToken(raw))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeCode strips the separators users commonly type into codes
+func normalizeCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
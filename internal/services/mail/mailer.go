@@ -0,0 +1,60 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go-mobile-backend-template/pkg/config"
+)
+
+// Mail drivers supported in configuration
+const (
+	DriverSMTP   = "smtp"
+	DriverFile   = "file"
+	DriverMemory = "memory"
+)
+
+// Message is an email with HTML and plain text alternatives
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Mailer delivers email messages
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New creates the mailer selected by the mail configuration. The file and
+// memory sinks never deliver mail, so they are only used when selected
+// explicitly.
+func New(cfg config.Mail) (Mailer, error) {
+	from := formatAddress(cfg.FromName, cfg.From)
+
+	switch strings.ToLower(cfg.Driver) {
+	case "":
+		return nil, fmt.Errorf("mail driver is required")
+	case DriverSMTP:
+		if cfg.SMTP.Host == "" {
+			return nil, fmt.Errorf("smtp host is required")
+		}
+		return NewSMTPMailer(cfg.SMTP, from), nil
+	case DriverFile:
+		return NewFileMailer(cfg.FileDir, from)
+	case DriverMemory:
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail driver: %s", cfg.Driver)
+	}
+}
+
+// formatAddress formats a display name and address for a From header
+func formatAddress(name, address string) string {
+	if name == "" {
+		return address
+	}
+	return fmt.Sprintf("%q <%s>", name, address)
+}
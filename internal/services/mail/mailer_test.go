@@ -0,0 +1,39 @@
+package mail
+
+import (
+	"fmt"
+	"testing"
+
+	"go-mobile-backend-template/pkg/config"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Mail
+		want    string
+		wantErr bool
+	}{
+		{name: "no driver", wantErr: true},
+		{name: "smtp without host", cfg: config.Mail{Driver: DriverSMTP}, wantErr: true},
+		{name: "smtp", cfg: config.Mail{Driver: DriverSMTP, SMTP: config.SMTP{Host: "smtp.example.com", Port: 587}}, want: "*mail.SMTPMailer"},
+		{name: "file", cfg: config.Mail{Driver: DriverFile, FileDir: t.TempDir()}, want: "*mail.FileMailer"},
+		{name: "memory", cfg: config.Mail{Driver: "Memory"}, want: "*mail.MemoryMailer"},
+		{name: "unknown driver", cfg: config.Mail{Driver: "sendmail"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mailer, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := fmt.Sprintf("%T", mailer); got != tt.want {
+				t.Errorf("New() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
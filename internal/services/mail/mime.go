@@ -0,0 +1,69 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// buildMIME encodes a message as multipart/alternative with text and HTML parts
+func buildMIME(from string, msg *Message) ([]byte, error) {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return nil, fmt.Errorf("invalid header value")
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	}
+
+	for _, part := range parts {
+		if part.content == "" {
+			continue
+		}
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build email: %w", err)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "From: %s\r\n", from)
+	fmt.Fprintf(&out, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&out, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&out, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&out, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&out, "Content-Type: multipart/alternative; boundary=%s\r\n", writer.Boundary())
+	fmt.Fprintf(&out, "\r\n")
+	out.Write(body.Bytes())
+
+	return out.Bytes(), nil
+}
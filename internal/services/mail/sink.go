@@ -0,0 +1,82 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// MemoryMailer keeps sent messages in memory. It is meant for tests and
+// local development.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+// NewMemoryMailer creates a new in-memory mailer
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+// Send records a message
+func (m *MemoryMailer) Send(ctx context.Context, msg *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	copied := *msg
+	m.messages = append(m.messages, &copied)
+	return nil
+}
+
+// Messages returns the messages sent so far
+func (m *MemoryMailer) Messages() []*Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := make([]*Message, len(m.messages))
+	copy(messages, m.messages)
+	return messages
+}
+
+// Reset discards recorded messages
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
+
+// FileMailer writes each message as an .eml file that can be opened in any
+// mail client. It is meant for local development.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer creates a file mailer writing to dir
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("mail file directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+// Send writes a message to the mail directory
+func (m *FileMailer) Send(ctx context.Context, msg *Message) error {
+	data, err := buildMIME(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s.eml", time.Now().UTC().Format("20060102T150405.000000000"))
+	if err := os.WriteFile(filepath.Join(m.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	return nil
+}
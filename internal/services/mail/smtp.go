@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+
+	"go-mobile-backend-template/pkg/config"
+)
+
+// SMTPMailer delivers messages through an SMTP server. STARTTLS is used
+// whenever the server offers it.
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPMailer creates a new SMTP mailer
+func NewSMTPMailer(cfg config.SMTP, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	return &SMTPMailer{
+		addr: net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		auth: auth,
+		from: from,
+	}
+}
+
+// Send delivers a message
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	sender, err := mail.ParseAddress(m.from)
+	if err != nil {
+		return fmt.Errorf("invalid from address: %w", err)
+	}
+
+	data, err := buildMIME(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	if err := smtp.SendMail(m.addr, m.auth, sender.Address, []string{msg.To}, data); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
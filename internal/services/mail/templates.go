@@ -0,0 +1,58 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.html templates/*.txt
+var templateFS embed.FS
+
+// Template names
+const (
+	TemplatePasswordReset     = "password_reset"
+	TemplateEmailVerification = "email_verification"
+)
+
+// Renderer renders the HTML and text variants of email templates
+type Renderer struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// NewRenderer parses the embedded email templates
+func NewRenderer() (*Renderer, error) {
+	html, err := htmltemplate.ParseFS(templateFS, "templates/*.html")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse html email templates: %w", err)
+	}
+
+	text, err := texttemplate.ParseFS(templateFS, "templates/*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse text email templates: %w", err)
+	}
+
+	return &Renderer{html: html, text: text}, nil
+}
+
+// Render builds a message from the named template
+func (r *Renderer) Render(name, to, subject string, data interface{}) (*Message, error) {
+	var html, text bytes.Buffer
+
+	if err := r.html.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s html template: %w", name, err)
+	}
+	if err := r.text.ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s text template: %w", name, err)
+	}
+
+	return &Message{
+		To:      to,
+		Subject: subject,
+		HTML:    html.String(),
+		Text:    text.String(),
+	}, nil
+}
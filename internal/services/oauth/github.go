@@ -0,0 +1,128 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+
+	"go-mobile-backend-template/pkg/config"
+)
+
+// githubAPIURL is the base URL of the GitHub REST API
+const githubAPIURL = "https://api.github.com"
+
+// GitHubProvider implements Provider for GitHub OAuth apps. GitHub does not
+// issue ID tokens, so the identity is read from the REST API.
+type GitHubProvider struct {
+	name   string
+	config *oauth2.Config
+}
+
+// NewGitHubProvider creates a GitHub provider
+func NewGitHubProvider(name string, cfg config.OAuthProviderConfig) *GitHubProvider {
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"read:user", "user:email"}
+	}
+
+	return &GitHubProvider{
+		name: name,
+		config: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     github.Endpoint,
+			Scopes:       scopes,
+		},
+	}
+}
+
+// Name returns the configured provider name
+func (p *GitHubProvider) Name() string {
+	return p.name
+}
+
+// AuthCodeURL returns the authorization URL with PKCE parameters
+func (p *GitHubProvider) AuthCodeURL(ctx context.Context, req *AuthRequest) (string, error) {
+	return p.config.AuthCodeURL(req.State, oauth2.S256ChallengeOption(req.CodeVerifier)), nil
+}
+
+// Exchange trades the code for an access token and fetches the user profile
+// and primary verified email
+func (p *GitHubProvider) Exchange(ctx context.Context, code string, req *AuthRequest) (*Identity, error) {
+	token, err := p.config.Exchange(ctx, code, oauth2.VerifierOption(req.CodeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	client := p.config.Client(ctx, token)
+
+	var profile map[string]interface{}
+	if err := getJSON(ctx, client, githubAPIURL+"/user", &profile); err != nil {
+		return nil, fmt.Errorf("failed to fetch github user: %w", err)
+	}
+
+	id, ok := profile["id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("github user response did not include an id")
+	}
+
+	name, _ := profile["name"].(string)
+	if name == "" {
+		name, _ = profile["login"].(string)
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, client, githubAPIURL+"/user/emails", &emails); err != nil {
+		return nil, fmt.Errorf("failed to fetch github emails: %w", err)
+	}
+
+	identity := &Identity{
+		ProviderUserID: strconv.FormatInt(int64(id), 10),
+		Name:           name,
+		Profile:        profile,
+		AccessToken:    token.AccessToken,
+		RefreshToken:   token.RefreshToken,
+		Expiry:         token.Expiry,
+	}
+
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = email.Email
+			identity.EmailVerified = email.Verified
+			break
+		}
+	}
+
+	return identity, nil
+}
+
+// getJSON performs an authenticated GET request and decodes the JSON response
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,149 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"go-mobile-backend-template/pkg/config"
+)
+
+// googleIssuerURL is Google's OpenID Connect issuer
+const googleIssuerURL = "https://accounts.google.com"
+
+// OIDCProvider implements Provider for any OpenID Connect issuer.
+// Discovery is performed on first use so an unreachable issuer does not
+// prevent the server from starting.
+type OIDCProvider struct {
+	name         string
+	issuerURL    string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDCProvider creates a generic OpenID Connect provider
+func NewOIDCProvider(name string, cfg config.OAuthProviderConfig) *OIDCProvider {
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+
+	return &OIDCProvider{
+		name:         name,
+		issuerURL:    cfg.IssuerURL,
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		redirectURL:  cfg.RedirectURL,
+		scopes:       scopes,
+	}
+}
+
+// NewGoogleProvider creates a Google provider, which is an OIDC provider
+// with a fixed issuer
+func NewGoogleProvider(name string, cfg config.OAuthProviderConfig) *OIDCProvider {
+	cfg.IssuerURL = googleIssuerURL
+	return NewOIDCProvider(name, cfg)
+}
+
+// Name returns the configured provider name
+func (p *OIDCProvider) Name() string {
+	return p.name
+}
+
+// AuthCodeURL returns the authorization URL with PKCE and nonce parameters
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, req *AuthRequest) (string, error) {
+	oauthConfig, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return oauthConfig.AuthCodeURL(req.State,
+		oauth2.S256ChallengeOption(req.CodeVerifier),
+		oidc.Nonce(req.Nonce),
+	), nil
+}
+
+// Exchange trades the code for tokens and verifies the returned ID token
+func (p *OIDCProvider) Exchange(ctx context.Context, code string, req *AuthRequest) (*Identity, error) {
+	oauthConfig, verifier, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(req.CodeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, fmt.Errorf("token response did not include an id_token")
+	}
+
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify id_token: %w", err)
+	}
+
+	if idToken.Nonce != req.Nonce {
+		return nil, fmt.Errorf("id_token nonce mismatch")
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to parse id_token claims: %w", err)
+	}
+
+	var profile map[string]interface{}
+	if err := idToken.Claims(&profile); err != nil {
+		return nil, fmt.Errorf("failed to parse id_token claims: %w", err)
+	}
+
+	return &Identity{
+		ProviderUserID: idToken.Subject,
+		Email:          claims.Email,
+		EmailVerified:  claims.EmailVerified,
+		Name:           claims.Name,
+		Profile:        profile,
+		AccessToken:    token.AccessToken,
+		RefreshToken:   token.RefreshToken,
+		Expiry:         token.Expiry,
+	}, nil
+}
+
+// discover loads the issuer metadata once and builds the OAuth2 config and
+// ID token verifier from it. Failed discovery is retried on the next call.
+func (p *OIDCProvider) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider == nil {
+		provider, err := oidc.NewProvider(ctx, p.issuerURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to discover oidc issuer: %w", err)
+		}
+		p.provider = provider
+		p.verifier = provider.Verifier(&oidc.Config{ClientID: p.clientID})
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.clientID,
+		ClientSecret: p.clientSecret,
+		RedirectURL:  p.redirectURL,
+		Endpoint:     p.provider.Endpoint(),
+		Scopes:       p.scopes,
+	}, p.verifier, nil
+}
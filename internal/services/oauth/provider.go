@@ -0,0 +1,152 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go-mobile-backend-template/pkg/config"
+)
+
+// Provider types supported in configuration
+const (
+	ProviderTypeGoogle = "google"
+	ProviderTypeGitHub = "github"
+	ProviderTypeOIDC   = "oidc"
+)
+
+// OAuth errors
+var (
+	ErrUnknownProvider = errors.New("unknown oauth provider")
+	ErrInvalidState    = errors.New("invalid or expired oauth state")
+	ErrEmailRequired   = errors.New("provider did not return a verified email address")
+	ErrAlreadyLinked   = errors.New("identity is already linked to another account")
+	ErrAccountExists   = errors.New("an account with this email already exists, sign in and link the provider from it")
+	ErrLastLoginMethod = errors.New("cannot unlink the only way to sign in to this account")
+)
+
+// Identity is the user profile returned by a provider after a successful
+// authorization code exchange
+type Identity struct {
+	ProviderUserID string
+	Email          string
+	EmailVerified  bool
+	Name           string
+	Profile        map[string]interface{}
+	AccessToken    string
+	RefreshToken   string
+	Expiry         time.Time
+}
+
+// AuthRequest carries the per-login values that must survive the round trip
+// through the provider. Binding stays with the client that started the flow
+// and is never sent to the provider.
+type AuthRequest struct {
+	State        string
+	Binding      string
+	CodeVerifier string
+	Nonce        string
+}
+
+// Provider is implemented by every social login provider
+type Provider interface {
+	// Name returns the configured provider name used in routes
+	Name() string
+	// AuthCodeURL returns the URL that starts the authorization code flow
+	AuthCodeURL(ctx context.Context, req *AuthRequest) (string, error)
+	// Exchange trades an authorization code for the user's identity
+	Exchange(ctx context.Context, code string, req *AuthRequest) (*Identity, error)
+}
+
+// Registry holds the configured providers by name
+type Registry struct {
+	providers map[string]Provider
+	autoLink  map[string]bool
+}
+
+// NewRegistry creates providers for every entry in the OAuth configuration
+func NewRegistry(cfg config.OAuth) (*Registry, error) {
+	registry := &Registry{
+		providers: make(map[string]Provider),
+		autoLink:  make(map[string]bool),
+	}
+
+	for name, providerCfg := range cfg.Providers {
+		provider, err := newProvider(name, providerCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure oauth provider %s: %w", name, err)
+		}
+		registry.Register(provider)
+		registry.autoLink[strings.ToLower(name)] = providerCfg.AutoLinkByEmail
+	}
+
+	return registry, nil
+}
+
+// Register adds a provider to the registry, replacing one with the same name
+func (r *Registry) Register(provider Provider) {
+	r.providers[strings.ToLower(provider.Name())] = provider
+}
+
+// Get returns the provider with the given name
+func (r *Registry) Get(name string) (Provider, error) {
+	provider, ok := r.providers[strings.ToLower(name)]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+// AutoLinkByEmail reports whether sign-ins with the provider may attach to an
+// existing account with the same verified email. It is off unless enabled
+// in configuration, because it trusts the provider with the account.
+func (r *Registry) AutoLinkByEmail(name string) bool {
+	return r.autoLink[strings.ToLower(name)]
+}
+
+// SetAutoLinkByEmail enables or disables linking by email for a provider
+func (r *Registry) SetAutoLinkByEmail(name string, enabled bool) {
+	r.autoLink[strings.ToLower(name)] = enabled
+}
+
+// Names returns the names of all configured providers
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newProvider builds a provider from its configuration
+func newProvider(name string, cfg config.OAuthProviderConfig) (Provider, error) {
+	if cfg.ClientID == "" {
+		return nil, fmt.Errorf("client_id is required")
+	}
+	if cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("redirect_url is required")
+	}
+
+	providerType := cfg.Type
+	if providerType == "" {
+		providerType = name
+	}
+
+	switch strings.ToLower(providerType) {
+	case ProviderTypeGoogle:
+		return NewGoogleProvider(name, cfg), nil
+	case ProviderTypeGitHub:
+		return NewGitHubProvider(name, cfg), nil
+	case ProviderTypeOIDC:
+		if cfg.IssuerURL == "" {
+			return nil, fmt.Errorf("issuer_url is required for oidc providers")
+		}
+		return NewOIDCProvider(name, cfg), nil
+	default:
+		return nil, fmt.Errorf("unsupported provider type: %s", providerType)
+	}
+}
@@ -0,0 +1,263 @@
+package oauth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go-mobile-backend-template/internal/db/repository"
+	"go-mobile-backend-template/internal/services/auth"
+)
+
+// passwordSetKey marks users created through social login in users.metadata.
+// Their random password is unknown to them, so it does not count as a way
+// to sign in when unlinking providers.
+const passwordSetKey = "password_set"
+
+// CallbackResult describes the outcome of a completed provider flow
+type CallbackResult struct {
+	User    *repository.User
+	Link    *repository.OAuthProvider
+	Created bool // a new user was created just in time
+	Linked  bool // the flow was started by a logged-in user linking a provider
+}
+
+// Service runs social login flows and manages linked identities
+type Service struct {
+	registry *Registry
+	state    *StateCodec
+	users    repository.UserRepository
+	links    repository.OAuthProviderRepository
+}
+
+// NewService creates a new OAuth service
+func NewService(registry *Registry, state *StateCodec, users repository.UserRepository, links repository.OAuthProviderRepository) *Service {
+	return &Service{
+		registry: registry,
+		state:    state,
+		users:    users,
+		links:    links,
+	}
+}
+
+// Providers returns the names of the configured providers
+func (s *Service) Providers() []string {
+	return s.registry.Names()
+}
+
+// Start returns the provider authorization URL for a new flow and the
+// binding secret that must be presented with the callback. linkUserID is
+// non-zero when a logged-in user links an additional provider.
+func (s *Service) Start(ctx context.Context, providerName string, linkUserID uint) (string, string, error) {
+	provider, err := s.registry.Get(providerName)
+	if err != nil {
+		return "", "", err
+	}
+
+	req, err := s.state.NewAuthRequest(provider.Name(), linkUserID)
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, req)
+	if err != nil {
+		return "", "", err
+	}
+
+	return authURL, req.Binding, nil
+}
+
+// Complete finishes a flow with the code and state returned by the provider
+// and the binding returned by Start. Sign-in flows resolve or create the
+// user; link flows attach the identity to the user that started the flow.
+func (s *Service) Complete(ctx context.Context, providerName, code, state, binding string) (*CallbackResult, error) {
+	provider, err := s.registry.Get(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	flow, req, err := s.state.Open(ctx, provider.Name(), state, binding)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := provider.Exchange(ctx, code, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if flow.LinkUserID != 0 {
+		return s.link(ctx, flow.LinkUserID, provider.Name(), identity)
+	}
+	return s.signIn(ctx, provider.Name(), identity)
+}
+
+// Links returns the providers linked to a user
+func (s *Service) Links(ctx context.Context, userID uint) ([]*repository.OAuthProvider, error) {
+	return s.links.GetByUserID(ctx, userID)
+}
+
+// Unlink removes a provider from a user, refusing to remove the last way
+// the user can sign in
+func (s *Service) Unlink(ctx context.Context, userID uint, providerName string) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	links, err := s.links.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	remaining := 0
+	for _, link := range links {
+		if !strings.EqualFold(link.Provider, providerName) {
+			remaining++
+		}
+	}
+
+	if remaining == 0 && !hasPassword(user) {
+		return ErrLastLoginMethod
+	}
+
+	return s.links.Delete(ctx, userID, providerName)
+}
+
+// signIn resolves the user for a provider identity. Existing links win;
+// otherwise a new user is created. A verified email matching an existing
+// account only links to it when the provider has auto-linking enabled;
+// without it the user must sign in and link the provider themselves.
+func (s *Service) signIn(ctx context.Context, providerName string, identity *Identity) (*CallbackResult, error) {
+	if link, err := s.links.GetByProviderUserID(ctx, providerName, identity.ProviderUserID); err == nil {
+		user, err := s.users.GetByID(ctx, link.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		applyIdentity(link, identity)
+		if err := s.links.Update(ctx, link); err != nil {
+			return nil, err
+		}
+
+		return &CallbackResult{User: user, Link: link}, nil
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, ErrEmailRequired
+	}
+
+	result := &CallbackResult{}
+	user, err := s.users.GetByEmail(ctx, identity.Email)
+	if err == nil && !s.registry.AutoLinkByEmail(providerName) {
+		return nil, ErrAccountExists
+	}
+	if err != nil {
+		user, err = s.createUser(ctx, identity)
+		if err != nil {
+			return nil, err
+		}
+		result.Created = true
+	}
+
+	link := &repository.OAuthProvider{
+		UserID:         user.ID,
+		Provider:       providerName,
+		ProviderUserID: identity.ProviderUserID,
+	}
+	applyIdentity(link, identity)
+	if err := s.links.Create(ctx, link); err != nil {
+		return nil, err
+	}
+
+	result.User = user
+	result.Link = link
+	return result, nil
+}
+
+// link attaches a provider identity to an existing user
+func (s *Service) link(ctx context.Context, userID uint, providerName string, identity *Identity) (*CallbackResult, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := s.links.GetByProviderUserID(ctx, providerName, identity.ProviderUserID)
+	if err == nil {
+		if link.UserID != userID {
+			return nil, ErrAlreadyLinked
+		}
+
+		applyIdentity(link, identity)
+		if err := s.links.Update(ctx, link); err != nil {
+			return nil, err
+		}
+		return &CallbackResult{User: user, Link: link, Linked: true}, nil
+	}
+
+	link = &repository.OAuthProvider{
+		UserID:         userID,
+		Provider:       providerName,
+		ProviderUserID: identity.ProviderUserID,
+	}
+	applyIdentity(link, identity)
+	if err := s.links.Create(ctx, link); err != nil {
+		return nil, err
+	}
+
+	return &CallbackResult{User: user, Link: link, Linked: true}, nil
+}
+
+// createUser creates a user just in time for a first social login. The user
+// gets an unusable random password until they set one.
+func (s *Service) createUser(ctx context.Context, identity *Identity) (*repository.User, error) {
+	password, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	name := identity.Name
+	if name == "" {
+		name = strings.Split(identity.Email, "@")[0]
+	}
+
+	now := time.Now()
+	user := &repository.User{
+		Email:           identity.Email,
+		Password:        hashedPassword,
+		Name:            name,
+		IsActive:        true,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+		Metadata:        repository.JSONB{passwordSetKey: false},
+	}
+
+	if err := s.users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// applyIdentity copies the latest tokens and profile onto a link
+func applyIdentity(link *repository.OAuthProvider, identity *Identity) {
+	link.AccessToken = identity.AccessToken
+	link.RefreshToken = identity.RefreshToken
+	link.ProfileData = repository.JSONB(identity.Profile)
+	link.TokenExpiresAt = nil
+	if !identity.Expiry.IsZero() {
+		expiry := identity.Expiry
+		link.TokenExpiresAt = &expiry
+	}
+}
+
+// hasPassword reports whether the user knows their password
+func hasPassword(user *repository.User) bool {
+	set, ok := user.Metadata[passwordSetKey].(bool)
+	return !ok || set
+}
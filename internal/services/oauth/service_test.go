@@ -0,0 +1,620 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"go-mobile-backend-template/internal/db/repository"
+	"go-mobile-backend-template/internal/services/auth"
+	"go-mobile-backend-template/pkg/config"
+)
+
+const (
+	testClientID    = "client"
+	testRedirectURL = "http://localhost/api/v1/auth/oauth/fake/callback"
+	testKeyID       = "test-key"
+)
+
+// fakeIdentity is the account a user signs in to at the fake issuer
+type fakeIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// fakeGrant is an authorization code issued by the fake issuer
+type fakeGrant struct {
+	identity  fakeIdentity
+	challenge string
+	nonce     string
+}
+
+// fakeIssuer is an OpenID Connect issuer serving discovery, JWKS and the
+// token endpoint. Authorize stands in for the user approving the request
+// at the provider.
+type fakeIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	grants map[string]fakeGrant
+	nextID int
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	issuer := &fakeIssuer{t: t, key: key, grants: make(map[string]fakeGrant)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.discovery)
+	mux.HandleFunc("/jwks", issuer.jwks)
+	mux.HandleFunc("/token", issuer.token)
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+
+	return issuer
+}
+
+func (f *fakeIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                f.server.URL,
+		"authorization_endpoint":                f.server.URL + "/authorize",
+		"token_endpoint":                        f.server.URL + "/token",
+		"jwks_uri":                              f.server.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (f *fakeIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": testKeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(f.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(f.key.E)).Bytes()),
+		}},
+	})
+}
+
+// token redeems a code once, checking the PKCE verifier against the
+// challenge sent to the authorization endpoint
+func (f *fakeIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	f.mu.Lock()
+	grant, ok := f.grants[r.PostForm.Get("code")]
+	delete(f.grants, r.PostForm.Get("code"))
+	f.mu.Unlock()
+
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            f.server.URL,
+		"aud":            testClientID,
+		"sub":            grant.identity.Subject,
+		"email":          grant.identity.Email,
+		"email_verified": grant.identity.EmailVerified,
+		"nonce":          grant.nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	})
+	idToken.Header["kid"] = testKeyID
+	signed, err := idToken.SignedString(f.key)
+	if err != nil {
+		f.t.Errorf("SignedString() error = %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "access-" + grant.identity.Subject,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     signed,
+	})
+}
+
+// Authorize approves the authorization request at authURL for identity and
+// returns the code and state the provider redirects back with
+func (f *fakeIssuer) Authorize(authURL string, identity fakeIdentity) (string, string) {
+	f.t.Helper()
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		f.t.Fatalf("url.Parse() error = %v", err)
+	}
+
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		f.t.Fatalf("authorization URL without a PKCE challenge: %s", authURL)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	code := "code-" + string(rune('a'+f.nextID))
+	f.grants[code] = fakeGrant{
+		identity:  identity,
+		challenge: query.Get("code_challenge"),
+		nonce:     query.Get("nonce"),
+	}
+
+	return code, query.Get("state")
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// memoryUserRepository implements the UserRepository methods used by the
+// service
+type memoryUserRepository struct {
+	repository.UserRepository
+
+	mu     sync.Mutex
+	users  map[uint]*repository.User
+	nextID uint
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *repository.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	user.ID = r.nextID
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *memoryUserRepository) GetByID(ctx context.Context, id uint) (*repository.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (r *memoryUserRepository) GetByEmail(ctx context.Context, email string) (*repository.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+// memoryLinkRepository is an in-memory OAuthProviderRepository
+type memoryLinkRepository struct {
+	mu     sync.Mutex
+	links  []*repository.OAuthProvider
+	nextID uint
+}
+
+func (r *memoryLinkRepository) Create(ctx context.Context, link *repository.OAuthProvider) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	link.ID = r.nextID
+	r.links = append(r.links, link)
+	return nil
+}
+
+func (r *memoryLinkRepository) GetByProviderUserID(ctx context.Context, provider, providerUserID string) (*repository.OAuthProvider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, link := range r.links {
+		if link.Provider == provider && link.ProviderUserID == providerUserID {
+			return link, nil
+		}
+	}
+	return nil, errors.New("link not found")
+}
+
+func (r *memoryLinkRepository) GetByUserID(ctx context.Context, userID uint) ([]*repository.OAuthProvider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var links []*repository.OAuthProvider
+	for _, link := range r.links {
+		if link.UserID == userID {
+			links = append(links, link)
+		}
+	}
+	return links, nil
+}
+
+func (r *memoryLinkRepository) Update(ctx context.Context, link *repository.OAuthProvider) error {
+	return nil
+}
+
+func (r *memoryLinkRepository) Delete(ctx context.Context, userID uint, provider string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.links[:0]
+	for _, link := range r.links {
+		if link.UserID != userID || !strings.EqualFold(link.Provider, provider) {
+			kept = append(kept, link)
+		}
+	}
+	r.links = kept
+	return nil
+}
+
+// oauthTest wires a Service to a fake issuer registered as provider "fake"
+type oauthTest struct {
+	issuer   *fakeIssuer
+	registry *Registry
+	service  *Service
+	users    *memoryUserRepository
+	links    *memoryLinkRepository
+}
+
+func newOAuthTest(t *testing.T) *oauthTest {
+	t.Helper()
+	issuer := newFakeIssuer(t)
+
+	registry, err := NewRegistry(config.OAuth{})
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	registry.Register(NewOIDCProvider("fake", config.OAuthProviderConfig{
+		ClientID:     testClientID,
+		ClientSecret: "secret",
+		RedirectURL:  testRedirectURL,
+		IssuerURL:    issuer.server.URL,
+	}))
+
+	state, err := NewStateCodec("jwt-secret", 10*time.Minute, auth.NewTokenDenylist(nil))
+	if err != nil {
+		t.Fatalf("NewStateCodec() error = %v", err)
+	}
+
+	users := &memoryUserRepository{users: make(map[uint]*repository.User)}
+	links := &memoryLinkRepository{}
+
+	return &oauthTest{
+		issuer:   issuer,
+		registry: registry,
+		service:  NewService(registry, state, users, links),
+		users:    users,
+		links:    links,
+	}
+}
+
+// signIn runs a full flow for identity, started for linkUserID
+func (o *oauthTest) signIn(t *testing.T, identity fakeIdentity, linkUserID uint) (*CallbackResult, error) {
+	t.Helper()
+	ctx := context.Background()
+	authURL, binding, err := o.service.Start(ctx, "fake", linkUserID)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	code, state := o.issuer.Authorize(authURL, identity)
+	return o.service.Complete(ctx, "fake", code, state, binding)
+}
+
+// addUser creates an existing account that knows its password
+func (o *oauthTest) addUser(t *testing.T, email string) *repository.User {
+	t.Helper()
+	user := &repository.User{Email: email, Name: "Existing", IsActive: true}
+	if err := o.users.Create(context.Background(), user); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	return user
+}
+
+func TestOAuthStart(t *testing.T) {
+	o := newOAuthTest(t)
+	authURL, binding, err := o.service.Start(context.Background(), "fake", 0)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if binding == "" {
+		t.Fatal("Start() returned an empty binding")
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	query := parsed.Query()
+
+	if !strings.HasPrefix(authURL, o.issuer.server.URL+"/authorize?") {
+		t.Errorf("authorization URL = %s, want the issuer's authorization endpoint", authURL)
+	}
+	for name, want := range map[string]string{
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"response_type":         "code",
+		"code_challenge_method": "S256",
+	} {
+		if got := query.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	for _, name := range []string{"state", "code_challenge", "nonce"} {
+		if query.Get(name) == "" {
+			t.Errorf("authorization URL is missing %s", name)
+		}
+	}
+	if strings.Contains(authURL, binding) {
+		t.Error("authorization URL contains the binding")
+	}
+
+	if _, _, err := o.service.Start(context.Background(), "unknown", 0); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("Start() of an unknown provider error = %v, want %v", err, ErrUnknownProvider)
+	}
+}
+
+func TestOAuthCallbackSignIn(t *testing.T) {
+	o := newOAuthTest(t)
+	identity := fakeIdentity{Subject: "alice", Email: "alice@example.com", EmailVerified: true}
+
+	first, err := o.signIn(t, identity, 0)
+	if err != nil {
+		t.Fatalf("first sign-in error = %v", err)
+	}
+	if !first.Created || first.Linked || first.User.Email != identity.Email || hasPassword(first.User) {
+		t.Fatalf("first sign-in result = %+v, want a new user without a password", first)
+	}
+	if first.Link.ProviderUserID != "alice" || first.Link.AccessToken != "access-alice" {
+		t.Errorf("link = %+v, want the provider identity and tokens", first.Link)
+	}
+
+	second, err := o.signIn(t, identity, 0)
+	if err != nil {
+		t.Fatalf("second sign-in error = %v", err)
+	}
+	if second.Created || second.User.ID != first.User.ID {
+		t.Errorf("second sign-in result = %+v, want the existing user %d", second, first.User.ID)
+	}
+
+	unverified := fakeIdentity{Subject: "bob", Email: "bob@example.com"}
+	if _, err := o.signIn(t, unverified, 0); !errors.Is(err, ErrEmailRequired) {
+		t.Errorf("sign-in with an unverified email error = %v, want %v", err, ErrEmailRequired)
+	}
+}
+
+func TestOAuthCallbackState(t *testing.T) {
+	identity := fakeIdentity{Subject: "alice", Email: "alice@example.com", EmailVerified: true}
+
+	tests := []struct {
+		name     string
+		complete func(o *oauthTest, code, state, binding string) error
+	}{
+		{
+			name: "missing binding",
+			complete: func(o *oauthTest, code, state, binding string) error {
+				_, err := o.service.Complete(context.Background(), "fake", code, state, "")
+				return err
+			},
+		},
+		{
+			name: "another flow's binding",
+			complete: func(o *oauthTest, code, state, binding string) error {
+				_, other, err := o.service.Start(context.Background(), "fake", 0)
+				if err != nil {
+					return err
+				}
+				_, err = o.service.Complete(context.Background(), "fake", code, state, other)
+				return err
+			},
+		},
+		{
+			name: "tampered state",
+			complete: func(o *oauthTest, code, state, binding string) error {
+				_, err := o.service.Complete(context.Background(), "fake", code, state[:len(state)-2]+"AA", binding)
+				return err
+			},
+		},
+		{
+			name: "state reused",
+			complete: func(o *oauthTest, code, state, binding string) error {
+				if _, err := o.service.Complete(context.Background(), "fake", code, state, binding); err != nil {
+					return err
+				}
+				_, err := o.service.Complete(context.Background(), "fake", code, state, binding)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOAuthTest(t)
+			authURL, binding, err := o.service.Start(context.Background(), "fake", 0)
+			if err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			code, state := o.issuer.Authorize(authURL, identity)
+
+			if err := tt.complete(o, code, state, binding); !errors.Is(err, ErrInvalidState) {
+				t.Fatalf("Complete() error = %v, want %v", err, ErrInvalidState)
+			}
+		})
+	}
+}
+
+func TestOAuthCallbackPKCE(t *testing.T) {
+	o := newOAuthTest(t)
+	ctx := context.Background()
+	identity := fakeIdentity{Subject: "alice", Email: "alice@example.com", EmailVerified: true}
+
+	// A code issued for one flow's challenge cannot be redeemed with another
+	// flow's verifier
+	firstURL, _, err := o.service.Start(ctx, "fake", 0)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	secondURL, secondBinding, err := o.service.Start(ctx, "fake", 0)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	code, _ := o.issuer.Authorize(firstURL, identity)
+	_, secondState := o.issuer.Authorize(secondURL, identity)
+
+	_, err = o.service.Complete(ctx, "fake", code, secondState, secondBinding)
+	if err == nil || !strings.Contains(err.Error(), "exchange") {
+		t.Fatalf("Complete() with a mismatched verifier error = %v, want an exchange failure", err)
+	}
+	if len(o.links.links) != 0 {
+		t.Errorf("%d links created by a failed exchange", len(o.links.links))
+	}
+}
+
+func TestOAuthAutoLinkByEmail(t *testing.T) {
+	identity := fakeIdentity{Subject: "alice", Email: "alice@example.com", EmailVerified: true}
+
+	tests := []struct {
+		name     string
+		autoLink bool
+		wantErr  error
+	}{
+		{name: "disabled by default", wantErr: ErrAccountExists},
+		{name: "enabled for the provider", autoLink: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOAuthTest(t)
+			existing := o.addUser(t, identity.Email)
+			if tt.autoLink {
+				o.registry.SetAutoLinkByEmail("fake", true)
+			}
+
+			result, err := o.signIn(t, identity, 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("sign-in error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(o.links.links) != 0 {
+					t.Errorf("%d links created without auto-linking", len(o.links.links))
+				}
+				return
+			}
+			if result.Created || result.User.ID != existing.ID {
+				t.Errorf("sign-in result = %+v, want the existing user %d", result, existing.ID)
+			}
+		})
+	}
+}
+
+func TestOAuthLink(t *testing.T) {
+	o := newOAuthTest(t)
+	user := o.addUser(t, "alice@example.com")
+
+	// Linking does not depend on the provider email
+	identity := fakeIdentity{Subject: "alice-at-provider", Email: "other@example.com"}
+	result, err := o.signIn(t, identity, user.ID)
+	if err != nil {
+		t.Fatalf("link error = %v", err)
+	}
+	if !result.Linked || result.User.ID != user.ID || result.Link.UserID != user.ID {
+		t.Fatalf("link result = %+v, want a link to user %d", result, user.ID)
+	}
+
+	// Signing in with the linked identity resolves the same user
+	signedIn, err := o.signIn(t, identity, 0)
+	if err != nil {
+		t.Fatalf("sign-in error = %v", err)
+	}
+	if signedIn.User.ID != user.ID {
+		t.Errorf("sign-in user = %d, want %d", signedIn.User.ID, user.ID)
+	}
+
+	// The identity cannot be linked to a second account
+	other := o.addUser(t, "mallory@example.com")
+	if _, err := o.signIn(t, identity, other.ID); !errors.Is(err, ErrAlreadyLinked) {
+		t.Errorf("link to another account error = %v, want %v", err, ErrAlreadyLinked)
+	}
+}
+
+func TestOAuthUnlink(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		password bool
+		wantErr  error
+	}{
+		{name: "account with a password", password: true},
+		{name: "only sign-in method", wantErr: ErrLastLoginMethod},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOAuthTest(t)
+			identity := fakeIdentity{Subject: "alice", Email: "alice@example.com", EmailVerified: true}
+
+			var userID uint
+			if tt.password {
+				userID = o.addUser(t, identity.Email).ID
+				if _, err := o.signIn(t, identity, userID); err != nil {
+					t.Fatalf("link error = %v", err)
+				}
+			} else {
+				result, err := o.signIn(t, identity, 0)
+				if err != nil {
+					t.Fatalf("sign-in error = %v", err)
+				}
+				userID = result.User.ID
+			}
+
+			err := o.service.Unlink(ctx, userID, "fake")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Unlink() error = %v, want %v", err, tt.wantErr)
+			}
+
+			links, _ := o.service.Links(ctx, userID)
+			wantLinks := 1
+			if tt.wantErr == nil {
+				wantLinks = 0
+			}
+			if len(links) != wantLinks {
+				t.Errorf("%d links after Unlink(), want %d", len(links), wantLinks)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package oauth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"go-mobile-backend-template/internal/services/auth"
+)
+
+// stateKeyContext separates the state encryption key from other uses of the
+// JWT secret
+const stateKeyContext = "oauth-state:"
+
+// FlowState is everything the callback needs to finish a flow started by
+// this server. Binding is the hash of a secret held by the client that
+// started the flow, so a callback cannot be completed by anyone else.
+type FlowState struct {
+	ID           string    `json:"i"`
+	Provider     string    `json:"p"`
+	Binding      string    `json:"b"`
+	CodeVerifier string    `json:"v"`
+	Nonce        string    `json:"n"`
+	LinkUserID   uint      `json:"u,omitempty"`
+	ExpiresAt    time.Time `json:"e"`
+}
+
+// StateCodec seals flow state into the OAuth state parameter. The state is
+// encrypted rather than only signed because it carries the PKCE verifier,
+// which must not be visible to the provider or in browser history. Opened
+// states are denylisted, so each can complete one flow.
+type StateCodec struct {
+	aead     cipher.AEAD
+	expire   time.Duration
+	denylist *auth.TokenDenylist
+}
+
+// NewStateCodec creates a state codec keyed from secret that records used
+// states in denylist
+func NewStateCodec(secret string, expire time.Duration, denylist *auth.TokenDenylist) (*StateCodec, error) {
+	key := sha256.Sum256([]byte(stateKeyContext + secret))
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state cipher: %w", err)
+	}
+
+	return &StateCodec{aead: aead, expire: expire, denylist: denylist}, nil
+}
+
+// NewAuthRequest starts a new flow for provider. linkUserID is set when a
+// logged-in user is linking an additional provider. The returned request's
+// Binding must be presented again to open the state.
+func (s *StateCodec) NewAuthRequest(provider string, linkUserID uint) (*AuthRequest, error) {
+	binding := oauth2.GenerateVerifier()
+	flow := &FlowState{
+		ID:           oauth2.GenerateVerifier(),
+		Provider:     provider,
+		Binding:      hashBinding(binding),
+		CodeVerifier: oauth2.GenerateVerifier(),
+		Nonce:        oauth2.GenerateVerifier(),
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(s.expire),
+	}
+
+	state, err := s.seal(flow)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthRequest{
+		State:        state,
+		Binding:      binding,
+		CodeVerifier: flow.CodeVerifier,
+		Nonce:        flow.Nonce,
+	}, nil
+}
+
+// Open decrypts a state parameter and checks that it belongs to provider,
+// was started by the client presenting binding and has not expired. A state
+// can be opened once.
+func (s *StateCodec) Open(ctx context.Context, provider, state, binding string) (*FlowState, *AuthRequest, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(state)
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return nil, nil, ErrInvalidState
+	}
+
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, nil, ErrInvalidState
+	}
+
+	var flow FlowState
+	if err := json.Unmarshal(plaintext, &flow); err != nil {
+		return nil, nil, ErrInvalidState
+	}
+
+	if flow.Provider != provider || time.Now().After(flow.ExpiresAt) {
+		return nil, nil, ErrInvalidState
+	}
+
+	if binding == "" || subtle.ConstantTimeCompare([]byte(hashBinding(binding)), []byte(flow.Binding)) != 1 {
+		return nil, nil, ErrInvalidState
+	}
+
+	consumed, err := s.denylist.Consume(ctx, stateKeyContext+flow.ID, flow.ExpiresAt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to consume state: %w", err)
+	}
+	if !consumed {
+		return nil, nil, ErrInvalidState
+	}
+
+	return &flow, &AuthRequest{
+		State:        state,
+		Binding:      binding,
+		CodeVerifier: flow.CodeVerifier,
+		Nonce:        flow.Nonce,
+	}, nil
+}
+
+// hashBinding hashes a flow binding secret for storage in the state
+func hashBinding(binding string) string {
+	sum := sha256.Sum256([]byte(binding))
+	return hex.EncodeToString(sum[:])
+}
+
+// seal encrypts flow state into a URL-safe string
+func (s *StateCodec) seal(flow *FlowState) (string, error) {
+	plaintext, err := json.Marshal(flow)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate state nonce: %w", err)
+	}
+
+	sealed := s.aead.Seal(nonce, nonce, plaintext, nil)
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
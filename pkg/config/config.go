@@ -15,8 +15,11 @@ type Config struct {
 	Database      Database      `mapstructure:"database"`
 	Redis         Redis         `mapstructure:"redis"`
 	JWT           JWT           `mapstructure:"jwt"`
+	Auth          Auth          `mapstructure:"auth"`
+	Mail          Mail          `mapstructure:"mail"`
 	R2            R2            `mapstructure:"r2"`
 	GoogleScripts GoogleScripts `mapstructure:"google_scripts"`
+	Realtime      Realtime      `mapstructure:"realtime"`
 	Logging       Logging       `mapstructure:"logging"`
 	Generator     interface{}   `mapstructure:"generator"`
 }
@@ -53,6 +56,81 @@ type JWT struct {
 	RefreshTokenExpire    time.Duration `mapstructure:"refresh_token_expire"`
 	AccessTokenExpireInt  int           `mapstructure:"access_token_expire_int"`
 	RefreshTokenExpireInt int           `mapstructure:"refresh_token_expire_int"`
+	Algorithm             string        `mapstructure:"algorithm"`         // HS256, RS256, ES256 or EdDSA
+	KeySource             string        `mapstructure:"key_source"`        // dir or db, for asymmetric algorithms
+	KeyDir                string        `mapstructure:"key_dir"`           // PEM key directory for the dir key source
+	KeyRotation           int           `mapstructure:"key_rotation"`      // hours a key signs before rotation, 0 disables
+	KeyRefresh            int           `mapstructure:"key_refresh"`       // minutes between key reloads
+	AcceptHS256           bool          `mapstructure:"accept_hs256"`      // keep accepting tokens signed with secret
+	Issuer                string        `mapstructure:"issuer"`            // iss claim of issued tokens
+	Audience              string        `mapstructure:"audience"`          // aud claim of issued tokens
+	EmbedPermissions      bool          `mapstructure:"embed_permissions"` // put roles and scopes in access tokens
+}
+
+// Auth configuration
+type Auth struct {
+	TOTPIssuer               string  `mapstructure:"totp_issuer"`
+	TwoFactorChallengeExpire int     `mapstructure:"two_factor_challenge_expire"` // minutes
+	PasswordResetExpire      int     `mapstructure:"password_reset_expire"`       // minutes
+	EmailVerificationExpire  int     `mapstructure:"email_verification_expire"`   // minutes
+	PasswordResetURL         string  `mapstructure:"password_reset_url"`
+	EmailVerificationURL     string  `mapstructure:"email_verification_url"`
+	APIKeyRateLimit          int     `mapstructure:"api_key_rate_limit"`   // requests per api_key_rate_window for new keys
+	APIKeyRateWindow         int     `mapstructure:"api_key_rate_window"`  // minutes
+	APIKeyUsageFlush         int     `mapstructure:"api_key_usage_flush"`  // seconds between last_used_at writes
+	PermissionCacheTTL       int     `mapstructure:"permission_cache_ttl"` // seconds, 0 disables caching
+	Lockout                  Lockout `mapstructure:"lockout"`
+	OAuth                    OAuth   `mapstructure:"oauth"`
+}
+
+// Lockout configuration for brute-force protection of logins. Locks start at
+// lock_duration and grow by backoff with each further failure, up to
+// max_lock_duration. A zero attempt limit disables that kind of lockout.
+type Lockout struct {
+	MaxAttempts     int     `mapstructure:"max_attempts"`      // failures before an account is locked
+	IPMaxAttempts   int     `mapstructure:"ip_max_attempts"`   // failures per IP within ip_window before it is blocked
+	IPWindow        int     `mapstructure:"ip_window"`         // minutes
+	LockDuration    int     `mapstructure:"lock_duration"`     // minutes
+	MaxLockDuration int     `mapstructure:"max_lock_duration"` // minutes
+	Backoff         float64 `mapstructure:"backoff"`
+}
+
+// OAuth configuration for social login
+type OAuth struct {
+	StateExpire int                            `mapstructure:"state_expire"` // minutes
+	Providers   map[string]OAuthProviderConfig `mapstructure:"providers"`
+}
+
+// OAuthProviderConfig configures one social login provider. Type is one of
+// google, github or oidc; IssuerURL is only used by generic OIDC providers.
+// AutoLinkByEmail lets a first sign-in attach to an existing account with the
+// same verified email; it is off by default.
+type OAuthProviderConfig struct {
+	Type            string   `mapstructure:"type"`
+	ClientID        string   `mapstructure:"client_id"`
+	ClientSecret    string   `mapstructure:"client_secret"`
+	RedirectURL     string   `mapstructure:"redirect_url"`
+	IssuerURL       string   `mapstructure:"issuer_url"`
+	Scopes          []string `mapstructure:"scopes"`
+	AutoLinkByEmail bool     `mapstructure:"auto_link_by_email"`
+}
+
+// Mail configuration. Driver is one of smtp, file or memory; the file and
+// memory sinks are for development and must be selected explicitly.
+type Mail struct {
+	Driver   string `mapstructure:"driver"`
+	From     string `mapstructure:"from"`
+	FromName string `mapstructure:"from_name"`
+	FileDir  string `mapstructure:"file_dir"`
+	SMTP     SMTP   `mapstructure:"smtp"`
+}
+
+// SMTP configuration
+type SMTP struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
 }
 
 // R2 (Cloudflare) configuration
@@ -72,6 +150,58 @@ type GoogleScripts struct {
 	MigrationsDir string `mapstructure:"migrations_dir"`
 }
 
+// Realtime configuration
+type Realtime struct {
+	Backplane        string            `mapstructure:"backplane"`         // memory or redis
+	PresenceTTL      int               `mapstructure:"presence_ttl"`      // seconds presence lasts without a refresh
+	MaxSubscriptions int               `mapstructure:"max_subscriptions"` // channels per client
+	QueueSize        int               `mapstructure:"queue_size"`        // messages queued per client
+	SlowConsumer     string            `mapstructure:"slow_consumer"`     // drop_oldest, disconnect or coalesce
+	AckTimeout       int               `mapstructure:"ack_timeout"`       // seconds before an unacknowledged message is resent
+	AckRetries       int               `mapstructure:"ack_retries"`       // resends before giving up, negative for none
+	RPCTimeout       int               `mapstructure:"rpc_timeout"`       // seconds an RPC call may take
+	HistorySize      int               `mapstructure:"history_size"`      // messages kept per channel
+	HistoryTTL       int               `mapstructure:"history_ttl"`       // hours the history of an idle channel is kept
+	PresenceChannels []string          `mapstructure:"presence_channels"` // members see each other's presence
+	Channels         []RealtimeChannel `mapstructure:"channels"`
+	DBStream         DBStream          `mapstructure:"db_stream"`
+}
+
+// DBStream configures the database change stream sent to db:* channels
+type DBStream struct {
+	Backend     string                     `mapstructure:"backend"` // notify or replication
+	Replication DBStreamReplication        `mapstructure:"replication"`
+	Columns     map[string]DBStreamColumns `mapstructure:"columns"` // per table
+}
+
+// DBStreamReplication configures the logical replication backend
+type DBStreamReplication struct {
+	Slot         string `mapstructure:"slot"`
+	Plugin       string `mapstructure:"plugin"` // pgoutput or wal2json
+	Publication  string `mapstructure:"publication"`
+	PollInterval int    `mapstructure:"poll_interval"` // milliseconds
+	BatchSize    int    `mapstructure:"batch_size"`
+}
+
+// DBStreamColumns limits the streamed columns of a table. With allow set
+// only those columns are sent; deny columns are never sent.
+type DBStreamColumns struct {
+	Allow []string `mapstructure:"allow"`
+	Deny  []string `mapstructure:"deny"`
+}
+
+// RealtimeChannel restricts the realtime channels matching pattern (a name,
+// or a prefix followed by "*") to users with the resource:action permission.
+// With owner_column, other users may subscribe but only receive database
+// rows whose owner_column is their user ID.
+type RealtimeChannel struct {
+	Pattern     string `mapstructure:"pattern"`
+	Resource    string `mapstructure:"resource"`
+	Action      string `mapstructure:"action"`
+	OwnerColumn string `mapstructure:"owner_column"`
+	ReadOnly    bool   `mapstructure:"read_only"` // only the server publishes
+}
+
 // Logging configuration
 type Logging struct {
 	Level  string `mapstructure:"level"`
@@ -130,6 +260,41 @@ func setDefaults() {
 	// JWT defaults
 	viper.SetDefault("jwt.access_token_expire_int", 15)     // minutes
 	viper.SetDefault("jwt.refresh_token_expire_int", 10080) // minutes (7 days)
+	viper.SetDefault("jwt.algorithm", "HS256")
+	viper.SetDefault("jwt.key_source", "dir")
+	viper.SetDefault("jwt.key_dir", "./keys")
+	viper.SetDefault("jwt.key_rotation", 720) // hours (30 days)
+	viper.SetDefault("jwt.key_refresh", 5)    // minutes
+	viper.SetDefault("jwt.accept_hs256", true)
+	viper.SetDefault("jwt.issuer", "go-mobile-backend")
+	viper.SetDefault("jwt.audience", "go-mobile-backend-api")
+	viper.SetDefault("jwt.embed_permissions", false)
+
+	// Auth defaults
+	viper.SetDefault("auth.totp_issuer", "Go Mobile Backend")
+	viper.SetDefault("auth.two_factor_challenge_expire", 5)  // minutes
+	viper.SetDefault("auth.oauth.state_expire", 10)          // minutes
+	viper.SetDefault("auth.password_reset_expire", 60)       // minutes
+	viper.SetDefault("auth.email_verification_expire", 1440) // minutes (24 hours)
+	viper.SetDefault("auth.password_reset_url", "http://localhost:3000/reset-password")
+	viper.SetDefault("auth.email_verification_url", "http://localhost:3000/verify-email")
+	viper.SetDefault("auth.api_key_rate_limit", 1000)  // requests per window
+	viper.SetDefault("auth.api_key_rate_window", 60)   // minutes
+	viper.SetDefault("auth.api_key_usage_flush", 30)   // seconds
+	viper.SetDefault("auth.permission_cache_ttl", 300) // seconds
+	viper.SetDefault("auth.lockout.max_attempts", 5)
+	viper.SetDefault("auth.lockout.ip_max_attempts", 20)
+	viper.SetDefault("auth.lockout.ip_window", 15)         // minutes
+	viper.SetDefault("auth.lockout.lock_duration", 1)      // minutes
+	viper.SetDefault("auth.lockout.max_lock_duration", 60) // minutes
+	viper.SetDefault("auth.lockout.backoff", 2.0)
+
+	// Mail defaults
+	viper.SetDefault("mail.driver", "smtp")
+	viper.SetDefault("mail.from", "no-reply@example.com")
+	viper.SetDefault("mail.from_name", "Go Mobile Backend")
+	viper.SetDefault("mail.file_dir", "./tmp/mail")
+	viper.SetDefault("mail.smtp.port", 587)
 
 	// Google Scripts defaults
 	viper.SetDefault("google_scripts.url", "")
@@ -137,6 +302,24 @@ func setDefaults() {
 	viper.SetDefault("google_scripts.project_id", "")
 	viper.SetDefault("google_scripts.migrations_dir", "./migrations")
 
+	// Realtime defaults
+	viper.SetDefault("realtime.backplane", "memory")
+	viper.SetDefault("realtime.presence_ttl", 60)
+	viper.SetDefault("realtime.max_subscriptions", 50)
+	viper.SetDefault("realtime.queue_size", 256)
+	viper.SetDefault("realtime.slow_consumer", "drop_oldest")
+	viper.SetDefault("realtime.ack_timeout", 10)
+	viper.SetDefault("realtime.ack_retries", 3)
+	viper.SetDefault("realtime.rpc_timeout", 30)
+	viper.SetDefault("realtime.history_size", 100)
+	viper.SetDefault("realtime.history_ttl", 24)
+	viper.SetDefault("realtime.db_stream.backend", "notify")
+	viper.SetDefault("realtime.db_stream.replication.slot", "realtime")
+	viper.SetDefault("realtime.db_stream.replication.plugin", "pgoutput")
+	viper.SetDefault("realtime.db_stream.replication.publication", "realtime")
+	viper.SetDefault("realtime.db_stream.replication.poll_interval", 1000)
+	viper.SetDefault("realtime.db_stream.replication.batch_size", 1000)
+
 	// Logging defaults
 	viper.SetDefault("logging.level", "info")
 	viper.SetDefault("logging.format", "json")